@@ -0,0 +1,251 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"dhcp-server/audit"
+	"dhcp-server/config"
+)
+
+// auditErrorLimit 失败请求记录错误信息时最多读取的响应字节数
+const auditErrorLimit = 4096
+
+// auditRecorder 记录响应状态码，失败时保留响应开头用于提取错误信息
+type auditRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// WriteHeader 记录状态码
+func (ar *auditRecorder) WriteHeader(status int) {
+	ar.status = status
+	ar.ResponseWriter.WriteHeader(status)
+}
+
+// Write 写入响应，状态码为错误时保存响应开头
+func (ar *auditRecorder) Write(data []byte) (int, error) {
+	if ar.status >= 400 && ar.body.Len() < auditErrorLimit {
+		remaining := auditErrorLimit - ar.body.Len()
+		if len(data) < remaining {
+			remaining = len(data)
+		}
+		ar.body.Write(data[:remaining])
+	}
+	return ar.ResponseWriter.Write(data)
+}
+
+// errorMessage 从JSON错误响应中提取错误信息，兼容旧接口和版本化API的格式
+func (ar *auditRecorder) errorMessage() string {
+	var resp struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(ar.body.Bytes(), &resp) == nil && resp.Error != "" {
+		return resp.Error
+	}
+	var envelope ErrorResponse
+	if json.Unmarshal(ar.body.Bytes(), &envelope) == nil && envelope.Error.Message != "" {
+		return envelope.Error.Message
+	}
+	return http.StatusText(ar.status)
+}
+
+// isMutating 是否为修改类请求
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// actorName 审计记录中的操作者名称
+func actorName(p Principal, ok bool) string {
+	switch {
+	case !ok:
+		return authKindAnonymous
+	case p.Username != "":
+		return p.Username
+	case p.Token != "":
+		return "token:" + p.Token
+	}
+	return authKindAnonymous
+}
+
+// auditChangesKey 请求上下文中收集配置变化的键
+type auditChangesKey struct{}
+
+// auditChanges 认证中间件识别出的身份和请求处理期间每次保存配置产生的变化
+type auditChanges struct {
+	mutex     sync.Mutex
+	principal *Principal // 认证失败时为nil
+	changes   []audit.Change
+}
+
+// identify 记录请求的身份
+func (ac *auditChanges) identify(p Principal) {
+	ac.mutex.Lock()
+	defer ac.mutex.Unlock()
+
+	ac.principal = &p
+}
+
+// identity 获取请求的身份
+func (ac *auditChanges) identity() (Principal, bool) {
+	ac.mutex.Lock()
+	defer ac.mutex.Unlock()
+
+	if ac.principal == nil {
+		return Principal{}, false
+	}
+	return *ac.principal, true
+}
+
+// auditIdentify 把认证结果告知外层的审计中间件，权限不足被拒绝的请求也记录操作者
+func auditIdentify(r *http.Request, p Principal) {
+	if changes, ok := r.Context().Value(auditChangesKey{}).(*auditChanges); ok {
+		changes.identify(p)
+	}
+}
+
+// add 追加一次保存的变化
+func (ac *auditChanges) add(changes []audit.Change) {
+	ac.mutex.Lock()
+	defer ac.mutex.Unlock()
+
+	ac.changes = append(ac.changes, changes...)
+}
+
+// list 获取收集到的变化
+func (ac *auditChanges) list() []audit.Change {
+	ac.mutex.Lock()
+	defer ac.mutex.Unlock()
+
+	return ac.changes
+}
+
+// saveConfig 保存当前配置
+func (api *APIServer) saveConfig(r *http.Request) error {
+	return api.writeConfig(r, api.config)
+}
+
+// writeConfig 保存配置并写回令牌使用时间，同时把配置文件的变化记入请求的审计记录；
+// 只在读取快照和写入文件期间持有锁，保证差异归属于正确的请求
+func (api *APIServer) writeConfig(r *http.Request, cfg *config.Config) error {
+	api.flushTokenUsage(cfg)
+
+	api.auditMutex.Lock()
+	defer api.auditMutex.Unlock()
+
+	before, err := audit.LoadSnapshot(api.configPath)
+	if err != nil {
+		log.Printf("审计: 读取配置快照失败: %v", err)
+	}
+	if err := cfg.SaveConfig(api.configPath); err != nil {
+		return err
+	}
+
+	collected, ok := r.Context().Value(auditChangesKey{}).(*auditChanges)
+	if !ok || before == nil {
+		return nil
+	}
+	after, err := audit.LoadSnapshot(api.configPath)
+	if err != nil {
+		log.Printf("审计: 读取配置快照失败: %v", err)
+		return nil
+	}
+	collected.add(audit.Diff(before, after))
+	return nil
+}
+
+// auditMiddleware 记录所有修改类请求的操作者、来源、结果和配置文件变化，位于认证中间件之外，
+// 认证失败（401）和权限不足（403）的请求同样记录；配置变化由处理请求时的每次保存收集，请求之间不互相阻塞
+func (api *APIServer) auditMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isMutating(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		changes := &auditChanges{}
+		start := time.Now()
+		recorder := &auditRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(context.WithValue(r.Context(), auditChangesKey{}, changes)))
+
+		p, ok := changes.identity()
+		kind := authKindAnonymous
+		if ok {
+			kind = p.Kind
+		}
+		entry := audit.Entry{
+			Timestamp: start,
+			Actor:     actorName(p, ok),
+			AuthKind:  kind,
+			SourceIP:  clientAddr(r),
+			Method:    r.Method,
+			Endpoint:  r.URL.Path,
+			Query:     r.URL.RawQuery,
+			Status:    recorder.status,
+			Result:    audit.ResultSuccess,
+			Duration:  time.Since(start).Milliseconds(),
+		}
+		if recorder.status >= 400 {
+			entry.Result = audit.ResultFailure
+			entry.Error = recorder.errorMessage()
+		}
+		entry.Changes = changes.list()
+		audit.Record(entry)
+	})
+}
+
+// handleAudit 查询审计日志，支持 actor、method、endpoint（前缀）、section、result、since、until（RFC3339）、limit 过滤
+func (api *APIServer) handleAudit(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed"})
+		return
+	}
+
+	query := r.URL.Query()
+	filter := audit.Filter{
+		Actor:    query.Get("actor"),
+		Method:   query.Get("method"),
+		Endpoint: query.Get("endpoint"),
+		Section:  query.Get("section"),
+		Result:   query.Get("result"),
+	}
+	for name, target := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "无效的时间 " + name + ": " + value})
+			return
+		}
+		*target = t
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "无效的limit: " + value})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	total, entries := audit.Query(filter)
+	json.NewEncoder(w).Encode(AuditResponse{Total: total, Entries: entries})
+}
@@ -0,0 +1,665 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"dhcp-server/apitypes"
+	"dhcp-server/config"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	sessionCookieName     = "dhcp_session"
+	defaultSessionTTL     = 12 * time.Hour
+	tokenPrefix           = "dhcp_"
+	minPasswordLength     = 8
+	maxLoginFailures      = 5                // 同一地址连续失败次数上限
+	loginLockoutDuration  = 15 * time.Minute // 超过失败次数后的锁定时间
+	bootstrapAdminName    = "admin"
+	bootstrapPasswordSize = 12
+)
+
+// dummyPasswordHash 用户不存在时也执行一次bcrypt比较，避免通过响应时间枚举用户名
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
+// 认证方式
+const (
+	authKindAnonymous = "anonymous" // 认证已关闭
+	authKindSession   = "session"   // Web界面登录会话
+	authKindToken     = "token"     // Bearer令牌
+)
+
+// Principal 请求的认证身份，在apitypes.Principal上附加权限判断方法
+type Principal apitypes.Principal
+
+type principalKey struct{}
+
+// principalFromContext 获取请求的认证身份
+func principalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// session 登录会话
+type session struct {
+	username string
+	expires  time.Time
+}
+
+// loginFailure 登录失败记录
+type loginFailure struct {
+	count       int
+	lockedUntil time.Time
+}
+
+// authManager 会话和登录失败记录，仅保存在内存中，重启后需重新登录
+type authManager struct {
+	mutex    sync.Mutex
+	sessions map[string]*session
+	failures map[string]*loginFailure
+	lastUsed map[string]time.Time // 令牌最后使用时间，保存配置时写回
+}
+
+// newAuthManager 创建认证管理器
+func newAuthManager() *authManager {
+	return &authManager{
+		sessions: make(map[string]*session),
+		failures: make(map[string]*loginFailure),
+		lastUsed: make(map[string]time.Time),
+	}
+}
+
+// createSession 创建会话并返回会话ID
+func (am *authManager) createSession(username string, ttl time.Duration) (string, error) {
+	id, err := randomString(32)
+	if err != nil {
+		return "", err
+	}
+
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	now := time.Now()
+	for key, s := range am.sessions {
+		if now.After(s.expires) {
+			delete(am.sessions, key)
+		}
+	}
+	am.sessions[id] = &session{username: username, expires: now.Add(ttl)}
+	return id, nil
+}
+
+// lookupSession 查找未过期的会话
+func (am *authManager) lookupSession(id string) (string, bool) {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	s, ok := am.sessions[id]
+	if !ok {
+		return "", false
+	}
+	if time.Now().After(s.expires) {
+		delete(am.sessions, id)
+		return "", false
+	}
+	return s.username, true
+}
+
+// deleteSession 删除会话
+func (am *authManager) deleteSession(id string) {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	delete(am.sessions, id)
+}
+
+// deleteUserSessions 删除用户的所有会话，keep除外
+func (am *authManager) deleteUserSessions(username, keep string) {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	for id, s := range am.sessions {
+		if s.username == username && id != keep {
+			delete(am.sessions, id)
+		}
+	}
+}
+
+// touchToken 记录令牌使用时间
+func (am *authManager) touchToken(name string, now time.Time) {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	am.lastUsed[name] = now
+}
+
+// tokenLastUsed 获取令牌在内存中记录的最后使用时间
+func (am *authManager) tokenLastUsed(name string) (time.Time, bool) {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	t, ok := am.lastUsed[name]
+	return t, ok
+}
+
+// forgetToken 删除已吊销令牌的使用记录
+func (am *authManager) forgetToken(name string) {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	delete(am.lastUsed, name)
+}
+
+// isLocked 检查来源地址是否因多次登录失败被锁定
+func (am *authManager) isLocked(addr string) bool {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	f, ok := am.failures[addr]
+	return ok && time.Now().Before(f.lockedUntil)
+}
+
+// recordLogin 记录登录结果，连续失败达到上限时锁定来源地址
+func (am *authManager) recordLogin(addr string, success bool) {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	if success {
+		delete(am.failures, addr)
+		return
+	}
+	f, ok := am.failures[addr]
+	if !ok {
+		f = &loginFailure{}
+		am.failures[addr] = f
+	}
+	f.count++
+	if f.count >= maxLoginFailures {
+		f.count = 0
+		f.lockedUntil = time.Now().Add(loginLockoutDuration)
+		log.Printf("来自 %s 的登录连续失败 %d 次，锁定 %v", addr, maxLoginFailures, loginLockoutDuration)
+	}
+}
+
+// randomString 生成URL安全的随机字符串
+func randomString(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// HashPassword 生成bcrypt密码哈希
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// HashToken 计算令牌的SHA-256哈希
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateToken 生成新的Bearer令牌，返回令牌明文和哈希
+func GenerateToken() (string, string, error) {
+	random, err := randomString(32)
+	if err != nil {
+		return "", "", err
+	}
+	token := tokenPrefix + random
+	return token, HashToken(token), nil
+}
+
+// EnsureAdminUser 启用认证但未配置任何用户和令牌时，创建初始管理员并把随机密码输出到out一次
+func EnsureAdminUser(cfg *config.Config, configPath string, out io.Writer) error {
+	if cfg.Auth.Disabled || len(cfg.Auth.Users) > 0 || len(cfg.Auth.Tokens) > 0 {
+		return nil
+	}
+
+	password, err := randomString(bootstrapPasswordSize)
+	if err != nil {
+		return err
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+	cfg.Auth.Users = append(cfg.Auth.Users, config.AuthUser{Username: bootstrapAdminName, PasswordHash: hash, Role: config.RoleAdmin})
+	if err := cfg.SaveConfig(configPath); err != nil {
+		return fmt.Errorf("保存初始管理员失败: %v", err)
+	}
+
+	// 密码只输出到out（通常为标准错误），不写入日志文件
+	fmt.Fprintf(out, "已创建初始管理员账号: 用户名=%s 密码=%s ，请登录后立即修改密码\n", bootstrapAdminName, password)
+	log.Printf("已创建初始管理员账号 %s，密码已输出到标准错误", bootstrapAdminName)
+	return nil
+}
+
+// findUser 查找本地用户
+func (api *APIServer) findUser(username string) *config.AuthUser {
+	for i, user := range api.config.Auth.Users {
+		if user.Username == username {
+			return &api.config.Auth.Users[i]
+		}
+	}
+	return nil
+}
+
+// findToken 根据令牌明文查找配置的令牌
+func (api *APIServer) findToken(token string) *config.APIToken {
+	hash := HashToken(token)
+	for i, t := range api.config.Auth.Tokens {
+		if subtle.ConstantTimeCompare([]byte(t.TokenHash), []byte(hash)) == 1 {
+			return &api.config.Auth.Tokens[i]
+		}
+	}
+	return nil
+}
+
+// authenticate 根据Bearer令牌或会话Cookie识别请求身份
+func (api *APIServer) authenticate(r *http.Request) (Principal, bool) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		if !strings.HasPrefix(header, "Bearer ") {
+			return Principal{}, false
+		}
+		token := api.findToken(strings.TrimSpace(strings.TrimPrefix(header, "Bearer ")))
+		if token == nil {
+			return Principal{}, false
+		}
+		now := time.Now()
+		if token.Expired(now) {
+			log.Printf("令牌 %s 已过期", token.Name)
+			return Principal{}, false
+		}
+		if !sourceAllowed(token.SourceCIDRs, clientAddr(r)) {
+			log.Printf("令牌 %s 不允许从 %s 使用", token.Name, clientAddr(r))
+			return Principal{}, false
+		}
+		api.auth.touchToken(token.Name, now)
+		return Principal{Token: token.Name, Kind: authKindToken, Role: token.Role, Scopes: token.Scopes}, true
+	}
+
+	cookie, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		return Principal{}, false
+	}
+	username, ok := api.auth.lookupSession(cookie.Value)
+	if !ok {
+		return Principal{}, false
+	}
+	user := api.findUser(username)
+	if user == nil {
+		return Principal{}, false
+	}
+	return Principal{Username: username, Kind: authKindSession, Role: user.Role}, true
+}
+
+// authMiddleware 要求请求携带有效的会话或令牌并拥有路由声明的权限，页面请求未登录时跳转到登录页；
+// 通过检查的请求经审计中间件交给mux处理
+func (api *APIServer) authMiddleware(mux *http.ServeMux) http.Handler {
+	next := http.Handler(mux)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if api.config.Auth.Disabled {
+			p := Principal{Kind: authKindAnonymous, Role: config.RoleAdmin}
+			auditIdentify(r, p)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, p)))
+			return
+		}
+
+		_, pattern := mux.Handler(r)
+		permission := api.routes[pattern]
+		if permission == permPublic {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		p, ok := api.authenticate(r)
+		if !ok {
+			// 页面跳转到登录页，API和监控抓取返回401
+			if !strings.HasPrefix(r.URL.Path, "/api/") && r.URL.Path != "/metrics" {
+				http.Redirect(w, r, "/login", http.StatusFound)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("WWW-Authenticate", `Bearer realm="dhcp-server"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Authentication required"})
+			return
+		}
+		auditIdentify(r, p)
+
+		if !p.authorize(permission, r.Method) {
+			scope := config.RoleAdmin
+			if permission != "" {
+				scope = requiredScope(permission, r.Method)
+			}
+			log.Printf("拒绝访问: %s %s, 身份=%s%s, 角色=%s", r.Method, r.URL.Path, p.Username, p.Token, p.Role)
+			writeForbidden(w, scope)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, p)))
+	})
+}
+
+// corsMiddleware 只允许配置中的来源跨域访问，并允许携带Cookie
+func (api *APIServer) corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		allowed := false
+		for _, o := range api.config.Auth.CORSOrigins {
+			if origin != "" && strings.EqualFold(o, origin) {
+				allowed = true
+				break
+			}
+		}
+
+		if allowed {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			w.Header().Add("Vary", "Origin")
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// sourceAllowed 检查来源地址是否在令牌允许的网段内，未限制时允许任意来源
+func sourceAllowed(cidrs []string, addr string) bool {
+	if len(cidrs) == 0 {
+		return true
+	}
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, cidr := range cidrs {
+		if _, network, err := net.ParseCIDR(cidr); err == nil && network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientAddr 获取请求的来源IP
+func clientAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// sessionTTL 获取会话有效期
+func (api *APIServer) sessionTTL() time.Duration {
+	if api.config.Auth.SessionTTL > 0 {
+		return api.config.Auth.SessionTTL
+	}
+	return defaultSessionTTL
+}
+
+// setSessionCookie 写入会话Cookie，maxAge为负数时删除
+func setSessionCookie(w http.ResponseWriter, r *http.Request, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// handleLogin 用户名密码登录，成功后设置会话Cookie
+func (api *APIServer) handleLogin(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed"})
+		return
+	}
+
+	addr := clientAddr(r)
+	if api.auth.isLocked(addr) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(map[string]string{"error": "登录失败次数过多，请稍后再试"})
+		return
+	}
+
+	var req LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid JSON"})
+		return
+	}
+
+	hash := dummyPasswordHash
+	user := api.findUser(req.Username)
+	if user != nil {
+		hash = []byte(user.PasswordHash)
+	}
+	if err := bcrypt.CompareHashAndPassword(hash, []byte(req.Password)); err != nil || user == nil {
+		api.auth.recordLogin(addr, false)
+		log.Printf("登录失败: 用户=%s, 来源=%s", req.Username, addr)
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "用户名或密码错误"})
+		return
+	}
+	api.auth.recordLogin(addr, true)
+
+	ttl := api.sessionTTL()
+	id, err := api.auth.createSession(user.Username, ttl)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "创建会话失败"})
+		return
+	}
+	setSessionCookie(w, r, id, int(ttl.Seconds()))
+
+	log.Printf("用户登录: %s, 来源=%s", user.Username, addr)
+	json.NewEncoder(w).Encode(LoginResponse{Success: true, Username: user.Username, Expires: time.Now().Add(ttl)})
+}
+
+// handleLogout 注销当前会话
+func (api *APIServer) handleLogout(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed"})
+		return
+	}
+
+	if cookie, err := r.Cookie(sessionCookieName); err == nil {
+		api.auth.deleteSession(cookie.Value)
+	}
+	setSessionCookie(w, r, "", -1)
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true})
+}
+
+// handleAuthMe 返回当前请求的认证身份
+func (api *APIServer) handleAuthMe(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed"})
+		return
+	}
+
+	p, ok := principalFromContext(r.Context())
+	if !ok {
+		p = Principal{Kind: authKindAnonymous, Role: config.RoleAdmin}
+	}
+	json.NewEncoder(w).Encode(p)
+}
+
+// handleChangePassword 修改当前登录用户的密码，并注销该用户的其他会话
+func (api *APIServer) handleChangePassword(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed"})
+		return
+	}
+
+	p, ok := principalFromContext(r.Context())
+	if !ok || p.Kind != authKindSession {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"error": "只有登录用户可以修改密码"})
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid JSON"})
+		return
+	}
+	if len(req.NewPassword) < minPasswordLength {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf("新密码至少需要%d个字符", minPasswordLength)})
+		return
+	}
+
+	// 修改密码不要求If-Match，但与其他修改配置的请求依次执行
+	api.configMutex.Lock()
+	defer api.configMutex.Unlock()
+
+	user := api.findUser(p.Username)
+	if user == nil || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.OldPassword)) != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "原密码错误"})
+		return
+	}
+
+	hash, err := HashPassword(req.NewPassword)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "生成密码哈希失败"})
+		return
+	}
+	user.PasswordHash = hash
+	if err := api.saveConfig(r); err != nil {
+		w.WriteHeader(saveErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": "保存配置失败: " + err.Error()})
+		return
+	}
+
+	current := ""
+	if cookie, err := r.Cookie(sessionCookieName); err == nil {
+		current = cookie.Value
+	}
+	api.auth.deleteUserSessions(p.Username, current)
+
+	log.Printf("用户 %s 已修改密码", p.Username)
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "message": "密码已修改"})
+}
+
+// handleLoginPage 登录页面
+func (api *APIServer) handleLoginPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(loginPageHTML))
+}
+
+const loginPageHTML = `<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>登录 - DHCP服务器管理</title>
+    <style>
+        * { margin: 0; padding: 0; box-sizing: border-box; }
+        body {
+            font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, Arial, sans-serif;
+            background: linear-gradient(135deg, #74b9ff 0%, #0984e3 50%, #00b894 100%);
+            min-height: 100vh; display: flex; align-items: center; justify-content: center;
+        }
+        .login-box {
+            background: rgba(255, 255, 255, 0.95); border-radius: 16px; padding: 40px;
+            width: 360px; box-shadow: 0 10px 40px rgba(0, 0, 0, 0.2);
+        }
+        h1 { font-size: 22px; color: #2d3436; margin-bottom: 24px; text-align: center; }
+        label { display: block; font-size: 14px; color: #636e72; margin-bottom: 6px; }
+        input {
+            width: 100%; padding: 10px 12px; border: 1px solid #dfe6e9; border-radius: 8px;
+            font-size: 15px; margin-bottom: 18px;
+        }
+        button {
+            width: 100%; padding: 12px; border: none; border-radius: 8px; background: #0984e3;
+            color: #fff; font-size: 16px; cursor: pointer;
+        }
+        button:disabled { background: #74b9ff; cursor: default; }
+        .error { color: #d63031; font-size: 14px; min-height: 20px; margin-bottom: 12px; }
+    </style>
+</head>
+<body>
+    <form class="login-box" id="loginForm">
+        <h1>DHCP服务器管理</h1>
+        <label for="username">用户名</label>
+        <input id="username" autocomplete="username" required autofocus>
+        <label for="password">密码</label>
+        <input id="password" type="password" autocomplete="current-password" required>
+        <div class="error" id="error"></div>
+        <button type="submit" id="submit">登录</button>
+    </form>
+    <script>
+        document.getElementById('loginForm').addEventListener('submit', async (e) => {
+            e.preventDefault();
+            const button = document.getElementById('submit');
+            const error = document.getElementById('error');
+            button.disabled = true;
+            error.textContent = '';
+            try {
+                const resp = await fetch('/api/auth/login', {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({
+                        username: document.getElementById('username').value,
+                        password: document.getElementById('password').value
+                    })
+                });
+                const data = await resp.json();
+                if (resp.ok) {
+                    window.location.href = '/';
+                    return;
+                }
+                error.textContent = data.error || '登录失败';
+            } catch (err) {
+                error.textContent = '无法连接服务器';
+            }
+            button.disabled = false;
+        });
+    </script>
+</body>
+</html>`
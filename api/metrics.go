@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"dhcp-server/metrics"
+)
+
+// gaugeFamily 创建抓取时计算的仪表
+func gaugeFamily(name, help string) metrics.Family {
+	return metrics.Family{Name: name, Help: help, Type: metrics.TypeGauge}
+}
+
+// addSample 添加一个样本，labels按名称、值交替给出
+func addSample(f *metrics.Family, value float64, labels ...string) {
+	sample := metrics.Sample{Value: value}
+	for i := 0; i+1 < len(labels); i += 2 {
+		sample.Labels = append(sample.Labels, metrics.Label{Name: labels[i], Value: labels[i+1]})
+	}
+	f.Samples = append(f.Samples, sample)
+}
+
+// boolValue 布尔值转换为指标值
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// collectGauges 抓取时读取地址池、网关和扫描器的当前状态
+func (api *APIServer) collectGauges() []metrics.Family {
+	var families []metrics.Family
+
+	if api.pool != nil {
+		size := gaugeFamily("dhcp_pool_size", "地址池的地址数量")
+		used := gaugeFamily("dhcp_pool_used", "地址池中未过期的租约数，包括静态绑定")
+		static := gaugeFamily("dhcp_pool_static", "地址池中的静态绑定数")
+		conflicts := gaugeFamily("dhcp_pool_conflicts", "地址池中被标记为冲突的地址数")
+		for _, usage := range api.pool.Usage() {
+			addSample(&size, float64(usage.Size), "pool", usage.Name)
+			addSample(&used, float64(usage.Used), "pool", usage.Name)
+			addSample(&static, float64(usage.Static), "pool", usage.Name)
+			addSample(&conflicts, float64(usage.Conflicts), "pool", usage.Name)
+		}
+		pending := gaugeFamily("dhcp_pool_pending_offers", "已提供但尚未确认的地址数")
+		addSample(&pending, float64(api.pool.PendingOffers()))
+		families = append(families, size, used, static, conflicts, pending)
+	}
+
+	if api.checker != nil {
+		up := gaugeFamily("dhcp_gateway_up", "网关健康状态，1为健康")
+		rtt := gaugeFamily("dhcp_gateway_rtt_seconds", "最近一次成功健康检查的往返时间")
+		status := api.checker.GetGatewayStatus()
+		rtts := api.checker.GetGatewayRTT()
+		for _, gw := range api.config.Gateways {
+			addSample(&up, boolValue(status[gw.Name]), "gateway", gw.Name, "ip", gw.IP)
+			if d, ok := rtts[gw.Name]; ok {
+				addSample(&rtt, d.Seconds(), "gateway", gw.Name, "ip", gw.IP)
+			}
+		}
+		families = append(families, up, rtt)
+	}
+
+	if api.scanner != nil {
+		running := gaugeFamily("dhcp_scanner_running", "扫描器是否正在运行，1为运行")
+		addSample(&running, boolValue(api.scanner.IsRunning()))
+		families = append(families, running)
+	}
+
+	return families
+}
+
+// handleMetrics 以Prometheus文本格式输出监控指标
+func (api *APIServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed"})
+		return
+	}
+
+	families := append(metrics.Gather(), api.collectGauges()...)
+	w.Header().Set("Content-Type", metrics.ContentType)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if err := metrics.WriteText(w, families); err != nil {
+		log.Printf("输出监控指标失败: %v", err)
+	}
+}
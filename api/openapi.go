@@ -0,0 +1,784 @@
+package api
+
+import (
+	"encoding"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"dhcp-server/config"
+	"dhcp-server/dhcp"
+)
+
+// apiParam 查询参数
+type apiParam struct {
+	name        string
+	typ         string // string、integer、boolean
+	description string
+}
+
+func queryString(name, description string) apiParam {
+	return apiParam{name: name, typ: "string", description: description}
+}
+
+func queryInt(name, description string) apiParam {
+	return apiParam{name: name, typ: "integer", description: description}
+}
+
+func queryBool(name, description string) apiParam {
+	return apiParam{name: name, typ: "boolean", description: description}
+}
+
+// rawSchema 无法由Go类型描述的响应体，直接给出JSON Schema
+type rawSchema map[string]interface{}
+
+// oneOf 响应体可能是多个类型之一
+type oneOf []interface{}
+
+var (
+	objectSchema = rawSchema{"type": "object", "additionalProperties": true}
+	textSchema   = rawSchema{"type": "string"}
+)
+
+// apiOperation 一个接口操作，路径参数写作{name}，请求体和响应体用对应Go类型的零值描述
+type apiOperation struct {
+	method      string
+	path        string
+	tag         string
+	summary     string
+	params      []apiParam
+	request     interface{}
+	response    interface{}
+	status      int    // 成功状态码，默认200
+	contentType string // 响应类型，默认application/json
+}
+
+// 各列表共用的分页参数
+var pageParams = []apiParam{
+	queryInt("limit", "每页条数，默认50，最大500"),
+	queryString("sort", "排序字段，前缀-表示降序"),
+	queryString("cursor", "上一页返回的next_cursor"),
+}
+
+func withPage(params ...apiParam) []apiParam {
+	return append(params, pageParams...)
+}
+
+// apiOperations 所有接口，新增或修改路由时需同步更新，TestOpenAPISpecMatchesRoutes 会检查两者是否一致
+var apiOperations = []apiOperation{
+	{method: "GET", path: "/", tag: "pages", summary: "管理界面", response: textSchema, contentType: "text/html"},
+	{method: "GET", path: "/login", tag: "pages", summary: "登录页面", response: textSchema, contentType: "text/html"},
+	{method: "GET", path: "/docs", tag: "pages", summary: "API文档查看器", response: textSchema, contentType: "text/html"},
+	{method: "GET", path: "/api/openapi.json", tag: "system", summary: "OpenAPI文档", response: objectSchema},
+	{method: "GET", path: "/api/health", tag: "system", summary: "健康检查", response: HealthResponse{}},
+	{method: "POST", path: "/api/server/restart", tag: "system", summary: "重启服务器", response: MessageResponse{}},
+	{method: "GET", path: "/metrics", tag: "system", summary: "Prometheus监控指标", response: textSchema, contentType: "text/plain; version=0.0.4"},
+
+	{method: "POST", path: "/api/auth/login", tag: "auth", summary: "用户名密码登录，成功后设置会话Cookie", request: LoginRequest{}, response: LoginResponse{}},
+	{method: "POST", path: "/api/auth/logout", tag: "auth", summary: "注销当前会话", response: MessageResponse{}},
+	{method: "GET", path: "/api/auth/me", tag: "auth", summary: "当前认证身份", response: Principal{}},
+	{method: "POST", path: "/api/auth/password", tag: "auth", summary: "修改当前用户密码", request: ChangePasswordRequest{}, response: MessageResponse{}},
+	{method: "GET", path: "/api/auth/tokens", tag: "auth", summary: "API令牌列表", response: []TokenInfo{}},
+	{method: "POST", path: "/api/auth/tokens", tag: "auth", summary: "创建API令牌，令牌明文只返回一次", request: CreateTokenRequest{}, response: CreateTokenResponse{}, status: http.StatusCreated},
+	{method: "DELETE", path: "/api/auth/tokens", tag: "auth", summary: "吊销API令牌", params: []apiParam{queryString("name", "令牌名称")}, response: MessageResponse{}},
+	{method: "GET", path: "/api/audit", tag: "auth", summary: "查询审计日志", params: []apiParam{
+		queryString("actor", "操作者"),
+		queryString("method", "请求方法"),
+		queryString("endpoint", "接口路径前缀"),
+		queryString("section", "涉及的配置段"),
+		queryString("result", "success 或 failure"),
+		queryString("since", "开始时间（RFC3339）"),
+		queryString("until", "结束时间（RFC3339）"),
+		queryInt("limit", "最多返回条数"),
+	}, response: AuditResponse{}},
+
+	{method: "GET", path: "/api/leases", tag: "leases", summary: "所有租约", response: []LeaseInfo{}},
+	{method: "GET", path: "/api/leases/active", tag: "leases", summary: "活跃租约", response: []LeaseInfo{}},
+	{method: "GET", path: "/api/leases/history", tag: "leases", summary: "租约历史", params: []apiParam{
+		queryInt("limit", "最多返回条数，默认100"),
+		queryString("mac", "按MAC过滤"),
+		queryString("ip", "按IP过滤"),
+	}, response: []dhcp.HistoryRecord{}},
+	{method: "POST", path: "/api/leases/convert-to-static", tag: "leases", summary: "将动态租约转换为静态绑定", request: ConvertToStaticRequest{}, response: objectSchema, status: http.StatusCreated},
+	{method: "GET", path: "/api/available-ips", tag: "leases", summary: "可用于静态绑定的IP地址", response: []string{}},
+	{method: "GET", path: "/api/stats", tag: "leases", summary: "地址池和网关统计", response: StatsResponse{}},
+
+	{method: "GET", path: "/api/gateways", tag: "gateways", summary: "网关及健康状态，以网关名称为键", response: map[string]GatewayStatus{}},
+	{method: "POST", path: "/api/gateways", tag: "gateways", summary: "添加网关", request: GatewayRequest{}, response: objectSchema, status: http.StatusCreated},
+	{method: "PUT", path: "/api/gateways", tag: "gateways", summary: "更新网关", request: GatewayRequest{}, response: objectSchema},
+	{method: "DELETE", path: "/api/gateways", tag: "gateways", summary: "删除网关", request: GatewayDeleteRequest{}, response: objectSchema},
+
+	{method: "GET", path: "/api/devices", tag: "devices", summary: "设备列表", params: []apiParam{
+		queryString("mac", "按MAC查询"),
+		queryString("type", "按设备类型过滤"),
+	}, response: []config.DeviceInfo{}},
+	{method: "POST", path: "/api/devices", tag: "devices", summary: "添加设备", request: config.DeviceInfo{}, response: config.DeviceInfo{}, status: http.StatusCreated},
+	{method: "PUT", path: "/api/devices", tag: "devices", summary: "更新设备", request: config.DeviceInfo{}, response: config.DeviceInfo{}},
+	{method: "DELETE", path: "/api/devices", tag: "devices", summary: "删除设备及其静态绑定", request: DeviceDeleteRequest{}, response: objectSchema},
+	{method: "POST", path: "/api/devices/discover", tag: "devices", summary: "从租约中发现设备", response: objectSchema},
+	{method: "POST", path: "/api/devices/batch", tag: "devices", summary: "批量添加设备", request: BatchDevicesRequest{}, response: objectSchema, status: http.StatusCreated},
+	{method: "PUT", path: "/api/devices/gateway", tag: "devices", summary: "设置设备网关", request: DeviceGatewayRequest{}, response: objectSchema},
+	{method: "GET", path: "/api/devices/policy", tag: "devices", summary: "设备生效的DHCP策略", params: []apiParam{queryString("mac", "设备MAC")}, response: objectSchema},
+	{method: "GET", path: "/api/groups", tag: "devices", summary: "设备组及成员", response: []GroupInfo{}},
+	{method: "POST", path: "/api/groups", tag: "devices", summary: "添加设备组", request: config.DeviceGroup{}, response: config.DeviceGroup{}, status: http.StatusCreated},
+	{method: "PUT", path: "/api/groups", tag: "devices", summary: "更新设备组", request: config.DeviceGroup{}, response: config.DeviceGroup{}},
+	{method: "DELETE", path: "/api/groups", tag: "devices", summary: "删除设备组", params: []apiParam{queryString("name", "设备组名称")}, response: MessageResponse{}},
+	{method: "GET", path: "/api/schedules", tag: "devices", summary: "访问时间表", response: []config.AccessSchedule{}},
+	{method: "POST", path: "/api/schedules", tag: "devices", summary: "添加访问时间表", request: config.AccessSchedule{}, response: config.AccessSchedule{}, status: http.StatusCreated},
+	{method: "PUT", path: "/api/schedules", tag: "devices", summary: "更新访问时间表", request: config.AccessSchedule{}, response: config.AccessSchedule{}},
+	{method: "DELETE", path: "/api/schedules", tag: "devices", summary: "删除访问时间表", params: []apiParam{queryString("name", "时间表名称")}, response: MessageResponse{}},
+	{method: "GET", path: "/api/oui", tag: "devices", summary: "查询MAC厂商", params: []apiParam{queryString("mac", "MAC地址")}, response: objectSchema},
+	{method: "POST", path: "/api/oui/reload", tag: "devices", summary: "重新加载厂商数据库", response: objectSchema},
+	{method: "GET", path: "/api/fingerprints", tag: "devices", summary: "DHCP指纹", params: []apiParam{queryBool("unmatched", "只返回未识别的指纹")}, response: objectSchema},
+	{method: "GET", path: "/api/presence", tag: "devices", summary: "设备在线记录", params: []apiParam{
+		queryString("mac", "设备MAC"),
+		queryInt("days", "统计天数"),
+	}, response: objectSchema},
+
+	{method: "GET", path: "/api/scanner", tag: "scanner", summary: "扫描器状态", response: objectSchema},
+	{method: "GET", path: "/api/scanner/results", tag: "scanner", summary: "扫描结果", response: objectSchema},
+	{method: "GET", path: "/api/scanner/log", tag: "scanner", summary: "扫描日志", response: objectSchema},
+	{method: "POST", path: "/api/scanner/start", tag: "scanner", summary: "启动扫描器", response: MessageResponse{}},
+	{method: "POST", path: "/api/scanner/stop", tag: "scanner", summary: "停止扫描器", response: MessageResponse{}},
+	{method: "GET", path: "/api/scanner/config", tag: "scanner", summary: "扫描器配置", response: config.ScannerConfig{}},
+	{method: "POST", path: "/api/scanner/config", tag: "scanner", summary: "更新扫描器配置", request: config.ScannerConfig{}, response: MessageResponse{}},
+	{method: "GET", path: "/api/scanner/profiles", tag: "scanner", summary: "扫描方案", response: objectSchema},
+	{method: "GET", path: "/api/scanner/jobs", tag: "scanner", summary: "扫描任务列表，带id时返回单个任务及结果", params: []apiParam{queryString("id", "任务ID")}, response: oneOf{objectSchema, dhcp.ScanJob{}}},
+	{method: "POST", path: "/api/scanner/jobs", tag: "scanner", summary: "提交扫描任务", request: ScanJobRequest{}, response: objectSchema, status: http.StatusAccepted},
+	{method: "DELETE", path: "/api/scanner/jobs", tag: "scanner", summary: "取消扫描任务", params: []apiParam{queryString("id", "任务ID")}, response: MessageResponse{}},
+
+	{method: "GET", path: "/api/bindings", tag: "bindings", summary: "静态绑定列表", response: []BindingInfo{}},
+	{method: "POST", path: "/api/bindings", tag: "bindings", summary: "添加静态绑定", request: BindingInfo{}, response: BindingInfo{}, status: http.StatusCreated},
+	{method: "PUT", path: "/api/bindings", tag: "bindings", summary: "更新静态绑定", request: BindingUpdateRequest{}, response: BindingInfo{}},
+	{method: "DELETE", path: "/api/bindings", tag: "bindings", summary: "删除静态绑定", request: BindingDeleteRequest{}, status: http.StatusNoContent},
+
+	{method: "GET", path: "/api/config", tag: "config", summary: "当前配置，raw=true时返回配置文件原文", params: []apiParam{queryBool("raw", "返回原始YAML")}, response: oneOf{ConfigResponse{}, RawConfigResponse{}}},
+	{method: "POST", path: "/api/config", tag: "config", summary: "保存完整配置文件", request: ConfigSaveRequest{}, response: objectSchema},
+	{method: "POST", path: "/api/config/validate", tag: "config", summary: "验证配置文件", request: ConfigValidateRequest{}, response: objectSchema},
+	{method: "GET", path: "/api/config/backups", tag: "config", summary: "配置备份列表", response: []config.BackupInfo{}},
+	{method: "POST", path: "/api/config/restore", tag: "config", summary: "从备份恢复配置", request: ConfigRestoreRequest{}, response: objectSchema},
+	{method: "POST", path: "/api/config/reload", tag: "config", summary: "重新加载配置文件", response: objectSchema},
+	{method: "GET", path: "/api/config/server", tag: "config", summary: "服务器配置", response: config.ServerConfig{}},
+	{method: "POST", path: "/api/config/server", tag: "config", summary: "更新服务器配置", request: config.ServerConfig{}, response: objectSchema},
+	{method: "GET", path: "/api/config/network", tag: "config", summary: "网络配置", response: config.NetworkConfig{}},
+	{method: "POST", path: "/api/config/network", tag: "config", summary: "更新网络配置", request: config.NetworkConfig{}, response: objectSchema},
+	{method: "GET", path: "/api/config/health-check", tag: "config", summary: "网关健康检查配置", response: config.HealthConfig{}},
+	{method: "POST", path: "/api/config/health-check", tag: "config", summary: "更新网关健康检查配置", request: config.HealthConfig{}, response: objectSchema},
+
+	{method: "GET", path: "/api/logs", tag: "logs", summary: "最近的日志", params: []apiParam{queryInt("limit", "行数，默认500")}, response: LogsResponse{}},
+	{method: "GET", path: "/api/logs/stream", tag: "logs", summary: "实时日志（Server-Sent Events）", response: textSchema, contentType: "text/event-stream"},
+	{method: "GET", path: "/api/events", tag: "logs", summary: "最近的事件", params: []apiParam{
+		queryString("type", "事件类型"),
+		queryInt("limit", "最多返回条数"),
+	}, response: objectSchema},
+
+	{method: "GET", path: "/api/security/rogue-servers", tag: "security", summary: "检测到的非法DHCP服务器", response: objectSchema},
+	{method: "DELETE", path: "/api/security/rogue-servers", tag: "security", summary: "清除非法DHCP服务器记录", params: []apiParam{queryString("server_id", "服务器标识")}, response: MessageResponse{}},
+	{method: "GET", path: "/api/security/access", tag: "security", summary: "访问控制策略", response: config.AccessConfig{}},
+	{method: "POST", path: "/api/security/access", tag: "security", summary: "更新访问控制策略", request: config.AccessConfig{}, response: objectSchema},
+	{method: "PUT", path: "/api/security/access", tag: "security", summary: "更新访问控制策略", request: config.AccessConfig{}, response: objectSchema},
+	{method: "POST", path: "/api/security/access/list", tag: "security", summary: "添加访问控制规则", request: AccessListRequest{}, response: objectSchema},
+	{method: "DELETE", path: "/api/security/access/list", tag: "security", summary: "删除访问控制规则", params: []apiParam{
+		queryString("list", "allow 或 deny"),
+		queryString("mac", "MAC地址或前缀"),
+	}, response: objectSchema},
+	{method: "GET", path: "/api/security/quarantine", tag: "security", summary: "隔离配置及隔离中的租约", response: objectSchema},
+	{method: "POST", path: "/api/security/quarantine", tag: "security", summary: "更新隔离配置", request: config.QuarantineConfig{}, response: objectSchema},
+	{method: "PUT", path: "/api/security/quarantine", tag: "security", summary: "更新隔离配置", request: config.QuarantineConfig{}, response: objectSchema},
+	{method: "GET", path: "/api/security/flood", tag: "security", summary: "防洪保护状态", response: objectSchema},
+
+	{method: "GET", path: "/api/v1/leases", tag: "v1", summary: "租约分页列表，排序字段: ip、mac、hostname、start_time", params: withPage(
+		queryString("mac", "按MAC过滤"),
+		queryString("hostname", "主机名包含"),
+		queryString("gateway", "网关名称或IP"),
+		queryBool("static", "是否静态绑定"),
+		queryString("state", "active 或 expired"),
+	), response: LeaseList{}},
+	{method: "GET", path: "/api/v1/leases/{ip}", tag: "v1", summary: "单个租约", response: LeaseInfo{}},
+	{method: "GET", path: "/api/v1/bindings", tag: "v1", summary: "静态绑定分页列表，排序字段: alias、ip、mac", params: withPage(
+		queryString("mac", "按MAC过滤"),
+		queryString("gateway", "网关名称"),
+		queryString("q", "别名、主机名或IP包含"),
+	), response: BindingList{}},
+	{method: "POST", path: "/api/v1/bindings", tag: "v1", summary: "创建静态绑定", request: BindingInfo{}, response: BindingInfo{}, status: http.StatusCreated},
+	{method: "GET", path: "/api/v1/bindings/{alias}", tag: "v1", summary: "单个静态绑定", response: BindingInfo{}},
+	{method: "PUT", path: "/api/v1/bindings/{alias}", tag: "v1", summary: "更新静态绑定", request: BindingInfo{}, response: BindingInfo{}},
+	{method: "DELETE", path: "/api/v1/bindings/{alias}", tag: "v1", summary: "删除静态绑定", status: http.StatusNoContent},
+	{method: "GET", path: "/api/v1/devices", tag: "v1", summary: "设备分页列表，排序字段: mac、hostname、device_type、owner、last_seen", params: withPage(
+		queryString("type", "设备类型"),
+		queryString("owner", "所有者"),
+		queryString("tag", "标签"),
+		queryBool("active", "是否活跃"),
+		queryString("q", "MAC、主机名、型号、描述或所有者包含"),
+	), response: DeviceList{}},
+	{method: "POST", path: "/api/v1/devices", tag: "v1", summary: "创建设备", request: config.DeviceInfo{}, response: config.DeviceInfo{}, status: http.StatusCreated},
+	{method: "GET", path: "/api/v1/devices/{mac}", tag: "v1", summary: "单个设备", response: config.DeviceInfo{}},
+	{method: "PUT", path: "/api/v1/devices/{mac}", tag: "v1", summary: "更新设备", request: config.DeviceInfo{}, response: config.DeviceInfo{}},
+	{method: "DELETE", path: "/api/v1/devices/{mac}", tag: "v1", summary: "删除设备及其静态绑定", response: objectSchema},
+}
+
+// apiTags 接口分组及说明，按文档中的顺序排列
+var apiTags = [][2]string{
+	{"system", "系统与监控"},
+	{"auth", "认证、令牌与审计"},
+	{"leases", "租约与统计"},
+	{"gateways", "网关"},
+	{"devices", "设备、设备组与时间表"},
+	{"scanner", "网络扫描"},
+	{"bindings", "静态绑定"},
+	{"config", "配置管理"},
+	{"logs", "日志与事件"},
+	{"security", "安全"},
+	{"v1", "版本化API，统一错误响应和游标分页"},
+	{"pages", "Web页面"},
+}
+
+// pathParamPattern 匹配路径参数{name}
+var pathParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+// routePattern 操作对应的路由：带参数的路径由以/结尾的子树路由处理
+func (op apiOperation) routePattern() string {
+	if i := strings.Index(op.path, "{"); i >= 0 {
+		return op.path[:i]
+	}
+	return op.path
+}
+
+// operationID 由方法和路径生成，如 getApiV1LeasesIp
+func (op apiOperation) operationID() string {
+	id := strings.ToLower(op.method)
+	for _, part := range regexp.MustCompile(`[^A-Za-z0-9]+`).Split(op.path, -1) {
+		if part != "" {
+			id += strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	if op.path == "/" {
+		id += "Index"
+	}
+	return id
+}
+
+// DocumentedMethods 返回OpenAPI文档中每个路由的请求方法
+func (api *APIServer) DocumentedMethods() map[string][]string {
+	result := make(map[string][]string)
+	for _, op := range apiOperations {
+		pattern := op.routePattern()
+		result[pattern] = append(result[pattern], op.method)
+	}
+	return result
+}
+
+// schemaBuilder 由Go类型生成JSON Schema，命名结构体放入components
+type schemaBuilder struct {
+	schemas map[string]interface{}
+	names   map[reflect.Type]string
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	durationType      = reflect.TypeOf(time.Duration(0))
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// schemaFor 生成值对应的Schema
+func (b *schemaBuilder) schemaFor(v interface{}) interface{} {
+	switch s := v.(type) {
+	case rawSchema:
+		return map[string]interface{}(s)
+	case oneOf:
+		var schemas []interface{}
+		for _, item := range s {
+			schemas = append(schemas, b.schemaFor(item))
+		}
+		return map[string]interface{}{"oneOf": schemas}
+	}
+	return b.schema(reflect.TypeOf(v))
+}
+
+// schema 按encoding/json的编码规则生成类型的Schema
+func (b *schemaBuilder) schema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == durationType:
+		return map[string]interface{}{"type": "integer", "format": "int64", "description": "纳秒"}
+	case t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType):
+		return map[string]interface{}{}
+	case t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType):
+		return map[string]interface{}{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + b.define(t)}
+	}
+	return map[string]interface{}{}
+}
+
+// define 将命名结构体放入components并返回名称，不同包的同名类型加包名前缀
+func (b *schemaBuilder) define(t reflect.Type) string {
+	if name, ok := b.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, taken := b.schemas[name]; taken {
+		pkg := t.PkgPath()
+		name = pkg[strings.LastIndex(pkg, "/")+1:] + "." + name
+	}
+	b.names[t] = name
+	b.schemas[name] = map[string]interface{}{} // 占位，允许递归引用
+	b.schemas[name] = b.structSchema(t)
+	return name
+}
+
+// structSchema 生成结构体的对象Schema，匿名嵌入的结构体字段展开到外层
+func (b *schemaBuilder) structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	b.addFields(t, properties)
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+func (b *schemaBuilder) addFields(t reflect.Type, properties map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			b.addFields(fieldType, properties)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue // 未导出字段
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if strings.Contains(tag, ",string") {
+			properties[name] = map[string]interface{}{"type": "string"}
+			continue
+		}
+		properties[name] = b.schema(field.Type)
+	}
+}
+
+// OpenAPIDocument 生成OpenAPI 3文档，认证要求和所需权限取自路由注册时的声明
+func (api *APIServer) OpenAPIDocument() map[string]interface{} {
+	builder := &schemaBuilder{schemas: make(map[string]interface{}), names: make(map[reflect.Type]string)}
+	legacyError := map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"error": map[string]interface{}{"type": "string"}},
+	}
+	v1Error := builder.schemaFor(ErrorResponse{})
+
+	paths := make(map[string]interface{})
+	for _, op := range apiOperations {
+		operation := map[string]interface{}{
+			"operationId": op.operationID(),
+			"summary":     op.summary,
+			"tags":        []string{op.tag},
+		}
+
+		var parameters []interface{}
+		for _, match := range pathParamPattern.FindAllStringSubmatch(op.path, -1) {
+			parameters = append(parameters, map[string]interface{}{
+				"name": match[1], "in": "path", "required": true,
+				"schema": map[string]interface{}{"type": "string"},
+			})
+		}
+		for _, param := range op.params {
+			parameters = append(parameters, map[string]interface{}{
+				"name": param.name, "in": "query", "description": param.description,
+				"schema": map[string]interface{}{"type": param.typ},
+			})
+		}
+		versioned := api.versionedPaths[op.routePattern()]
+		if versioned && isMutating(op.method) {
+			parameters = append(parameters, map[string]interface{}{
+				"name": "If-Match", "in": "header", "required": true,
+				"description": "最近一次响应的ETag（配置修订号），缺少时返回428，不一致时返回412",
+				"schema":      map[string]interface{}{"type": "string"},
+			})
+		}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+
+		if op.request != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": builder.schemaFor(op.request)},
+				},
+			}
+		}
+
+		status := op.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := map[string]interface{}{"description": http.StatusText(status)}
+		if versioned {
+			success["headers"] = map[string]interface{}{
+				"ETag": map[string]interface{}{
+					"description": "当前配置修订号",
+					"schema":      map[string]interface{}{"type": "string"},
+				},
+			}
+		}
+		if op.response != nil {
+			contentType := op.contentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			success["content"] = map[string]interface{}{
+				contentType: map[string]interface{}{"schema": builder.schemaFor(op.response)},
+			}
+		}
+		errorSchema := legacyError
+		if strings.HasPrefix(op.path, "/api/v1/") {
+			errorSchema = v1Error.(map[string]interface{})
+		}
+		operation["responses"] = map[string]interface{}{
+			strconv.Itoa(status): success,
+			"default": map[string]interface{}{
+				"description": "错误",
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": errorSchema},
+				},
+			},
+		}
+
+		switch permission := api.routes[op.routePattern()]; permission {
+		case permPublic:
+			operation["security"] = []interface{}{}
+		case permAuthenticated, "":
+		default:
+			operation["x-required-scope"] = requiredScope(permission, op.method)
+		}
+
+		item, ok := paths[op.path].(map[string]interface{})
+		if !ok {
+			item = make(map[string]interface{})
+			paths[op.path] = item
+		}
+		item[strings.ToLower(op.method)] = operation
+	}
+
+	var tags []interface{}
+	for _, tag := range apiTags {
+		tags = append(tags, map[string]interface{}{"name": tag[0], "description": tag[1]})
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "DHCP Server Management API",
+			"version":     "1.0.0",
+			"description": "DHCP服务器管理接口。写操作需要对应资源的write权限，见各操作的x-required-scope。",
+		},
+		"tags":  tags,
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": builder.schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer"},
+				"cookieAuth": map[string]interface{}{"type": "apiKey", "in": "cookie", "name": sessionCookieName},
+			},
+		},
+		"security": []interface{}{
+			map[string]interface{}{"bearerAuth": []string{}},
+			map[string]interface{}{"cookieAuth": []string{}},
+		},
+	}
+}
+
+// handleOpenAPI 输出OpenAPI文档
+func (api *APIServer) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(api.OpenAPIDocument())
+}
+
+// handleDocsPage 交互式API文档页面，读取 /api/openapi.json 渲染并可直接发送请求
+func (api *APIServer) handleDocsPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(docsPageHTML))
+}
+
+const docsPageHTML = `<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>API文档 - DHCP服务器管理</title>
+    <style>
+        * { margin: 0; padding: 0; box-sizing: border-box; }
+        body {
+            font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, Arial, sans-serif;
+            background: #f5f6fa; color: #2d3436; line-height: 1.5;
+        }
+        header {
+            background: linear-gradient(135deg, #0984e3 0%, #00b894 100%); color: #fff;
+            padding: 18px 28px; display: flex; align-items: center; gap: 16px; flex-wrap: wrap;
+        }
+        header h1 { font-size: 20px; flex: 1; }
+        header a { color: #fff; font-size: 14px; }
+        header input {
+            padding: 6px 10px; border: none; border-radius: 6px; width: 280px; font-size: 13px;
+        }
+        main { max-width: 1100px; margin: 0 auto; padding: 20px; }
+        .tag { margin-bottom: 24px; }
+        .tag h2 { font-size: 17px; margin-bottom: 8px; }
+        .tag h2 small { color: #636e72; font-weight: normal; font-size: 13px; margin-left: 8px; }
+        .op { background: #fff; border-radius: 8px; margin-bottom: 6px; box-shadow: 0 1px 3px rgba(0,0,0,0.08); }
+        .op summary { padding: 10px 14px; cursor: pointer; display: flex; gap: 12px; align-items: center; }
+        .method {
+            display: inline-block; min-width: 64px; text-align: center; border-radius: 4px;
+            color: #fff; font-size: 12px; font-weight: bold; padding: 2px 0;
+        }
+        .GET { background: #0984e3; } .POST { background: #00b894; }
+        .PUT { background: #fdcb6e; color: #2d3436; } .DELETE { background: #d63031; }
+        .path { font-family: monospace; font-size: 14px; }
+        .summary { color: #636e72; font-size: 13px; flex: 1; }
+        .scope { font-size: 12px; color: #6c5ce7; font-family: monospace; }
+        .body { padding: 0 14px 14px; border-top: 1px solid #dfe6e9; }
+        .body h3 { font-size: 13px; margin: 12px 0 6px; color: #636e72; }
+        table { border-collapse: collapse; width: 100%; font-size: 13px; }
+        td { padding: 4px 6px; border-bottom: 1px solid #f1f2f6; vertical-align: middle; }
+        td input { width: 100%; padding: 4px 6px; border: 1px solid #dfe6e9; border-radius: 4px; }
+        pre, textarea {
+            font-family: monospace; font-size: 12px; background: #f8f9fa; border: 1px solid #dfe6e9;
+            border-radius: 6px; padding: 8px; overflow: auto; max-height: 360px; width: 100%;
+        }
+        textarea { min-height: 120px; }
+        button {
+            margin-top: 10px; padding: 6px 16px; border: none; border-radius: 6px;
+            background: #0984e3; color: #fff; cursor: pointer;
+        }
+        .status { font-size: 13px; margin: 10px 0 4px; font-weight: bold; }
+        .error { color: #d63031; padding: 20px; }
+    </style>
+</head>
+<body>
+    <header>
+        <h1 id="title">API文档</h1>
+        <input id="token" placeholder="Bearer令牌（可选，默认使用登录会话）">
+        <a href="/api/openapi.json" target="_blank">openapi.json</a>
+        <a href="/">返回管理界面</a>
+    </header>
+    <main id="content">加载中...</main>
+    <script>
+        let spec = null;
+
+        function el(tag, attrs, ...children) {
+            const node = document.createElement(tag);
+            for (const [k, v] of Object.entries(attrs || {})) {
+                if (k === 'class') node.className = v; else node.setAttribute(k, v);
+            }
+            for (const child of children) {
+                if (child !== null && child !== undefined) {
+                    node.append(child instanceof Node ? child : String(child));
+                }
+            }
+            return node;
+        }
+
+        function resolve(schema) {
+            while (schema && schema.$ref) {
+                schema = spec.components.schemas[schema.$ref.split('/').pop()];
+            }
+            return schema || {};
+        }
+
+        // example 由Schema生成示例值，depth防止递归引用无限展开
+        function example(schema, depth) {
+            schema = resolve(schema);
+            if (depth > 6) return null;
+            if (schema.oneOf) return example(schema.oneOf[0], depth + 1);
+            switch (schema.type) {
+                case 'object': {
+                    const result = {};
+                    for (const [name, prop] of Object.entries(schema.properties || {})) {
+                        result[name] = example(prop, depth + 1);
+                    }
+                    return result;
+                }
+                case 'array': return [example(schema.items, depth + 1)];
+                case 'integer': case 'number': return 0;
+                case 'boolean': return false;
+                case 'string': return schema.format === 'date-time' ? new Date().toISOString() : '';
+            }
+            return null;
+        }
+
+        function schemaOf(content) {
+            const first = content && Object.values(content)[0];
+            return first ? first.schema : null;
+        }
+
+        // 最近一次响应中的配置修订号，修改请求作为If-Match发送
+        let lastETag = '';
+
+        async function send(method, path, op, inputs, bodyArea, output) {
+            let url = path;
+            const query = new URLSearchParams();
+            const headers = {};
+            if (lastETag && method !== 'GET') headers['If-Match'] = lastETag;
+            for (const param of op.parameters || []) {
+                const value = inputs[param.name].value;
+                if (param.in === 'header') {
+                    if (value !== '') headers[param.name] = value;
+                } else if (param.in === 'path') {
+                    url = url.replace('{' + param.name + '}', encodeURIComponent(value));
+                } else if (value !== '') {
+                    query.set(param.name, value);
+                }
+            }
+            if ([...query].length) url += '?' + query;
+
+            const token = document.getElementById('token').value.trim();
+            if (token) headers['Authorization'] = 'Bearer ' + token;
+            const options = { method, headers, credentials: 'same-origin' };
+            if (bodyArea) {
+                headers['Content-Type'] = 'application/json';
+                options.body = bodyArea.value;
+            }
+
+            output.replaceChildren(el('div', { class: 'status' }, '请求中...'));
+            try {
+                const resp = await fetch(url, options);
+                if (resp.headers.get('ETag')) lastETag = resp.headers.get('ETag');
+                let text = await resp.text();
+                try { text = JSON.stringify(JSON.parse(text), null, 2); } catch (e) {}
+                output.replaceChildren(
+                    el('div', { class: 'status' }, resp.status + ' ' + resp.statusText),
+                    el('pre', {}, text));
+            } catch (e) {
+                output.replaceChildren(el('div', { class: 'status' }, '请求失败: ' + e));
+            }
+        }
+
+        function renderOperation(method, path, op) {
+            const upper = method.toUpperCase();
+            const body = el('div', { class: 'body' });
+            const inputs = {};
+
+            if (op.parameters && op.parameters.length) {
+                const table = el('table');
+                for (const param of op.parameters) {
+                    inputs[param.name] = el('input', { placeholder: param.in === 'header' ? '默认使用最近一次响应的ETag' : param.schema.type });
+                    table.append(el('tr', {},
+                        el('td', {}, el('code', {}, param.name), param.in === 'path' ? ' *' : ''),
+                        el('td', {}, param.description || ''),
+                        el('td', {}, inputs[param.name])));
+                }
+                body.append(el('h3', {}, '参数'), table);
+            }
+
+            let bodyArea = null;
+            if (op.requestBody) {
+                const schema = schemaOf(op.requestBody.content);
+                bodyArea = el('textarea');
+                bodyArea.value = JSON.stringify(example(schema, 0), null, 2);
+                body.append(el('h3', {}, '请求体'), bodyArea);
+            }
+
+            for (const [status, resp] of Object.entries(op.responses)) {
+                const schema = schemaOf(resp.content);
+                body.append(el('h3', {}, '响应 ' + status + ' ' + resp.description));
+                if (schema) body.append(el('pre', {}, JSON.stringify(example(schema, 0), null, 2)));
+            }
+
+            const output = el('div');
+            const button = el('button', {}, '发送请求');
+            button.addEventListener('click', () => send(upper, path, op, inputs, bodyArea, output));
+            body.append(button, output);
+
+            return el('details', { class: 'op' },
+                el('summary', {},
+                    el('span', { class: 'method ' + upper }, upper),
+                    el('span', { class: 'path' }, path),
+                    el('span', { class: 'summary' }, op.summary || ''),
+                    op['x-required-scope'] ? el('span', { class: 'scope' }, op['x-required-scope']) : null),
+                body);
+        }
+
+        function render() {
+            document.getElementById('title').textContent = spec.info.title + ' ' + spec.info.version;
+            const content = document.getElementById('content');
+            content.replaceChildren();
+            for (const tag of spec.tags) {
+                const section = el('section', { class: 'tag' },
+                    el('h2', {}, tag.name, el('small', {}, tag.description)));
+                for (const path of Object.keys(spec.paths).sort()) {
+                    for (const [method, op] of Object.entries(spec.paths[path])) {
+                        if (op.tags.includes(tag.name)) section.append(renderOperation(method, path, op));
+                    }
+                }
+                content.append(section);
+            }
+        }
+
+        fetch('/api/openapi.json', { credentials: 'same-origin' })
+            .then(resp => {
+                if (!resp.ok) throw new Error('HTTP ' + resp.status);
+                return resp.json();
+            })
+            .then(data => { spec = data; render(); })
+            .catch(e => {
+                document.getElementById('content').replaceChildren(
+                    el('div', { class: 'error' }, '加载OpenAPI文档失败: ' + e.message));
+            });
+    </script>
+</body>
+</html>
+`
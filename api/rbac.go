@@ -0,0 +1,178 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"dhcp-server/config"
+)
+
+// 路由权限：资源名称，读写由请求方法决定（GET/HEAD为read，其余为write）；
+// 以下两个特殊值不对应资源
+const (
+	permPublic        = "public"        // 无需认证
+	permAuthenticated = "authenticated" // 任意已认证身份
+)
+
+// 受保护的资源
+const (
+	resourceLeases   = "leases"
+	resourceHistory  = "history"
+	resourceStats    = "stats"
+	resourceDevices  = "devices"
+	resourceBindings = "bindings"
+	resourceScanner  = "scanner"
+	resourceConfig   = "config"
+	resourceGateways = "gateways"
+	resourceSecurity = "security"
+	resourceLogs     = "logs"
+	resourceEvents   = "events"
+	resourceServer   = "server"
+	resourceTokens   = "tokens"
+	resourceAudit    = "audit"
+)
+
+// knownResources 可在令牌权限范围中使用的资源
+var knownResources = []string{
+	resourceLeases, resourceHistory, resourceStats, resourceDevices, resourceBindings, resourceScanner,
+	resourceConfig, resourceGateways, resourceSecurity, resourceLogs, resourceEvents, resourceServer, resourceTokens,
+	resourceAudit,
+}
+
+// roleScopes 各角色拥有的权限，"*"表示全部
+var roleScopes = map[string][]string{
+	config.RoleViewer: {
+		"leases:read", "stats:read", "history:read",
+	},
+	config.RoleOperator: {
+		"leases:read", "stats:read", "history:read",
+		"devices:read", "devices:write",
+		"bindings:read", "bindings:write",
+		"scanner:read", "scanner:write",
+	},
+	config.RoleAdmin: {"*"},
+}
+
+// RouteMux 注册路由所需的接口，*http.ServeMux满足该接口
+type RouteMux interface {
+	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
+}
+
+// route 注册路由并声明所需权限
+func (api *APIServer) route(mux RouteMux, pattern, permission string, handler func(http.ResponseWriter, *http.Request)) {
+	if api.routes == nil {
+		api.routes = make(map[string]string)
+	}
+	api.routes[pattern] = permission
+	mux.HandleFunc(pattern, handler)
+}
+
+// RoutePermissions 返回已注册路由及其权限声明
+func (api *APIServer) RoutePermissions() map[string]string {
+	result := make(map[string]string, len(api.routes))
+	for pattern, permission := range api.routes {
+		result[pattern] = permission
+	}
+	return result
+}
+
+// requiredScope 根据路由权限和请求方法计算所需权限，如 devices:write
+func requiredScope(permission, method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		return permission + ":read"
+	}
+	return permission + ":write"
+}
+
+// roleAllows 检查角色是否拥有指定权限
+func roleAllows(role, scope string) bool {
+	for _, granted := range roleScopes[role] {
+		if granted == "*" || granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// scopeAllows 检查令牌权限范围是否包含指定权限：
+// "*"表示全部，"资源:*"表示该资源的读写，"资源:write"同时包含读
+func scopeAllows(scopes []string, scope string) bool {
+	resource := strings.SplitN(scope, ":", 2)[0]
+	for _, granted := range scopes {
+		switch granted {
+		case "*", scope, resource + ":*":
+			return true
+		case resource + ":write":
+			if scope == resource+":read" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ValidateScopes 检查令牌权限范围格式：*、资源:read、资源:write 或 资源:*
+func ValidateScopes(scopes []string) error {
+	for _, scope := range scopes {
+		if scope == "*" {
+			continue
+		}
+		parts := strings.SplitN(scope, ":", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("无效的权限范围: %s", scope)
+		}
+		switch parts[1] {
+		case "read", "write", "*":
+		default:
+			return fmt.Errorf("无效的权限范围: %s", scope)
+		}
+		known := false
+		for _, resource := range knownResources {
+			if parts[0] == resource {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("未知的资源: %s", parts[0])
+		}
+	}
+	return nil
+}
+
+// allows 检查身份是否拥有指定权限，配置了权限范围的令牌只按范围判断
+func (p Principal) allows(scope string) bool {
+	if p.Kind == authKindToken && len(p.Scopes) > 0 {
+		return scopeAllows(p.Scopes, scope)
+	}
+	return roleAllows(p.Role, scope)
+}
+
+// isAdmin 检查身份是否拥有全部权限
+func (p Principal) isAdmin() bool {
+	if p.Kind == authKindToken && len(p.Scopes) > 0 {
+		return scopeAllows(p.Scopes, "*")
+	}
+	return p.Role == config.RoleAdmin
+}
+
+// authorize 检查身份是否满足路由权限，未声明权限的路由只允许admin访问
+func (p Principal) authorize(permission, method string) bool {
+	switch permission {
+	case permPublic, permAuthenticated:
+		return true
+	case "":
+		return p.isAdmin()
+	}
+	return p.allows(requiredScope(permission, method))
+}
+
+// writeForbidden 返回权限不足错误
+func writeForbidden(w http.ResponseWriter, scope string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(map[string]string{"error": "权限不足，需要 " + scope})
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"dhcp-server/config"
+)
+
+// revisionETag 配置修订号对应的ETag
+func revisionETag(revision uint64) string {
+	return `"` + strconv.FormatUint(revision, 10) + `"`
+}
+
+// configETag 当前配置修订号对应的ETag
+func (api *APIServer) configETag() string {
+	return revisionETag(api.config.Revision)
+}
+
+// revisionWriter 在输出响应头时附带处理后的配置修订号
+type revisionWriter struct {
+	http.ResponseWriter
+	api         *APIServer
+	wroteHeader bool
+}
+
+// WriteHeader 处理函数未设置ETag时使用当前配置修订号
+func (rw *revisionWriter) WriteHeader(status int) {
+	if !rw.wroteHeader {
+		rw.wroteHeader = true
+		if rw.Header().Get("ETag") == "" {
+			rw.Header().Set("ETag", rw.api.configETag())
+		}
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+// Write 写入响应
+func (rw *revisionWriter) Write(data []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	return rw.ResponseWriter.Write(data)
+}
+
+// matchesETag 判断If-Match是否包含指定ETag，支持多个值、弱校验前缀和*
+func matchesETag(ifMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// versioned 修改配置的路由：响应附带配置修订号ETag，修改请求必须携带与当前修订号一致的If-Match，
+// 缺少时返回428，不一致时返回412，避免多个管理员同时编辑时互相覆盖。
+// 修改请求从检查If-Match到处理完成一直持有configMutex，检查通过的请求不会基于过期的配置保存
+func (api *APIServer) versioned(handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rw := &revisionWriter{ResponseWriter: w, api: api}
+
+		if isMutating(r.Method) {
+			api.configMutex.Lock()
+			defer api.configMutex.Unlock()
+
+			ifMatch := r.Header.Get("If-Match")
+			if ifMatch == "" {
+				rw.Header().Set("Content-Type", "application/json")
+				rw.WriteHeader(http.StatusPreconditionRequired)
+				json.NewEncoder(rw).Encode(map[string]string{"error": "修改配置需要携带If-Match请求头，请先读取获得ETag"})
+				return
+			}
+			if !matchesETag(ifMatch, api.configETag()) {
+				rw.Header().Set("Content-Type", "application/json")
+				rw.WriteHeader(http.StatusPreconditionFailed)
+				json.NewEncoder(rw).Encode(map[string]string{"error": "配置已被其他人修改，请刷新后重试"})
+				return
+			}
+		}
+
+		handler(rw, r)
+	}
+}
+
+// versionedRoute 注册修改配置的路由，修改请求需要携带If-Match
+func (api *APIServer) versionedRoute(mux RouteMux, pattern, permission string, handler func(http.ResponseWriter, *http.Request)) {
+	if api.versionedPaths == nil {
+		api.versionedPaths = make(map[string]bool)
+	}
+	api.versionedPaths[pattern] = true
+	api.route(mux, pattern, permission, api.versioned(handler))
+}
+
+// saveErrorStatus 保存配置失败时的状态码，配置文件被外部修改时返回412
+func saveErrorStatus(err error) int {
+	if err == config.ErrConfigModified {
+		return http.StatusPreconditionFailed
+	}
+	return http.StatusInternalServerError
+}
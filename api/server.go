@@ -9,14 +9,20 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"dhcp-server/audit"
 	"dhcp-server/config"
 	"dhcp-server/dhcp"
+	"dhcp-server/events"
+	"dhcp-server/fingerprint"
 	"dhcp-server/gateway"
+	"dhcp-server/oui"
+	"dhcp-server/presence"
 )
 
 // SSE日志连接管理
@@ -29,6 +35,14 @@ var (
 // 日志广播器
 func init() {
 	go logBroadcaster()
+
+	// 事件通知可以推送到日志流
+	events.SetStreamWriter(func(line string) {
+		select {
+		case logBroadcast <- line:
+		default:
+		}
+	})
 }
 
 // logBroadcaster 负责向所有连接的客户端广播日志
@@ -81,45 +95,11 @@ type APIServer struct {
 	server     *http.Server
 	// 添加重新加载回调函数
 	reloadCallback func(*config.Config) error
-}
-
-// LeaseInfo 租约信息响应
-type LeaseInfo struct {
-	IP            string    `json:"ip"`
-	MAC           string    `json:"mac"`
-	Hostname      string    `json:"hostname"`
-	StartTime     time.Time `json:"start_time"`
-	LeaseTime     string    `json:"lease_time"`
-	RemainingTime string    `json:"remaining_time"`
-	IsStatic      bool      `json:"is_static"`
-	Gateway       string    `json:"gateway"`    // 配置中的网关名称（保持兼容性）
-	GatewayIP     string    `json:"gateway_ip"` // 实际响应的网关IP地址
-	IsExpired     bool      `json:"is_expired"`
-}
-
-// HistoryRecord 历史记录
-type HistoryRecord struct {
-	IP        string    `json:"ip"`
-	MAC       string    `json:"mac"`
-	Hostname  string    `json:"hostname"`
-	Action    string    `json:"action"` // DISCOVER, REQUEST, RELEASE, etc.
-	Timestamp time.Time `json:"timestamp"`
-	Gateway   string    `json:"gateway"`
-	ServerIP  string    `json:"server_ip"`
-}
-
-// StatsResponse 统计信息响应
-type StatsResponse struct {
-	PoolStats     map[string]interface{} `json:"pool_stats"`
-	GatewayStatus map[string]bool        `json:"gateway_status"`
-	ServerInfo    ServerInfo             `json:"server_info"`
-}
-
-// ServerInfo 服务器信息
-type ServerInfo struct {
-	Version   string    `json:"version"`
-	StartTime time.Time `json:"start_time"`
-	Uptime    string    `json:"uptime"`
+	auth           *authManager      // 登录会话管理
+	routes         map[string]string // 路由权限声明
+	versionedPaths map[string]bool   // 修改请求需要If-Match的路由
+	auditMutex     sync.Mutex        // 保存配置时依次读取前后快照，便于审计配置差异
+	configMutex    sync.Mutex        // 修改配置的请求从检查If-Match到保存完成期间持有，避免并发修改互相覆盖
 }
 
 // NewAPIServer 创建新的API服务器
@@ -139,6 +119,7 @@ func NewAPIServer(pool *dhcp.IPPool, checker *gateway.HealthChecker, cfg *config
 		scanner:    scanner,
 		port:       port,
 		host:       host,
+		auth:       newAuthManager(),
 	}
 }
 
@@ -151,6 +132,7 @@ func (api *APIServer) SetReloadCallback(callback func(*config.Config) error) {
 func (api *APIServer) UpdateReferences(pool *dhcp.IPPool, checker *gateway.HealthChecker, cfg *config.Config, dhcpServer *dhcp.Server, scanner *dhcp.NetworkScanner) {
 	api.pool = pool
 	api.checker = checker
+	cfg.FollowRevision(api.config)
 	api.config = cfg
 	api.dhcpServer = dhcpServer
 	api.scanner = scanner
@@ -165,17 +147,23 @@ func (api *APIServer) UpdateReferences(pool *dhcp.IPPool, checker *gateway.Healt
 
 // Start 启动API服务器
 func (api *APIServer) Start() error {
-	mux := http.NewServeMux()
+	api.server = &http.Server{
+		Addr:    fmt.Sprintf("%s:%d", api.host, api.port),
+		Handler: api.Handler(),
+	}
 
-	// 注册路由到mux
-	api.RegisterRoutes(mux)
+	if api.config.TLS.Enabled {
+		tlsConfig, err := buildTLSConfig(api.config.TLS, api.host)
+		if err != nil {
+			return fmt.Errorf("TLS配置失败: %v", err)
+		}
+		api.server.TLSConfig = tlsConfig
 
-	// 添加CORS支持
-	corsHandler := corsMiddleware(mux)
+		log.Printf("启动HTTPS API服务器，地址: %s:%d", api.host, api.port)
+		log.Printf("API文档: https://%s:%d", api.host, api.port)
 
-	api.server = &http.Server{
-		Addr:    fmt.Sprintf("%s:%d", api.host, api.port),
-		Handler: corsHandler,
+		// 证书由TLSConfig.GetCertificate提供
+		return api.server.ListenAndServeTLS("", "")
 	}
 
 	log.Printf("启动HTTP API服务器，地址: %s:%d", api.host, api.port)
@@ -193,53 +181,110 @@ func (api *APIServer) Stop() error {
 	return nil
 }
 
-// RegisterRoutes 注册路由
-func (api *APIServer) RegisterRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/", api.handleIndex)
-	mux.HandleFunc("/api/health", api.handleHealth)
-	mux.HandleFunc("/api/leases", api.handleLeases)
-	mux.HandleFunc("/api/leases/active", api.handleActiveLeases)
-	mux.HandleFunc("/api/leases/history", api.handleHistory)
-	mux.HandleFunc("/api/stats", api.handleStats)
+// Handler 创建带认证、权限检查、审计和CORS支持的HTTP处理器
+func (api *APIServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	// 注册路由到mux
+	api.RegisterRoutes(mux)
+
+	// 添加认证和CORS支持，审计在认证之外以便记录被拒绝的请求，/api/v1/ 下的错误统一为错误响应格式
+	return api.corsMiddleware(v1Middleware(api.auditMiddleware(api.authMiddleware(mux))))
+}
+
+// RegisterRoutes 注册路由，每个路由都需要声明所需权限
+func (api *APIServer) RegisterRoutes(mux RouteMux) {
+	api.route(mux, "/", permAuthenticated, api.handleIndex)
+	api.route(mux, "/api/health", permPublic, api.handleHealth)
+	api.route(mux, "/api/openapi.json", permAuthenticated, api.handleOpenAPI)
+	api.route(mux, "/docs", permAuthenticated, api.handleDocsPage)
+
+	// 认证相关路由
+	api.route(mux, "/login", permPublic, api.handleLoginPage)
+	api.route(mux, "/api/auth/login", permPublic, api.handleLogin)
+	api.route(mux, "/api/auth/logout", permAuthenticated, api.handleLogout)
+	api.route(mux, "/api/auth/me", permAuthenticated, api.handleAuthMe)
+	api.route(mux, "/api/auth/password", permAuthenticated, api.handleChangePassword)
+	api.versionedRoute(mux, "/api/auth/tokens", resourceTokens, api.handleTokens)
+
+	// 审计日志
+	api.route(mux, "/api/audit", resourceAudit, api.handleAudit)
+	api.route(mux, "/api/leases", resourceLeases, api.handleLeases)
+	api.route(mux, "/api/leases/active", resourceLeases, api.handleActiveLeases)
+	api.route(mux, "/api/leases/history", resourceHistory, api.handleHistory)
+	api.route(mux, "/api/stats", resourceStats, api.handleStats)
+	api.route(mux, "/metrics", resourceStats, api.handleMetrics)
 
 	// 扫描器相关路由
-	mux.HandleFunc("/api/scanner", api.handleScanner)
-	mux.HandleFunc("/api/scanner/results", api.handleScannerResults)
-	mux.HandleFunc("/api/scanner/log", api.handleScannerLog)
-	mux.HandleFunc("/api/scanner/start", api.handleScannerStart)
-	mux.HandleFunc("/api/scanner/stop", api.handleScannerStop)
-	mux.HandleFunc("/api/scanner/config", api.handleScannerConfig)
-	mux.HandleFunc("/api/gateways", api.handleGateways)
+	api.route(mux, "/api/scanner", resourceScanner, api.handleScanner)
+	api.route(mux, "/api/scanner/results", resourceScanner, api.handleScannerResults)
+	api.route(mux, "/api/scanner/log", resourceScanner, api.handleScannerLog)
+	api.route(mux, "/api/scanner/start", resourceScanner, api.handleScannerStart)
+	api.route(mux, "/api/scanner/stop", resourceScanner, api.handleScannerStop)
+	api.versionedRoute(mux, "/api/scanner/config", resourceConfig, api.handleScannerConfig)
+	api.route(mux, "/api/scanner/profiles", resourceConfig, api.handleScannerProfiles)
+	api.route(mux, "/api/scanner/jobs", resourceScanner, api.handleScannerJobs)
+	api.versionedRoute(mux, "/api/gateways", resourceGateways, api.handleGateways)
 
 	// 设备管理相关端点
-	mux.HandleFunc("/api/devices", api.handleDevices)
-	mux.HandleFunc("/api/devices/discover", api.handleDeviceDiscover)
-	mux.HandleFunc("/api/devices/batch", api.handleBatchAddDevices)
-	mux.HandleFunc("/api/devices/gateway", api.handleDeviceGateway)
+	api.versionedRoute(mux, "/api/devices", resourceDevices, api.handleDevices)
+	api.route(mux, "/api/devices/discover", resourceDevices, api.handleDeviceDiscover)
+	api.versionedRoute(mux, "/api/devices/batch", resourceDevices, api.handleBatchAddDevices)
+	api.versionedRoute(mux, "/api/devices/gateway", resourceDevices, api.handleDeviceGateway)
+	api.route(mux, "/api/devices/policy", resourceDevices, api.handleDevicePolicy)
+	api.versionedRoute(mux, "/api/groups", resourceConfig, api.handleGroups)
+	api.versionedRoute(mux, "/api/schedules", resourceConfig, api.handleSchedules)
 
 	// 静态绑定管理接口
-	mux.HandleFunc("/api/bindings", api.handleStaticBindings)
-	mux.HandleFunc("/api/leases/convert-to-static", api.handleConvertLeaseToStatic)
+	api.versionedRoute(mux, "/api/bindings", resourceBindings, api.handleStaticBindings)
+	api.versionedRoute(mux, "/api/leases/convert-to-static", resourceBindings, api.handleConvertLeaseToStatic)
 
 	// 配置管理相关端点
-	mux.HandleFunc("/api/config", api.handleConfig)
-	mux.HandleFunc("/api/config/validate", api.handleConfigValidate)
-	mux.HandleFunc("/api/config/backups", api.handleConfigBackups)
-	mux.HandleFunc("/api/config/restore", api.handleConfigRestore)
-	mux.HandleFunc("/api/config/reload", api.handleConfigReload)
+	api.versionedRoute(mux, "/api/config", resourceConfig, api.handleConfig)
+	api.route(mux, "/api/config/validate", resourceConfig, api.handleConfigValidate)
+	api.route(mux, "/api/config/backups", resourceConfig, api.handleConfigBackups)
+	api.versionedRoute(mux, "/api/config/restore", resourceConfig, api.handleConfigRestore)
+	api.route(mux, "/api/config/reload", resourceConfig, api.handleConfigReload)
 
 	// 日志管理端点
-	mux.HandleFunc("/api/logs", api.handleLogs)
-	mux.HandleFunc("/api/logs/stream", api.handleLogStream)
+	api.route(mux, "/api/logs", resourceLogs, api.handleLogs)
+	api.route(mux, "/api/logs/stream", resourceLogs, api.handleLogStream)
+
+	// 事件查询端点
+	api.route(mux, "/api/events", resourceEvents, api.handleEvents)
+
+	// 厂商数据库端点
+	api.route(mux, "/api/oui", resourceDevices, api.handleOUILookup)
+	api.route(mux, "/api/oui/reload", resourceConfig, api.handleOUIReload)
+	api.route(mux, "/api/fingerprints", resourceDevices, api.handleFingerprints)
+
+	// 安全检测端点
+	api.route(mux, "/api/security/rogue-servers", resourceSecurity, api.handleRogueServers)
+	api.versionedRoute(mux, "/api/security/access", resourceSecurity, api.handleAccessPolicy)
+	api.versionedRoute(mux, "/api/security/access/list", resourceSecurity, api.handleAccessList)
+	api.versionedRoute(mux, "/api/security/quarantine", resourceSecurity, api.handleQuarantine)
+	api.route(mux, "/api/security/flood", resourceSecurity, api.handleFloodProtection)
+
+	// 设备在线记录
+	api.route(mux, "/api/presence", resourceDevices, api.handlePresence)
 
 	// 配置管理子模块端点
-	mux.HandleFunc("/api/config/server", api.handleServerConfig)
-	mux.HandleFunc("/api/config/network", api.handleNetworkConfig)
-	mux.HandleFunc("/api/config/health-check", api.handleHealthCheckConfig)
-	mux.HandleFunc("/api/available-ips", api.handleAvailableIPs)
+	api.versionedRoute(mux, "/api/config/server", resourceConfig, api.handleServerConfig)
+	api.versionedRoute(mux, "/api/config/network", resourceConfig, api.handleNetworkConfig)
+	api.versionedRoute(mux, "/api/config/health-check", resourceConfig, api.handleHealthCheckConfig)
+	api.route(mux, "/api/available-ips", resourceLeases, api.handleAvailableIPs)
 
 	// 服务器管理API
-	mux.HandleFunc("/api/server/restart", api.handleServerRestart)
+	api.route(mux, "/api/server/restart", resourceServer, api.handleServerRestart)
+
+	// 版本化API，资源路径风格，统一错误响应和游标分页；上述旧接口保持兼容
+	api.route(mux, "/api/v1/", permAuthenticated, api.handleV1NotFound)
+	api.route(mux, "/api/v1/leases", resourceLeases, api.handleV1Leases)
+	api.route(mux, "/api/v1/leases/", resourceLeases, api.handleV1Leases)
+	api.versionedRoute(mux, "/api/v1/bindings", resourceBindings, api.handleV1Bindings)
+	api.versionedRoute(mux, "/api/v1/bindings/", resourceBindings, api.handleV1Bindings)
+	api.versionedRoute(mux, "/api/v1/devices", resourceDevices, api.handleV1Devices)
+	api.versionedRoute(mux, "/api/v1/devices/", resourceDevices, api.handleV1Devices)
 }
 
 // handleIndex 处理首页请求
@@ -1331,6 +1376,9 @@ func (api *APIServer) handleIndex(w http.ResponseWriter, r *http.Request) {
                             <button class="btn btn-warning restart-btn" onclick="quickRestartServer()" title="快速重启DHCP服务器">
                                 🔄 重启服务器
                             </button>
+                            <button class="btn btn-secondary" onclick="logout()" title="退出登录">
+                                🚪 退出
+                            </button>
                         </div>
                     </div>
                 </div>
@@ -2425,6 +2473,35 @@ curl http://localhost:8080/api/health</pre>
     </div>
 
     <script>
+        // 会话过期或未登录时跳转到登录页；
+        // 记录响应中的配置修订号（ETag），修改请求携带If-Match，配置已被他人修改时服务器返回412
+        let configETag = '';
+        const originalFetch = window.fetch;
+        window.fetch = async (resource, options = {}) => {
+            const method = (options.method || 'GET').toUpperCase();
+            if (configETag && method !== 'GET' && method !== 'HEAD') {
+                const headers = new Headers(options.headers || {});
+                if (!headers.has('If-Match')) {
+                    headers.set('If-Match', configETag);
+                }
+                options = { ...options, headers };
+            }
+            const response = await originalFetch(resource, options);
+            const etag = response.headers.get('ETag');
+            if (etag) {
+                configETag = etag;
+            }
+            if (response.status === 401) {
+                window.location.href = '/login';
+            }
+            return response;
+        };
+
+        async function logout() {
+            await originalFetch('/api/auth/logout', { method: 'POST' });
+            window.location.href = '/login';
+        }
+
         let currentLeaseMode = 'active';
         let allLeases = [];
         let allHistory = [];
@@ -5687,6 +5764,25 @@ if (result.logs && result.logs.length > 0) {
 	w.Write([]byte(html))
 }
 
+// newLeaseInfo 将租约转换为API响应
+func newLeaseInfo(lease *dhcp.IPLease) LeaseInfo {
+	return LeaseInfo{
+		IP:            lease.IP.String(),
+		MAC:           lease.MAC,
+		Hostname:      lease.Hostname,
+		StartTime:     lease.StartTime,
+		LeaseTime:     lease.LeaseTime.String(),
+		RemainingTime: lease.RemainingTime().String(),
+		IsStatic:      lease.IsStatic,
+		Gateway:       lease.Gateway,
+		GatewayIP:     lease.GatewayIP,
+		IsExpired:     lease.IsExpired(),
+
+		Fingerprint:    lease.Fingerprint,
+		Classification: lease.Classification,
+	}
+}
+
 // handleLeases 处理所有租约查询
 func (api *APIServer) handleLeases(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -5698,19 +5794,7 @@ func (api *APIServer) handleLeases(w http.ResponseWriter, r *http.Request) {
 	var response []LeaseInfo
 
 	for _, lease := range leases {
-		leaseInfo := LeaseInfo{
-			IP:            lease.IP.String(),
-			MAC:           lease.MAC,
-			Hostname:      lease.Hostname,
-			StartTime:     lease.StartTime,
-			LeaseTime:     lease.LeaseTime.String(),
-			RemainingTime: lease.RemainingTime().String(),
-			IsStatic:      lease.IsStatic,
-			Gateway:       lease.Gateway,
-			GatewayIP:     lease.GatewayIP,
-			IsExpired:     lease.IsExpired(),
-		}
-		response = append(response, leaseInfo)
+		response = append(response, newLeaseInfo(lease))
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -5728,19 +5812,7 @@ func (api *APIServer) handleActiveLeases(w http.ResponseWriter, r *http.Request)
 	var response []LeaseInfo
 
 	for _, lease := range leases {
-		leaseInfo := LeaseInfo{
-			IP:            lease.IP.String(),
-			MAC:           lease.MAC,
-			Hostname:      lease.Hostname,
-			StartTime:     lease.StartTime,
-			LeaseTime:     lease.LeaseTime.String(),
-			RemainingTime: lease.RemainingTime().String(),
-			IsStatic:      lease.IsStatic,
-			Gateway:       lease.Gateway,
-			GatewayIP:     lease.GatewayIP,
-			IsExpired:     false, // 活跃租约不会过期
-		}
-		response = append(response, leaseInfo)
+		response = append(response, newLeaseInfo(lease))
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -5819,15 +5891,15 @@ func (api *APIServer) handleGetGateways(w http.ResponseWriter, r *http.Request)
 	gatewayStatus := api.dhcpServer.GetGatewayStatus()
 
 	// 添加网关详细信息
-	detailedStatus := make(map[string]interface{})
+	detailedStatus := make(map[string]GatewayStatus)
 	for _, gateway := range api.config.Gateways {
 		healthy, exists := gatewayStatus[gateway.Name]
-		detailedStatus[gateway.Name] = map[string]interface{}{
-			"healthy":     exists && healthy,
-			"ip":          gateway.IP,
-			"is_default":  gateway.IsDefault,
-			"description": gateway.Description,
-			"dns_servers": gateway.DNSServers,
+		detailedStatus[gateway.Name] = GatewayStatus{
+			Healthy:     exists && healthy,
+			IP:          gateway.IP,
+			IsDefault:   gateway.IsDefault,
+			Description: gateway.Description,
+			DNSServers:  gateway.DNSServers,
 		}
 	}
 
@@ -5836,13 +5908,7 @@ func (api *APIServer) handleGetGateways(w http.ResponseWriter, r *http.Request)
 
 // handleAddGateway 添加网关
 func (api *APIServer) handleAddGateway(w http.ResponseWriter, r *http.Request) {
-	var request struct {
-		Name        string   `json:"name"`
-		IP          string   `json:"ip"`
-		IsDefault   bool     `json:"is_default"`
-		Description string   `json:"description"`
-		DNSServers  []string `json:"dns_servers"`
-	}
+	var request GatewayRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -5906,8 +5972,8 @@ func (api *APIServer) handleAddGateway(w http.ResponseWriter, r *http.Request) {
 	api.config.Gateways = append(api.config.Gateways, newGateway)
 
 	// 保存配置
-	if err := api.config.SaveConfig(api.configPath); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+	if err := api.saveConfig(r); err != nil {
+		w.WriteHeader(saveErrorStatus(err))
 		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to save config: " + err.Error()})
 		return
 	}
@@ -5921,14 +5987,7 @@ func (api *APIServer) handleAddGateway(w http.ResponseWriter, r *http.Request) {
 
 // handleUpdateGateway 更新网关
 func (api *APIServer) handleUpdateGateway(w http.ResponseWriter, r *http.Request) {
-	var request struct {
-		Name        string   `json:"name"`
-		IP          string   `json:"ip"`
-		IsDefault   bool     `json:"is_default"`
-		Description string   `json:"description"`
-		DNSServers  []string `json:"dns_servers"`
-		OldName     string   `json:"old_name"` // 用于标识要更新的网关
-	}
+	var request GatewayRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -5999,6 +6058,18 @@ func (api *APIServer) handleUpdateGateway(w http.ResponseWriter, r *http.Request
 		}
 	}
 
+	// 网关改名时同步更新引用它的设备组，构建新列表后整体替换
+	if request.OldName != request.Name {
+		groups := make([]config.DeviceGroup, 0, len(api.config.Groups))
+		for _, group := range api.config.Groups {
+			if group.Gateway == request.OldName {
+				group.Gateway = request.Name
+			}
+			groups = append(groups, group)
+		}
+		api.config.Groups = groups
+	}
+
 	// 更新网关信息
 	api.config.Gateways[gatewayIndex].Name = request.Name
 	api.config.Gateways[gatewayIndex].IP = request.IP
@@ -6007,8 +6078,8 @@ func (api *APIServer) handleUpdateGateway(w http.ResponseWriter, r *http.Request
 	api.config.Gateways[gatewayIndex].DNSServers = request.DNSServers
 
 	// 保存配置
-	if err := api.config.SaveConfig(api.configPath); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+	if err := api.saveConfig(r); err != nil {
+		w.WriteHeader(saveErrorStatus(err))
 		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to save config: " + err.Error()})
 		return
 	}
@@ -6021,9 +6092,7 @@ func (api *APIServer) handleUpdateGateway(w http.ResponseWriter, r *http.Request
 
 // handleDeleteGateway 删除网关
 func (api *APIServer) handleDeleteGateway(w http.ResponseWriter, r *http.Request) {
-	var request struct {
-		Name string `json:"name"`
-	}
+	var request GatewayDeleteRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -6061,6 +6130,15 @@ func (api *APIServer) handleDeleteGateway(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	// 仍被设备组引用的网关不能删除，否则保存的配置无法通过验证
+	for _, group := range api.config.Groups {
+		if group.Gateway == request.Name {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Gateway is used by group " + group.Name})
+			return
+		}
+	}
+
 	// 如果删除的是默认网关，将第一个剩余网关设为默认
 	wasDefault := api.config.Gateways[gatewayIndex].IsDefault
 
@@ -6073,8 +6151,8 @@ func (api *APIServer) handleDeleteGateway(w http.ResponseWriter, r *http.Request
 	}
 
 	// 保存配置
-	if err := api.config.SaveConfig(api.configPath); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+	if err := api.saveConfig(r); err != nil {
+		w.WriteHeader(saveErrorStatus(err))
 		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to save config: " + err.Error()})
 		return
 	}
@@ -6092,33 +6170,17 @@ func (api *APIServer) handleHealth(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	health := map[string]interface{}{
-		"status":    "healthy",
-		"timestamp": time.Now(),
-		"uptime":    time.Since(api.dhcpServer.GetStartTime()).String(),
-		"version":   "1.0.0",
+	health := HealthResponse{
+		Status:    "healthy",
+		Timestamp: time.Now(),
+		Uptime:    time.Since(api.dhcpServer.GetStartTime()).String(),
+		Version:   "1.0.0",
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(health)
 }
 
-// corsMiddleware 添加CORS支持
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
-		next.ServeHTTP(w, r)
-	})
-}
-
 // handleDevices 处理设备管理请求
 func (api *APIServer) handleDevices(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -6191,57 +6253,7 @@ func (api *APIServer) handleGetDevices(w http.ResponseWriter, r *http.Request) {
 	} else {
 		// 获取所有设备，并更新活跃状态
 		devices = api.config.Devices
-
-		// 更新设备活跃状态和主机名（静态绑定不算活跃）
-		activeLeases := api.pool.GetActiveLeases()
-		activeMacs := make(map[string]bool)
-		leaseHostnames := make(map[string]string)
-		for _, lease := range activeLeases {
-			// 只有非静态租约才算活跃
-			if !lease.IsStatic {
-				activeMacs[lease.MAC] = true
-			}
-			// 但主机名可以从任何租约获取
-			if lease.Hostname != "" {
-				leaseHostnames[lease.MAC] = lease.Hostname
-			}
-		}
-
-		// 检查网络扫描器发现的活跃设备
-		scannerActiveMacs := make(map[string]bool)
-		if api.scanner != nil {
-			scanResults := api.scanner.GetScanResults()
-			for _, device := range scanResults {
-				if device.IsActive {
-					scannerActiveMacs[device.MAC] = true
-				}
-			}
-		}
-
-		for i := range devices {
-			// 设备活跃状态基于：1) 有活跃租约 或 2) 网络扫描器检测到活跃
-			devices[i].IsActive = activeMacs[devices[i].MAC] || scannerActiveMacs[devices[i].MAC]
-
-			// 从租约中更新主机名（如果设备信息中没有主机名或租约中有更新的主机名）
-			if hostname, exists := leaseHostnames[devices[i].MAC]; exists && hostname != "" {
-				devices[i].Hostname = hostname
-			}
-
-			// 检查设备是否有静态IP绑定
-			if binding := api.config.FindBindingByMAC(devices[i].MAC); binding != nil {
-				devices[i].HasStaticIP = true
-				devices[i].StaticIP = binding.IP
-				// 如果静态绑定中配置了网关，优先使用绑定的网关
-				if binding.Gateway != "" {
-					devices[i].Gateway = binding.Gateway
-				}
-				// 如果静态绑定中没有网关配置，保持设备自身的网关配置不变
-			} else {
-				devices[i].HasStaticIP = false
-				devices[i].StaticIP = ""
-				// 没有静态绑定时，保持设备自身的网关配置不变，不要清空
-			}
-		}
+		api.refreshDeviceStatus(devices)
 
 		// 根据设备类型过滤
 		if deviceType != "" {
@@ -6258,6 +6270,60 @@ func (api *APIServer) handleGetDevices(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(devices)
 }
 
+// refreshDeviceStatus 根据租约、扫描结果和静态绑定更新设备的活跃状态、主机名和静态IP
+func (api *APIServer) refreshDeviceStatus(devices []config.DeviceInfo) {
+	// 更新设备活跃状态和主机名（静态绑定不算活跃）
+	activeLeases := api.pool.GetActiveLeases()
+	activeMacs := make(map[string]bool)
+	leaseHostnames := make(map[string]string)
+	for _, lease := range activeLeases {
+		// 只有非静态租约才算活跃
+		if !lease.IsStatic {
+			activeMacs[lease.MAC] = true
+		}
+		// 但主机名可以从任何租约获取
+		if lease.Hostname != "" {
+			leaseHostnames[lease.MAC] = lease.Hostname
+		}
+	}
+
+	// 检查网络扫描器发现的活跃设备
+	scannerActiveMacs := make(map[string]bool)
+	if api.scanner != nil {
+		scanResults := api.scanner.GetScanResults()
+		for _, device := range scanResults {
+			if device.IsActive {
+				scannerActiveMacs[device.MAC] = true
+			}
+		}
+	}
+
+	for i := range devices {
+		// 设备活跃状态基于：1) 有活跃租约 或 2) 网络扫描器检测到活跃
+		devices[i].IsActive = activeMacs[devices[i].MAC] || scannerActiveMacs[devices[i].MAC]
+
+		// 从租约中更新主机名（如果设备信息中没有主机名或租约中有更新的主机名）
+		if hostname, exists := leaseHostnames[devices[i].MAC]; exists && hostname != "" {
+			devices[i].Hostname = hostname
+		}
+
+		// 检查设备是否有静态IP绑定
+		if binding := api.config.FindBindingByMAC(devices[i].MAC); binding != nil {
+			devices[i].HasStaticIP = true
+			devices[i].StaticIP = binding.IP
+			// 如果静态绑定中配置了网关，优先使用绑定的网关
+			if binding.Gateway != "" {
+				devices[i].Gateway = binding.Gateway
+			}
+			// 如果静态绑定中没有网关配置，保持设备自身的网关配置不变
+		} else {
+			devices[i].HasStaticIP = false
+			devices[i].StaticIP = ""
+			// 没有静态绑定时，保持设备自身的网关配置不变，不要清空
+		}
+	}
+}
+
 // handleAddDevice 添加设备信息
 func (api *APIServer) handleAddDevice(w http.ResponseWriter, r *http.Request) {
 	var device config.DeviceInfo
@@ -6281,6 +6347,17 @@ func (api *APIServer) handleAddDevice(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := config.ValidateOptions(device.Options); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if device.Schedule != "" && api.config.FindScheduleByName(device.Schedule) == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Schedule not found: " + device.Schedule})
+		return
+	}
+
 	// 设置时间戳
 	now := time.Now()
 	if device.FirstSeen.IsZero() {
@@ -6315,11 +6392,12 @@ func (api *APIServer) handleAddDevice(w http.ResponseWriter, r *http.Request) {
 	api.config.AddOrUpdateDevice(device)
 
 	// 保存配置到文件
-	if err := api.config.SaveConfig(api.configPath); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+	if err := api.saveConfig(r); err != nil {
+		w.WriteHeader(saveErrorStatus(err))
 		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to save config: " + err.Error()})
 		return
 	}
+	api.releaseQuarantine(device.MAC)
 
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(device)
@@ -6348,6 +6426,17 @@ func (api *APIServer) handleUpdateDevice(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if err := config.ValidateOptions(device.Options); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if device.Schedule != "" && api.config.FindScheduleByName(device.Schedule) == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Schedule not found: " + device.Schedule})
+		return
+	}
+
 	// 保持原有的首次见到时间
 	if !existing.FirstSeen.IsZero() {
 		device.FirstSeen = existing.FirstSeen
@@ -6358,20 +6447,19 @@ func (api *APIServer) handleUpdateDevice(w http.ResponseWriter, r *http.Request)
 	api.config.AddOrUpdateDevice(device)
 
 	// 保存配置到文件
-	if err := api.config.SaveConfig(api.configPath); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+	if err := api.saveConfig(r); err != nil {
+		w.WriteHeader(saveErrorStatus(err))
 		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to save config: " + err.Error()})
 		return
 	}
+	api.releaseQuarantine(device.MAC)
 
 	json.NewEncoder(w).Encode(device)
 }
 
 // handleDeleteDevice 删除设备信息
 func (api *APIServer) handleDeleteDevice(w http.ResponseWriter, r *http.Request) {
-	var request struct {
-		MAC string `json:"mac"`
-	}
+	var request DeviceDeleteRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -6421,8 +6509,8 @@ func (api *APIServer) handleDeleteDevice(w http.ResponseWriter, r *http.Request)
 	}
 
 	// 保存配置到文件
-	if err := api.config.SaveConfig(api.configPath); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+	if err := api.saveConfig(r); err != nil {
+		w.WriteHeader(saveErrorStatus(err))
 		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to save config: " + err.Error()})
 		return
 	}
@@ -6469,13 +6557,18 @@ func (api *APIServer) handleDeviceDiscover(w http.ResponseWriter, r *http.Reques
 	for _, lease := range activeLeases {
 		if api.config.FindDeviceByMAC(lease.MAC) == nil {
 			// 尝试根据MAC地址推断设备类型
-			deviceType := guessDeviceInfo(lease.MAC)
+			deviceType, model := guessDeviceInfo(lease)
+			vendor := oui.Lookup(lease.MAC)
 
 			unknownDevice := map[string]interface{}{
 				"mac":         lease.MAC,
 				"ip":          lease.IP,
 				"hostname":    lease.Hostname,
 				"device_type": deviceType,
+				"model":       model,
+				"vendor":      vendor.Vendor,
+				"randomized":  vendor.Randomized,
+				"fingerprint": lease.Fingerprint,
 				"last_seen":   lease.StartTime,
 				"is_active":   true,
 				"suggested":   true, // 标记为系统推测的信息
@@ -6502,9 +6595,7 @@ func (api *APIServer) handleBatchAddDevices(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	var request struct {
-		Devices []config.DeviceInfo `json:"devices"`
-	}
+	var request BatchDevicesRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -6564,12 +6655,15 @@ func (api *APIServer) handleBatchAddDevices(w http.ResponseWriter, r *http.Reque
 
 	// 保存配置到文件（如果有成功添加的设备）
 	if len(addedDevices) > 0 {
-		if err := api.config.SaveConfig(api.configPath); err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+		if err := api.saveConfig(r); err != nil {
+			w.WriteHeader(saveErrorStatus(err))
 			json.NewEncoder(w).Encode(map[string]string{"error": "Failed to save config: " + err.Error()})
 			return
 		}
 	}
+	for _, device := range addedDevices {
+		api.releaseQuarantine(device.MAC)
+	}
 
 	response := map[string]interface{}{
 		"added_count":   len(addedDevices),
@@ -6594,10 +6688,7 @@ func (api *APIServer) handleDeviceGateway(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	var request struct {
-		MAC     string `json:"mac"`
-		Gateway string `json:"gateway"`
-	}
+	var request DeviceGatewayRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
@@ -6640,11 +6731,12 @@ func (api *APIServer) handleDeviceGateway(w http.ResponseWriter, r *http.Request
 	}
 
 	// 保存配置到文件
-	if err := api.config.SaveConfig(api.configPath); err != nil {
+	if err := api.saveConfig(r); err != nil {
 		log.Printf("保存设备网关配置失败: %v", err)
-		http.Error(w, "Failed to save configuration", http.StatusInternalServerError)
+		http.Error(w, "Failed to save configuration", saveErrorStatus(err))
 		return
 	}
+	api.releaseQuarantine(request.MAC)
 
 	// 返回成功响应
 	w.Header().Set("Content-Type", "application/json")
@@ -6654,139 +6746,359 @@ func (api *APIServer) handleDeviceGateway(w http.ResponseWriter, r *http.Request
 	})
 }
 
-// handleStaticBindings 处理静态绑定管理请求
-func (api *APIServer) handleStaticBindings(w http.ResponseWriter, r *http.Request) {
+// handleDevicePolicy 查询设备按 设备 → 设备组 → 全局 解析后的DHCP策略（GET ?mac=...）
+func (api *APIServer) handleDevicePolicy(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	switch r.Method {
-	case "GET":
-		api.handleGetStaticBindings(w, r)
-	case "POST":
-		api.handleAddStaticBinding(w, r)
-	case "PUT":
-		api.handleUpdateStaticBinding(w, r)
-	case "DELETE":
-		api.handleDeleteStaticBinding(w, r)
-	default:
+	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed"})
-	}
-}
-
-// handleGetStaticBindings 获取所有静态绑定
-func (api *APIServer) handleGetStaticBindings(w http.ResponseWriter, r *http.Request) {
-	bindings := make([]map[string]interface{}, 0)
-
-	for _, binding := range api.config.Bindings {
-		bindingInfo := map[string]interface{}{
-			"alias":    binding.Alias,
-			"mac":      binding.MAC,
-			"ip":       binding.IP,
-			"gateway":  binding.Gateway,
-			"hostname": binding.Hostname,
-		}
-		bindings = append(bindings, bindingInfo)
-	}
-
-	json.NewEncoder(w).Encode(bindings)
-}
-
-// handleAddStaticBinding 添加静态绑定
-func (api *APIServer) handleAddStaticBinding(w http.ResponseWriter, r *http.Request) {
-	var request struct {
-		Alias    string `json:"alias"`
-		MAC      string `json:"mac"`
-		IP       string `json:"ip"`
-		Gateway  string `json:"gateway"`
-		Hostname string `json:"hostname"`
+		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+	hw, err := net.ParseMAC(r.URL.Query().Get("mac"))
+	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid JSON"})
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid MAC address format"})
 		return
 	}
 
-	// 验证必需字段
-	if request.Alias == "" || request.MAC == "" || request.IP == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Alias, MAC and IP are required"})
-		return
+	response := map[string]interface{}{
+		"mac":    hw.String(),
+		"policy": api.config.ResolvePolicy(hw.String()),
 	}
+	if api.dhcpServer != nil {
+		response["schedule_status"] = api.dhcpServer.CheckSchedule(hw.String())
+	}
+	json.NewEncoder(w).Encode(response)
+}
 
-	// 检查别名是否已存在
-	for _, binding := range api.config.Bindings {
-		if binding.Alias == request.Alias {
-			w.WriteHeader(http.StatusConflict)
-			json.NewEncoder(w).Encode(map[string]string{"error": "Binding alias already exists"})
+// handleSchedules 访问时间表管理：GET列出，POST添加，PUT更新，DELETE ?name=... 删除（仍被引用时拒绝）
+func (api *APIServer) handleSchedules(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		json.NewEncoder(w).Encode(api.config.Schedules)
+	case http.MethodPost, http.MethodPut:
+		var schedule config.AccessSchedule
+		if err := json.NewDecoder(r.Body).Decode(&schedule); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Invalid JSON"})
 			return
 		}
-	}
-
-	// 检查MAC地址是否已被绑定
-	for _, binding := range api.config.Bindings {
-		if binding.MAC == request.MAC {
-			w.WriteHeader(http.StatusConflict)
-			json.NewEncoder(w).Encode(map[string]string{"error": "MAC address already bound"})
+		if err := config.ValidateSchedule(schedule); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 			return
 		}
-	}
 
-	// 检查IP地址是否已被绑定
-	for _, binding := range api.config.Bindings {
-		if binding.IP == request.IP {
+		existing := api.config.FindScheduleByName(schedule.Name)
+		if r.Method == http.MethodPost && existing != nil {
 			w.WriteHeader(http.StatusConflict)
-			json.NewEncoder(w).Encode(map[string]string{"error": "IP address already bound"})
+			json.NewEncoder(w).Encode(map[string]string{"error": "Schedule already exists"})
+			return
+		}
+		if r.Method == http.MethodPut && existing == nil {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Schedule not found"})
 			return
 		}
-	}
 
-	// 创建新的静态绑定
-	newBinding := config.MACBinding{
-		Alias:    request.Alias,
-		MAC:      request.MAC,
-		IP:       request.IP,
-		Gateway:  request.Gateway,
-		Hostname: request.Hostname,
-	}
+		updated := make([]config.AccessSchedule, 0, len(api.config.Schedules)+1)
+		for _, existing := range api.config.Schedules {
+			if existing.Name == schedule.Name {
+				existing = schedule
+			}
+			updated = append(updated, existing)
+		}
+		if existing == nil {
+			updated = append(updated, schedule)
+		}
+		api.config.Schedules = updated
 
-	// 添加到配置
-	api.config.Bindings = append(api.config.Bindings, newBinding)
+		if err := api.saveConfig(r); err != nil {
+			w.WriteHeader(saveErrorStatus(err))
+			json.NewEncoder(w).Encode(map[string]string{"error": "保存配置失败: " + err.Error()})
+			return
+		}
 
-	// 保存配置到文件
-	if err := api.config.SaveConfig(api.configPath); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to save config: " + err.Error()})
-		return
-	}
+		log.Printf("访问时间表已保存: %s", schedule.Name)
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+		}
+		json.NewEncoder(w).Encode(schedule)
+	case http.MethodDelete:
+		name := r.URL.Query().Get("name")
+		if api.config.FindScheduleByName(name) == nil {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Schedule not found"})
+			return
+		}
 
-	// 重新加载配置以立即在IP池中添加新的静态绑定
-	if api.reloadCallback != nil {
-		go func() {
-			time.Sleep(100 * time.Millisecond) // 短暂延迟以确保文件写入完成
-			if err := api.reloadCallback(api.config); err != nil {
-				log.Printf("添加静态绑定后重新加载配置失败: %v", err)
+		var references []string
+		for _, device := range api.config.Devices {
+			if device.Schedule == name {
+				references = append(references, "device:"+device.MAC)
 			}
-		}()
-	}
+		}
+		for _, group := range api.config.Groups {
+			if group.Schedule == name {
+				references = append(references, "group:"+group.Name)
+			}
+		}
+		if len(references) > 0 {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":      "Schedule is still in use",
+				"references": references,
+			})
+			return
+		}
 
-	response := map[string]interface{}{
-		"alias":    request.Alias,
-		"mac":      request.MAC,
-		"ip":       request.IP,
-		"gateway":  request.Gateway,
-		"hostname": request.Hostname,
-	}
+		updated := make([]config.AccessSchedule, 0, len(api.config.Schedules))
+		for _, schedule := range api.config.Schedules {
+			if schedule.Name != name {
+				updated = append(updated, schedule)
+			}
+		}
+		api.config.Schedules = updated
 
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(response)
-}
+		if err := api.saveConfig(r); err != nil {
+			w.WriteHeader(saveErrorStatus(err))
+			json.NewEncoder(w).Encode(map[string]string{"error": "保存配置失败: " + err.Error()})
+			return
+		}
+
+		log.Printf("访问时间表已删除: %s", name)
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "message": "访问时间表已删除"})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed"})
+	}
+}
+
+// handleGroups 设备组管理：GET列出，POST添加，PUT更新，DELETE ?name=... 删除
+func (api *APIServer) handleGroups(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		groups := make([]GroupInfo, 0, len(api.config.Groups))
+		for _, group := range api.config.Groups {
+			var members []string
+			for i := range api.config.Devices {
+				if found := api.config.FindGroupForDevice(&api.config.Devices[i]); found != nil && found.Name == group.Name {
+					members = append(members, api.config.Devices[i].MAC)
+				}
+			}
+			groups = append(groups, GroupInfo{Group: group, Members: members})
+		}
+		json.NewEncoder(w).Encode(groups)
+	case http.MethodPost, http.MethodPut:
+		var group config.DeviceGroup
+		if err := json.NewDecoder(r.Body).Decode(&group); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Invalid JSON"})
+			return
+		}
+		if err := api.config.ValidateGroup(group); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		if api.dhcpServer != nil {
+			if err := api.dhcpServer.ValidateGroupRange(group); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+		}
+
+		existing := api.config.FindGroupByName(group.Name)
+		if r.Method == http.MethodPost && existing != nil {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Group already exists"})
+			return
+		}
+		if r.Method == http.MethodPut && existing == nil {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Group not found"})
+			return
+		}
+
+		// 构建新列表后整体替换，避免DHCP处理过程中读到修改了一半的列表
+		updated := make([]config.DeviceGroup, 0, len(api.config.Groups)+1)
+		for _, g := range api.config.Groups {
+			if g.Name == group.Name {
+				g = group
+			}
+			updated = append(updated, g)
+		}
+		if existing == nil {
+			updated = append(updated, group)
+		}
+		api.config.Groups = updated
+
+		if err := api.saveConfig(r); err != nil {
+			w.WriteHeader(saveErrorStatus(err))
+			json.NewEncoder(w).Encode(map[string]string{"error": "保存配置失败: " + err.Error()})
+			return
+		}
+
+		log.Printf("设备组已保存: %s, 标签=%v", group.Name, group.Tags)
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+		}
+		json.NewEncoder(w).Encode(group)
+	case http.MethodDelete:
+		name := r.URL.Query().Get("name")
+		if api.config.FindGroupByName(name) == nil {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Group not found"})
+			return
+		}
+
+		updated := make([]config.DeviceGroup, 0, len(api.config.Groups))
+		for _, g := range api.config.Groups {
+			if g.Name != name {
+				updated = append(updated, g)
+			}
+		}
+		api.config.Groups = updated
+
+		if err := api.saveConfig(r); err != nil {
+			w.WriteHeader(saveErrorStatus(err))
+			json.NewEncoder(w).Encode(map[string]string{"error": "保存配置失败: " + err.Error()})
+			return
+		}
+
+		log.Printf("设备组已删除: %s", name)
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "message": "设备组已删除"})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed"})
+	}
+}
+
+// handleStaticBindings 处理静态绑定管理请求
+func (api *APIServer) handleStaticBindings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case "GET":
+		api.handleGetStaticBindings(w, r)
+	case "POST":
+		api.handleAddStaticBinding(w, r)
+	case "PUT":
+		api.handleUpdateStaticBinding(w, r)
+	case "DELETE":
+		api.handleDeleteStaticBinding(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed"})
+	}
+}
+
+// handleGetStaticBindings 获取所有静态绑定
+func (api *APIServer) handleGetStaticBindings(w http.ResponseWriter, r *http.Request) {
+	bindings := make([]BindingInfo, 0, len(api.config.Bindings))
+
+	for _, binding := range api.config.Bindings {
+		bindings = append(bindings, BindingInfo{
+			Alias:    binding.Alias,
+			MAC:      binding.MAC,
+			IP:       binding.IP,
+			Gateway:  binding.Gateway,
+			Hostname: binding.Hostname,
+		})
+	}
+
+	json.NewEncoder(w).Encode(bindings)
+}
+
+// handleAddStaticBinding 添加静态绑定
+func (api *APIServer) handleAddStaticBinding(w http.ResponseWriter, r *http.Request) {
+	var request BindingInfo
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid JSON"})
+		return
+	}
+
+	// 验证必需字段
+	if request.Alias == "" || request.MAC == "" || request.IP == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Alias, MAC and IP are required"})
+		return
+	}
+
+	// 检查别名是否已存在
+	for _, binding := range api.config.Bindings {
+		if binding.Alias == request.Alias {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Binding alias already exists"})
+			return
+		}
+	}
+
+	// 检查MAC地址是否已被绑定
+	for _, binding := range api.config.Bindings {
+		if binding.MAC == request.MAC {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{"error": "MAC address already bound"})
+			return
+		}
+	}
+
+	// 检查IP地址是否已被绑定
+	for _, binding := range api.config.Bindings {
+		if binding.IP == request.IP {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{"error": "IP address already bound"})
+			return
+		}
+	}
+
+	// 创建新的静态绑定
+	newBinding := config.MACBinding{
+		Alias:    request.Alias,
+		MAC:      request.MAC,
+		IP:       request.IP,
+		Gateway:  request.Gateway,
+		Hostname: request.Hostname,
+	}
+
+	// 添加到配置
+	api.config.Bindings = append(api.config.Bindings, newBinding)
+
+	// 保存配置到文件
+	if err := api.saveConfig(r); err != nil {
+		w.WriteHeader(saveErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to save config: " + err.Error()})
+		return
+	}
+
+	// 重新加载配置以立即在IP池中添加新的静态绑定
+	if api.reloadCallback != nil {
+		go func() {
+			time.Sleep(100 * time.Millisecond) // 短暂延迟以确保文件写入完成
+			if err := api.reloadCallback(api.config); err != nil {
+				log.Printf("添加静态绑定后重新加载配置失败: %v", err)
+			}
+		}()
+	}
+
+	response := request
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
 
 // handleDeleteStaticBinding 删除静态绑定
 func (api *APIServer) handleDeleteStaticBinding(w http.ResponseWriter, r *http.Request) {
-	var request struct {
-		Alias string `json:"alias"`
-	}
+	var request BindingDeleteRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -6829,8 +7141,8 @@ func (api *APIServer) handleDeleteStaticBinding(w http.ResponseWriter, r *http.R
 	// 用户可能希望保留设备的网关配置，即使删除了静态IP绑定
 
 	// 保存配置到文件
-	if err := api.config.SaveConfig(api.configPath); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+	if err := api.saveConfig(r); err != nil {
+		w.WriteHeader(saveErrorStatus(err))
 		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to save config: " + err.Error()})
 		return
 	}
@@ -6850,14 +7162,7 @@ func (api *APIServer) handleDeleteStaticBinding(w http.ResponseWriter, r *http.R
 
 // handleUpdateStaticBinding 更新静态绑定
 func (api *APIServer) handleUpdateStaticBinding(w http.ResponseWriter, r *http.Request) {
-	var request struct {
-		OldAlias string `json:"old_alias"` // 用于识别要更新的绑定
-		Alias    string `json:"alias"`
-		MAC      string `json:"mac"`
-		IP       string `json:"ip"`
-		Gateway  string `json:"gateway"`
-		Hostname string `json:"hostname"`
-	}
+	var request BindingUpdateRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -6932,8 +7237,8 @@ func (api *APIServer) handleUpdateStaticBinding(w http.ResponseWriter, r *http.R
 	}
 
 	// 保存配置到文件
-	if err := api.config.SaveConfig(api.configPath); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+	if err := api.saveConfig(r); err != nil {
+		w.WriteHeader(saveErrorStatus(err))
 		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to save config: " + err.Error()})
 		return
 	}
@@ -6949,13 +7254,7 @@ func (api *APIServer) handleUpdateStaticBinding(w http.ResponseWriter, r *http.R
 	}
 
 	// 返回更新后的绑定信息
-	response := map[string]interface{}{
-		"alias":    request.Alias,
-		"mac":      request.MAC,
-		"ip":       request.IP,
-		"gateway":  request.Gateway,
-		"hostname": request.Hostname,
-	}
+	response := request.BindingInfo
 
 	json.NewEncoder(w).Encode(response)
 }
@@ -6970,12 +7269,7 @@ func (api *APIServer) handleConvertLeaseToStatic(w http.ResponseWriter, r *http.
 		return
 	}
 
-	var request struct {
-		MAC      string `json:"mac"`
-		Alias    string `json:"alias"`
-		Gateway  string `json:"gateway"`
-		Hostname string `json:"hostname"`
-	}
+	var request ConvertToStaticRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -7054,10 +7348,11 @@ func (api *APIServer) handleConvertLeaseToStatic(w http.ResponseWriter, r *http.
 	// 检查设备是否存在，如果不存在则自动创建设备记录
 	if api.config.FindDeviceByMAC(request.MAC) == nil {
 		// 创建新的设备记录
+		deviceType, model := guessDeviceInfo(lease)
 		newDevice := config.DeviceInfo{
 			MAC:         request.MAC,
-			DeviceType:  guessDeviceInfo(request.MAC),
-			Model:       "",
+			DeviceType:  deviceType,
+			Model:       model,
 			Description: "租约转静态时自动创建",
 			Owner:       "",
 			Hostname:    hostname,
@@ -7071,8 +7366,8 @@ func (api *APIServer) handleConvertLeaseToStatic(w http.ResponseWriter, r *http.
 	}
 
 	// 保存配置到文件
-	if err := api.config.SaveConfig(api.configPath); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+	if err := api.saveConfig(r); err != nil {
+		w.WriteHeader(saveErrorStatus(err))
 		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to save config: " + err.Error()})
 		return
 	}
@@ -7101,133 +7396,40 @@ func (api *APIServer) handleConvertLeaseToStatic(w http.ResponseWriter, r *http.
 	json.NewEncoder(w).Encode(response)
 }
 
-// guessDeviceInfo 根据MAC地址推测设备信息
-func guessDeviceInfo(mac string) string {
-	if len(mac) < 8 {
-		return "Unknown"
-	}
-
-	// 获取MAC地址的厂商前缀（前6位）
-
-	// 常见厂商的MAC前缀映射
-	macPrefixMap := map[string][]string{
-		"Apple": {
-			"00:03:93", "00:05:02", "00:0A:95", "00:0D:93", "00:11:24", "00:14:51",
-			"00:16:CB", "00:17:F2", "00:19:E3", "00:1B:63", "00:1C:B3", "00:1E:C2",
-			"00:1F:F3", "00:21:E9", "00:22:41", "00:23:12", "00:23:DF", "00:24:36",
-			"00:25:00", "00:25:4B", "00:25:BC", "00:26:08", "00:26:4A", "00:26:B0",
-			"00:26:BB", "3C:15:C2", "40:A6:D9", "40:B3:95", "44:00:10", "48:A1:95",
-			"4C:8D:79", "50:EA:D6", "58:55:CA", "5C:59:48", "5C:95:AE", "60:03:08",
-			"60:33:4B", "60:A3:7D", "60:C5:47", "64:20:0C", "64:B9:E8", "68:AB:BC",
-			"6C:72:20", "6C:8D:C1", "70:11:24", "70:73:CB", "70:CD:60", "74:F0:6D",
-			"78:31:C1", "78:4F:43", "7C:6D:62", "7C:C3:A1", "80:BE:05", "80:E6:50",
-			"84:38:35", "84:85:06", "88:63:DF", "8C:2D:AA", "8C:7C:92", "90:72:40",
-			"90:B0:ED", "94:F6:A3", "98:01:A7", "98:5A:EB", "9C:04:EB", "9C:84:BF",
-			"A0:99:9B", "A4:5E:60", "A4:B1:97", "A8:86:DD", "A8:BB:CF", "AC:BC:32",
-			"B0:65:BD", "B0:CA:68", "B4:F0:AB", "B8:8D:12", "B8:C7:5A", "BC:52:B7",
-			"BC:67:1C", "C0:84:7A", "C4:2C:03", "C8:BC:C8", "C8:E0:EB", "CC:25:EF",
-			"D0:23:DB", "D0:A6:37", "D4:90:9C", "D8:00:4D", "D8:30:62", "D8:96:95",
-			"DC:37:45", "DC:A9:04", "E0:AC:CB", "E0:B9:4D", "E4:8B:7F", "E4:C6:3D",
-			"E8:06:88", "EC:35:86", "F0:B4:79", "F0:DB:E2", "F4:F1:5A", "F8:27:93",
-			"F8:A9:D0", "FC:25:3F",
-		},
-		"Samsung": {
-			"00:07:AB", "00:12:FB", "00:13:77", "00:15:99", "00:16:32", "00:17:C9",
-			"00:17:D5", "00:18:AF", "00:1A:8A", "00:1B:98", "00:1C:43", "00:1D:25",
-			"00:1E:7D", "00:1F:CC", "00:21:19", "00:21:D1", "00:23:39", "00:24:54",
-			"00:26:37", "00:E3:B2", "08:08:C2", "08:37:3D", "08:FC:88", "0C:14:20",
-			"0C:89:10", "10:1D:C0", "14:7D:C5", "18:3A:2D", "18:AF:8F", "1C:28:AF",
-			"1C:5A:3E", "20:13:E0", "20:64:32", "20:A5:9E", "24:4B:81", "28:39:5E",
-			"28:ED:6A", "2C:44:01", "30:07:4D", "30:19:66", "30:CD:A7", "34:23:87",
-			"34:BE:00", "38:AA:3C", "3C:8B:FE", "40:0E:85", "40:43:7A", "44:4E:6D",
-			"44:78:3E", "48:5A:3F", "4C:3C:16", "4C:66:41", "50:32:75", "50:CC:F8",
-			"54:88:0E", "58:8B:F4", "5C:0A:5B", "5C:51:88", "60:6B:BD", "60:A1:0A",
-			"64:3E:8C", "68:EB:AE", "6C:2F:2C", "6C:94:66", "70:F9:27", "74:45:8A",
-			"78:1F:DB", "78:52:1A", "78:9E:D0", "7C:61:66", "7C:A1:AE", "80:18:A7",
-			"84:25:3F", "88:32:9B", "8C:77:12", "90:18:7C", "94:35:0A", "9C:02:98",
-			"9C:3A:AF", "A0:0B:BA", "A0:75:91", "A4:EB:D3", "A8:F2:74", "AC:5F:3E",
-			"B0:EC:71", "B4:62:93", "B8:5E:7B", "BC:14:85", "BC:F5:AC", "C0:BD:D1",
-			"C4:57:6E", "C8:A8:23", "CC:07:AB", "D0:17:6A", "D0:59:E4", "D4:E8:B2",
-			"D8:57:EF", "DC:71:44", "E0:91:F5", "E4:40:E2", "E8:50:8B", "EC:1F:72",
-			"F0:25:B7", "F4:09:D8", "F8:04:2E", "FC:00:12",
-		},
-		"Xiaomi": {
-			"34:CE:00", "50:8F:4C", "64:09:80", "68:DF:DD", "6C:96:CF", "74:51:BA",
-			"78:11:DC", "7C:49:EB", "8C:BE:BE", "98:FA:9B", "A0:86:C6", "AC:C1:EE",
-			"B0:E2:35", "C4:0B:CB", "D4:61:9D", "E4:46:DA", "F0:B4:29", "F8:8F:CA",
-			"FC:64:BA", "2C:EA:7F", "50:EC:50", "58:44:98", "68:3B:78", "78:02:F8",
-			"88:C3:97", "9C:99:A0", "A4:DA:32", "B0:E5:ED", "C8:47:8C", "D0:7E:35",
-			"E8:78:29", "F4:8E:38", "F4:F5:DB",
-		},
-		"Huawei": {
-			"00:E0:FC", "18:66:DA", "1C:1D:67", "20:F3:A3", "24:69:68", "28:6E:D4",
-			"2C:AB:00", "34:6B:D3", "38:BC:01", "3C:F8:08", "40:4E:36", "44:6D:6C",
-			"48:59:29", "4C:54:99", "50:3D:E5", "54:51:1B", "58:2A:F7", "5C:63:BF",
-			"60:DE:44", "64:3B:78", "68:3E:34", "6C:4B:90", "70:72:3C", "74:A7:22",
-			"78:D7:52", "7C:A2:3E", "80:71:7A", "84:A8:E4", "88:25:93", "8C:0E:E3",
-			"90:67:1C", "94:04:9C", "98:52:3D", "9C:28:EF", "A0:48:1C", "A4:50:46",
-			"A8:4E:3F", "AC:E2:15", "B0:91:34", "B4:CD:27", "B8:08:CF", "BC:76:5E",
-			"C0:EE:40", "C4:F0:81", "C8:94:02", "CC:96:A0", "D0:7A:B5", "D4:20:B0",
-			"D8:49:2F", "DC:D9:16", "E0:19:1D", "E4:D3:32", "E8:CD:2D", "EC:23:3D",
-			"F0:79:59", "F4:C7:14", "F8:E7:1E", "FC:48:EF",
-		},
-		"Microsoft": {
-			"00:0D:3A", "00:12:5A", "00:15:5D", "00:17:FA", "00:1D:D8", "00:50:F2",
-			"00:9D:8E", "04:C5:A4", "18:60:24", "1C:B7:2C", "28:18:78", "30:59:B7",
-			"34:17:EB", "38:2C:4A", "3C:5A:B4", "40:E2:30", "44:85:00", "54:27:1E",
-			"60:45:BD", "64:00:F1", "74:2F:68", "7C:1E:52", "80:E6:50", "98:5F:D3",
-			"A0:CE:C8", "B8:86:87", "D0:57:7B", "F4:A4:75", "F8:63:3F",
-		},
-		"Intel": {
-			"00:02:B3", "00:03:47", "00:04:23", "00:07:E9", "00:0E:35", "00:11:25",
-			"00:12:F0", "00:13:02", "00:13:CE", "00:15:00", "00:16:E3", "00:19:D1",
-			"00:1B:21", "00:1E:64", "00:1F:3B", "00:21:6A", "00:22:FB", "00:24:D7",
-			"00:27:10", "04:79:B7", "08:11:96", "0C:8B:FD", "10:0B:A9", "18:56:80",
-			"1C:3E:84", "20:16:B9", "24:77:03", "28:D2:44", "2C:6E:85", "30:E1:71",
-			"34:13:E8", "38:2C:4A", "3C:A9:F4", "40:B0:34", "44:85:00", "48:2A:E3",
-			"4C:79:6E", "50:76:AF", "54:27:1E", "58:91:CF", "5C:E0:C5", "60:67:20",
-			"64:27:37", "68:17:29", "6C:88:14", "70:5A:0F", "74:E5:43", "78:92:9C",
-			"7C:7A:91", "80:19:34", "84:3A:4B", "88:53:2E", "8C:A9:82", "90:E2:BA",
-			"94:65:9C", "98:4F:EE", "9C:B6:D0", "A0:A8:CD", "A4:4C:C8", "A8:6D:AA",
-			"AC:7B:A1", "B0:C0:90", "B4:B6:76", "B8:AE:ED", "BC:77:37", "C0:3F:D5",
-			"C4:8E:8F", "C8:D9:D2", "CC:3D:82", "D0:50:99", "D4:BE:D9", "D8:CB:8A",
-			"DC:53:60", "E0:94:67", "E4:A4:71", "E8:39:35", "EC:A8:6B", "F0:76:1C",
-			"F4:06:69", "F8:63:3F", "FC:AA:14",
-		},
+// vendorDeviceTypes 厂商关键字到设备类型的映射，按顺序匹配
+var vendorDeviceTypes = []struct {
+	keyword    string
+	deviceType string
+}{
+	{"apple", "iOS/macOS"},
+	{"samsung", "Android"},
+	{"xiaomi", "Android"},
+	{"huawei", "Android"},
+	{"microsoft", "Windows"},
+	{"intel", "Computer"},
+	{"vmware", "Virtual Machine"},
+	{"xensource", "Virtual Machine"},
+	{"qemu", "Virtual Machine"},
+}
+
+// guessDeviceInfo 推测设备类型和型号，优先使用DHCP指纹识别结果，其次根据MAC地址厂商推测
+func guessDeviceInfo(lease *dhcp.IPLease) (string, string) {
+	if lease.Classification != nil && lease.Classification.DeviceType != "" {
+		return lease.Classification.DeviceType, lease.Classification.Model
 	}
 
-	// 标准化MAC地址格式
-	normalizedMac := strings.ToUpper(strings.ReplaceAll(mac, ":", ""))
-	if len(normalizedMac) >= 6 {
-		macPrefix := normalizedMac[:6]
-		// 转换为带冒号的格式
-		formattedPrefix := fmt.Sprintf("%s:%s:%s", macPrefix[:2], macPrefix[2:4], macPrefix[4:6])
-
-		for vendor, prefixes := range macPrefixMap {
-			for _, vendorPrefix := range prefixes {
-				if formattedPrefix == vendorPrefix {
-					switch vendor {
-					case "Apple":
-						return "iOS/macOS"
-					case "Samsung":
-						return "Android"
-					case "Xiaomi":
-						return "Android"
-					case "Huawei":
-						return "Android"
-					case "Microsoft":
-						return "Windows"
-					case "Intel":
-						return "Computer"
-					default:
-						return "Unknown"
-					}
-				}
-			}
+	vendor := strings.ToLower(oui.Lookup(lease.MAC).Vendor)
+	if vendor == "" {
+		return "Unknown", ""
+	}
+
+	for _, item := range vendorDeviceTypes {
+		if strings.Contains(vendor, item.keyword) {
+			return item.deviceType, ""
 		}
 	}
 
-	return "Unknown"
+	return "Unknown", ""
 }
 
 // handleConfig 处理配置管理请求
@@ -7257,10 +7459,19 @@ func (api *APIServer) handleGetConfig(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		response := map[string]interface{}{
-			"content": string(data),
-			"path":    api.configPath,
-			"size":    len(data),
+		// 只有admin可以看到密码和令牌哈希，其他身份返回替换了敏感字段的内容
+		if !requestPrincipal(r).isAdmin() {
+			if data, err = audit.RedactConfig(data); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(map[string]string{"error": "Failed to parse config file"})
+				return
+			}
+		}
+
+		response := RawConfigResponse{
+			Content: string(data),
+			Path:    api.configPath,
+			Size:    len(data),
 		}
 
 		json.NewEncoder(w).Encode(response)
@@ -7268,13 +7479,14 @@ func (api *APIServer) handleGetConfig(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 返回解析后的配置对象
-	response := map[string]interface{}{
-		"server":       api.config.Server,
-		"network":      api.config.Network,
-		"gateways":     api.config.Gateways,
-		"bindings":     api.config.Bindings,
-		"devices":      api.config.Devices,
-		"health_check": api.config.HealthCheck,
+	response := ConfigResponse{
+		Server:      api.config.Server,
+		Network:     api.config.Network,
+		Gateways:    api.config.Gateways,
+		Bindings:    api.config.Bindings,
+		Devices:     api.config.Devices,
+		Groups:      api.config.Groups,
+		HealthCheck: api.config.HealthCheck,
 	}
 
 	json.NewEncoder(w).Encode(response)
@@ -7282,11 +7494,14 @@ func (api *APIServer) handleGetConfig(w http.ResponseWriter, r *http.Request) {
 
 // handleSaveConfig 保存配置
 func (api *APIServer) handleSaveConfig(w http.ResponseWriter, r *http.Request) {
-	var request struct {
-		Content    string `json:"content"`
-		AutoReload bool   `json:"auto_reload"`
+	// 整个配置包含用户和令牌，只允许admin保存，避免绕过令牌授权提升权限
+	if !requestPrincipal(r).isAdmin() {
+		writeForbidden(w, config.RoleAdmin)
+		return
 	}
 
+	var request ConfigSaveRequest
+
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid JSON"})
@@ -7308,12 +7523,16 @@ func (api *APIServer) handleSaveConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 保存配置文件
-	if err := newConfig.SaveConfig(api.configPath); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+	// 保存配置文件，修订号在当前配置基础上递增
+	newConfig.InheritRevision(api.config)
+	if err := api.writeConfig(r, newConfig); err != nil {
+		w.WriteHeader(saveErrorStatus(err))
 		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
+	// 当前配置记录新文件的修订号和摘要，返回的ETag可以直接用于下一次修改
+	api.config.InheritRevision(newConfig)
+	w.Header().Set("ETag", revisionETag(newConfig.Revision))
 
 	// 自动重新加载
 	if request.AutoReload && api.reloadCallback != nil {
@@ -7342,9 +7561,7 @@ func (api *APIServer) handleConfigValidate(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	var request struct {
-		Content string `json:"content"`
-	}
+	var request ConfigValidateRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -7398,11 +7615,14 @@ func (api *APIServer) handleConfigRestore(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	var request struct {
-		Filename   string `json:"filename"`
-		AutoReload bool   `json:"auto_reload"`
+	// 备份中的用户和令牌会整体替换当前配置，只允许admin恢复
+	if !requestPrincipal(r).isAdmin() {
+		writeForbidden(w, config.RoleAdmin)
+		return
 	}
 
+	var request ConfigRestoreRequest
+
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid JSON"})
@@ -7421,35 +7641,26 @@ func (api *APIServer) handleConfigRestore(w http.ResponseWriter, r *http.Request
 	}
 
 	// 验证备份配置
-	if err := config.ValidateYAML(string(data)); err != nil {
+	newConfig, err := config.LoadConfigFromString(string(data))
+	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid backup config: " + err.Error()})
 		return
 	}
 
-	// 创建当前配置的备份
-	if err := api.config.BackupConfig(api.configPath); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to backup current config"})
-		return
-	}
-
-	// 恢复配置文件
-	if err := ioutil.WriteFile(api.configPath, data, 0644); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to restore config"})
+	// 恢复配置文件（保存前自动备份当前配置），修订号在当前配置基础上递增
+	newConfig.InheritRevision(api.config)
+	if err := api.writeConfig(r, newConfig); err != nil {
+		w.WriteHeader(saveErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to restore config: " + err.Error()})
 		return
 	}
+	// 当前配置记录新文件的修订号和摘要，返回的ETag可以直接用于下一次修改
+	api.config.InheritRevision(newConfig)
+	w.Header().Set("ETag", revisionETag(newConfig.Revision))
 
 	// 自动重新加载
 	if request.AutoReload && api.reloadCallback != nil {
-		newConfig, err := config.LoadConfigFromString(string(data))
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{"error": "Failed to parse restored config"})
-			return
-		}
-
 		go func() {
 			time.Sleep(500 * time.Millisecond)
 			if err := api.reloadCallback(newConfig); err != nil {
@@ -7556,8 +7767,8 @@ func (api *APIServer) handleServerConfig(w http.ResponseWriter, r *http.Request)
 		api.config.Server = newServerConfig
 
 		// 保存配置到文件
-		if err := api.config.SaveConfig(api.configPath); err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+		if err := api.saveConfig(r); err != nil {
+			w.WriteHeader(saveErrorStatus(err))
 			json.NewEncoder(w).Encode(map[string]string{"error": "保存配置失败: " + err.Error()})
 			return
 		}
@@ -7614,8 +7825,8 @@ func (api *APIServer) handleNetworkConfig(w http.ResponseWriter, r *http.Request
 		api.config.Network = newNetworkConfig
 
 		// 保存配置到文件
-		if err := api.config.SaveConfig(api.configPath); err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+		if err := api.saveConfig(r); err != nil {
+			w.WriteHeader(saveErrorStatus(err))
 			json.NewEncoder(w).Encode(map[string]string{"error": "保存配置失败: " + err.Error()})
 			return
 		}
@@ -7664,8 +7875,8 @@ func (api *APIServer) handleHealthCheckConfig(w http.ResponseWriter, r *http.Req
 		api.config.HealthCheck = newHealthConfig
 
 		// 保存配置到文件
-		if err := api.config.SaveConfig(api.configPath); err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+		if err := api.saveConfig(r); err != nil {
+			w.WriteHeader(saveErrorStatus(err))
 			json.NewEncoder(w).Encode(map[string]string{"error": "保存配置失败: " + err.Error()})
 			return
 		}
@@ -7718,9 +7929,9 @@ func (api *APIServer) handleLogs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response := map[string]interface{}{
-		"logs":  logContent,
-		"count": len(logContent),
+	response := LogsResponse{
+		Logs:  logContent,
+		Count: len(logContent),
 	}
 
 	json.NewEncoder(w).Encode(response)
@@ -7728,11 +7939,17 @@ func (api *APIServer) handleLogs(w http.ResponseWriter, r *http.Request) {
 
 // handleLogStream 处理SSE日志流请求
 func (api *APIServer) handleLogStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed"})
+		return
+	}
+
 	// 设置SSE响应头
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	// 创建客户端通道
 	clientChan := make(chan string, 10)
@@ -7781,47 +7998,478 @@ func (api *APIServer) handleLogStream(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// readLastNLines 读取文件的最后N行
-func readLastNLines(filename string, n int) ([]string, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	var lines []string
-	scanner := bufio.NewScanner(file)
+// handleEvents 处理事件查询请求
+func (api *APIServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed"})
+		return
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	limit := 100
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
 	}
 
-	// 如果行数超过限制，只返回最后n行
-	if len(lines) > n {
-		lines = lines[len(lines)-n:]
+	recent := events.Recent(limit, r.URL.Query().Get("type"))
+	response := map[string]interface{}{
+		"events": recent,
+		"count":  len(recent),
 	}
-
-	return lines, nil
+	json.NewEncoder(w).Encode(response)
 }
 
-// handleScanner 处理扫描器状态请求
-func (api *APIServer) handleScanner(w http.ResponseWriter, r *http.Request) {
+// handleOUILookup 查询MAC地址厂商，不带mac参数时返回数据库统计
+func (api *APIServer) handleOUILookup(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	if api.scanner == nil {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "扫描器未启用"})
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed"})
 		return
 	}
 
-	switch r.Method {
-	case "GET":
-		status := api.scanner.GetStatus()
-		response := map[string]interface{}{
+	mac := r.URL.Query().Get("mac")
+	if mac == "" {
+		json.NewEncoder(w).Encode(oui.Default().Stats())
+		return
+	}
+
+	if _, err := net.ParseMAC(mac); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid MAC address"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(oui.Lookup(mac))
+}
+
+// handleOUIReload 从配置的IEEE CSV文件重新加载厂商数据库
+func (api *APIServer) handleOUIReload(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed"})
+		return
+	}
+
+	if err := oui.Load(api.config.OUI.Files); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "OUI database reloaded",
+		"stats":   oui.Default().Stats(),
+	})
+}
+
+// handleFingerprints 查看活跃租约的DHCP指纹及识别结果，便于编写自定义规则
+func (api *APIServer) handleFingerprints(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed"})
+		return
+	}
+
+	unmatchedOnly := r.URL.Query().Get("unmatched") == "true"
+
+	clients := make([]map[string]interface{}, 0)
+	for _, lease := range api.pool.GetActiveLeases() {
+		if lease.Fingerprint == nil {
+			continue
+		}
+		if unmatchedOnly && lease.Classification != nil {
+			continue
+		}
+		clients = append(clients, map[string]interface{}{
+			"mac":            lease.MAC,
+			"ip":             lease.IP.String(),
+			"hostname":       lease.Hostname,
+			"fingerprint":    lease.Fingerprint,
+			"classification": lease.Classification,
+		})
+	}
+
+	db := fingerprint.Default()
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"clients": clients,
+		"count":   len(clients),
+		"source":  db.Source(),
+		"rules":   len(db.Rules()),
+	})
+}
+
+// handleRogueServers 查看或确认检测到的非法DHCP服务器
+func (api *APIServer) handleRogueServers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if api.dhcpServer == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"error": "DHCP server not available"})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		servers := api.dhcpServer.GetRogueServers()
+		response := map[string]interface{}{
+			"servers": servers,
+			"count":   len(servers),
+		}
+		for key, value := range api.dhcpServer.RogueDetectionStatus() {
+			response[key] = value
+		}
+		json.NewEncoder(w).Encode(response)
+	case http.MethodDelete:
+		serverID := r.URL.Query().Get("server_id")
+		if serverID == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "server_id is required"})
+			return
+		}
+		if !api.dhcpServer.RemoveRogueServer(serverID) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Rogue server not found"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"message": "Rogue server record removed"})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed"})
+	}
+}
+
+// validateAccessConfig 校验访问控制配置
+func validateAccessConfig(access config.AccessConfig) error {
+	switch strings.ToLower(access.Mode) {
+	case "", dhcp.AccessModeOpen, dhcp.AccessModeAllowlist, dhcp.AccessModeDenylist:
+	default:
+		return fmt.Errorf("invalid mode: %s", access.Mode)
+	}
+	switch strings.ToLower(access.Action) {
+	case "", dhcp.AccessActionIgnore, dhcp.AccessActionNAK, dhcp.AccessActionQuarantine:
+	default:
+		return fmt.Errorf("invalid action: %s", access.Action)
+	}
+	for _, pattern := range append(append([]string{}, access.Allow...), access.Deny...) {
+		if !config.ValidMACPattern(pattern) {
+			return fmt.Errorf("invalid MAC pattern: %s", pattern)
+		}
+	}
+	return nil
+}
+
+// handleAccessPolicy 查询或替换MAC访问控制策略
+func (api *APIServer) handleAccessPolicy(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		json.NewEncoder(w).Encode(api.config.Security.Access)
+	case http.MethodPut, http.MethodPost:
+		var access config.AccessConfig
+		if err := json.NewDecoder(r.Body).Decode(&access); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Invalid JSON"})
+			return
+		}
+		if err := validateAccessConfig(access); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		if strings.ToLower(access.Action) == dhcp.AccessActionQuarantine {
+			if err := api.config.ValidateQuarantine(api.config.Security.Quarantine); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "quarantine not configured: " + err.Error()})
+				return
+			}
+		}
+
+		api.config.Security.Access = access
+		if err := api.saveConfig(r); err != nil {
+			w.WriteHeader(saveErrorStatus(err))
+			json.NewEncoder(w).Encode(map[string]string{"error": "保存配置失败: " + err.Error()})
+			return
+		}
+
+		log.Printf("访问控制策略已更新: 模式=%s, 拒绝方式=%s, 允许规则=%d, 拒绝规则=%d",
+			access.Mode, access.Action, len(access.Allow), len(access.Deny))
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": "访问控制策略已更新",
+			"access":  access,
+		})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed"})
+	}
+}
+
+// handleAccessList 添加（POST）或删除（DELETE ?list=allow|deny&mac=...）访问控制规则
+func (api *APIServer) handleAccessList(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var list, pattern string
+	switch r.Method {
+	case http.MethodPost:
+		var req AccessListRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Invalid JSON"})
+			return
+		}
+		list, pattern = req.List, req.MAC
+	case http.MethodDelete:
+		list, pattern = r.URL.Query().Get("list"), r.URL.Query().Get("mac")
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed"})
+		return
+	}
+
+	var target *[]string
+	switch list {
+	case "allow":
+		target = &api.config.Security.Access.Allow
+	case "deny":
+		target = &api.config.Security.Access.Deny
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "list must be allow or deny"})
+		return
+	}
+
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if !config.ValidMACPattern(pattern) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid MAC pattern: " + pattern})
+		return
+	}
+
+	// 构建新列表后整体替换，避免DHCP处理过程中读到修改了一半的列表
+	index := -1
+	for i, existing := range *target {
+		if strings.EqualFold(existing, pattern) {
+			index = i
+			break
+		}
+	}
+	updated := make([]string, 0, len(*target)+1)
+	if r.Method == http.MethodPost {
+		if index >= 0 {
+			json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "message": "规则已存在"})
+			return
+		}
+		updated = append(append(updated, *target...), pattern)
+	} else {
+		if index < 0 {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "规则不存在"})
+			return
+		}
+		updated = append(append(updated, (*target)[:index]...), (*target)[index+1:]...)
+	}
+	*target = updated
+
+	if err := api.saveConfig(r); err != nil {
+		w.WriteHeader(saveErrorStatus(err))
+		json.NewEncoder(w).Encode(map[string]string{"error": "保存配置失败: " + err.Error()})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "访问控制规则已更新",
+		"access":  api.config.Security.Access,
+	})
+}
+
+// handleFloodProtection 查询限速和地址耗尽攻击检测状态
+func (api *APIServer) handleFloodProtection(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed"})
+		return
+	}
+	if api.dhcpServer == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"error": "DHCP server not available"})
+		return
+	}
+
+	response := api.dhcpServer.FloodProtectionStatus()
+	response["config"] = api.config.Security.Flood
+	json.NewEncoder(w).Encode(response)
+}
+
+// releaseQuarantine 设备登记后将其迁出隔离地址池
+func (api *APIServer) releaseQuarantine(mac string) {
+	if api.dhcpServer == nil {
+		return
+	}
+	if api.dhcpServer.ReleaseQuarantine(mac) {
+		log.Printf("设备 %s 已登记，解除隔离", mac)
+	}
+}
+
+// handleQuarantine 查询（GET）或替换（PUT）隔离地址池配置，GET同时返回当前隔离的设备
+func (api *APIServer) handleQuarantine(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		leases := make([]map[string]interface{}, 0)
+		if api.pool != nil {
+			for _, lease := range api.pool.GetQuarantinedLeases() {
+				leases = append(leases, map[string]interface{}{
+					"ip":             lease.IP.String(),
+					"mac":            lease.MAC,
+					"hostname":       lease.Hostname,
+					"start_time":     lease.StartTime,
+					"remaining_time": lease.RemainingTime().String(),
+				})
+			}
+		}
+		sort.Slice(leases, func(i, j int) bool {
+			return leases[i]["mac"].(string) < leases[j]["mac"].(string)
+		})
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"enabled":    strings.ToLower(api.config.Security.Access.Action) == dhcp.AccessActionQuarantine,
+			"quarantine": api.config.Security.Quarantine,
+			"leases":     leases,
+		})
+	case http.MethodPut, http.MethodPost:
+		var quarantine config.QuarantineConfig
+		if err := json.NewDecoder(r.Body).Decode(&quarantine); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Invalid JSON"})
+			return
+		}
+		if err := api.config.ValidateQuarantine(quarantine); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		api.config.Security.Quarantine = quarantine
+		if err := api.saveConfig(r); err != nil {
+			w.WriteHeader(saveErrorStatus(err))
+			json.NewEncoder(w).Encode(map[string]string{"error": "保存配置失败: " + err.Error()})
+			return
+		}
+
+		log.Printf("隔离地址池已更新: %s - %s, 门户网关=%s", quarantine.StartIP, quarantine.EndIP, quarantine.Gateway)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":    true,
+			"message":    "隔离地址池已更新",
+			"quarantine": quarantine,
+		})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed"})
+	}
+}
+
+// handlePresence 查询设备在线记录，指定mac时返回该设备的会话和每日在线时长
+func (api *APIServer) handlePresence(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed"})
+		return
+	}
+
+	mac := r.URL.Query().Get("mac")
+	if mac == "" {
+		records := presence.List()
+		online := 0
+		for _, record := range records {
+			if record.Online {
+				online++
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"devices": records,
+			"count":   len(records),
+			"online":  online,
+		})
+		return
+	}
+
+	days := 7
+	if value := r.URL.Query().Get("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > 366 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "days must be between 1 and 366"})
+			return
+		}
+		days = parsed
+	}
+
+	timeline, ok := presence.Get(mac, days)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Device not found"})
+		return
+	}
+	json.NewEncoder(w).Encode(timeline)
+}
+
+// readLastNLines 读取文件的最后N行
+func readLastNLines(filename string, n int) ([]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// 如果行数超过限制，只返回最后n行
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+
+	return lines, nil
+}
+
+// handleScanner 处理扫描器状态请求
+func (api *APIServer) handleScanner(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if api.scanner == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"error": "扫描器未启用"})
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		status := api.scanner.GetStatus()
+		response := map[string]interface{}{
 			"enabled":        status.IsEnabled,
 			"is_running":     status.IsRunning,
 			"last_scan":      status.LastScanTime,
@@ -7869,6 +8517,18 @@ func (api *APIServer) handleScannerResults(w http.ResponseWriter, r *http.Reques
 			"is_active": device.IsActive,
 			"vendor":    device.Vendor,
 			"response":  device.Response,
+			"method":    device.Method,
+
+			"locally_administered": device.LocallyAdministered,
+			"randomized":           device.Randomized,
+			"hostname_source":      device.HostnameSource,
+			"model":                device.Model,
+			"model_source":         device.ModelSource,
+			"workgroup":            device.Workgroup,
+			"names":                device.Names,
+			"open_ports":           device.OpenPorts,
+			"services":             device.Services,
+			"suggested_type":       device.SuggestedType,
 		}
 		devices = append(devices, deviceInfo)
 	}
@@ -7984,8 +8644,8 @@ func (api *APIServer) handleScannerConfig(w http.ResponseWriter, r *http.Request
 		api.config.Scanner = newScannerConfig
 
 		// 保存配置到文件
-		if err := api.config.SaveConfig(api.configPath); err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+		if err := api.saveConfig(r); err != nil {
+			w.WriteHeader(saveErrorStatus(err))
 			json.NewEncoder(w).Encode(map[string]string{"error": "保存配置失败: " + err.Error()})
 			return
 		}
@@ -8019,9 +8679,137 @@ func (api *APIServer) handleScannerConfig(w http.ResponseWriter, r *http.Request
 	}
 }
 
+// handleScannerProfiles 获取生效的扫描方案
+func (api *APIServer) handleScannerProfiles(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if api.scanner == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"error": "扫描器未启用"})
+		return
+	}
+
+	if r.Method != "GET" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed"})
+		return
+	}
+
+	profiles := api.scanner.Profiles()
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"profiles": profiles,
+		"count":    len(profiles),
+	})
+}
+
+// handleScannerJobs 处理扫描任务：GET查询任务（带id返回单个任务及结果），POST提交扫描，DELETE取消任务
+func (api *APIServer) handleScannerJobs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if api.scanner == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"error": "扫描器未启用"})
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		if id := r.URL.Query().Get("id"); id != "" {
+			job, ok := api.scanner.GetScanJob(id)
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(map[string]string{"error": "扫描任务不存在"})
+				return
+			}
+			json.NewEncoder(w).Encode(job)
+			return
+		}
+		jobs := api.scanner.GetScanJobs()
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"jobs":  jobs,
+			"count": len(jobs),
+		})
+	case "POST":
+		var req ScanJobRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Invalid JSON"})
+			return
+		}
+
+		profile, ok := api.scanner.FindProfile(req.Profile)
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "扫描方案不存在: " + req.Profile})
+			return
+		}
+
+		// 未指定方案但指定了目标时作为临时扫描
+		if req.Profile == "" && len(req.Targets) > 0 {
+			profile.Name = "adhoc"
+			profile.Excludes = nil
+		}
+		if len(req.Targets) > 0 {
+			profile.Targets = req.Targets
+		}
+		if len(req.Excludes) > 0 {
+			profile.Excludes = req.Excludes
+		}
+		if len(req.Methods) > 0 {
+			profile.Methods = req.Methods
+		}
+		if len(req.TCPPorts) > 0 {
+			profile.TCPPorts = req.TCPPorts
+		}
+		if req.MaxConcurrency > 0 {
+			profile.MaxConcurrency = req.MaxConcurrency
+		}
+
+		job, err := api.scanner.SubmitScan(profile, dhcp.ScanTriggerAPI)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": "扫描任务已提交",
+			"id":      job.ID,
+			"profile": job.Profile,
+			"total":   job.TotalIPs,
+		})
+	case "DELETE":
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "id is required"})
+			return
+		}
+		if err := api.scanner.CancelScan(id); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": "扫描任务已取消",
+		})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed"})
+	}
+}
+
 // handleAvailableIPs 返回所有可用的静态IP地址
 func (api *APIServer) handleAvailableIPs(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed"})
+		return
+	}
 	ips := api.pool.GetAvailableIPs()
 	json.NewEncoder(w).Encode(ips)
 }
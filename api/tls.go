@@ -0,0 +1,352 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"dhcp-server/config"
+)
+
+const (
+	defaultCertFile      = "api-cert.pem"
+	defaultKeyFile       = "api-key.pem"
+	certReloadInterval   = 10 * time.Second         // 检查证书文件变化的最小间隔
+	selfSignedCertValid  = 2 * 365 * 24 * time.Hour // 自签名证书有效期
+	selfSignedRenewAhead = 30 * 24 * time.Hour      // 自签名证书到期前多久重新生成
+	selfSignedCommonName = "DHCP Server"
+)
+
+// certReloader 按需检查证书、私钥和客户端CA文件的修改时间，变化后在下一次握手时重新加载；
+// 自签名模式下证书临近到期时重新生成
+type certReloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+	selfSigned   []string // 自签名证书包含的主机，为nil时不自动重新生成
+
+	mutex     sync.Mutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  map[string]time.Time
+	lastCheck time.Time
+}
+
+// newCertReloader 创建并立即加载证书，加载失败时返回错误；selfSignedHosts不为nil时临近到期的自签名证书会重新生成
+func newCertReloader(certFile, keyFile, clientCAFile string, selfSignedHosts []string) (*certReloader, error) {
+	cr := &certReloader{
+		certFile:     certFile,
+		keyFile:      keyFile,
+		clientCAFile: clientCAFile,
+		selfSigned:   selfSignedHosts,
+		modTimes:     make(map[string]time.Time),
+	}
+	if err := cr.load(); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if cr.expiring(now) {
+		if err := cr.renew(); err != nil {
+			return nil, err
+		}
+	}
+	cr.lastCheck = now
+	return cr, nil
+}
+
+// files 需要监视的文件
+func (cr *certReloader) files() []string {
+	files := []string{cr.certFile, cr.keyFile}
+	if cr.clientCAFile != "" {
+		files = append(files, cr.clientCAFile)
+	}
+	return files
+}
+
+// load 读取证书和客户端CA（调用者持有锁或尚未并发使用）
+func (cr *certReloader) load() error {
+	cert, err := tls.LoadX509KeyPair(cr.certFile, cr.keyFile)
+	if err != nil {
+		return fmt.Errorf("加载证书失败: %v", err)
+	}
+	if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+		return fmt.Errorf("解析证书失败: %v", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if cr.clientCAFile != "" {
+		data, err := ioutil.ReadFile(cr.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("读取客户端CA失败: %v", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(data) {
+			return fmt.Errorf("客户端CA文件中没有有效的证书: %s", cr.clientCAFile)
+		}
+	}
+
+	for _, file := range cr.files() {
+		if info, err := os.Stat(file); err == nil {
+			cr.modTimes[file] = info.ModTime()
+		}
+	}
+	cr.cert = &cert
+	cr.clientCAs = clientCAs
+	return nil
+}
+
+// expiring 检查当前证书是否为本程序生成的自签名证书且临近到期（调用者持有锁或尚未并发使用）
+func (cr *certReloader) expiring(now time.Time) bool {
+	if cr.selfSigned == nil || cr.cert == nil || cr.cert.Leaf == nil {
+		return false
+	}
+	leaf := cr.cert.Leaf
+	if leaf.Subject.CommonName != selfSignedCommonName || leaf.Issuer.String() != leaf.Subject.String() {
+		// 管理员放置的证书不自动替换
+		return false
+	}
+	return now.Add(selfSignedRenewAhead).After(leaf.NotAfter)
+}
+
+// renew 重新生成自签名证书并加载（调用者持有锁或尚未并发使用）
+func (cr *certReloader) renew() error {
+	expires := cr.cert.Leaf.NotAfter
+	if err := generateSelfSignedCert(cr.certFile, cr.keyFile, cr.selfSigned); err != nil {
+		return fmt.Errorf("重新生成自签名证书失败: %v", err)
+	}
+	if err := cr.load(); err != nil {
+		return err
+	}
+	log.Printf("自签名证书将于 %s 到期，已重新生成: %s, 指纹=%s", expires.Format("2006-01-02"), cr.certFile, certFingerprint(cr.cert))
+	return nil
+}
+
+// maybeReload 距上次检查超过间隔且文件有变化时重新加载，失败时继续使用旧证书
+func (cr *certReloader) maybeReload() {
+	cr.mutex.Lock()
+	defer cr.mutex.Unlock()
+
+	now := time.Now()
+	if now.Sub(cr.lastCheck) < certReloadInterval {
+		return
+	}
+	cr.lastCheck = now
+
+	if cr.expiring(now) {
+		if err := cr.renew(); err != nil {
+			log.Printf("%v，继续使用旧证书", err)
+		}
+		return
+	}
+
+	changed := false
+	for _, file := range cr.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		if !info.ModTime().Equal(cr.modTimes[file]) {
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+
+	if err := cr.load(); err != nil {
+		log.Printf("证书文件已变化但重新加载失败，继续使用旧证书: %v", err)
+		return
+	}
+	log.Printf("已重新加载API证书: %s, 指纹=%s", cr.certFile, certFingerprint(cr.cert))
+}
+
+// getCertificate 供tls.Config.GetCertificate使用
+func (cr *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cr.maybeReload()
+
+	cr.mutex.Lock()
+	defer cr.mutex.Unlock()
+	return cr.cert, nil
+}
+
+// getClientCAs 获取当前的客户端CA
+func (cr *certReloader) getClientCAs() *x509.CertPool {
+	cr.mutex.Lock()
+	defer cr.mutex.Unlock()
+	return cr.clientCAs
+}
+
+// certFingerprint 证书的SHA-256指纹，便于管理员核对自签名证书
+func certFingerprint(cert *tls.Certificate) string {
+	if cert == nil || len(cert.Certificate) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(cert.Certificate[0])
+	return hex.EncodeToString(sum[:])
+}
+
+// tlsMinVersion 解析最低TLS版本，默认1.2
+func tlsMinVersion(version string) uint16 {
+	if version == "1.3" {
+		return tls.VersionTLS13
+	}
+	return tls.VersionTLS12
+}
+
+// buildTLSConfig 根据配置创建TLS配置，需要时生成自签名证书
+func buildTLSConfig(cfg config.TLSConfig, apiHost string) (*tls.Config, error) {
+	certFile, keyFile := cfg.CertFile, cfg.KeyFile
+	if certFile == "" {
+		certFile = defaultCertFile
+	}
+	if keyFile == "" {
+		keyFile = defaultKeyFile
+	}
+
+	var hosts []string
+	if cfg.SelfSigned {
+		hosts = selfSignedHosts(apiHost, cfg.Hosts)
+		if !fileExists(certFile) && !fileExists(keyFile) {
+			if err := generateSelfSignedCert(certFile, keyFile, hosts); err != nil {
+				return nil, fmt.Errorf("生成自签名证书失败: %v", err)
+			}
+		}
+	}
+
+	reloader, err := newCertReloader(certFile, keyFile, cfg.ClientCAFile, hosts)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("API证书: %s, 指纹=%s", certFile, certFingerprint(reloader.cert))
+
+	tlsConfig := &tls.Config{
+		MinVersion:     tlsMinVersion(cfg.MinVersion),
+		GetCertificate: reloader.getCertificate,
+	}
+	if cfg.ClientCAFile != "" {
+		// 客户端CA同样支持热更新，每次握手使用最新的CA
+		tlsConfig.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			reloader.maybeReload()
+			return &tls.Config{
+				MinVersion:     tlsConfig.MinVersion,
+				GetCertificate: reloader.getCertificate,
+				ClientAuth:     tls.RequireAndVerifyClientCert,
+				ClientCAs:      reloader.getClientCAs(),
+			}, nil
+		}
+		log.Printf("已启用客户端证书认证（mTLS），CA: %s", cfg.ClientCAFile)
+	}
+	return tlsConfig, nil
+}
+
+// fileExists 检查文件是否存在
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// selfSignedHosts 自签名证书包含的主机：localhost、本机主机名、监听地址、本机所有IP和配置的额外主机
+func selfSignedHosts(apiHost string, extra []string) []string {
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+	if hostname, err := os.Hostname(); err == nil {
+		hosts = append(hosts, hostname)
+	}
+	if apiHost != "" && apiHost != "0.0.0.0" {
+		hosts = append(hosts, apiHost)
+	}
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && !ipNet.IP.IsLinkLocalUnicast() {
+				hosts = append(hosts, ipNet.IP.String())
+			}
+		}
+	}
+	return append(hosts, extra...)
+}
+
+// generateSelfSignedCert 生成ECDSA P-256自签名证书并写入文件，私钥文件权限为0600
+func generateSelfSignedCert(certFile, keyFile string, hosts []string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: selfSignedCommonName, Organization: []string{"DHCP Server"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedCertValid),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	seen := make(map[string]bool)
+	for _, host := range hosts {
+		if host == "" || seen[host] {
+			continue
+		}
+		seen[host] = true
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range []string{certFile, keyFile} {
+		if dir := filepath.Dir(file); dir != "" {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+		}
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := ioutil.WriteFile(certFile, certPEM, 0644); err != nil {
+		return err
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := ioutil.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		return err
+	}
+
+	log.Printf("已生成自签名证书: %s（有效期至 %s），包含: %v", certFile, template.NotAfter.Format("2006-01-02"), append(template.DNSNames, ipStrings(template.IPAddresses)...))
+	return nil
+}
+
+// ipStrings 将IP列表转换为字符串
+func ipStrings(ips []net.IP) []string {
+	result := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		result = append(result, ip.String())
+	}
+	return result
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	// 抑制测试期间的日志输出
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+func TestGenerateSelfSignedCert(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls", "cert.pem")
+	keyFile := filepath.Join(dir, "tls", "key.pem")
+
+	hosts := []string{"localhost", "127.0.0.1", "::1", "dhcp.example.internal", "localhost", ""}
+	if err := generateSelfSignedCert(certFile, keyFile, hosts); err != nil {
+		t.Fatalf("生成自签名证书失败: %v", err)
+	}
+
+	if info, err := os.Stat(keyFile); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("私钥文件权限应为0600: %v", err)
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("证书和私钥应匹配: %v", err)
+	}
+	reloader, err := newCertReloader(certFile, keyFile, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf := reloader.cert.Leaf
+	if certFingerprint(reloader.cert) != certFingerprint(&cert) {
+		t.Error("加载的证书应与生成的证书一致")
+	}
+
+	if leaf.Subject.CommonName != selfSignedCommonName || leaf.Issuer.String() != leaf.Subject.String() {
+		t.Errorf("应为自签名证书: subject=%s issuer=%s", leaf.Subject, leaf.Issuer)
+	}
+	if len(leaf.DNSNames) != 2 || leaf.DNSNames[0] != "localhost" || leaf.DNSNames[1] != "dhcp.example.internal" {
+		t.Errorf("DNS名称应去重并忽略空值: %v", leaf.DNSNames)
+	}
+	if ips := ipStrings(leaf.IPAddresses); len(ips) != 2 || ips[0] != "127.0.0.1" || ips[1] != "::1" {
+		t.Errorf("IP地址不正确: %v", ips)
+	}
+	if validity := leaf.NotAfter.Sub(time.Now()); validity < selfSignedCertValid-time.Hour || validity > selfSignedCertValid {
+		t.Errorf("有效期应为 %v, 实际剩余 %v", selfSignedCertValid, validity)
+	}
+	if err := leaf.VerifyHostname("dhcp.example.internal"); err != nil {
+		t.Errorf("证书应包含配置的主机名: %v", err)
+	}
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	if err := generateSelfSignedCert(certFile, keyFile, []string{"localhost"}); err != nil {
+		t.Fatal(err)
+	}
+	reloader, err := newCertReloader(certFile, keyFile, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := certFingerprint(reloader.cert)
+
+	// 检查间隔内不重新读取文件
+	if err := generateSelfSignedCert(certFile, keyFile, []string{"localhost"}); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+	os.Chtimes(keyFile, later, later)
+	if cert, _ := reloader.getCertificate(nil); certFingerprint(cert) != original {
+		t.Error("检查间隔内不应重新加载证书")
+	}
+
+	// 超过检查间隔且文件变化后重新加载
+	reloader.lastCheck = time.Now().Add(-certReloadInterval)
+	cert, _ := reloader.getCertificate(nil)
+	replaced := certFingerprint(cert)
+	if replaced == original {
+		t.Fatal("证书文件变化后应重新加载")
+	}
+
+	// 新文件无效时继续使用旧证书
+	if err := ioutil.WriteFile(certFile, []byte("invalid"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later = later.Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+	reloader.lastCheck = time.Now().Add(-certReloadInterval)
+	if cert, _ := reloader.getCertificate(nil); certFingerprint(cert) != replaced {
+		t.Error("重新加载失败时应继续使用旧证书")
+	}
+	if _, err := newCertReloader(certFile, keyFile, "", nil); err == nil {
+		t.Error("无效的证书文件应返回错误")
+	}
+}
+
+func TestCertReloaderRenewsSelfSigned(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	hosts := []string{"localhost"}
+	if err := generateSelfSignedCert(certFile, keyFile, hosts); err != nil {
+		t.Fatal(err)
+	}
+
+	// 未启用自签名时不替换证书
+	manual, err := newCertReloader(certFile, keyFile, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manual.expiring(time.Now().Add(selfSignedCertValid)) {
+		t.Error("未启用自签名时不应重新生成证书")
+	}
+
+	reloader, err := newCertReloader(certFile, keyFile, "", hosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := certFingerprint(reloader.cert)
+	if reloader.expiring(time.Now()) {
+		t.Fatal("新生成的证书不应视为临近到期")
+	}
+	if !reloader.expiring(reloader.cert.Leaf.NotAfter.Add(-selfSignedRenewAhead / 2)) {
+		t.Fatal("到期前30天内应视为临近到期")
+	}
+
+	// 临近到期时重新生成并立即使用新证书
+	if err := reloader.renew(); err != nil {
+		t.Fatalf("重新生成证书失败: %v", err)
+	}
+	renewed := certFingerprint(reloader.cert)
+	if renewed == original {
+		t.Fatal("重新生成后应使用新证书")
+	}
+	if cert, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil || certFingerprint(&cert) != renewed {
+		t.Errorf("新证书应写入文件: %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"dhcp-server/config"
+)
+
+// requestPrincipal 获取请求身份，未经过认证中间件时视为关闭认证
+func requestPrincipal(r *http.Request) Principal {
+	if p, ok := principalFromContext(r.Context()); ok {
+		return p
+	}
+	return Principal{Kind: authKindAnonymous, Role: config.RoleAdmin}
+}
+
+// canGrant 检查创建者能否授予令牌指定的权限，防止通过新令牌提升权限
+func (p Principal) canGrant(role string, scopes []string) error {
+	if len(scopes) == 0 {
+		if role == config.RoleAdmin {
+			if !p.isAdmin() {
+				return fmt.Errorf("无权创建admin角色的令牌")
+			}
+			return nil
+		}
+		scopes = roleScopes[role]
+	}
+
+	for _, scope := range scopes {
+		if scope == "*" {
+			if !p.isAdmin() {
+				return fmt.Errorf("无权授予权限范围 *")
+			}
+			continue
+		}
+		resource := strings.SplitN(scope, ":", 2)[0]
+		needed := []string{scope}
+		if strings.HasSuffix(scope, ":*") {
+			needed = []string{resource + ":read", resource + ":write"}
+		}
+		for _, s := range needed {
+			if !p.allows(s) {
+				return fmt.Errorf("无权授予权限范围 %s", s)
+			}
+		}
+	}
+	return nil
+}
+
+// flushTokenUsage 把内存中的令牌使用时间写回配置，随配置一起保存
+func (api *APIServer) flushTokenUsage(cfg *config.Config) {
+	for i := range cfg.Auth.Tokens {
+		token := &cfg.Auth.Tokens[i]
+		if used, ok := api.auth.tokenLastUsed(token.Name); ok {
+			token.LastUsed = &used
+		}
+	}
+}
+
+// handleTokens API令牌管理：GET列出，POST创建（明文只返回一次），DELETE ?name=... 吊销
+func (api *APIServer) handleTokens(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		now := time.Now()
+		tokens := make([]TokenInfo, 0, len(api.config.Auth.Tokens))
+		for _, token := range api.config.Auth.Tokens {
+			if used, ok := api.auth.tokenLastUsed(token.Name); ok {
+				token.LastUsed = &used
+			}
+			tokens = append(tokens, TokenInfo{APIToken: token, Expired: token.Expired(now)})
+		}
+		json.NewEncoder(w).Encode(tokens)
+	case http.MethodPost:
+		var req CreateTokenRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Invalid JSON"})
+			return
+		}
+
+		now := time.Now()
+		token := config.APIToken{
+			Name:        strings.TrimSpace(req.Name),
+			Role:        req.Role,
+			Scopes:      req.Scopes,
+			SourceCIDRs: req.SourceCIDRs,
+			CreatedAt:   &now,
+			ExpiresAt:   req.ExpiresAt,
+		}
+		if req.ExpiresIn != "" {
+			duration, err := time.ParseDuration(req.ExpiresIn)
+			if err != nil || duration <= 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "无效的有效期: " + req.ExpiresIn})
+				return
+			}
+			expires := now.Add(duration)
+			token.ExpiresAt = &expires
+		}
+		if token.ExpiresAt != nil && !token.ExpiresAt.After(now) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "过期时间必须晚于当前时间"})
+			return
+		}
+		if err := config.ValidateToken(token); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		if err := ValidateScopes(token.Scopes); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		creator := requestPrincipal(r)
+		if err := creator.canGrant(token.Role, token.Scopes); err != nil {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		for _, existing := range api.config.Auth.Tokens {
+			if existing.Name == token.Name {
+				w.WriteHeader(http.StatusConflict)
+				json.NewEncoder(w).Encode(map[string]string{"error": "Token already exists"})
+				return
+			}
+		}
+
+		plaintext, hash, err := GenerateToken()
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "生成令牌失败"})
+			return
+		}
+		token.TokenHash = hash
+
+		api.config.Auth.Tokens = append(api.config.Auth.Tokens, token)
+		if err := api.saveConfig(r); err != nil {
+			api.config.Auth.Tokens = api.config.Auth.Tokens[:len(api.config.Auth.Tokens)-1]
+			w.WriteHeader(saveErrorStatus(err))
+			json.NewEncoder(w).Encode(map[string]string{"error": "保存配置失败: " + err.Error()})
+			return
+		}
+
+		log.Printf("API令牌已创建: %s, 创建者=%s%s, 权限=%v, 角色=%s", token.Name, creator.Username, creator.Token, token.Scopes, token.Role)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(CreateTokenResponse{
+			Token:   plaintext,
+			Info:    TokenInfo{APIToken: token},
+			Message: "令牌只显示一次，请妥善保存",
+		})
+	case http.MethodDelete:
+		name := r.URL.Query().Get("name")
+		updated := make([]config.APIToken, 0, len(api.config.Auth.Tokens))
+		for _, token := range api.config.Auth.Tokens {
+			if token.Name != name {
+				updated = append(updated, token)
+			}
+		}
+		if len(updated) == len(api.config.Auth.Tokens) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Token not found"})
+			return
+		}
+		api.config.Auth.Tokens = updated
+		api.auth.forgetToken(name)
+
+		if err := api.saveConfig(r); err != nil {
+			w.WriteHeader(saveErrorStatus(err))
+			json.NewEncoder(w).Encode(map[string]string{"error": "保存配置失败: " + err.Error()})
+			return
+		}
+
+		log.Printf("API令牌已吊销: %s", name)
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "message": "令牌已吊销"})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Method not allowed"})
+	}
+}
@@ -0,0 +1,68 @@
+package api
+
+import "dhcp-server/apitypes"
+
+// 请求和响应类型定义在apitypes包中，与client包共用，这里保留别名
+
+// 请求类型
+type (
+	LoginRequest           = apitypes.LoginRequest
+	ChangePasswordRequest  = apitypes.ChangePasswordRequest
+	GatewayRequest         = apitypes.GatewayRequest
+	GatewayDeleteRequest   = apitypes.GatewayDeleteRequest
+	DeviceDeleteRequest    = apitypes.DeviceDeleteRequest
+	BatchDevicesRequest    = apitypes.BatchDevicesRequest
+	DeviceGatewayRequest   = apitypes.DeviceGatewayRequest
+	BindingInfo            = apitypes.BindingInfo
+	BindingUpdateRequest   = apitypes.BindingUpdateRequest
+	BindingDeleteRequest   = apitypes.BindingDeleteRequest
+	ConvertToStaticRequest = apitypes.ConvertToStaticRequest
+	ConfigSaveRequest      = apitypes.ConfigSaveRequest
+	ConfigValidateRequest  = apitypes.ConfigValidateRequest
+	ConfigRestoreRequest   = apitypes.ConfigRestoreRequest
+	AccessListRequest      = apitypes.AccessListRequest
+	CreateTokenRequest     = apitypes.CreateTokenRequest
+	ScanJobRequest         = apitypes.ScanJobRequest
+)
+
+// 响应类型
+type (
+	LoginResponse       = apitypes.LoginResponse
+	MessageResponse     = apitypes.MessageResponse
+	HealthResponse      = apitypes.HealthResponse
+	GatewayStatus       = apitypes.GatewayStatus
+	GroupInfo           = apitypes.GroupInfo
+	ConfigResponse      = apitypes.ConfigResponse
+	RawConfigResponse   = apitypes.RawConfigResponse
+	LogsResponse        = apitypes.LogsResponse
+	AuditResponse       = apitypes.AuditResponse
+	LeaseInfo           = apitypes.LeaseInfo
+	HistoryRecord       = apitypes.HistoryRecord
+	StatsResponse       = apitypes.StatsResponse
+	ServerInfo          = apitypes.ServerInfo
+	TokenInfo           = apitypes.TokenInfo
+	CreateTokenResponse = apitypes.CreateTokenResponse
+)
+
+// 版本化API的错误和分页列表
+type (
+	APIError      = apitypes.APIError
+	ErrorResponse = apitypes.ErrorResponse
+	LeaseList     = apitypes.LeaseList
+	BindingList   = apitypes.BindingList
+	DeviceList    = apitypes.DeviceList
+)
+
+// 错误码
+const (
+	ErrCodeInvalidRequest       = apitypes.ErrCodeInvalidRequest
+	ErrCodeUnauthenticated      = apitypes.ErrCodeUnauthenticated
+	ErrCodeForbidden            = apitypes.ErrCodeForbidden
+	ErrCodeNotFound             = apitypes.ErrCodeNotFound
+	ErrCodeMethodNotAllowed     = apitypes.ErrCodeMethodNotAllowed
+	ErrCodeConflict             = apitypes.ErrCodeConflict
+	ErrCodePreconditionFailed   = apitypes.ErrCodePreconditionFailed
+	ErrCodePreconditionRequired = apitypes.ErrCodePreconditionRequired
+	ErrCodeTooManyRequests      = apitypes.ErrCodeTooManyRequests
+	ErrCodeInternal             = apitypes.ErrCodeInternal
+)
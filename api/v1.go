@@ -0,0 +1,644 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"dhcp-server/config"
+)
+
+// v1Prefix 版本化API的路径前缀
+const v1Prefix = "/api/v1/"
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 500
+)
+
+// errorCode 根据状态码确定错误码
+func errorCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return ErrCodeInvalidRequest
+	case http.StatusUnauthorized:
+		return ErrCodeUnauthenticated
+	case http.StatusForbidden:
+		return ErrCodeForbidden
+	case http.StatusNotFound:
+		return ErrCodeNotFound
+	case http.StatusMethodNotAllowed:
+		return ErrCodeMethodNotAllowed
+	case http.StatusConflict:
+		return ErrCodeConflict
+	case http.StatusPreconditionFailed:
+		return ErrCodePreconditionFailed
+	case http.StatusPreconditionRequired:
+		return ErrCodePreconditionRequired
+	case http.StatusTooManyRequests:
+		return ErrCodeTooManyRequests
+	}
+	if status >= 500 {
+		return ErrCodeInternal
+	}
+	return ErrCodeInvalidRequest
+}
+
+// writeV1Error 输出统一错误响应
+func writeV1Error(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: APIError{Code: errorCode(status), Message: message}})
+}
+
+// writeV1JSON 输出JSON响应
+func writeV1JSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// envelopeWriter 将旧接口的错误响应（{"error":"..."} 或 http.Error的纯文本）转换为统一错误响应，成功响应原样输出
+type envelopeWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// WriteHeader 错误状态码暂不输出，等待finish统一处理
+func (ew *envelopeWriter) WriteHeader(status int) {
+	if ew.status != 0 {
+		return
+	}
+	ew.status = status
+	if status < 400 {
+		ew.ResponseWriter.WriteHeader(status)
+	}
+}
+
+// Write 成功响应直接输出，错误响应先缓存
+func (ew *envelopeWriter) Write(data []byte) (int, error) {
+	if ew.status == 0 {
+		ew.WriteHeader(http.StatusOK)
+	}
+	if ew.status >= 400 {
+		return ew.body.Write(data)
+	}
+	return ew.ResponseWriter.Write(data)
+}
+
+// finish 输出缓存的错误响应
+func (ew *envelopeWriter) finish() {
+	if ew.status < 400 {
+		return
+	}
+
+	var envelope ErrorResponse
+	if json.Unmarshal(ew.body.Bytes(), &envelope) == nil && envelope.Error.Code != "" {
+		// 已经是统一格式
+		ew.ResponseWriter.WriteHeader(ew.status)
+		ew.ResponseWriter.Write(ew.body.Bytes())
+		return
+	}
+
+	message := ""
+	var legacy map[string]interface{}
+	if json.Unmarshal(ew.body.Bytes(), &legacy) == nil {
+		if text, ok := legacy["error"].(string); ok {
+			message = text
+		}
+	} else {
+		message = strings.TrimSpace(ew.body.String())
+	}
+	if message == "" {
+		message = http.StatusText(ew.status)
+	}
+	writeV1Error(ew.ResponseWriter, ew.status, message)
+}
+
+// v1Middleware 保证 /api/v1/ 下所有错误（包括认证和权限错误）都使用统一错误响应
+func v1Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, v1Prefix) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ew := &envelopeWriter{ResponseWriter: w}
+		next.ServeHTTP(ew, r)
+		ew.finish()
+	})
+}
+
+// delegate 以新的请求体调用旧接口的处理函数，复用其校验、保存和重新加载逻辑
+func (api *APIServer) delegate(w http.ResponseWriter, r *http.Request, method string, body interface{}, handler http.HandlerFunc) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		writeV1Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	req := r.Clone(r.Context())
+	req.Method = method
+	req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	req.ContentLength = int64(len(data))
+
+	w.Header().Set("Content-Type", "application/json")
+	ew := &envelopeWriter{ResponseWriter: w}
+	handler(ew, req)
+	ew.finish()
+}
+
+// decodeV1Body 解析请求体，失败时输出错误响应并返回false
+func decodeV1Body(w http.ResponseWriter, r *http.Request, target interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(target); err != nil {
+		writeV1Error(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// resourceID 从 /api/v1/<资源>/<标识> 中取出标识，集合路径返回空字符串
+func resourceID(r *http.Request, collection string) string {
+	return strings.Trim(strings.TrimPrefix(r.URL.Path, collection), "/")
+}
+
+// listCursor 分页游标，记录上一页最后一项的排序键和标识
+type listCursor struct {
+	Sort string `json:"s"`
+	Desc bool   `json:"d,omitempty"`
+	Key  string `json:"k"`
+	ID   string `json:"i"`
+}
+
+// encode 游标编码为不透明字符串
+func (c listCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// listParams 列表查询参数：limit、cursor、sort（字段名，前缀 - 表示降序）
+type listParams struct {
+	limit  int
+	sort   string
+	desc   bool
+	cursor *listCursor
+}
+
+// parseListParams 解析分页和排序参数
+func parseListParams(r *http.Request, sortFields []string) (listParams, error) {
+	query := r.URL.Query()
+	params := listParams{limit: defaultPageLimit, sort: sortFields[0]}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return params, fmt.Errorf("无效的limit: %s", value)
+		}
+		if limit > maxPageLimit {
+			limit = maxPageLimit
+		}
+		params.limit = limit
+	}
+
+	if value := query.Get("sort"); value != "" {
+		field := strings.TrimPrefix(value, "-")
+		valid := false
+		for _, f := range sortFields {
+			if f == field {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return params, fmt.Errorf("不支持的排序字段: %s，可选: %s", field, strings.Join(sortFields, ", "))
+		}
+		params.sort = field
+		params.desc = strings.HasPrefix(value, "-")
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		data, err := base64.RawURLEncoding.DecodeString(value)
+		var c listCursor
+		if err != nil || json.Unmarshal(data, &c) != nil {
+			return params, fmt.Errorf("无效的cursor")
+		}
+		if c.Sort != params.sort || c.Desc != params.desc {
+			return params, fmt.Errorf("cursor与当前排序方式不一致")
+		}
+		params.cursor = &c
+	}
+	return params, nil
+}
+
+// listItem 待分页的列表项，keys为各排序字段的可比较字符串
+type listItem struct {
+	id    string
+	keys  map[string]string
+	value interface{}
+}
+
+// paginate 按排序键和标识稳定排序，从游标之后取一页，返回本页的值和下一页游标
+func paginate(items []listItem, params listParams) ([]interface{}, string) {
+	less := func(a, b listItem) bool {
+		ka, kb := a.keys[params.sort], b.keys[params.sort]
+		if ka != kb {
+			return ka < kb
+		}
+		return a.id < b.id
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if params.desc {
+			return less(items[j], items[i])
+		}
+		return less(items[i], items[j])
+	})
+
+	start := 0
+	if c := params.cursor; c != nil {
+		last := listItem{id: c.ID, keys: map[string]string{params.sort: c.Key}}
+		start = sort.Search(len(items), func(i int) bool {
+			if params.desc {
+				return less(items[i], last)
+			}
+			return less(last, items[i])
+		})
+	}
+
+	end := start + params.limit
+	if end > len(items) {
+		end = len(items)
+	}
+	page := make([]interface{}, 0, end-start)
+	for _, item := range items[start:end] {
+		page = append(page, item.value)
+	}
+
+	next := ""
+	if end < len(items) {
+		last := items[end-1]
+		next = listCursor{Sort: params.sort, Desc: params.desc, Key: last.keys[params.sort], ID: last.id}.encode()
+	}
+	return page, next
+}
+
+// ipSortKey IPv4地址的排序键，保证按数值排序
+func ipSortKey(ip string) string {
+	if parsed := net.ParseIP(ip).To4(); parsed != nil {
+		return fmt.Sprintf("%03d.%03d.%03d.%03d", parsed[0], parsed[1], parsed[2], parsed[3])
+	}
+	return ip
+}
+
+// timeSortKey 时间的排序键
+func timeSortKey(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000000000")
+}
+
+// containsFold 不区分大小写的子串匹配
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// parseBoolFilter 解析布尔过滤参数，未提供时返回nil
+func parseBoolFilter(r *http.Request, name string) (*bool, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("无效的%s: %s", name, value)
+	}
+	return &b, nil
+}
+
+// handleV1NotFound 版本化API下未定义的路径
+func (api *APIServer) handleV1NotFound(w http.ResponseWriter, r *http.Request) {
+	writeV1Error(w, http.StatusNotFound, "接口不存在: "+r.URL.Path)
+}
+
+// handleV1Leases 租约集合和单个租约
+//
+//	GET /api/v1/leases       过滤参数: mac、hostname（包含）、gateway（名称或IP）、static、state（active/expired）
+//	                         排序字段: ip（默认）、mac、hostname、start_time
+//	GET /api/v1/leases/{ip}
+func (api *APIServer) handleV1Leases(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeV1Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if ip := resourceID(r, "/api/v1/leases"); ip != "" {
+		if net.ParseIP(ip) == nil {
+			writeV1Error(w, http.StatusBadRequest, "无效的IP地址: "+ip)
+			return
+		}
+		lease, ok := api.pool.GetLease(ip)
+		if !ok {
+			writeV1Error(w, http.StatusNotFound, "租约不存在: "+ip)
+			return
+		}
+		writeV1JSON(w, http.StatusOK, newLeaseInfo(lease))
+		return
+	}
+
+	params, err := parseListParams(r, []string{"ip", "mac", "hostname", "start_time"})
+	if err != nil {
+		writeV1Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	static, err := parseBoolFilter(r, "static")
+	if err != nil {
+		writeV1Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	query := r.URL.Query()
+	state := query.Get("state")
+	if state != "" && state != "active" && state != "expired" {
+		writeV1Error(w, http.StatusBadRequest, "无效的state: "+state+"，可选: active, expired")
+		return
+	}
+
+	var items []listItem
+	for _, lease := range api.pool.GetAllLeases() {
+		info := newLeaseInfo(lease)
+		if mac := query.Get("mac"); mac != "" && !strings.EqualFold(info.MAC, mac) {
+			continue
+		}
+		if hostname := query.Get("hostname"); hostname != "" && !containsFold(info.Hostname, hostname) {
+			continue
+		}
+		if gw := query.Get("gateway"); gw != "" && info.Gateway != gw && info.GatewayIP != gw {
+			continue
+		}
+		if static != nil && info.IsStatic != *static {
+			continue
+		}
+		if (state == "active" && info.IsExpired) || (state == "expired" && !info.IsExpired) {
+			continue
+		}
+		items = append(items, listItem{
+			id: info.IP,
+			keys: map[string]string{
+				"ip":         ipSortKey(info.IP),
+				"mac":        strings.ToLower(info.MAC),
+				"hostname":   strings.ToLower(info.Hostname),
+				"start_time": timeSortKey(info.StartTime),
+			},
+			value: info,
+		})
+	}
+
+	page, next := paginate(items, params)
+	resp := LeaseList{Items: make([]LeaseInfo, 0, len(page)), Total: len(items), NextCursor: next}
+	for _, v := range page {
+		resp.Items = append(resp.Items, v.(LeaseInfo))
+	}
+	writeV1JSON(w, http.StatusOK, resp)
+}
+
+// findBinding 按别名查找静态绑定
+func (api *APIServer) findBinding(alias string) (BindingInfo, bool) {
+	for _, b := range api.config.Bindings {
+		if b.Alias == alias {
+			return BindingInfo{Alias: b.Alias, MAC: b.MAC, IP: b.IP, Gateway: b.Gateway, Hostname: b.Hostname}, true
+		}
+	}
+	return BindingInfo{}, false
+}
+
+// handleV1Bindings 静态绑定集合和单个绑定
+//
+//	GET  /api/v1/bindings            过滤参数: mac、gateway、q（别名、主机名或IP包含）
+//	                                 排序字段: alias（默认）、ip、mac
+//	POST /api/v1/bindings            创建绑定
+//	GET|PUT|DELETE /api/v1/bindings/{alias}
+func (api *APIServer) handleV1Bindings(w http.ResponseWriter, r *http.Request) {
+	alias := resourceID(r, "/api/v1/bindings")
+	if alias == "" {
+		switch r.Method {
+		case http.MethodGet:
+			api.listV1Bindings(w, r)
+		case http.MethodPost:
+			var binding BindingInfo
+			if decodeV1Body(w, r, &binding) {
+				api.delegate(w, r, http.MethodPost, binding, api.handleAddStaticBinding)
+			}
+		default:
+			writeV1Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		binding, ok := api.findBinding(alias)
+		if !ok {
+			writeV1Error(w, http.StatusNotFound, "Binding not found")
+			return
+		}
+		writeV1JSON(w, http.StatusOK, binding)
+	case http.MethodPut:
+		var binding BindingInfo
+		if !decodeV1Body(w, r, &binding) {
+			return
+		}
+		if binding.Alias == "" {
+			binding.Alias = alias
+		}
+		request := BindingUpdateRequest{OldAlias: alias, BindingInfo: binding}
+		api.delegate(w, r, http.MethodPut, request, api.handleUpdateStaticBinding)
+	case http.MethodDelete:
+		api.delegate(w, r, http.MethodDelete, BindingDeleteRequest{Alias: alias}, api.handleDeleteStaticBinding)
+	default:
+		writeV1Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// listV1Bindings 静态绑定分页列表
+func (api *APIServer) listV1Bindings(w http.ResponseWriter, r *http.Request) {
+	params, err := parseListParams(r, []string{"alias", "ip", "mac"})
+	if err != nil {
+		writeV1Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	query := r.URL.Query()
+
+	var items []listItem
+	for _, b := range api.config.Bindings {
+		if mac := query.Get("mac"); mac != "" && !strings.EqualFold(b.MAC, mac) {
+			continue
+		}
+		if gw := query.Get("gateway"); gw != "" && b.Gateway != gw {
+			continue
+		}
+		if q := query.Get("q"); q != "" && !containsFold(b.Alias, q) && !containsFold(b.Hostname, q) && !strings.Contains(b.IP, q) {
+			continue
+		}
+		items = append(items, listItem{
+			id: b.Alias,
+			keys: map[string]string{
+				"alias": strings.ToLower(b.Alias),
+				"ip":    ipSortKey(b.IP),
+				"mac":   strings.ToLower(b.MAC),
+			},
+			value: BindingInfo{Alias: b.Alias, MAC: b.MAC, IP: b.IP, Gateway: b.Gateway, Hostname: b.Hostname},
+		})
+	}
+
+	page, next := paginate(items, params)
+	resp := BindingList{Items: make([]BindingInfo, 0, len(page)), Total: len(items), NextCursor: next}
+	for _, v := range page {
+		resp.Items = append(resp.Items, v.(BindingInfo))
+	}
+	writeV1JSON(w, http.StatusOK, resp)
+}
+
+// v1Devices 带活跃状态的设备列表副本
+func (api *APIServer) v1Devices() []config.DeviceInfo {
+	devices := append([]config.DeviceInfo(nil), api.config.Devices...)
+	api.refreshDeviceStatus(devices)
+	return devices
+}
+
+// handleV1Devices 设备集合和单个设备
+//
+//	GET  /api/v1/devices             过滤参数: type、owner、tag、active、q（MAC、主机名、型号、描述或所有者包含）
+//	                                 排序字段: mac（默认）、hostname、device_type、owner、last_seen
+//	POST /api/v1/devices             创建设备
+//	GET|PUT|DELETE /api/v1/devices/{mac}
+func (api *APIServer) handleV1Devices(w http.ResponseWriter, r *http.Request) {
+	mac := resourceID(r, "/api/v1/devices")
+	if mac == "" {
+		switch r.Method {
+		case http.MethodGet:
+			api.listV1Devices(w, r)
+		case http.MethodPost:
+			var device config.DeviceInfo
+			if decodeV1Body(w, r, &device) {
+				api.delegate(w, r, http.MethodPost, device, api.handleAddDevice)
+			}
+		default:
+			writeV1Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+		return
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodPut && r.Method != http.MethodDelete {
+		writeV1Error(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	hw, err := net.ParseMAC(mac)
+	if err != nil {
+		writeV1Error(w, http.StatusBadRequest, "无效的MAC地址: "+mac)
+		return
+	}
+	// 路径中的MAC不区分大小写和分隔符，转交给旧接口时使用配置中保存的写法
+	stored := hw.String()
+	for _, device := range api.config.Devices {
+		if sameMAC(device.MAC, hw) {
+			stored = device.MAC
+			break
+		}
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		for _, device := range api.v1Devices() {
+			if sameMAC(device.MAC, hw) {
+				writeV1JSON(w, http.StatusOK, device)
+				return
+			}
+		}
+		writeV1Error(w, http.StatusNotFound, "Device not found")
+	case http.MethodPut:
+		var device config.DeviceInfo
+		if !decodeV1Body(w, r, &device) {
+			return
+		}
+		if device.MAC != "" && !sameMAC(device.MAC, hw) {
+			writeV1Error(w, http.StatusBadRequest, "请求体中的MAC与路径不一致")
+			return
+		}
+		device.MAC = stored
+		api.delegate(w, r, http.MethodPut, device, api.handleUpdateDevice)
+	case http.MethodDelete:
+		api.delegate(w, r, http.MethodDelete, DeviceDeleteRequest{MAC: stored}, api.handleDeleteDevice)
+	}
+}
+
+// sameMAC 比较MAC地址，忽略大小写和分隔符格式
+func sameMAC(mac string, hw net.HardwareAddr) bool {
+	parsed, err := net.ParseMAC(mac)
+	return err == nil && bytes.Equal(parsed, hw)
+}
+
+// listV1Devices 设备分页列表
+func (api *APIServer) listV1Devices(w http.ResponseWriter, r *http.Request) {
+	params, err := parseListParams(r, []string{"mac", "hostname", "device_type", "owner", "last_seen"})
+	if err != nil {
+		writeV1Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	active, err := parseBoolFilter(r, "active")
+	if err != nil {
+		writeV1Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	query := r.URL.Query()
+
+	var items []listItem
+	for _, d := range api.v1Devices() {
+		if t := query.Get("type"); t != "" && !strings.EqualFold(d.DeviceType, t) {
+			continue
+		}
+		if owner := query.Get("owner"); owner != "" && d.Owner != owner {
+			continue
+		}
+		if tag := query.Get("tag"); tag != "" && !hasTag(d.Tags, tag) {
+			continue
+		}
+		if active != nil && d.IsActive != *active {
+			continue
+		}
+		if q := query.Get("q"); q != "" && !containsFold(d.MAC, q) && !containsFold(d.Hostname, q) &&
+			!containsFold(d.Model, q) && !containsFold(d.Description, q) && !containsFold(d.Owner, q) {
+			continue
+		}
+		items = append(items, listItem{
+			id: d.MAC,
+			keys: map[string]string{
+				"mac":         strings.ToLower(d.MAC),
+				"hostname":    strings.ToLower(d.Hostname),
+				"device_type": strings.ToLower(d.DeviceType),
+				"owner":       strings.ToLower(d.Owner),
+				"last_seen":   timeSortKey(d.LastSeen),
+			},
+			value: d,
+		})
+	}
+
+	page, next := paginate(items, params)
+	resp := DeviceList{Items: make([]config.DeviceInfo, 0, len(page)), Total: len(items), NextCursor: next}
+	for _, v := range page {
+		resp.Items = append(resp.Items, v.(config.DeviceInfo))
+	}
+	writeV1JSON(w, http.StatusOK, resp)
+}
+
+// hasTag 标签列表中是否包含指定标签
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
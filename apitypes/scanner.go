@@ -0,0 +1,66 @@
+package apitypes
+
+import "time"
+
+// ScanJobRequest 按需扫描请求，指定方案并可覆盖其中的目标和探测方式
+type ScanJobRequest struct {
+	Profile        string   `json:"profile"`
+	Targets        []string `json:"targets"`
+	Excludes       []string `json:"excludes"`
+	Methods        []string `json:"methods"`
+	TCPPorts       []int    `json:"tcp_ports"`
+	MaxConcurrency int      `json:"max_concurrency"`
+}
+
+// ScanJob 扫描任务及其结果
+type ScanJob struct {
+	ID           string          `json:"id"`
+	Profile      string          `json:"profile"`
+	Trigger      string          `json:"trigger"` // schedule、api
+	Status       string          `json:"status"`  // pending、running、completed、failed、cancelled
+	Targets      []string        `json:"targets"`
+	Excludes     []string        `json:"excludes,omitempty"`
+	Methods      []string        `json:"methods"`
+	TCPPorts     []int           `json:"tcp_ports,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+	StartedAt    time.Time       `json:"started_at"`
+	FinishedAt   time.Time       `json:"finished_at"`
+	TotalIPs     int             `json:"total_ips"`
+	ScannedIPs   int             `json:"scanned_ips"`
+	Progress     int             `json:"progress"` // 0-100
+	FoundDevices int             `json:"found_devices"`
+	Error        string          `json:"error,omitempty"`
+	Results      []ScannedDevice `json:"results,omitempty"`
+}
+
+// ScannedDevice 扫描发现的设备
+type ScannedDevice struct {
+	MAC      string    `json:"mac"`
+	IP       string    `json:"ip"`
+	Hostname string    `json:"hostname"`
+	LastSeen time.Time `json:"last_seen"`
+	IsActive bool      `json:"is_active"`
+	Vendor   string    `json:"vendor"`
+	Response string    `json:"response"` // 响应时间
+	Method   string    `json:"method"`   // 发现方式：arp、icmp
+
+	LocallyAdministered bool `json:"locally_administered"` // 本地管理MAC地址
+	Randomized          bool `json:"randomized"`           // 疑似随机化MAC地址
+
+	HostnameSource string            `json:"hostname_source,omitempty"` // 主机名来源：dns、mdns、netbios、ssdp
+	Model          string            `json:"model,omitempty"`           // 型号（mDNS device-info或UPnP描述）
+	ModelSource    string            `json:"model_source,omitempty"`    // 型号来源
+	Workgroup      string            `json:"workgroup,omitempty"`       // NetBIOS工作组
+	Names          map[string]string `json:"names,omitempty"`           // 各来源发现的名称
+
+	OpenPorts     []int         `json:"open_ports,omitempty"`     // 开放的TCP端口
+	Services      []ServiceInfo `json:"services,omitempty"`       // 端口上识别到的服务和banner
+	SuggestedType string        `json:"suggested_type,omitempty"` // 根据服务推测的设备类型
+}
+
+// ServiceInfo 开放端口上识别到的服务
+type ServiceInfo struct {
+	Port    int    `json:"port"`
+	Service string `json:"service,omitempty"`
+	Banner  string `json:"banner,omitempty"` // SSH版本、HTTP/RTSP Server头等
+}
@@ -0,0 +1,250 @@
+// Package apitypes 管理接口的请求和响应类型，由api包和client包共用，
+// 只依赖配置、审计和指纹等数据类型，不引入服务器实现
+package apitypes
+
+import (
+	"time"
+
+	"dhcp-server/audit"
+	"dhcp-server/config"
+	"dhcp-server/fingerprint"
+)
+
+// 以下请求类型由处理函数解析，同时用于生成OpenAPI文档
+
+// LoginRequest 登录请求
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// ChangePasswordRequest 修改密码请求
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
+// GatewayRequest 添加或更新网关请求
+type GatewayRequest struct {
+	Name        string   `json:"name"`
+	IP          string   `json:"ip"`
+	IsDefault   bool     `json:"is_default"`
+	Description string   `json:"description"`
+	DNSServers  []string `json:"dns_servers"`
+	OldName     string   `json:"old_name,omitempty"` // 更新时用于标识要更新的网关
+}
+
+// GatewayDeleteRequest 删除网关请求
+type GatewayDeleteRequest struct {
+	Name string `json:"name"`
+}
+
+// DeviceDeleteRequest 删除设备请求
+type DeviceDeleteRequest struct {
+	MAC string `json:"mac"`
+}
+
+// BatchDevicesRequest 批量添加设备请求
+type BatchDevicesRequest struct {
+	Devices []config.DeviceInfo `json:"devices"`
+}
+
+// DeviceGatewayRequest 设置设备网关请求
+type DeviceGatewayRequest struct {
+	MAC     string `json:"mac"`
+	Gateway string `json:"gateway"`
+}
+
+// BindingInfo 静态绑定，也是添加绑定的请求
+type BindingInfo struct {
+	Alias    string `json:"alias"`
+	MAC      string `json:"mac"`
+	IP       string `json:"ip"`
+	Gateway  string `json:"gateway"`
+	Hostname string `json:"hostname"`
+}
+
+// BindingUpdateRequest 更新静态绑定请求
+type BindingUpdateRequest struct {
+	OldAlias string `json:"old_alias"` // 用于识别要更新的绑定
+	BindingInfo
+}
+
+// BindingDeleteRequest 删除静态绑定请求
+type BindingDeleteRequest struct {
+	Alias string `json:"alias"`
+}
+
+// ConvertToStaticRequest 将动态租约转换为静态绑定请求
+type ConvertToStaticRequest struct {
+	MAC      string `json:"mac"`
+	Alias    string `json:"alias"`
+	Gateway  string `json:"gateway"`
+	Hostname string `json:"hostname"`
+}
+
+// ConfigSaveRequest 保存配置文件请求
+type ConfigSaveRequest struct {
+	Content    string `json:"content"` // YAML格式的完整配置
+	AutoReload bool   `json:"auto_reload"`
+}
+
+// ConfigValidateRequest 验证配置文件请求
+type ConfigValidateRequest struct {
+	Content string `json:"content"`
+}
+
+// ConfigRestoreRequest 从备份恢复配置请求
+type ConfigRestoreRequest struct {
+	Filename   string `json:"filename"`
+	AutoReload bool   `json:"auto_reload"`
+}
+
+// AccessListRequest 向允许或拒绝列表添加规则的请求
+type AccessListRequest struct {
+	List string `json:"list"` // allow 或 deny
+	MAC  string `json:"mac"`  // MAC地址或前缀
+}
+
+// 以下响应类型由处理函数输出
+
+// LoginResponse 登录成功响应，会话通过Cookie返回
+type LoginResponse struct {
+	Success  bool      `json:"success"`
+	Username string    `json:"username"`
+	Expires  time.Time `json:"expires"`
+}
+
+// MessageResponse 写操作的通用响应，部分接口会附带更多字段
+type MessageResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+// HealthResponse 健康检查响应
+type HealthResponse struct {
+	Status    string    `json:"status"`
+	Timestamp time.Time `json:"timestamp"`
+	Uptime    string    `json:"uptime"`
+	Version   string    `json:"version"`
+}
+
+// GatewayStatus 网关配置及健康状态
+type GatewayStatus struct {
+	Healthy     bool     `json:"healthy"`
+	IP          string   `json:"ip"`
+	IsDefault   bool     `json:"is_default"`
+	Description string   `json:"description"`
+	DNSServers  []string `json:"dns_servers"`
+}
+
+// GroupInfo 设备分组及其成员MAC地址
+type GroupInfo struct {
+	Group   config.DeviceGroup `json:"group"`
+	Members []string           `json:"members"`
+}
+
+// ConfigResponse 解析后的配置
+type ConfigResponse struct {
+	Server      config.ServerConfig  `json:"server"`
+	Network     config.NetworkConfig `json:"network"`
+	Gateways    []config.Gateway     `json:"gateways"`
+	Bindings    []config.MACBinding  `json:"bindings"`
+	Devices     []config.DeviceInfo  `json:"devices"`
+	Groups      []config.DeviceGroup `json:"groups"`
+	HealthCheck config.HealthConfig  `json:"health_check"`
+}
+
+// RawConfigResponse 原始配置文件内容（?raw=true）
+type RawConfigResponse struct {
+	Content string `json:"content"`
+	Path    string `json:"path"`
+	Size    int    `json:"size"`
+}
+
+// LogsResponse 最近的日志行
+type LogsResponse struct {
+	Logs  []string `json:"logs"`
+	Count int      `json:"count"`
+}
+
+// AuditResponse 审计日志查询结果，total为过滤后截断前的条数
+type AuditResponse struct {
+	Total   int           `json:"total"`
+	Entries []audit.Entry `json:"entries"`
+}
+
+// LeaseInfo 租约信息响应
+type LeaseInfo struct {
+	IP            string    `json:"ip"`
+	MAC           string    `json:"mac"`
+	Hostname      string    `json:"hostname"`
+	StartTime     time.Time `json:"start_time"`
+	LeaseTime     string    `json:"lease_time"`
+	RemainingTime string    `json:"remaining_time"`
+	IsStatic      bool      `json:"is_static"`
+	Gateway       string    `json:"gateway"`    // 配置中的网关名称（保持兼容性）
+	GatewayIP     string    `json:"gateway_ip"` // 实际响应的网关IP地址
+	IsExpired     bool      `json:"is_expired"`
+
+	Fingerprint    *fingerprint.Fingerprint `json:"fingerprint,omitempty"`    // 原始DHCP指纹
+	Classification *fingerprint.Match       `json:"classification,omitempty"` // 指纹识别结果
+}
+
+// HistoryRecord 历史记录
+type HistoryRecord struct {
+	IP        string    `json:"ip"`
+	MAC       string    `json:"mac"`
+	Hostname  string    `json:"hostname"`
+	Action    string    `json:"action"` // DISCOVER, REQUEST, RELEASE, etc.
+	Timestamp time.Time `json:"timestamp"`
+	Gateway   string    `json:"gateway"`
+	ServerIP  string    `json:"server_ip"`
+	Detail    string    `json:"detail,omitempty"` // 附加说明，如访问控制的判定原因
+}
+
+// StatsResponse 统计信息响应
+type StatsResponse struct {
+	PoolStats     map[string]interface{} `json:"pool_stats"`
+	GatewayStatus map[string]bool        `json:"gateway_status"`
+	ServerInfo    ServerInfo             `json:"server_info"`
+}
+
+// ServerInfo 服务器信息
+type ServerInfo struct {
+	Version   string    `json:"version"`
+	StartTime time.Time `json:"start_time"`
+	Uptime    string    `json:"uptime"`
+}
+
+// Principal 请求的认证身份
+type Principal struct {
+	Username string   `json:"username,omitempty"`
+	Token    string   `json:"token,omitempty"` // 令牌名称
+	Kind     string   `json:"kind"`
+	Role     string   `json:"role"`
+	Scopes   []string `json:"scopes,omitempty"` // 令牌的权限范围
+}
+
+// TokenInfo 令牌列表中的一项，不包含哈希
+type TokenInfo struct {
+	config.APIToken
+	Expired bool `json:"expired"`
+}
+
+// CreateTokenRequest 创建令牌请求
+type CreateTokenRequest struct {
+	Name        string     `json:"name"`
+	Role        string     `json:"role"` // 角色，未设置scopes时必须设置
+	Scopes      []string   `json:"scopes"`
+	SourceCIDRs []string   `json:"source_cidrs"`
+	ExpiresIn   string     `json:"expires_in"` // 有效期，如 720h，与expires_at二选一
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+// CreateTokenResponse 创建令牌响应，明文令牌只返回这一次
+type CreateTokenResponse struct {
+	Token   string    `json:"token"`
+	Info    TokenInfo `json:"info"`
+	Message string    `json:"message"`
+}
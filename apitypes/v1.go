@@ -0,0 +1,49 @@
+package apitypes
+
+import "dhcp-server/config"
+
+// 错误码
+const (
+	ErrCodeInvalidRequest       = "invalid_request"
+	ErrCodeUnauthenticated      = "unauthenticated"
+	ErrCodeForbidden            = "forbidden"
+	ErrCodeNotFound             = "not_found"
+	ErrCodeMethodNotAllowed     = "method_not_allowed"
+	ErrCodeConflict             = "conflict"
+	ErrCodePreconditionFailed   = "precondition_failed"
+	ErrCodePreconditionRequired = "precondition_required"
+	ErrCodeTooManyRequests      = "too_many_requests"
+	ErrCodeInternal             = "internal_error"
+)
+
+// APIError 版本化API的错误信息
+type APIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ErrorResponse 版本化API的统一错误响应
+type ErrorResponse struct {
+	Error APIError `json:"error"`
+}
+
+// LeaseList 租约分页列表
+type LeaseList struct {
+	Items      []LeaseInfo `json:"items"`
+	Total      int         `json:"total"`                 // 过滤后的总数
+	NextCursor string      `json:"next_cursor,omitempty"` // 为空表示没有下一页
+}
+
+// BindingList 静态绑定分页列表
+type BindingList struct {
+	Items      []BindingInfo `json:"items"`
+	Total      int           `json:"total"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// DeviceList 设备分页列表
+type DeviceList struct {
+	Items      []config.DeviceInfo `json:"items"`
+	Total      int                 `json:"total"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,398 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"dhcp-server/config"
+)
+
+// 操作结果
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+const (
+	defaultRetentionDays = 90
+	defaultMaxEntries    = 100000
+	cleanupInterval      = time.Hour // 删除过期归档文件的最小间隔
+	dayLayout            = "2006-01-02"
+	defaultQueryLimit    = 100
+	maxQueryLimit        = 1000
+)
+
+// Entry 一条审计记录
+type Entry struct {
+	ID        uint64    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Actor     string    `json:"actor"`     // 操作者：用户名、token:名称 或 anonymous
+	AuthKind  string    `json:"auth_kind"` // 认证方式：session、token、anonymous
+	SourceIP  string    `json:"source_ip"`
+	Method    string    `json:"method"`
+	Endpoint  string    `json:"endpoint"`
+	Query     string    `json:"query,omitempty"`
+	Status    int       `json:"status"`
+	Result    string    `json:"result"` // success 或 failure
+	Error     string    `json:"error,omitempty"`
+	Duration  int64     `json:"duration_ms"`
+	Changes   []Change  `json:"changes,omitempty"` // 配置文件变化
+}
+
+// Sections 记录涉及的配置段
+func (e Entry) Sections() []string {
+	var sections []string
+	seen := make(map[string]bool)
+	for _, change := range e.Changes {
+		if !seen[change.Section] {
+			seen[change.Section] = true
+			sections = append(sections, change.Section)
+		}
+	}
+	return sections
+}
+
+// Filter 查询条件，零值字段不过滤
+type Filter struct {
+	Actor    string
+	Method   string
+	Endpoint string // 前缀匹配
+	Section  string // 涉及的配置段
+	Result   string
+	Since    time.Time
+	Until    time.Time
+	Limit    int
+}
+
+// matches 检查记录是否满足查询条件
+func (f Filter) matches(e Entry) bool {
+	if f.Actor != "" && e.Actor != f.Actor {
+		return false
+	}
+	if f.Method != "" && !strings.EqualFold(e.Method, f.Method) {
+		return false
+	}
+	if f.Endpoint != "" && !strings.HasPrefix(e.Endpoint, f.Endpoint) {
+		return false
+	}
+	if f.Result != "" && e.Result != f.Result {
+		return false
+	}
+	if !f.Since.IsZero() && e.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Timestamp.After(f.Until) {
+		return false
+	}
+	if f.Section != "" {
+		found := false
+		for _, section := range e.Sections() {
+			if section == f.Section {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Logger 只追加的审计日志，文件中每行一条记录，按天归档为带日期后缀的文件，内存中保留索引用于查询
+type Logger struct {
+	mutex       sync.Mutex
+	entries     []Entry
+	file        string
+	fileDay     string // 当前日志文件中记录的日期，写入其他日期的记录前归档
+	retention   time.Duration
+	maxEntries  int
+	nextID      uint64
+	lastCleanup time.Time
+}
+
+// archive 归档的日志文件
+type archive struct {
+	path string
+	day  string
+}
+
+// dayOf 记录所属的日期
+func dayOf(t time.Time) string {
+	return t.Local().Format(dayLayout)
+}
+
+// NewLogger 创建审计日志
+func NewLogger() *Logger {
+	return &Logger{
+		retention:  defaultRetentionDays * 24 * time.Hour,
+		maxEntries: defaultMaxEntries,
+		nextID:     1,
+	}
+}
+
+// Configure 应用保留策略，日志文件变化时加载已有记录
+func (l *Logger) Configure(cfg config.AuditConfig) error {
+	retentionDays := cfg.RetentionDays
+	if retentionDays <= 0 {
+		retentionDays = defaultRetentionDays
+	}
+	maxEntries := cfg.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultMaxEntries
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.retention = time.Duration(retentionDays) * 24 * time.Hour
+	l.maxEntries = maxEntries
+
+	if cfg.File == l.file {
+		return nil
+	}
+	l.file = cfg.File
+	if l.file == "" {
+		return nil
+	}
+	return l.load()
+}
+
+// load 从未过期的归档文件和当前日志文件加载记录（调用方需持有mutex）
+func (l *Logger) load() error {
+	archives, err := l.archives()
+	if err != nil {
+		return fmt.Errorf("读取审计日志失败: %v", err)
+	}
+	cutoff := dayOf(time.Now().Add(-l.retention))
+
+	var entries []Entry
+	for _, a := range archives {
+		if a.day < cutoff {
+			continue
+		}
+		loaded, err := readEntries(a.path)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, loaded...)
+	}
+	current, err := readEntries(l.file)
+	if err != nil {
+		return err
+	}
+	entries = append(entries, current...)
+
+	l.fileDay = ""
+	if len(current) > 0 {
+		l.fileDay = dayOf(current[0].Timestamp)
+	}
+	for _, entry := range entries {
+		if entry.ID >= l.nextID {
+			l.nextID = entry.ID + 1
+		}
+	}
+
+	l.entries = entries
+	l.prune(time.Now())
+	log.Printf("已加载 %d 条审计记录: %s", len(l.entries), l.file)
+	return nil
+}
+
+// readEntries 读取一个日志文件中的记录，文件不存在时返回空
+func readEntries(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取审计日志失败: %v", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			log.Printf("跳过无法解析的审计记录: %v", err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取审计日志失败: %v", err)
+	}
+	return entries, nil
+}
+
+// archives 列出日志文件的归档（文件名后缀为日期），按日期从旧到新排序（调用方需持有mutex）
+func (l *Logger) archives() ([]archive, error) {
+	matches, err := filepath.Glob(l.file + ".*")
+	if err != nil {
+		return nil, err
+	}
+	var archives []archive
+	for _, path := range matches {
+		suffix := strings.TrimPrefix(path, l.file+".")
+		if len(suffix) < len(dayLayout) {
+			continue
+		}
+		day := suffix[:len(dayLayout)]
+		if _, err := time.Parse(dayLayout, day); err != nil {
+			continue
+		}
+		archives = append(archives, archive{path: path, day: day})
+	}
+	sort.Slice(archives, func(i, j int) bool { return archives[i].path < archives[j].path })
+	return archives, nil
+}
+
+// Record 追加一条审计记录
+func (l *Logger) Record(entry Entry) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	entry.ID = l.nextID
+	l.nextID++
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	l.entries = append(l.entries, entry)
+
+	if l.file != "" {
+		if err := l.appendFile(entry); err != nil {
+			log.Printf("写入审计日志失败: %v", err)
+		}
+	}
+	l.prune(entry.Timestamp)
+}
+
+// appendFile 以追加方式写入一条记录，日期变化时先归档当前文件（调用方需持有mutex）
+func (l *Logger) appendFile(entry Entry) error {
+	day := dayOf(entry.Timestamp)
+	if l.fileDay != "" && l.fileDay != day {
+		if err := l.rotate(); err != nil {
+			log.Printf("归档审计日志失败: %v", err)
+		}
+	}
+	l.fileDay = day
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(l.file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// rotate 把当前日志文件重命名为 <file>.<日期>，已写入的内容不会被改写（调用方需持有mutex）
+func (l *Logger) rotate() error {
+	target := l.file + "." + l.fileDay
+	for i := 1; ; i++ {
+		if _, err := os.Stat(target); os.IsNotExist(err) {
+			break
+		}
+		target = fmt.Sprintf("%s.%s-%d", l.file, l.fileDay, i)
+	}
+	err := os.Rename(l.file, target)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// prune 按保留策略清理内存中的记录，并按间隔删除整份过期的归档文件（调用方需持有mutex）
+func (l *Logger) prune(now time.Time) {
+	cutoff := now.Add(-l.retention)
+	drop := 0
+	for drop < len(l.entries) && l.entries[drop].Timestamp.Before(cutoff) {
+		drop++
+	}
+	if over := len(l.entries) - drop - l.maxEntries; over > 0 {
+		drop += over
+	}
+	if drop > 0 {
+		l.entries = append([]Entry(nil), l.entries[drop:]...)
+	}
+
+	if l.file == "" || now.Sub(l.lastCleanup) < cleanupInterval {
+		return
+	}
+	l.lastCleanup = now
+	archives, err := l.archives()
+	if err != nil {
+		log.Printf("清理审计日志失败: %v", err)
+		return
+	}
+	expired := dayOf(cutoff)
+	for _, a := range archives {
+		if a.day >= expired {
+			break
+		}
+		if err := os.Remove(a.path); err != nil {
+			log.Printf("删除过期的审计日志失败: %v", err)
+			continue
+		}
+		log.Printf("已删除过期的审计日志: %s", a.path)
+	}
+}
+
+// Query 查询审计记录，最新的在前，返回满足条件的总数和本次返回的记录
+func (l *Logger) Query(filter Filter) (int, []Entry) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultQueryLimit
+	}
+	if limit > maxQueryLimit {
+		limit = maxQueryLimit
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	total := 0
+	result := make([]Entry, 0)
+	for i := len(l.entries) - 1; i >= 0; i-- {
+		if !filter.matches(l.entries[i]) {
+			continue
+		}
+		total++
+		if len(result) < limit {
+			result = append(result, l.entries[i])
+		}
+	}
+	return total, result
+}
+
+// 默认审计日志
+var defaultLogger = NewLogger()
+
+// Configure 配置默认审计日志
+func Configure(cfg config.AuditConfig) error {
+	return defaultLogger.Configure(cfg)
+}
+
+// Record 向默认审计日志追加记录
+func Record(entry Entry) {
+	defaultLogger.Record(entry)
+}
+
+// Query 查询默认审计日志
+func Query(filter Filter) (int, []Entry) {
+	return defaultLogger.Query(filter)
+}
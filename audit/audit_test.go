@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"dhcp-server/config"
+)
+
+func TestMain(m *testing.M) {
+	// 抑制测试期间的日志输出
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+func TestLoggerRotation(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "audit.log")
+	cfg := config.AuditConfig{File: file, RetentionDays: 2}
+	logger := NewLogger()
+	if err := logger.Configure(cfg); err != nil {
+		t.Fatal(err)
+	}
+	// 第0天为昨天，保证重新加载时归档仍在保留期内
+	yesterday := time.Now().AddDate(0, 0, -1)
+	at := func(day, hour int) time.Time {
+		return time.Date(yesterday.Year(), yesterday.Month(), yesterday.Day()+day, hour, 0, 0, 0, time.Local)
+	}
+	lines := func(path string) int {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("读取 %s 失败: %v", path, err)
+		}
+		return strings.Count(string(data), "\n")
+	}
+
+	logger.Record(Entry{Timestamp: at(0, 10), Actor: "admin", Method: "POST", Endpoint: "/api/bindings"})
+	logger.Record(Entry{Timestamp: at(0, 23), Actor: "admin", Method: "DELETE", Endpoint: "/api/bindings"})
+	written, _ := ioutil.ReadFile(file)
+
+	// 跨天时归档当前文件，内容保持不变
+	logger.Record(Entry{Timestamp: at(1, 8), Actor: "token:ci", Method: "PUT", Endpoint: "/api/gateways"})
+	archived, err := ioutil.ReadFile(file + "." + at(0, 0).Format("2006-01-02"))
+	if err != nil || string(archived) != string(written) {
+		t.Fatalf("前一天的记录应原样归档: %v", err)
+	}
+	if n := lines(file); n != 1 {
+		t.Errorf("当前文件应只有当天的1条记录, 实际为 %d", n)
+	}
+
+	// 重新加载时读取归档和当前文件
+	reloaded := NewLogger()
+	if err := reloaded.Configure(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if total, _ := reloaded.Query(Filter{Since: at(0, 0)}); total != 3 {
+		t.Errorf("重新加载后应有3条记录, 实际为 %d", total)
+	}
+
+	// 超过保留天数的归档整份删除，不改写其他文件
+	logger.Record(Entry{Timestamp: at(5, 9), Actor: "admin", Method: "POST", Endpoint: "/api/config"})
+	for _, day := range []string{at(0, 0).Format("2006-01-02"), at(1, 0).Format("2006-01-02")} {
+		if _, err := os.Stat(file + "." + day); !os.IsNotExist(err) {
+			t.Errorf("过期的归档 %s 应被删除: %v", day, err)
+		}
+	}
+	if n := lines(file); n != 1 {
+		t.Errorf("当前文件应只有1条记录, 实际为 %d", n)
+	}
+	if total, entries := logger.Query(Filter{}); total != 1 || entries[0].ID != 4 {
+		t.Errorf("内存中应只保留未过期的记录: %+v", entries)
+	}
+}
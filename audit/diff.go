@@ -0,0 +1,277 @@
+package audit
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// redacted 敏感字段在审计记录中的替代值
+const redacted = "[REDACTED]"
+
+// Change 配置中一处变化，Before/After为空表示新增或删除
+type Change struct {
+	Section string      `json:"section"` // 顶层配置段，如 bindings
+	Path    string      `json:"path"`    // 变化位置，如 bindings[aa:bb:cc:dd:ee:ff].ip
+	Before  interface{} `json:"before,omitempty"`
+	After   interface{} `json:"after,omitempty"`
+}
+
+// Snapshot 配置文件的通用结构，用于比较前后差异
+type Snapshot map[string]interface{}
+
+// LoadSnapshot 读取配置文件，文件不存在时返回空快照
+func LoadSnapshot(path string) (Snapshot, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Snapshot{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[interface{}]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	snapshot := Snapshot{}
+	for key, value := range raw {
+		snapshot[fmt.Sprint(key)] = normalize(value)
+	}
+	return snapshot, nil
+}
+
+// normalize 将yaml解析出的map[interface{}]interface{}转换为可JSON序列化的结构
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[fmt.Sprint(key)] = normalize(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = normalize(item)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+// Diff 比较两份配置快照，返回按路径排序的变化列表
+func Diff(before, after Snapshot) []Change {
+	var changes []Change
+	for _, section := range sortedKeys(before, after) {
+		if section == "revision" {
+			// 修订号每次保存都会递增，不属于配置内容的变化
+			continue
+		}
+		diffValue(section, section, "", before[section], after[section], &changes)
+	}
+	return changes
+}
+
+// diffValue 递归比较配置值
+func diffValue(section, path, key string, before, after interface{}, changes *[]Change) {
+	if reflect.DeepEqual(before, after) {
+		return
+	}
+
+	beforeMap, beforeIsMap := before.(map[string]interface{})
+	afterMap, afterIsMap := after.(map[string]interface{})
+	if beforeIsMap && afterIsMap {
+		for _, k := range sortedKeys(beforeMap, afterMap) {
+			diffValue(section, path+"."+k, k, beforeMap[k], afterMap[k], changes)
+		}
+		return
+	}
+
+	beforeList, beforeIsList := before.([]interface{})
+	afterList, afterIsList := after.([]interface{})
+	if beforeIsList && afterIsList {
+		if diffList(section, path, beforeList, afterList, changes) {
+			return
+		}
+	}
+
+	if inHeaders(path) {
+		// 请求头的名称保留，值可能是认证令牌
+		before, after = redactValue(before), redactValue(after)
+	}
+	*changes = append(*changes, Change{
+		Section: section,
+		Path:    path,
+		Before:  redact(key, before),
+		After:   redact(key, after),
+	})
+}
+
+// listKeyFields 列表元素的标识字段，按顺序尝试
+var listKeyFields = []string{"mac", "name", "username", "ip"}
+
+// diffList 列表元素都有相同的标识字段时按标识比较，避免删除一项导致后续元素全部显示为变化；
+// 无法识别元素时返回false，由调用方整体记录
+func diffList(section, path string, before, after []interface{}, changes *[]Change) bool {
+	for _, field := range listKeyFields {
+		beforeItems, ok1 := indexList(before, field)
+		afterItems, ok2 := indexList(after, field)
+		if !ok1 || !ok2 {
+			continue
+		}
+		keys := make([]string, 0, len(beforeItems)+len(afterItems))
+		for k := range beforeItems {
+			keys = append(keys, k)
+		}
+		for k := range afterItems {
+			if _, ok := beforeItems[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			diffValue(section, fmt.Sprintf("%s[%s]", path, k), "", beforeItems[k], afterItems[k], changes)
+		}
+		return true
+	}
+	return false
+}
+
+// indexList 按标识字段索引列表，元素缺少该字段或标识重复时返回false
+func indexList(list []interface{}, field string) (map[string]interface{}, bool) {
+	result := make(map[string]interface{}, len(list))
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		id, ok := m[field]
+		if !ok || id == nil || fmt.Sprint(id) == "" {
+			return nil, false
+		}
+		key := fmt.Sprint(id)
+		if _, dup := result[key]; dup {
+			return nil, false
+		}
+		result[key] = m
+	}
+	return result, true
+}
+
+// isSensitive 密码和令牌哈希等字段不写入审计记录
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	return strings.HasSuffix(key, "_hash") || strings.Contains(key, "password") || strings.Contains(key, "secret") || key == "authorization"
+}
+
+// inHeaders 变化位置是否在请求头（如webhook的headers）之内
+func inHeaders(path string) bool {
+	return strings.Contains(strings.ToLower(path), ".headers.")
+}
+
+// redactValue 非空值替换为 [REDACTED]
+func redactValue(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	return redacted
+}
+
+// redact 替换敏感字段的值，请求头只保留名称
+func redact(key string, value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	if isSensitive(key) {
+		return redacted
+	}
+	if headers, ok := value.(map[string]interface{}); ok && strings.ToLower(key) == "headers" {
+		result := make(map[string]interface{}, len(headers))
+		for name, v := range headers {
+			result[name] = redactValue(v)
+		}
+		return result
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return redactMap(v)
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = redact("", item)
+		}
+		return result
+	}
+	return value
+}
+
+// RedactConfig 替换配置文件中的密码、令牌哈希和请求头的值，用于向非admin展示原始配置；结果不保留注释
+func RedactConfig(data []byte) ([]byte, error) {
+	var doc yaml.MapSlice
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(redactYAML("", doc))
+}
+
+// redactYAML 按原有顺序复制YAML内容并替换敏感字段，与redact的规则相同
+func redactYAML(key string, value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	if isSensitive(key) {
+		return redacted
+	}
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		headers := strings.ToLower(key) == "headers"
+		result := make(yaml.MapSlice, len(v))
+		for i, item := range v {
+			if headers {
+				result[i] = yaml.MapItem{Key: item.Key, Value: redactValue(item.Value)}
+			} else {
+				result[i] = yaml.MapItem{Key: item.Key, Value: redactYAML(fmt.Sprint(item.Key), item.Value)}
+			}
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = redactYAML("", item)
+		}
+		return result
+	}
+	return value
+}
+
+// redactMap 复制map并替换其中的敏感字段；比较使用原始值，敏感字段的变化仍会被记录
+func redactMap(m map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		result[k] = redact(k, v)
+	}
+	return result
+}
+
+// sortedKeys 合并两个map的键并排序
+func sortedKeys(a, b map[string]interface{}) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	keys := make([]string, 0, len(a)+len(b))
+	for _, m := range []map[string]interface{}{a, b} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,97 @@
+package audit
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDiffRedactsSensitive(t *testing.T) {
+	webhook := func(name, key string) map[string]interface{} {
+		return map[string]interface{}{
+			"name":    name,
+			"url":     "https://hooks.example.com/dhcp",
+			"headers": map[string]interface{}{"X-Api-Key": key, "Content-Type": "application/json"},
+		}
+	}
+	before := Snapshot{
+		"auth": map[string]interface{}{
+			"users": []interface{}{map[string]interface{}{"username": "admin", "password_hash": "$2a$10$old", "role": "admin"}},
+		},
+		"events": map[string]interface{}{"webhooks": []interface{}{webhook("ops", "old-key")}},
+	}
+	after := Snapshot{
+		"auth": map[string]interface{}{
+			"users": []interface{}{map[string]interface{}{"username": "admin", "password_hash": "$2a$10$new", "role": "admin"}},
+		},
+		"events": map[string]interface{}{"webhooks": []interface{}{webhook("ops", "new-key"), webhook("backup", "second-key")}},
+	}
+
+	changes := Diff(before, after)
+	paths := make(map[string]Change)
+	for _, change := range changes {
+		paths[change.Path] = change
+	}
+
+	if change, ok := paths["auth.users[admin].password_hash"]; !ok || change.Before != redacted || change.After != redacted {
+		t.Errorf("密码哈希的变化应记录且显示为 %s: %+v", redacted, change)
+	}
+	if change, ok := paths["events.webhooks[ops].headers.X-Api-Key"]; !ok || change.Before != redacted || change.After != redacted {
+		t.Errorf("请求头的值应显示为 %s: %+v", redacted, change)
+	}
+	added, ok := paths["events.webhooks[backup]"]
+	if !ok {
+		t.Fatalf("应记录新增的webhook: %+v", changes)
+	}
+	headers := added.After.(map[string]interface{})["headers"].(map[string]interface{})
+	if headers["X-Api-Key"] != redacted || headers["Content-Type"] != redacted {
+		t.Errorf("新增webhook的请求头值应显示为 %s, 名称保留: %+v", redacted, headers)
+	}
+	if added.After.(map[string]interface{})["url"] != "https://hooks.example.com/dhcp" {
+		t.Errorf("非敏感字段应保留: %+v", added.After)
+	}
+
+	data, _ := json.Marshal(changes)
+	for _, secret := range []string{"old-key", "new-key", "second-key", "$2a$10$"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("审计记录不应包含 %s: %s", secret, data)
+		}
+	}
+}
+
+func TestRedactConfig(t *testing.T) {
+	data := []byte(`server:
+  interface: eth0
+auth:
+  users:
+  - username: admin
+    password_hash: $2a$10$secret
+    role: admin
+  tokens:
+  - name: ci
+    token_hash: abcdef
+events:
+  webhooks:
+  - url: https://hooks.example.com/dhcp
+    headers:
+      X-Api-Key: key-value
+`)
+	out, err := RedactConfig(data)
+	if err != nil {
+		t.Fatalf("处理配置失败: %v", err)
+	}
+	content := string(out)
+	for _, secret := range []string{"$2a$10$secret", "abcdef", "key-value"} {
+		if strings.Contains(content, secret) {
+			t.Errorf("不应包含 %s:\n%s", secret, content)
+		}
+	}
+	for _, kept := range []string{"interface: eth0", "username: admin", "X-Api-Key: '[REDACTED]'", "url: https://hooks.example.com/dhcp"} {
+		if !strings.Contains(content, kept) {
+			t.Errorf("应保留 %s:\n%s", kept, content)
+		}
+	}
+	if strings.Index(content, "server:") > strings.Index(content, "auth:") {
+		t.Errorf("应保持原有顺序:\n%s", content)
+	}
+}
@@ -0,0 +1,236 @@
+// Package client 管理接口的Go客户端，请求和响应类型来自apitypes包，不依赖服务器实现
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"time"
+
+	"dhcp-server/apitypes"
+)
+
+// 默认重试设置
+const (
+	DefaultMaxRetries = 2
+	DefaultRetryDelay = 200 * time.Millisecond
+)
+
+// Client 管理接口客户端，可以并发使用
+type Client struct {
+	BaseURL    string        // 服务器地址，如 https://dhcp.example.com:8080
+	Token      string        // Bearer令牌，为空时使用Login获得的会话Cookie
+	HTTPClient *http.Client  // 发送请求使用的HTTP客户端
+	MaxRetries int           // 幂等请求（GET、PUT、DELETE）遇到网络错误或502/503/504时的重试次数
+	RetryDelay time.Duration // 首次重试前的等待时间，之后每次加倍
+}
+
+// New 创建客户端，token为空时需要先调用Login。
+// 修改配置的请求需要通过WithIfMatch携带读取资源时记录的修订号（ETag），
+// 配置已被他人修改时返回412错误，重新读取后再修改即可
+func New(baseURL, token string) *Client {
+	jar, _ := cookiejar.New(nil)
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		Token:      token,
+		HTTPClient: &http.Client{Jar: jar},
+		MaxRetries: DefaultMaxRetries,
+		RetryDelay: DefaultRetryDelay,
+	}
+}
+
+// Error 服务器返回的错误，兼容旧接口的 {"error": "..."} 和 /api/v1 的错误响应格式
+type Error struct {
+	StatusCode int
+	Code       string // 仅 /api/v1 接口提供，如 not_found
+	Message    string
+}
+
+func (e *Error) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("HTTP %d %s: %s", e.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Message)
+}
+
+// IsStatus 判断错误是否为指定状态码的服务器错误
+func IsStatus(err error, status int) bool {
+	e, ok := err.(*Error)
+	return ok && e.StatusCode == status
+}
+
+// parseError 解析错误响应体
+func parseError(status int, body []byte) *Error {
+	e := &Error{StatusCode: status}
+
+	var v1 apitypes.ErrorResponse
+	if json.Unmarshal(body, &v1) == nil && v1.Error.Message != "" {
+		e.Code, e.Message = v1.Error.Code, v1.Error.Message
+		return e
+	}
+	var legacy map[string]interface{}
+	if json.Unmarshal(body, &legacy) == nil {
+		if message, ok := legacy["error"].(string); ok {
+			e.Message = message
+			return e
+		}
+	}
+	e.Message = strings.TrimSpace(string(body))
+	if e.Message == "" {
+		e.Message = http.StatusText(status)
+	}
+	return e
+}
+
+type ifMatchKey struct{}
+
+type etagKey struct{}
+
+// WithIfMatch 让修改请求携带If-Match，etag通常是修改前读取该资源时由RecordETag记录的值，
+// "*"表示跳过修订号检查。未设置时修改请求不携带If-Match，服务器返回428
+func WithIfMatch(ctx context.Context, etag string) context.Context {
+	return context.WithValue(ctx, ifMatchKey{}, etag)
+}
+
+// RecordETag 请求成功时把响应中的配置修订号写入etag，失败的响应（包括412）不会修改etag
+func RecordETag(ctx context.Context, etag *string) context.Context {
+	return context.WithValue(ctx, etagKey{}, etag)
+}
+
+// idempotent 可以安全重试的请求方法
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryable 是否值得重试的状态码
+func retryable(status int) bool {
+	switch status {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// send 发送请求并返回响应，幂等请求失败时按退避间隔重试；调用方负责关闭响应体
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body interface{}, accept string) (*http.Response, error) {
+	var payload []byte
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("编码请求失败: %v", err)
+		}
+		payload = data
+	}
+
+	target := c.BaseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	attempts := 1
+	if idempotent(method) && c.MaxRetries > 0 {
+		attempts += c.MaxRetries
+	}
+	delay := c.RetryDelay
+	ifMatch, _ := ctx.Value(ifMatchKey{}).(string)
+	record, _ := ctx.Value(etagKey{}).(*string)
+
+	for attempt := 1; ; attempt++ {
+		var reader io.Reader
+		if payload != nil {
+			reader = bytes.NewReader(payload)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, target, reader)
+		if err != nil {
+			return nil, err
+		}
+		if payload != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.Header.Set("Accept", accept)
+		if c.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.Token)
+		}
+		if ifMatch != "" && method != http.MethodGet && method != http.MethodHead {
+			req.Header.Set("If-Match", ifMatch)
+		}
+
+		resp, err := c.HTTPClient.Do(req)
+		if err == nil && record != nil && resp.StatusCode/100 == 2 && resp.Header.Get("ETag") != "" {
+			*record = resp.Header.Get("ETag")
+		}
+		if err == nil && (attempt >= attempts || !retryable(resp.StatusCode)) {
+			return resp, nil
+		}
+		if err != nil && (attempt >= attempts || ctx.Err() != nil) {
+			return nil, err
+		}
+		if resp != nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// do 发送JSON请求，成功时把响应解码到out（out为nil时忽略响应体）
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	resp, err := c.send(ctx, method, path, query, body, "application/json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return parseError(resp.StatusCode, data)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("解析响应失败: %v", err)
+	}
+	return nil
+}
+
+// doText 发送请求并返回文本响应体
+func (c *Client) doText(ctx context.Context, method, path string) (string, error) {
+	resp, err := c.send(ctx, method, path, nil, nil, "text/plain")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", parseError(resp.StatusCode, data)
+	}
+	return string(data), nil
+}
+
+// Object 未定义专门类型的JSON对象响应
+type Object map[string]interface{}
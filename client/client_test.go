@@ -0,0 +1,203 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"dhcp-server/api"
+	"dhcp-server/apitypes"
+	"dhcp-server/client"
+	"dhcp-server/config"
+	"dhcp-server/dhcp"
+)
+
+func TestMain(m *testing.M) {
+	// 抑制测试期间的日志输出
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// newTestConfig 客户端测试使用的最小配置
+func newTestConfig() *config.Config {
+	return &config.Config{
+		Server: config.ServerConfig{
+			Interface: "eth0",
+			Port:      67,
+			LeaseTime: 24 * time.Hour,
+			APIPort:   8080,
+		},
+		Network: config.NetworkConfig{
+			Subnet:           "192.168.1.0/24",
+			Netmask:          "255.255.255.0",
+			StartIP:          "192.168.1.100",
+			EndIP:            "192.168.1.200",
+			DNSServers:       []string{"8.8.8.8"},
+			DefaultGateway:   "192.168.1.1",
+			LeaseTime:        86400,
+			BroadcastAddress: "192.168.1.255",
+		},
+		Gateways: []config.Gateway{
+			{Name: "main_gateway", IP: "192.168.1.1", IsDefault: true},
+		},
+	}
+}
+
+func TestAPIClient(t *testing.T) {
+	cfg := newTestConfig()
+	server, err := dhcp.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("创建DHCP服务器失败: %v", err)
+	}
+
+	token, hash, _ := api.GenerateToken()
+	cfg.Auth.Tokens = []config.APIToken{{Name: "tool", TokenHash: hash, Role: config.RoleAdmin}}
+	passwordHash, _ := api.HashPassword("secret")
+	cfg.Auth.Users = []config.AuthUser{{Username: "alice", PasswordHash: passwordHash, Role: config.RoleViewer}}
+
+	configPath := t.TempDir() + "/config.yaml"
+	if err := cfg.SaveConfig(configPath); err != nil {
+		t.Fatalf("保存配置失败: %v", err)
+	}
+	if _, err := server.GetPool().RequestIP("aa:bb:cc:00:55:66", nil, "client-test"); err != nil {
+		t.Fatalf("分配IP失败: %v", err)
+	}
+	apiServer := api.NewAPIServer(server.GetPool(), server.GetChecker(), cfg, configPath, server, nil, 8080)
+
+	// 带认证的完整处理器
+	ts := httptest.NewServer(apiServer.Handler())
+	defer ts.Close()
+	ctx := context.Background()
+	c := client.New(ts.URL, token)
+
+	if health, err := c.Health(ctx); err != nil || health.Status != "healthy" {
+		t.Fatalf("健康检查失败: %v", err)
+	}
+	if me, err := c.Me(ctx); err != nil || me.Token != "tool" {
+		t.Fatalf("令牌身份不正确: %+v %v", me, err)
+	}
+	if _, err := client.New(ts.URL, "").Me(ctx); !client.IsStatus(err, http.StatusUnauthorized) {
+		t.Errorf("未认证请求应返回401, 实际为 %v", err)
+	}
+
+	session := client.New(ts.URL, "")
+	if _, err := session.Login(ctx, "alice", "wrong"); !client.IsStatus(err, http.StatusUnauthorized) {
+		t.Errorf("错误密码应返回401, 实际为 %v", err)
+	}
+	if _, err := session.Login(ctx, "alice", "secret"); err != nil {
+		t.Fatalf("登录失败: %v", err)
+	}
+	if me, err := session.Me(ctx); err != nil || me.Username != "alice" {
+		t.Errorf("会话身份不正确: %+v %v", me, err)
+	}
+
+	leases, err := c.Leases(ctx)
+	if err != nil || len(leases) != 1 || leases[0].MAC != "aa:bb:cc:00:55:66" {
+		t.Fatalf("租约列表不正确: %+v %v", leases, err)
+	}
+	page, err := c.V1().Leases(ctx, client.ListOptions{Limit: 10})
+	if err != nil || page.Total != 1 {
+		t.Fatalf("v1租约列表不正确: %+v %v", page, err)
+	}
+
+	// 修改前先读取并记录ETag，修改时通过If-Match携带
+	var etag string
+	if _, err := c.Bindings(client.RecordETag(ctx, &etag)); err != nil || etag == "" {
+		t.Fatalf("读取绑定失败或缺少ETag: %q %v", etag, err)
+	}
+	ifMatch := func() context.Context {
+		return client.RecordETag(client.WithIfMatch(ctx, etag), &etag)
+	}
+
+	binding := apitypes.BindingInfo{Alias: "printer", MAC: "aa:bb:cc:00:77:88", IP: "192.168.1.150", Gateway: "main_gateway"}
+	if _, err := c.AddBinding(ctx, binding); !client.IsStatus(err, http.StatusPreconditionRequired) {
+		t.Errorf("未指定If-Match的修改应返回428, 实际为 %v", err)
+	}
+	stale := etag
+	if _, err := c.AddBinding(ifMatch(), binding); err != nil || etag == stale {
+		t.Fatalf("添加绑定失败或未记录新的ETag: %q %v", etag, err)
+	}
+	current := etag
+	if _, err := c.AddBinding(client.RecordETag(client.WithIfMatch(ctx, stale), &etag), apitypes.BindingInfo{Alias: "scanner", MAC: "aa:bb:cc:00:77:99", IP: "192.168.1.151"}); !client.IsStatus(err, http.StatusPreconditionFailed) {
+		t.Errorf("使用过期ETag修改应返回412, 实际为 %v", err)
+	}
+	if etag != current {
+		t.Errorf("412响应不应更新记录的ETag: %q", etag)
+	}
+	binding.Hostname = "printer-1"
+	if updated, err := c.UpdateBinding(ifMatch(), "printer", binding); err != nil || updated.Hostname != "printer-1" {
+		t.Fatalf("更新绑定失败: %+v %v", updated, err)
+	}
+	if got, err := c.V1().Binding(ctx, "printer"); err != nil || got.Hostname != "printer-1" {
+		t.Fatalf("v1查询绑定失败: %+v %v", got, err)
+	}
+	if err := c.DeleteBinding(ifMatch(), "printer"); err != nil {
+		t.Fatalf("删除绑定失败: %v", err)
+	}
+	_, err = c.V1().Binding(ctx, "printer")
+	if e, ok := err.(*client.Error); !ok || e.StatusCode != http.StatusNotFound || e.Code != apitypes.ErrCodeNotFound {
+		t.Errorf("已删除的绑定应返回not_found, 实际为 %v", err)
+	}
+
+	// 由RegisterRoutes构建的处理器，前两次请求返回503
+	mux := http.NewServeMux()
+	apiServer.RegisterRoutes(mux)
+	var attempts int32
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	defer flaky.Close()
+
+	fc := client.New(flaky.URL, "")
+	fc.RetryDelay = time.Millisecond
+	if _, err := fc.Stats(ctx); err != nil || atomic.LoadInt32(&attempts) != 3 {
+		t.Errorf("GET请求应重试后成功: 次数=%d, 错误=%v", atomic.LoadInt32(&attempts), err)
+	}
+	atomic.StoreInt32(&attempts, 0)
+	if _, err := fc.AddBinding(ctx, binding); !client.IsStatus(err, http.StatusServiceUnavailable) || atomic.LoadInt32(&attempts) != 1 {
+		t.Errorf("POST请求不应重试: 次数=%d, 错误=%v", atomic.LoadInt32(&attempts), err)
+	}
+
+	// 上下文取消时请求立即返回
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer slow.Close()
+	timeoutCtx, cancelTimeout := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancelTimeout()
+	if _, err := client.New(slow.URL, "").Health(timeoutCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("超时应返回context.DeadlineExceeded, 实际为 %v", err)
+	}
+
+	// SSE日志流
+	plain := httptest.NewServer(mux)
+	defer plain.Close()
+	streamCtx, cancelStream := context.WithCancel(ctx)
+	stream, err := client.New(plain.URL, "").StreamLogs(streamCtx)
+	if err != nil {
+		t.Fatalf("连接日志流失败: %v", err)
+	}
+	defer stream.Close()
+	if line, err := stream.Next(); err != nil || line != "Connected to log stream" {
+		t.Fatalf("日志流首条消息不正确: %q %v", line, err)
+	}
+	api.GetLogBroadcast() <- "client test line"
+	if line, err := stream.Next(); err != nil || line != "client test line" {
+		t.Errorf("未收到广播的日志: %q %v", line, err)
+	}
+	cancelStream()
+	if _, err := stream.Next(); err == nil {
+		t.Error("取消后日志流应返回错误")
+	}
+}
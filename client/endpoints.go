@@ -0,0 +1,761 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"dhcp-server/apitypes"
+	"dhcp-server/config"
+)
+
+// query 由名称、值交替给出的参数构造查询串，忽略空值
+func query(pairs ...string) url.Values {
+	values := url.Values{}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] != "" {
+			values.Set(pairs[i], pairs[i+1])
+		}
+	}
+	return values
+}
+
+// positive 正整数转为查询参数，其余值表示使用服务器默认值
+func positive(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// Health 健康检查，无需认证
+func (c *Client) Health(ctx context.Context) (*apitypes.HealthResponse, error) {
+	var resp apitypes.HealthResponse
+	if err := c.do(ctx, http.MethodGet, "/api/health", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Metrics Prometheus文本格式的监控指标
+func (c *Client) Metrics(ctx context.Context) (string, error) {
+	return c.doText(ctx, http.MethodGet, "/metrics")
+}
+
+// OpenAPI 服务器的OpenAPI文档
+func (c *Client) OpenAPI(ctx context.Context) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodGet, "/api/openapi.json", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// RestartServer 重启服务器
+func (c *Client) RestartServer(ctx context.Context) (*apitypes.MessageResponse, error) {
+	var resp apitypes.MessageResponse
+	if err := c.do(ctx, http.MethodPost, "/api/server/restart", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Login 用户名密码登录，会话Cookie保存在HTTPClient的Cookie Jar中
+func (c *Client) Login(ctx context.Context, username, password string) (*apitypes.LoginResponse, error) {
+	var resp apitypes.LoginResponse
+	if err := c.do(ctx, http.MethodPost, "/api/auth/login", nil, apitypes.LoginRequest{Username: username, Password: password}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Logout 注销当前会话
+func (c *Client) Logout(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, "/api/auth/logout", nil, nil, nil)
+}
+
+// Me 当前认证身份
+func (c *Client) Me(ctx context.Context) (*apitypes.Principal, error) {
+	var resp apitypes.Principal
+	if err := c.do(ctx, http.MethodGet, "/api/auth/me", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ChangePassword 修改当前登录用户的密码
+func (c *Client) ChangePassword(ctx context.Context, oldPassword, newPassword string) error {
+	req := apitypes.ChangePasswordRequest{OldPassword: oldPassword, NewPassword: newPassword}
+	return c.do(ctx, http.MethodPost, "/api/auth/password", nil, req, nil)
+}
+
+// Tokens API令牌列表
+func (c *Client) Tokens(ctx context.Context) ([]apitypes.TokenInfo, error) {
+	var resp []apitypes.TokenInfo
+	if err := c.do(ctx, http.MethodGet, "/api/auth/tokens", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// CreateToken 创建API令牌，令牌明文只在响应中出现一次
+func (c *Client) CreateToken(ctx context.Context, req apitypes.CreateTokenRequest) (*apitypes.CreateTokenResponse, error) {
+	var resp apitypes.CreateTokenResponse
+	if err := c.do(ctx, http.MethodPost, "/api/auth/tokens", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RevokeToken 吊销API令牌
+func (c *Client) RevokeToken(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "/api/auth/tokens", query("name", name), nil, nil)
+}
+
+// AuditFilter 审计日志查询条件，零值字段不过滤
+type AuditFilter struct {
+	Actor    string
+	Method   string
+	Endpoint string // 路径前缀
+	Section  string // 涉及的配置段
+	Result   string // success 或 failure
+	Since    time.Time
+	Until    time.Time
+	Limit    int
+}
+
+// Audit 查询审计日志
+func (c *Client) Audit(ctx context.Context, filter AuditFilter) (*apitypes.AuditResponse, error) {
+	q := query("actor", filter.Actor, "method", filter.Method, "endpoint", filter.Endpoint,
+		"section", filter.Section, "result", filter.Result, "limit", positive(filter.Limit))
+	if !filter.Since.IsZero() {
+		q.Set("since", filter.Since.Format(time.RFC3339))
+	}
+	if !filter.Until.IsZero() {
+		q.Set("until", filter.Until.Format(time.RFC3339))
+	}
+	var resp apitypes.AuditResponse
+	if err := c.do(ctx, http.MethodGet, "/api/audit", q, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Leases 所有租约
+func (c *Client) Leases(ctx context.Context) ([]apitypes.LeaseInfo, error) {
+	var resp []apitypes.LeaseInfo
+	if err := c.do(ctx, http.MethodGet, "/api/leases", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ActiveLeases 活跃租约
+func (c *Client) ActiveLeases(ctx context.Context) ([]apitypes.LeaseInfo, error) {
+	var resp []apitypes.LeaseInfo
+	if err := c.do(ctx, http.MethodGet, "/api/leases/active", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// History 租约历史，limit为0时使用服务器默认值，mac和ip为空时不过滤
+func (c *Client) History(ctx context.Context, limit int, mac, ip string) ([]apitypes.HistoryRecord, error) {
+	var resp []apitypes.HistoryRecord
+	if err := c.do(ctx, http.MethodGet, "/api/leases/history", query("limit", positive(limit), "mac", mac, "ip", ip), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ConvertToStatic 将动态租约转换为静态绑定
+func (c *Client) ConvertToStatic(ctx context.Context, req apitypes.ConvertToStaticRequest) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodPost, "/api/leases/convert-to-static", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// AvailableIPs 可用于静态绑定的IP地址
+func (c *Client) AvailableIPs(ctx context.Context) ([]string, error) {
+	var resp []string
+	if err := c.do(ctx, http.MethodGet, "/api/available-ips", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Stats 地址池和网关统计
+func (c *Client) Stats(ctx context.Context) (*apitypes.StatsResponse, error) {
+	var resp apitypes.StatsResponse
+	if err := c.do(ctx, http.MethodGet, "/api/stats", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Gateways 网关及健康状态，以网关名称为键
+func (c *Client) Gateways(ctx context.Context) (map[string]apitypes.GatewayStatus, error) {
+	var resp map[string]apitypes.GatewayStatus
+	if err := c.do(ctx, http.MethodGet, "/api/gateways", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// AddGateway 添加网关
+func (c *Client) AddGateway(ctx context.Context, req apitypes.GatewayRequest) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodPost, "/api/gateways", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// UpdateGateway 更新名称为req.OldName的网关
+func (c *Client) UpdateGateway(ctx context.Context, req apitypes.GatewayRequest) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodPut, "/api/gateways", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// DeleteGateway 删除网关
+func (c *Client) DeleteGateway(ctx context.Context, name string) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodDelete, "/api/gateways", nil, apitypes.GatewayDeleteRequest{Name: name}, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Devices 设备列表，mac和deviceType为空时返回全部
+func (c *Client) Devices(ctx context.Context, mac, deviceType string) ([]config.DeviceInfo, error) {
+	var resp []config.DeviceInfo
+	if err := c.do(ctx, http.MethodGet, "/api/devices", query("mac", mac, "type", deviceType), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// AddDevice 添加设备
+func (c *Client) AddDevice(ctx context.Context, device config.DeviceInfo) (*config.DeviceInfo, error) {
+	var resp config.DeviceInfo
+	if err := c.do(ctx, http.MethodPost, "/api/devices", nil, device, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpdateDevice 按MAC更新设备
+func (c *Client) UpdateDevice(ctx context.Context, device config.DeviceInfo) (*config.DeviceInfo, error) {
+	var resp config.DeviceInfo
+	if err := c.do(ctx, http.MethodPut, "/api/devices", nil, device, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteDevice 删除设备及其静态绑定
+func (c *Client) DeleteDevice(ctx context.Context, mac string) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodDelete, "/api/devices", nil, apitypes.DeviceDeleteRequest{MAC: mac}, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// DiscoverDevices 从租约中发现设备
+func (c *Client) DiscoverDevices(ctx context.Context) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodPost, "/api/devices/discover", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// BatchAddDevices 批量添加设备
+func (c *Client) BatchAddDevices(ctx context.Context, devices []config.DeviceInfo) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodPost, "/api/devices/batch", nil, apitypes.BatchDevicesRequest{Devices: devices}, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// SetDeviceGateway 设置设备使用的网关
+func (c *Client) SetDeviceGateway(ctx context.Context, mac, gateway string) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodPut, "/api/devices/gateway", nil, apitypes.DeviceGatewayRequest{MAC: mac, Gateway: gateway}, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// DevicePolicy 设备生效的DHCP策略
+func (c *Client) DevicePolicy(ctx context.Context, mac string) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodGet, "/api/devices/policy", query("mac", mac), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Groups 设备组及成员
+func (c *Client) Groups(ctx context.Context) ([]apitypes.GroupInfo, error) {
+	var resp []apitypes.GroupInfo
+	if err := c.do(ctx, http.MethodGet, "/api/groups", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// AddGroup 添加设备组
+func (c *Client) AddGroup(ctx context.Context, group config.DeviceGroup) (*config.DeviceGroup, error) {
+	var resp config.DeviceGroup
+	if err := c.do(ctx, http.MethodPost, "/api/groups", nil, group, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpdateGroup 按名称更新设备组
+func (c *Client) UpdateGroup(ctx context.Context, group config.DeviceGroup) (*config.DeviceGroup, error) {
+	var resp config.DeviceGroup
+	if err := c.do(ctx, http.MethodPut, "/api/groups", nil, group, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteGroup 删除设备组
+func (c *Client) DeleteGroup(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "/api/groups", query("name", name), nil, nil)
+}
+
+// Schedules 访问时间表
+func (c *Client) Schedules(ctx context.Context) ([]config.AccessSchedule, error) {
+	var resp []config.AccessSchedule
+	if err := c.do(ctx, http.MethodGet, "/api/schedules", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// AddSchedule 添加访问时间表
+func (c *Client) AddSchedule(ctx context.Context, schedule config.AccessSchedule) (*config.AccessSchedule, error) {
+	var resp config.AccessSchedule
+	if err := c.do(ctx, http.MethodPost, "/api/schedules", nil, schedule, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpdateSchedule 按名称更新访问时间表
+func (c *Client) UpdateSchedule(ctx context.Context, schedule config.AccessSchedule) (*config.AccessSchedule, error) {
+	var resp config.AccessSchedule
+	if err := c.do(ctx, http.MethodPut, "/api/schedules", nil, schedule, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteSchedule 删除访问时间表
+func (c *Client) DeleteSchedule(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "/api/schedules", query("name", name), nil, nil)
+}
+
+// LookupOUI 查询MAC地址厂商，mac为空时返回数据库统计
+func (c *Client) LookupOUI(ctx context.Context, mac string) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodGet, "/api/oui", query("mac", mac), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ReloadOUI 重新加载厂商数据库
+func (c *Client) ReloadOUI(ctx context.Context) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodPost, "/api/oui/reload", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Fingerprints DHCP指纹，unmatched为true时只返回未识别的指纹
+func (c *Client) Fingerprints(ctx context.Context, unmatched bool) (Object, error) {
+	q := url.Values{}
+	if unmatched {
+		q.Set("unmatched", "true")
+	}
+	var resp Object
+	if err := c.do(ctx, http.MethodGet, "/api/fingerprints", q, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Presence 设备在线记录，days为0时使用服务器默认值
+func (c *Client) Presence(ctx context.Context, mac string, days int) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodGet, "/api/presence", query("mac", mac, "days", positive(days)), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ScannerStatus 扫描器状态
+func (c *Client) ScannerStatus(ctx context.Context) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodGet, "/api/scanner", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ScannerResults 扫描结果
+func (c *Client) ScannerResults(ctx context.Context) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodGet, "/api/scanner/results", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ScannerLog 扫描日志
+func (c *Client) ScannerLog(ctx context.Context) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodGet, "/api/scanner/log", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// StartScanner 启动扫描器
+func (c *Client) StartScanner(ctx context.Context) (*apitypes.MessageResponse, error) {
+	var resp apitypes.MessageResponse
+	if err := c.do(ctx, http.MethodPost, "/api/scanner/start", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// StopScanner 停止扫描器
+func (c *Client) StopScanner(ctx context.Context) (*apitypes.MessageResponse, error) {
+	var resp apitypes.MessageResponse
+	if err := c.do(ctx, http.MethodPost, "/api/scanner/stop", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ScannerConfig 扫描器配置
+func (c *Client) ScannerConfig(ctx context.Context) (*config.ScannerConfig, error) {
+	var resp config.ScannerConfig
+	if err := c.do(ctx, http.MethodGet, "/api/scanner/config", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpdateScannerConfig 更新扫描器配置
+func (c *Client) UpdateScannerConfig(ctx context.Context, cfg config.ScannerConfig) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodPost, "/api/scanner/config", nil, cfg, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ScanProfiles 扫描方案
+func (c *Client) ScanProfiles(ctx context.Context) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodGet, "/api/scanner/profiles", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ScanJobs 扫描任务列表
+func (c *Client) ScanJobs(ctx context.Context) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodGet, "/api/scanner/jobs", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ScanJob 单个扫描任务及结果
+func (c *Client) ScanJob(ctx context.Context, id string) (*apitypes.ScanJob, error) {
+	var resp apitypes.ScanJob
+	if err := c.do(ctx, http.MethodGet, "/api/scanner/jobs", query("id", id), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SubmitScan 提交扫描任务
+func (c *Client) SubmitScan(ctx context.Context, req apitypes.ScanJobRequest) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodPost, "/api/scanner/jobs", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// CancelScan 取消扫描任务
+func (c *Client) CancelScan(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/api/scanner/jobs", query("id", id), nil, nil)
+}
+
+// Bindings 静态绑定列表
+func (c *Client) Bindings(ctx context.Context) ([]apitypes.BindingInfo, error) {
+	var resp []apitypes.BindingInfo
+	if err := c.do(ctx, http.MethodGet, "/api/bindings", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// AddBinding 添加静态绑定
+func (c *Client) AddBinding(ctx context.Context, binding apitypes.BindingInfo) (*apitypes.BindingInfo, error) {
+	var resp apitypes.BindingInfo
+	if err := c.do(ctx, http.MethodPost, "/api/bindings", nil, binding, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpdateBinding 更新别名为oldAlias的静态绑定
+func (c *Client) UpdateBinding(ctx context.Context, oldAlias string, binding apitypes.BindingInfo) (*apitypes.BindingInfo, error) {
+	var resp apitypes.BindingInfo
+	req := apitypes.BindingUpdateRequest{OldAlias: oldAlias, BindingInfo: binding}
+	if err := c.do(ctx, http.MethodPut, "/api/bindings", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteBinding 删除静态绑定
+func (c *Client) DeleteBinding(ctx context.Context, alias string) error {
+	return c.do(ctx, http.MethodDelete, "/api/bindings", nil, apitypes.BindingDeleteRequest{Alias: alias}, nil)
+}
+
+// Config 解析后的配置
+func (c *Client) Config(ctx context.Context) (*apitypes.ConfigResponse, error) {
+	var resp apitypes.ConfigResponse
+	if err := c.do(ctx, http.MethodGet, "/api/config", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RawConfig 配置文件原文
+func (c *Client) RawConfig(ctx context.Context) (*apitypes.RawConfigResponse, error) {
+	var resp apitypes.RawConfigResponse
+	if err := c.do(ctx, http.MethodGet, "/api/config", query("raw", "true"), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SaveConfig 保存完整的YAML配置，autoReload为true时立即生效
+func (c *Client) SaveConfig(ctx context.Context, content string, autoReload bool) (Object, error) {
+	var resp Object
+	req := apitypes.ConfigSaveRequest{Content: content, AutoReload: autoReload}
+	if err := c.do(ctx, http.MethodPost, "/api/config", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ValidateConfig 验证YAML配置
+func (c *Client) ValidateConfig(ctx context.Context, content string) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodPost, "/api/config/validate", nil, apitypes.ConfigValidateRequest{Content: content}, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ConfigBackups 配置备份列表
+func (c *Client) ConfigBackups(ctx context.Context) ([]config.BackupInfo, error) {
+	var resp []config.BackupInfo
+	if err := c.do(ctx, http.MethodGet, "/api/config/backups", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// RestoreConfig 从备份恢复配置
+func (c *Client) RestoreConfig(ctx context.Context, filename string, autoReload bool) (Object, error) {
+	var resp Object
+	req := apitypes.ConfigRestoreRequest{Filename: filename, AutoReload: autoReload}
+	if err := c.do(ctx, http.MethodPost, "/api/config/restore", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ReloadConfig 重新加载配置文件
+func (c *Client) ReloadConfig(ctx context.Context) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodPost, "/api/config/reload", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ServerConfig 服务器配置
+func (c *Client) ServerConfig(ctx context.Context) (*config.ServerConfig, error) {
+	var resp config.ServerConfig
+	if err := c.do(ctx, http.MethodGet, "/api/config/server", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpdateServerConfig 更新服务器配置
+func (c *Client) UpdateServerConfig(ctx context.Context, cfg config.ServerConfig) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodPost, "/api/config/server", nil, cfg, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// NetworkConfig 网络配置
+func (c *Client) NetworkConfig(ctx context.Context) (*config.NetworkConfig, error) {
+	var resp config.NetworkConfig
+	if err := c.do(ctx, http.MethodGet, "/api/config/network", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpdateNetworkConfig 更新网络配置
+func (c *Client) UpdateNetworkConfig(ctx context.Context, cfg config.NetworkConfig) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodPost, "/api/config/network", nil, cfg, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// HealthCheckConfig 网关健康检查配置
+func (c *Client) HealthCheckConfig(ctx context.Context) (*config.HealthConfig, error) {
+	var resp config.HealthConfig
+	if err := c.do(ctx, http.MethodGet, "/api/config/health-check", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpdateHealthCheckConfig 更新网关健康检查配置
+func (c *Client) UpdateHealthCheckConfig(ctx context.Context, cfg config.HealthConfig) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodPost, "/api/config/health-check", nil, cfg, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Logs 最近的日志，limit为0时使用服务器默认值
+func (c *Client) Logs(ctx context.Context, limit int) (*apitypes.LogsResponse, error) {
+	var resp apitypes.LogsResponse
+	if err := c.do(ctx, http.MethodGet, "/api/logs", query("limit", positive(limit)), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Events 最近的事件，eventType为空时返回全部类型
+func (c *Client) Events(ctx context.Context, limit int, eventType string) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodGet, "/api/events", query("limit", positive(limit), "type", eventType), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// RogueServers 检测到的非法DHCP服务器
+func (c *Client) RogueServers(ctx context.Context) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodGet, "/api/security/rogue-servers", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ClearRogueServer 清除一条非法DHCP服务器记录
+func (c *Client) ClearRogueServer(ctx context.Context, serverID string) error {
+	return c.do(ctx, http.MethodDelete, "/api/security/rogue-servers", query("server_id", serverID), nil, nil)
+}
+
+// AccessPolicy MAC访问控制策略
+func (c *Client) AccessPolicy(ctx context.Context) (*config.AccessConfig, error) {
+	var resp config.AccessConfig
+	if err := c.do(ctx, http.MethodGet, "/api/security/access", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpdateAccessPolicy 替换MAC访问控制策略
+func (c *Client) UpdateAccessPolicy(ctx context.Context, access config.AccessConfig) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodPut, "/api/security/access", nil, access, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// AddAccessRule 向允许（allow）或拒绝（deny）列表添加规则
+func (c *Client) AddAccessRule(ctx context.Context, list, mac string) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodPost, "/api/security/access/list", nil, apitypes.AccessListRequest{List: list, MAC: mac}, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// RemoveAccessRule 从允许（allow）或拒绝（deny）列表删除规则
+func (c *Client) RemoveAccessRule(ctx context.Context, list, mac string) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodDelete, "/api/security/access/list", query("list", list, "mac", mac), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Quarantine 隔离配置及隔离中的租约
+func (c *Client) Quarantine(ctx context.Context) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodGet, "/api/security/quarantine", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// UpdateQuarantine 更新隔离配置
+func (c *Client) UpdateQuarantine(ctx context.Context, quarantine config.QuarantineConfig) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodPut, "/api/security/quarantine", nil, quarantine, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// FloodProtection 防洪保护状态
+func (c *Client) FloodProtection(ctx context.Context) (Object, error) {
+	var resp Object
+	if err := c.do(ctx, http.MethodGet, "/api/security/flood", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// LogStream 实时日志流（Server-Sent Events），不再使用时需调用Close
+type LogStream struct {
+	body   io.ReadCloser
+	reader *bufio.Reader
+}
+
+// StreamLogs 连接实时日志流，ctx取消时连接断开，Next返回错误
+func (c *Client) StreamLogs(ctx context.Context) (*LogStream, error) {
+	resp, err := c.send(ctx, http.MethodGet, "/api/logs/stream", nil, nil, "text/event-stream")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		data, _ := ioutil.ReadAll(resp.Body)
+		return nil, parseError(resp.StatusCode, data)
+	}
+	return &LogStream{body: resp.Body, reader: bufio.NewReader(resp.Body)}, nil
+}
+
+// Next 阻塞读取下一条消息，多行data合并为一条；连接关闭时返回io.EOF
+func (s *LogStream) Next() (string, error) {
+	var data []string
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && len(data) > 0 {
+				return strings.Join(data, "\n"), nil
+			}
+			return "", err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		switch {
+		case line == "":
+			// 空行结束一条消息
+			if len(data) > 0 {
+				return strings.Join(data, "\n"), nil
+			}
+		case strings.HasPrefix(line, ":"):
+			// 注释行
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+}
+
+// Close 断开日志流
+func (s *LogStream) Close() error {
+	return s.body.Close()
+}
+
+// FollowLogs 连接日志流并对每条消息调用handler，直到ctx取消、连接断开或handler返回错误；
+// ctx取消时返回ctx.Err()
+func (c *Client) FollowLogs(ctx context.Context, handler func(line string) error) error {
+	stream, err := c.StreamLogs(ctx)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	for {
+		line, err := stream.Next()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		if err := handler(line); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"dhcp-server/apitypes"
+	"dhcp-server/config"
+)
+
+// ListOptions /api/v1 列表的分页、排序和过滤参数
+type ListOptions struct {
+	Limit   int        // 每页条数，0表示使用服务器默认值
+	Sort    string     // 排序字段，前缀-表示降序
+	Cursor  string     // 上一页返回的NextCursor
+	Filters url.Values // 过滤参数，如 mac、gateway、q
+}
+
+func (o ListOptions) values() url.Values {
+	values := url.Values{}
+	for key, list := range o.Filters {
+		values[key] = list
+	}
+	if o.Limit > 0 {
+		values.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Sort != "" {
+		values.Set("sort", o.Sort)
+	}
+	if o.Cursor != "" {
+		values.Set("cursor", o.Cursor)
+	}
+	return values
+}
+
+// V1 版本化接口（/api/v1），资源路径风格，错误带有Code
+type V1 struct {
+	c *Client
+}
+
+// V1 返回版本化接口的客户端
+func (c *Client) V1() V1 {
+	return V1{c: c}
+}
+
+// Leases 租约分页列表
+func (v V1) Leases(ctx context.Context, opts ListOptions) (*apitypes.LeaseList, error) {
+	var resp apitypes.LeaseList
+	if err := v.c.do(ctx, http.MethodGet, "/api/v1/leases", opts.values(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Lease 单个租约
+func (v V1) Lease(ctx context.Context, ip string) (*apitypes.LeaseInfo, error) {
+	var resp apitypes.LeaseInfo
+	if err := v.c.do(ctx, http.MethodGet, "/api/v1/leases/"+url.PathEscape(ip), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Bindings 静态绑定分页列表
+func (v V1) Bindings(ctx context.Context, opts ListOptions) (*apitypes.BindingList, error) {
+	var resp apitypes.BindingList
+	if err := v.c.do(ctx, http.MethodGet, "/api/v1/bindings", opts.values(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Binding 单个静态绑定
+func (v V1) Binding(ctx context.Context, alias string) (*apitypes.BindingInfo, error) {
+	var resp apitypes.BindingInfo
+	if err := v.c.do(ctx, http.MethodGet, "/api/v1/bindings/"+url.PathEscape(alias), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CreateBinding 创建静态绑定
+func (v V1) CreateBinding(ctx context.Context, binding apitypes.BindingInfo) (*apitypes.BindingInfo, error) {
+	var resp apitypes.BindingInfo
+	if err := v.c.do(ctx, http.MethodPost, "/api/v1/bindings", nil, binding, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpdateBinding 更新静态绑定，binding.Alias为空时保持原别名
+func (v V1) UpdateBinding(ctx context.Context, alias string, binding apitypes.BindingInfo) (*apitypes.BindingInfo, error) {
+	var resp apitypes.BindingInfo
+	if err := v.c.do(ctx, http.MethodPut, "/api/v1/bindings/"+url.PathEscape(alias), nil, binding, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteBinding 删除静态绑定
+func (v V1) DeleteBinding(ctx context.Context, alias string) error {
+	return v.c.do(ctx, http.MethodDelete, "/api/v1/bindings/"+url.PathEscape(alias), nil, nil, nil)
+}
+
+// Devices 设备分页列表
+func (v V1) Devices(ctx context.Context, opts ListOptions) (*apitypes.DeviceList, error) {
+	var resp apitypes.DeviceList
+	if err := v.c.do(ctx, http.MethodGet, "/api/v1/devices", opts.values(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Device 单个设备
+func (v V1) Device(ctx context.Context, mac string) (*config.DeviceInfo, error) {
+	var resp config.DeviceInfo
+	if err := v.c.do(ctx, http.MethodGet, "/api/v1/devices/"+url.PathEscape(mac), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CreateDevice 创建设备
+func (v V1) CreateDevice(ctx context.Context, device config.DeviceInfo) (*config.DeviceInfo, error) {
+	var resp config.DeviceInfo
+	if err := v.c.do(ctx, http.MethodPost, "/api/v1/devices", nil, device, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpdateDevice 更新设备
+func (v V1) UpdateDevice(ctx context.Context, mac string, device config.DeviceInfo) (*config.DeviceInfo, error) {
+	var resp config.DeviceInfo
+	if err := v.c.do(ctx, http.MethodPut, "/api/v1/devices/"+url.PathEscape(mac), nil, device, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteDevice 删除设备及其静态绑定
+func (v V1) DeleteDevice(ctx context.Context, mac string) (Object, error) {
+	var resp Object
+	if err := v.c.do(ctx, http.MethodDelete, "/api/v1/devices/"+url.PathEscape(mac), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -1,11 +1,16 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,15 +19,32 @@ import (
 
 // Config 主配置结构
 type Config struct {
-	Server      ServerConfig  `yaml:"server"`
-	Network     NetworkConfig `yaml:"network"`
-	Gateways    []Gateway     `yaml:"gateways"`
-	Bindings    []MACBinding  `yaml:"bindings"`
-	Devices     []DeviceInfo  `yaml:"devices"` // 设备信息配置
-	HealthCheck HealthConfig  `yaml:"health_check"`
-	Scanner     ScannerConfig `yaml:"scanner"` // 网络扫描器配置
+	Revision    uint64            `yaml:"revision"` // 配置修订号，每次保存递增
+	Server      ServerConfig      `yaml:"server"`
+	Network     NetworkConfig     `yaml:"network"`
+	Gateways    []Gateway         `yaml:"gateways"`
+	Bindings    []MACBinding      `yaml:"bindings"`
+	Devices     []DeviceInfo      `yaml:"devices"`   // 设备信息配置
+	Groups      []DeviceGroup     `yaml:"groups"`    // 设备组策略
+	Schedules   []AccessSchedule  `yaml:"schedules"` // 访问时间表
+	HealthCheck HealthConfig      `yaml:"health_check"`
+	Scanner     ScannerConfig     `yaml:"scanner"`     // 网络扫描器配置
+	ForceRenew  ForceRenewConfig  `yaml:"force_renew"` // FORCERENEW推送配置
+	Events      EventsConfig      `yaml:"events"`      // 事件通知配置
+	OUI         OUIConfig         `yaml:"oui"`         // 厂商数据库配置
+	Fingerprint FingerprintConfig `yaml:"fingerprint"` // DHCP指纹识别配置
+	Security    SecurityConfig    `yaml:"security"`    // 安全配置
+	Presence    PresenceConfig    `yaml:"presence"`    // 设备在线记录配置
+	Auth        AuthConfig        `yaml:"auth"`        // 管理API认证配置
+	TLS         TLSConfig         `yaml:"tls"`         // 管理API的HTTPS配置
+	Audit       AuditConfig       `yaml:"audit"`       // 管理操作审计日志
+
+	digest string // 最近一次加载或保存的配置文件摘要，用于发现外部修改
 }
 
+// ErrConfigModified 配置文件在加载后被外部修改
+var ErrConfigModified = errors.New("配置文件已被外部修改，请重新加载配置后再试")
+
 // ServerConfig DHCP服务器配置
 type ServerConfig struct {
 	Interface        string        `yaml:"interface" json:"interface"`
@@ -83,32 +105,318 @@ type HealthConfig struct {
 
 // ScannerConfig 网络扫描器配置
 type ScannerConfig struct {
-	Enabled         bool          `yaml:"enabled" json:"enabled"`                   // 是否启用扫描器
-	ScanInterval    int           `yaml:"scan_interval" json:"scan_interval"`       // 扫描间隔（秒）
-	MaxConcurrency  int           `yaml:"max_concurrency" json:"max_concurrency"`   // 最大并发数
-	PingTimeout     int           `yaml:"ping_timeout" json:"ping_timeout"`         // Ping超时时间（毫秒）
-	InactiveTimeout int           `yaml:"inactive_timeout" json:"inactive_timeout"` // 非活跃超时时间（小时）
-	StartIP         string        `yaml:"start_ip" json:"start_ip"`                 // 扫描起始IP
-	EndIP           string        `yaml:"end_ip" json:"end_ip"`                     // 扫描结束IP
-	AutoConflict    bool          `yaml:"auto_conflict" json:"auto_conflict"`       // 自动检测IP冲突
-	ConflictTimeout time.Duration `yaml:"conflict_timeout" json:"conflict_timeout"` // 冲突IP超时时间
-	LogLevel        string        `yaml:"log_level" json:"log_level"`               // 日志级别
+	Enabled         bool              `yaml:"enabled" json:"enabled"`                   // 是否启用扫描器
+	ScanInterval    int               `yaml:"scan_interval" json:"scan_interval"`       // 扫描间隔（秒）
+	MaxConcurrency  int               `yaml:"max_concurrency" json:"max_concurrency"`   // 最大并发数
+	PingTimeout     int               `yaml:"ping_timeout" json:"ping_timeout"`         // Ping超时时间（毫秒）
+	InactiveTimeout int               `yaml:"inactive_timeout" json:"inactive_timeout"` // 非活跃超时时间（小时）
+	StartIP         string            `yaml:"start_ip" json:"start_ip"`                 // 扫描起始IP
+	EndIP           string            `yaml:"end_ip" json:"end_ip"`                     // 扫描结束IP
+	AutoConflict    bool              `yaml:"auto_conflict" json:"auto_conflict"`       // 自动检测IP冲突
+	ConflictTimeout time.Duration     `yaml:"conflict_timeout" json:"conflict_timeout"` // 冲突IP超时时间
+	LogLevel        string            `yaml:"log_level" json:"log_level"`               // 日志级别
+	Method          string            `yaml:"method" json:"method"`                     // 探测方式：icmp、arp、both（默认both）
+	ARPTimeout      int               `yaml:"arp_timeout" json:"arp_timeout"`           // ARP应答等待时间（毫秒）
+	NameSources     []string          `yaml:"name_sources" json:"name_sources"`         // 名称发现来源：mdns、netbios、ssdp，为空时全部启用，none表示禁用
+	NameTimeout     int               `yaml:"name_timeout" json:"name_timeout"`         // 名称发现等待时间（毫秒）
+	Profiles        []ScanProfile     `yaml:"profiles" json:"profiles"`                 // 扫描配置方案，为空时按上述设置生成default方案
+	ServiceScan     ServiceScanConfig `yaml:"service_scan" json:"service_scan"`         // TCP服务识别
+}
+
+// ServiceScanConfig TCP服务识别配置，对发现的主机探测端口并获取banner
+type ServiceScanConfig struct {
+	Enabled        bool  `yaml:"enabled" json:"enabled"`                 // 是否启用
+	Ports          []int `yaml:"ports" json:"ports"`                     // 探测端口，为空时使用内置常用端口
+	Timeout        int   `yaml:"timeout" json:"timeout"`                 // 单个连接和读取超时（毫秒），默认1000
+	MaxConcurrency int   `yaml:"max_concurrency" json:"max_concurrency"` // 同时进行的连接数，默认32
+}
+
+// ScanProfile 命名扫描方案
+type ScanProfile struct {
+	Name           string   `yaml:"name" json:"name"`                       // 方案名称
+	Targets        []string `yaml:"targets" json:"targets"`                 // 扫描目标：CIDR、IP或范围（如 192.168.1.10-192.168.1.50）
+	Excludes       []string `yaml:"excludes" json:"excludes"`               // 排除的目标，格式同上
+	Methods        []string `yaml:"methods" json:"methods"`                 // 探测方式：icmp、arp、tcp，为空时使用scanner.method
+	TCPPorts       []int    `yaml:"tcp_ports" json:"tcp_ports"`             // TCP探测端口
+	Interval       int      `yaml:"interval" json:"interval"`               // 定时扫描间隔（秒），0表示仅按需扫描
+	MaxConcurrency int      `yaml:"max_concurrency" json:"max_concurrency"` // 最大并发数，0表示使用scanner.max_concurrency
+}
+
+// OUIConfig 厂商数据库配置
+type OUIConfig struct {
+	Files []string `yaml:"files" json:"files"` // IEEE CSV文件路径（oui.csv、mam.csv、oui36.csv等），为空时仅使用内置数据
+}
+
+// PresenceConfig 设备在线记录配置
+type PresenceConfig struct {
+	File           string `yaml:"file" json:"file"`                       // 持久化文件路径，为空时仅保存在内存中
+	OfflineTimeout int    `yaml:"offline_timeout" json:"offline_timeout"` // 超过该时间（秒）未见到视为离线，默认600
+	RetentionDays  int    `yaml:"retention_days" json:"retention_days"`   // 会话记录保留天数，默认30
+}
+
+// AuditConfig 审计日志配置
+type AuditConfig struct {
+	File          string `yaml:"file" json:"file"`                     // 审计日志文件（每行一条JSON），为空时仅保存在内存中
+	RetentionDays int    `yaml:"retention_days" json:"retention_days"` // 保留天数，默认90
+	MaxEntries    int    `yaml:"max_entries" json:"max_entries"`       // 内存中用于查询的最多条数，默认100000
+}
+
+// TLSConfig 管理API的HTTPS配置，证书文件变化时自动重新加载
+type TLSConfig struct {
+	Enabled      bool     `yaml:"enabled" json:"enabled"`
+	CertFile     string   `yaml:"cert_file" json:"cert_file"`             // 证书文件（PEM），可包含中间证书
+	KeyFile      string   `yaml:"key_file" json:"key_file"`               // 私钥文件（PEM）
+	MinVersion   string   `yaml:"min_version" json:"min_version"`         // 最低TLS版本：1.2或1.3，默认1.2
+	ClientCAFile string   `yaml:"client_ca_file" json:"client_ca_file"`   // 客户端CA证书，配置后要求客户端证书（mTLS）
+	SelfSigned   bool     `yaml:"self_signed" json:"self_signed"`         // 证书文件不存在时生成自签名证书
+	Hosts        []string `yaml:"hosts,omitempty" json:"hosts,omitempty"` // 自签名证书额外包含的主机名或IP
+}
+
+// AuthConfig 管理API认证配置，默认要求登录
+type AuthConfig struct {
+	Disabled    bool          `yaml:"disabled" json:"disabled"`         // 关闭认证，允许匿名访问全部接口（不推荐）
+	SessionTTL  time.Duration `yaml:"session_ttl" json:"session_ttl"`   // 登录会话有效期，默认12小时
+	Users       []AuthUser    `yaml:"users" json:"users"`               // 本地用户
+	Tokens      []APIToken    `yaml:"tokens" json:"tokens"`             // 自动化使用的Bearer令牌
+	CORSOrigins []string      `yaml:"cors_origins" json:"cors_origins"` // 允许跨域访问的来源，为空时不允许跨域
+}
+
+// 管理角色，权限依次递增
+const (
+	RoleViewer   = "viewer"   // 只读：租约、统计和历史
+	RoleOperator = "operator" // 另可管理设备、静态绑定和扫描器
+	RoleAdmin    = "admin"    // 另可修改配置、恢复备份和重启服务
+)
+
+// AuthUser 本地用户
+type AuthUser struct {
+	Username     string `yaml:"username" json:"username"`
+	PasswordHash string `yaml:"password_hash" json:"-"` // bcrypt哈希
+	Role         string `yaml:"role" json:"role"`       // 角色，必须设置
+}
+
+// APIToken Bearer令牌，只保存哈希
+type APIToken struct {
+	Name        string     `yaml:"name" json:"name"`
+	TokenHash   string     `yaml:"token_hash" json:"-"`                                  // 令牌的SHA-256哈希（十六进制）
+	Role        string     `yaml:"role,omitempty" json:"role,omitempty"`                 // 角色，未配置scopes时必须设置
+	Scopes      []string   `yaml:"scopes,omitempty" json:"scopes,omitempty"`             // 权限范围，如 leases:read、bindings:write，配置后代替角色
+	SourceCIDRs []string   `yaml:"source_cidrs,omitempty" json:"source_cidrs,omitempty"` // 允许使用令牌的来源网段，为空时不限制
+	CreatedAt   *time.Time `yaml:"created_at,omitempty" json:"created_at,omitempty"`     // 创建时间
+	ExpiresAt   *time.Time `yaml:"expires_at,omitempty" json:"expires_at,omitempty"`     // 过期时间，为空时永不过期
+	LastUsed    *time.Time `yaml:"last_used,omitempty" json:"last_used,omitempty"`       // 最后使用时间
+}
+
+// Expired 检查令牌是否已过期
+func (t APIToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// ValidateToken 检查令牌的名称、角色和来源网段，权限范围的资源名由API服务器检查
+func ValidateToken(token APIToken) error {
+	if token.Name == "" {
+		return fmt.Errorf("令牌名称不能为空")
+	}
+	if token.Role == "" && len(token.Scopes) == 0 {
+		return fmt.Errorf("令牌 %s 必须设置角色（viewer、operator、admin）或权限范围", token.Name)
+	}
+	if token.Role != "" && !ValidRole(token.Role) {
+		return fmt.Errorf("令牌 %s 的角色无效: %s", token.Name, token.Role)
+	}
+	for _, cidr := range token.SourceCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("令牌 %s 的来源网段无效: %s", token.Name, cidr)
+		}
+	}
+	return nil
+}
+
+// ValidRole 检查角色名称
+func ValidRole(role string) bool {
+	switch role {
+	case RoleViewer, RoleOperator, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+// SecurityConfig 安全配置
+type SecurityConfig struct {
+	RogueDetection RogueDetectionConfig  `yaml:"rogue_detection" json:"rogue_detection"`   // 非法DHCP服务器检测
+	Access         AccessConfig          `yaml:"access" json:"access"`                     // MAC访问控制
+	Quarantine     QuarantineConfig      `yaml:"quarantine" json:"quarantine"`             // 隔离地址池，访问控制拒绝方式为quarantine时使用
+	Flood          FloodProtectionConfig `yaml:"flood_protection" json:"flood_protection"` // DHCP洪泛和地址耗尽攻击防护
+}
+
+// FloodProtectionConfig DHCP洪泛和地址耗尽攻击防护配置
+type FloodProtectionConfig struct {
+	Enabled          bool          `yaml:"enabled" json:"enabled"`
+	PerMACRate       float64       `yaml:"per_mac_rate" json:"per_mac_rate"`             // 单个MAC每秒允许的DISCOVER/REQUEST数，默认1
+	PerMACBurst      int           `yaml:"per_mac_burst" json:"per_mac_burst"`           // 单个MAC的突发上限，默认5
+	GlobalRate       float64       `yaml:"global_rate" json:"global_rate"`               // 全局每秒允许的DISCOVER/REQUEST数，默认50
+	GlobalBurst      int           `yaml:"global_burst" json:"global_burst"`             // 全局突发上限，默认100
+	OfferTimeout     time.Duration `yaml:"offer_timeout" json:"offer_timeout"`           // Offer预留时间，超时未收到REQUEST即释放，默认30秒
+	MaxPendingOffers int           `yaml:"max_pending_offers" json:"max_pending_offers"` // 同时待确认的Offer上限，默认为地址池大小的1/4
+	NewMACThreshold  int           `yaml:"new_mac_threshold" json:"new_mac_threshold"`   // 同一端口或Option 82电路每秒出现的新MAC数超过该值时告警，默认10
+}
+
+// AccessConfig MAC访问控制配置，规则支持完整MAC或 aa:bb:cc:* 形式的OUI通配
+type AccessConfig struct {
+	Mode   string   `yaml:"mode" json:"mode"`     // open（默认）、allowlist（仅已登记设备和allow列表）、denylist
+	Action string   `yaml:"action" json:"action"` // 拒绝方式：ignore（默认，不应答）、nak（REQUEST回复NAK）、quarantine（分配隔离地址）
+	Allow  []string `yaml:"allow" json:"allow"`   // allowlist模式下额外允许的MAC
+	Deny   []string `yaml:"deny" json:"deny"`     // 拒绝的MAC，两种模式下都优先生效
+}
+
+// ValidMACPattern 检查访问控制规则：完整MAC地址，或以*结尾的前缀（如 aa:bb:cc:* 匹配整个OUI）
+func ValidMACPattern(pattern string) bool {
+	normalized := strings.ToLower(strings.TrimSpace(pattern))
+	normalized = strings.NewReplacer(":", "", "-", "", ".", "").Replace(normalized)
+	prefix := strings.TrimSuffix(normalized, "*")
+	wildcard := prefix != normalized
+
+	if strings.Contains(prefix, "*") {
+		return false
+	}
+	for _, c := range prefix {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	if wildcard {
+		return len(prefix) < 12
+	}
+	return len(prefix) == 12
+}
+
+// QuarantineConfig 隔离地址池配置，未登记设备获得短租期的隔离地址，只能访问认证门户
+type QuarantineConfig struct {
+	StartIP    string        `yaml:"start_ip" json:"start_ip"`       // 隔离范围起始IP，需在子网内且不与正常地址池重叠
+	EndIP      string        `yaml:"end_ip" json:"end_ip"`           // 隔离范围结束IP
+	LeaseTime  time.Duration `yaml:"lease_time" json:"lease_time"`   // 隔离租期，默认5分钟
+	Gateway    string        `yaml:"gateway" json:"gateway"`         // 下发的网关（认证门户），为空时不下发默认路由
+	DNSServers []string      `yaml:"dns_servers" json:"dns_servers"` // 下发的DNS（通常为门户的劫持DNS）
+	PortalURL  string        `yaml:"portal_url" json:"portal_url"`   // 认证门户地址，通过选项114下发（RFC 8910）
+}
+
+// RogueDetectionConfig 非法DHCP服务器检测配置
+type RogueDetectionConfig struct {
+	Enabled        bool          `yaml:"enabled" json:"enabled"`                 // 是否启用检测
+	ProbeInterval  time.Duration `yaml:"probe_interval" json:"probe_interval"`   // 主动探测间隔，默认5分钟
+	AllowedServers []string      `yaml:"allowed_servers" json:"allowed_servers"` // 受信任的其他DHCP服务器IP（如冗余服务器）
+}
+
+// FingerprintConfig DHCP指纹识别配置
+type FingerprintConfig struct {
+	DatabaseFile string `yaml:"database_file" json:"database_file"` // 自定义指纹规则文件（YAML），优先于内置规则
+}
+
+// ForceRenewConfig FORCERENEW推送配置（RFC 3203，认证遵循RFC 6704）
+type ForceRenewConfig struct {
+	Enabled           bool          `yaml:"enabled" json:"enabled"`                         // 是否在网关故障时推送FORCERENEW
+	RetryCount        int           `yaml:"retry_count" json:"retry_count"`                 // 客户端未续租时的重发次数
+	RetryInterval     time.Duration `yaml:"retry_interval" json:"retry_interval"`           // 重发间隔
+	FallbackLeaseTime time.Duration `yaml:"fallback_lease_time" json:"fallback_lease_time"` // 不支持FORCERENEW的客户端使用的短租期，0表示不启用
+}
+
+// EventsConfig 事件通知配置
+type EventsConfig struct {
+	Webhooks  []WebhookConfig  `yaml:"webhooks" json:"webhooks"`     // HTTP webhook
+	ExecHooks []ExecHookConfig `yaml:"exec_hooks" json:"exec_hooks"` // 外部脚本钩子
+	Stream    bool             `yaml:"stream" json:"stream"`         // 是否推送到实时日志流
+}
+
+// WebhookConfig HTTP webhook配置
+type WebhookConfig struct {
+	Name          string            `yaml:"name" json:"name"`
+	URL           string            `yaml:"url" json:"url"`
+	Headers       map[string]string `yaml:"headers" json:"headers"`               // 附加请求头，如认证令牌
+	Events        []string          `yaml:"events" json:"events"`                 // 订阅的事件类型，支持 gateway.* 形式，为空表示全部
+	Timeout       time.Duration     `yaml:"timeout" json:"timeout"`               // 单次请求超时
+	RetryCount    int               `yaml:"retry_count" json:"retry_count"`       // 最大尝试次数
+	RetryInterval time.Duration     `yaml:"retry_interval" json:"retry_interval"` // 首次重试间隔，之后指数增长
+}
+
+// ExecHookConfig 外部脚本钩子配置
+type ExecHookConfig struct {
+	Name    string        `yaml:"name" json:"name"`
+	Command string        `yaml:"command" json:"command"`
+	Args    []string      `yaml:"args" json:"args"`
+	Events  []string      `yaml:"events" json:"events"`   // 订阅的事件类型，为空表示全部
+	Timeout time.Duration `yaml:"timeout" json:"timeout"` // 脚本执行超时
 }
 
 // DeviceInfo 设备信息
 type DeviceInfo struct {
-	MAC         string    `json:"mac" yaml:"mac"`
-	DeviceType  string    `json:"device_type" yaml:"device_type"` // 设备类型：Android、iPhone、Windows、MacOS、Linux等
-	Model       string    `json:"model" yaml:"model"`             // 型号
-	Description string    `json:"description" yaml:"description"` // 描述
-	Owner       string    `json:"owner" yaml:"owner"`             // 所有者
-	Hostname    string    `json:"hostname" yaml:"hostname"`       // 主机名
-	Gateway     string    `json:"gateway" yaml:"gateway"`         // 配置的网关名称
-	LastSeen    time.Time `json:"last_seen" yaml:"-"`             // 最后见到时间
-	FirstSeen   time.Time `json:"first_seen" yaml:"-"`            // 首次见到时间
-	IsActive    bool      `json:"is_active" yaml:"-"`             // 是否活跃
-	StaticIP    string    `json:"static_ip" yaml:"-"`             // 静态IP地址（如果有的话）
-	HasStaticIP bool      `json:"has_static_ip" yaml:"-"`         // 是否有静态IP绑定
+	MAC           string    `json:"mac" yaml:"mac"`
+	DeviceType    string    `json:"device_type" yaml:"device_type"` // 设备类型：Android、iPhone、Windows、MacOS、Linux等
+	Model         string    `json:"model" yaml:"model"`             // 型号
+	Description   string    `json:"description" yaml:"description"` // 描述
+	Owner         string    `json:"owner" yaml:"owner"`             // 所有者
+	Hostname      string    `json:"hostname" yaml:"hostname"`       // 主机名
+	Gateway       string    `json:"gateway" yaml:"gateway"`         // 配置的网关名称
+	LastSeen      time.Time `json:"last_seen" yaml:"-"`             // 最后见到时间
+	FirstSeen     time.Time `json:"first_seen" yaml:"-"`            // 首次见到时间
+	IsActive      bool      `json:"is_active" yaml:"-"`             // 是否活跃
+	StaticIP      string    `json:"static_ip" yaml:"-"`             // 静态IP地址（如果有的话）
+	HasStaticIP   bool      `json:"has_static_ip" yaml:"-"`         // 是否有静态IP绑定
+	Critical      bool      `json:"critical" yaml:"critical"`       // 关键设备，离线时发布critical级别事件
+	SuggestedType string    `json:"suggested_type" yaml:"-"`        // 扫描器根据开放服务推测的设备类型
+
+	Tags       []string      `json:"tags,omitempty" yaml:"tags,omitempty"`               // 标签，决定所属设备组
+	DNSServers []string      `json:"dns_servers,omitempty" yaml:"dns_servers,omitempty"` // 设备专用DNS，优先于设备组
+	LeaseTime  time.Duration `json:"lease_time,omitempty" yaml:"lease_time,omitempty"`   // 设备专用租期，优先于设备组
+	Options    []DHCPOption  `json:"options,omitempty" yaml:"options,omitempty"`         // 设备专用DHCP选项，同代码覆盖设备组选项
+	Schedule   string        `json:"schedule,omitempty" yaml:"schedule,omitempty"`       // 访问时间表名称，优先于设备组
+}
+
+// DeviceGroup 设备组，带有任一匹配标签的设备共享组内策略，设备自身的配置优先
+type DeviceGroup struct {
+	Name        string        `yaml:"name" json:"name"`
+	Description string        `yaml:"description" json:"description"`
+	Tags        []string      `yaml:"tags" json:"tags"`               // 成员标签
+	Gateway     string        `yaml:"gateway" json:"gateway"`         // 网关名称
+	DNSServers  []string      `yaml:"dns_servers" json:"dns_servers"` // DNS服务器
+	LeaseTime   time.Duration `yaml:"lease_time" json:"lease_time"`   // 租期，0表示使用全局租期
+	Options     []DHCPOption  `yaml:"options" json:"options"`         // 自定义DHCP选项
+	StartIP     string        `yaml:"start_ip" json:"start_ip"`       // 组内设备的分配范围，为空时使用全局地址池
+	EndIP       string        `yaml:"end_ip" json:"end_ip"`
+	Schedule    string        `yaml:"schedule" json:"schedule"` // 访问时间表名称
+}
+
+// AccessSchedule 访问时间表，设备只能在允许的时间段内获取或续租地址，租期截断到时间段结束
+type AccessSchedule struct {
+	Name     string           `yaml:"name" json:"name"`
+	Windows  []ScheduleWindow `yaml:"windows" json:"windows"`   // 每周允许的时间段
+	Cron     []string         `yaml:"cron" json:"cron"`         // 类cron表达式（分 时 日 月 周），匹配的每一分钟都允许
+	Timezone string           `yaml:"timezone" json:"timezone"` // 时区，如Asia/Shanghai，默认使用本地时区
+}
+
+// ScheduleWindow 每周时间段
+type ScheduleWindow struct {
+	Days  []string `yaml:"days" json:"days"`   // 星期：mon、tue…sun，为空表示每天
+	Start string   `yaml:"start" json:"start"` // 开始时间 HH:MM
+	End   string   `yaml:"end" json:"end"`     // 结束时间 HH:MM，不大于开始时间表示跨越午夜
+}
+
+// DHCPOption 自定义DHCP选项
+type DHCPOption struct {
+	Code  int    `yaml:"code" json:"code"`   // 选项代码（1-254）
+	Type  string `yaml:"type" json:"type"`   // 值类型：string（默认）、ip、ips（逗号分隔）、uint8、uint16、uint32、bool、hex
+	Value string `yaml:"value" json:"value"` // 选项值
+}
+
+// DevicePolicy 按 设备 → 设备组 → 全局 解析后的DHCP策略
+type DevicePolicy struct {
+	Group      string            `json:"group,omitempty"`       // 所属设备组
+	Gateway    string            `json:"gateway,omitempty"`     // 网关名称，为空时使用默认网关
+	DNSServers []string          `json:"dns_servers,omitempty"` // 为空时使用网关DNS和全局DNS
+	LeaseTime  time.Duration     `json:"lease_time"`
+	Options    []DHCPOption      `json:"options,omitempty"`
+	StartIP    string            `json:"start_ip,omitempty"` // 为空时使用全局地址池
+	EndIP      string            `json:"end_ip,omitempty"`
+	Schedule   string            `json:"schedule,omitempty"` // 访问时间表名称，为空表示不限制
+	Sources    map[string]string `json:"sources"`            // 各项策略的来源：device、group:<名称>、global
 }
 
 // LoadConfig 加载配置文件
@@ -127,6 +435,7 @@ func LoadConfig(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("配置验证失败: %v", err)
 	}
 
+	config.digest = fileDigest(data)
 	return &config, nil
 }
 
@@ -173,6 +482,114 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// 验证扫描方案
+	profileNames := make(map[string]bool)
+	for _, profile := range c.Scanner.Profiles {
+		if profile.Name == "" {
+			return fmt.Errorf("扫描方案名称不能为空")
+		}
+		if profileNames[profile.Name] {
+			return fmt.Errorf("扫描方案名称重复: %s", profile.Name)
+		}
+		profileNames[profile.Name] = true
+		if len(profile.Targets) == 0 {
+			return fmt.Errorf("扫描方案 %s 未配置扫描目标", profile.Name)
+		}
+	}
+
+	// 验证访问时间表
+	scheduleNames := make(map[string]bool)
+	for _, schedule := range c.Schedules {
+		if schedule.Name == "" {
+			return fmt.Errorf("时间表名称不能为空")
+		}
+		if scheduleNames[schedule.Name] {
+			return fmt.Errorf("时间表名称重复: %s", schedule.Name)
+		}
+		scheduleNames[schedule.Name] = true
+		if err := ValidateSchedule(schedule); err != nil {
+			return err
+		}
+	}
+
+	// 验证设备组
+	groupNames := make(map[string]bool)
+	for _, group := range c.Groups {
+		if err := c.ValidateGroup(group); err != nil {
+			return err
+		}
+		if groupNames[group.Name] {
+			return fmt.Errorf("设备组名称重复: %s", group.Name)
+		}
+		groupNames[group.Name] = true
+	}
+	for _, device := range c.Devices {
+		if err := ValidateOptions(device.Options); err != nil {
+			return fmt.Errorf("设备 %s: %v", device.MAC, err)
+		}
+		if device.Schedule != "" && !scheduleNames[device.Schedule] {
+			return fmt.Errorf("设备 %s 的时间表不存在: %s", device.MAC, device.Schedule)
+		}
+	}
+
+	// 验证访问控制
+	switch strings.ToLower(c.Security.Access.Mode) {
+	case "", "open", "allowlist", "denylist":
+	default:
+		return fmt.Errorf("无效的访问控制模式: %s", c.Security.Access.Mode)
+	}
+	switch strings.ToLower(c.Security.Access.Action) {
+	case "", "ignore", "nak":
+	case "quarantine":
+		if err := c.ValidateQuarantine(c.Security.Quarantine); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("无效的访问控制拒绝方式: %s", c.Security.Access.Action)
+	}
+	for _, pattern := range c.Security.Access.Allow {
+		if !ValidMACPattern(pattern) {
+			return fmt.Errorf("访问控制允许列表中的MAC规则无效: %s", pattern)
+		}
+	}
+	for _, pattern := range c.Security.Access.Deny {
+		if !ValidMACPattern(pattern) {
+			return fmt.Errorf("访问控制拒绝列表中的MAC规则无效: %s", pattern)
+		}
+	}
+
+	// 验证管理账号角色
+	for _, user := range c.Auth.Users {
+		if user.Role == "" {
+			return fmt.Errorf("用户 %s 必须设置角色（viewer、operator、admin）", user.Username)
+		}
+		if !ValidRole(user.Role) {
+			return fmt.Errorf("用户 %s 的角色无效: %s", user.Username, user.Role)
+		}
+	}
+	tokenNames := make(map[string]bool)
+	for _, token := range c.Auth.Tokens {
+		if err := ValidateToken(token); err != nil {
+			return err
+		}
+		if tokenNames[token.Name] {
+			return fmt.Errorf("令牌名称重复: %s", token.Name)
+		}
+		tokenNames[token.Name] = true
+	}
+
+	// 验证HTTPS配置
+	if c.TLS.Enabled {
+		switch c.TLS.MinVersion {
+		case "", "1.2", "1.3":
+		default:
+			return fmt.Errorf("无效的TLS最低版本: %s", c.TLS.MinVersion)
+		}
+		if !c.TLS.SelfSigned && (c.TLS.CertFile == "" || c.TLS.KeyFile == "") {
+			return fmt.Errorf("启用TLS时需要配置证书和私钥文件，或启用self_signed")
+		}
+	}
+
 	// 验证API监听地址
 	if c.Server.APIHost != "" {
 		// 如果配置了APIHost，验证其有效性
@@ -184,6 +601,38 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// ValidateQuarantine 验证隔离地址池：范围有效、位于子网内且不与正常地址池重叠
+func (c *Config) ValidateQuarantine(q QuarantineConfig) error {
+	start := net.ParseIP(q.StartIP).To4()
+	end := net.ParseIP(q.EndIP).To4()
+	if start == nil || end == nil {
+		return fmt.Errorf("无效的隔离地址范围: %s - %s", q.StartIP, q.EndIP)
+	}
+	if binary.BigEndian.Uint32(start) > binary.BigEndian.Uint32(end) {
+		return fmt.Errorf("隔离地址范围起始地址大于结束地址: %s - %s", q.StartIP, q.EndIP)
+	}
+	if _, subnet, err := net.ParseCIDR(c.Network.Subnet); err == nil {
+		if !subnet.Contains(start) || !subnet.Contains(end) {
+			return fmt.Errorf("隔离地址范围不在子网 %s 内", c.Network.Subnet)
+		}
+	}
+	if poolStart, poolEnd := net.ParseIP(c.Network.StartIP).To4(), net.ParseIP(c.Network.EndIP).To4(); poolStart != nil && poolEnd != nil {
+		if binary.BigEndian.Uint32(start) <= binary.BigEndian.Uint32(poolEnd) &&
+			binary.BigEndian.Uint32(end) >= binary.BigEndian.Uint32(poolStart) {
+			return fmt.Errorf("隔离地址范围与地址池 %s - %s 重叠", c.Network.StartIP, c.Network.EndIP)
+		}
+	}
+	if q.Gateway != "" && net.ParseIP(q.Gateway) == nil {
+		return fmt.Errorf("无效的隔离网关: %s", q.Gateway)
+	}
+	for _, dns := range q.DNSServers {
+		if net.ParseIP(dns) == nil {
+			return fmt.Errorf("无效的隔离DNS: %s", dns)
+		}
+	}
+	return nil
+}
+
 // GetDefaultGateway 获取默认网关
 func (c *Config) GetDefaultGateway() *Gateway {
 	for _, gw := range c.Gateways {
@@ -224,6 +673,268 @@ func (c *Config) FindDeviceByMAC(mac string) *DeviceInfo {
 	return nil
 }
 
+// FindGroupByName 根据名称查找设备组
+func (c *Config) FindGroupByName(name string) *DeviceGroup {
+	for i, group := range c.Groups {
+		if group.Name == name {
+			return &c.Groups[i]
+		}
+	}
+	return nil
+}
+
+// FindScheduleByName 根据名称查找访问时间表
+func (c *Config) FindScheduleByName(name string) *AccessSchedule {
+	for i, schedule := range c.Schedules {
+		if schedule.Name == name {
+			return &c.Schedules[i]
+		}
+	}
+	return nil
+}
+
+// FindGroupForDevice 查找设备所属的设备组，多个组匹配时按配置顺序取第一个
+func (c *Config) FindGroupForDevice(device *DeviceInfo) *DeviceGroup {
+	if device == nil {
+		return nil
+	}
+	for i, group := range c.Groups {
+		for _, tag := range group.Tags {
+			for _, deviceTag := range device.Tags {
+				if strings.EqualFold(tag, deviceTag) {
+					return &c.Groups[i]
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ResolvePolicy 解析设备的DHCP策略：每一项依次取设备、设备组、全局配置中第一个设置的值
+func (c *Config) ResolvePolicy(mac string) DevicePolicy {
+	policy := DevicePolicy{
+		LeaseTime: c.Server.LeaseTime,
+		Sources: map[string]string{
+			"gateway":     "global",
+			"dns_servers": "global",
+			"lease_time":  "global",
+			"range":       "global",
+			"schedule":    "global",
+		},
+	}
+
+	device := c.FindDeviceByMAC(mac)
+	group := c.FindGroupForDevice(device)
+	if group != nil {
+		source := "group:" + group.Name
+		policy.Group = group.Name
+		if group.Gateway != "" {
+			policy.Gateway = group.Gateway
+			policy.Sources["gateway"] = source
+		}
+		if len(group.DNSServers) > 0 {
+			policy.DNSServers = group.DNSServers
+			policy.Sources["dns_servers"] = source
+		}
+		if group.LeaseTime > 0 {
+			policy.LeaseTime = group.LeaseTime
+			policy.Sources["lease_time"] = source
+		}
+		if group.StartIP != "" && group.EndIP != "" {
+			policy.StartIP = group.StartIP
+			policy.EndIP = group.EndIP
+			policy.Sources["range"] = source
+		}
+		if group.Schedule != "" {
+			policy.Schedule = group.Schedule
+			policy.Sources["schedule"] = source
+		}
+		policy.Options = mergeOptions(policy.Options, group.Options)
+	}
+
+	if device != nil {
+		if device.Gateway != "" {
+			policy.Gateway = device.Gateway
+			policy.Sources["gateway"] = "device"
+		}
+		if len(device.DNSServers) > 0 {
+			policy.DNSServers = device.DNSServers
+			policy.Sources["dns_servers"] = "device"
+		}
+		if device.LeaseTime > 0 {
+			policy.LeaseTime = device.LeaseTime
+			policy.Sources["lease_time"] = "device"
+		}
+		if device.Schedule != "" {
+			policy.Schedule = device.Schedule
+			policy.Sources["schedule"] = "device"
+		}
+		policy.Options = mergeOptions(policy.Options, device.Options)
+	}
+
+	return policy
+}
+
+// mergeOptions 合并DHCP选项，overrides中的同代码选项覆盖base
+func mergeOptions(base, overrides []DHCPOption) []DHCPOption {
+	if len(overrides) == 0 {
+		return base
+	}
+	merged := make([]DHCPOption, 0, len(base)+len(overrides))
+	for _, option := range base {
+		overridden := false
+		for _, override := range overrides {
+			if override.Code == option.Code {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			merged = append(merged, option)
+		}
+	}
+	return append(merged, overrides...)
+}
+
+// ValidateGroup 验证设备组配置
+func (c *Config) ValidateGroup(group DeviceGroup) error {
+	if group.Name == "" {
+		return fmt.Errorf("设备组名称不能为空")
+	}
+	if group.Schedule != "" && c.FindScheduleByName(group.Schedule) == nil {
+		return fmt.Errorf("设备组 %s 的时间表不存在: %s", group.Name, group.Schedule)
+	}
+	if group.Gateway != "" && c.FindGatewayByName(group.Gateway) == nil {
+		return fmt.Errorf("设备组 %s 的网关不存在: %s", group.Name, group.Gateway)
+	}
+	for _, dns := range group.DNSServers {
+		if net.ParseIP(dns) == nil {
+			return fmt.Errorf("设备组 %s 的DNS无效: %s", group.Name, dns)
+		}
+	}
+	if group.StartIP != "" || group.EndIP != "" {
+		start := net.ParseIP(group.StartIP).To4()
+		end := net.ParseIP(group.EndIP).To4()
+		if start == nil || end == nil || binary.BigEndian.Uint32(start) > binary.BigEndian.Uint32(end) {
+			return fmt.Errorf("设备组 %s 的分配范围无效: %s - %s", group.Name, group.StartIP, group.EndIP)
+		}
+		if _, subnet, err := net.ParseCIDR(c.Network.Subnet); err == nil && (!subnet.Contains(start) || !subnet.Contains(end)) {
+			return fmt.Errorf("设备组 %s 的分配范围不在子网 %s 内", group.Name, c.Network.Subnet)
+		}
+		if err := c.validateGroupRange(group.Name, start, end); err != nil {
+			return err
+		}
+	}
+	return ValidateOptions(group.Options)
+}
+
+// validateGroupRange 设备组的分配范围不能包含网络地址、广播地址和网关地址，也不能与隔离范围重叠
+func (c *Config) validateGroupRange(name string, start, end net.IP) error {
+	first, last := binary.BigEndian.Uint32(start), binary.BigEndian.Uint32(end)
+	contains := func(ip net.IP) bool {
+		ip4 := ip.To4()
+		if ip4 == nil {
+			return false
+		}
+		v := binary.BigEndian.Uint32(ip4)
+		return v >= first && v <= last
+	}
+
+	if _, subnet, err := net.ParseCIDR(c.Network.Subnet); err == nil && len(subnet.Mask) == net.IPv4len {
+		network := subnet.IP.To4()
+		broadcast := make(net.IP, net.IPv4len)
+		for i := range network {
+			broadcast[i] = network[i] | ^subnet.Mask[i]
+		}
+		if contains(network) {
+			return fmt.Errorf("设备组 %s 的分配范围包含网络地址 %s", name, network)
+		}
+		if contains(broadcast) {
+			return fmt.Errorf("设备组 %s 的分配范围包含广播地址 %s", name, broadcast)
+		}
+	}
+	if contains(net.ParseIP(c.Network.BroadcastAddress)) {
+		return fmt.Errorf("设备组 %s 的分配范围包含广播地址 %s", name, c.Network.BroadcastAddress)
+	}
+
+	// 未检测到接口地址时服务器使用第一个网关的地址
+	gateways := []string{c.Network.DefaultGateway, c.Security.Quarantine.Gateway}
+	for _, gw := range c.Gateways {
+		gateways = append(gateways, gw.IP)
+	}
+	for _, gw := range gateways {
+		if contains(net.ParseIP(gw)) {
+			return fmt.Errorf("设备组 %s 的分配范围包含网关地址 %s", name, gw)
+		}
+	}
+
+	q := c.Security.Quarantine
+	if qStart, qEnd := net.ParseIP(q.StartIP).To4(), net.ParseIP(q.EndIP).To4(); qStart != nil && qEnd != nil {
+		if first <= binary.BigEndian.Uint32(qEnd) && last >= binary.BigEndian.Uint32(qStart) {
+			return fmt.Errorf("设备组 %s 的分配范围与隔离地址范围 %s - %s 重叠", name, q.StartIP, q.EndIP)
+		}
+	}
+	return nil
+}
+
+// ValidateOptions 验证自定义DHCP选项
+func ValidateOptions(options []DHCPOption) error {
+	for _, option := range options {
+		if option.Code <= 0 || option.Code >= 255 {
+			return fmt.Errorf("无效的DHCP选项代码: %d", option.Code)
+		}
+		if option.Code == 53 || option.Code == 54 {
+			return fmt.Errorf("DHCP选项 %d 由服务器管理，不能自定义", option.Code)
+		}
+		if _, err := option.Bytes(); err != nil {
+			return fmt.Errorf("DHCP选项 %d 的值无效: %v", option.Code, err)
+		}
+	}
+	return nil
+}
+
+// Bytes 按类型编码选项值
+func (o DHCPOption) Bytes() ([]byte, error) {
+	switch strings.ToLower(o.Type) {
+	case "", "string":
+		return []byte(o.Value), nil
+	case "ip", "ips":
+		var data []byte
+		for _, part := range strings.Split(o.Value, ",") {
+			ip := net.ParseIP(strings.TrimSpace(part)).To4()
+			if ip == nil {
+				return nil, fmt.Errorf("无效的IP: %s", part)
+			}
+			data = append(data, ip...)
+		}
+		return data, nil
+	case "uint8", "uint16", "uint32":
+		bits := map[string]int{"uint8": 8, "uint16": 16, "uint32": 32}[strings.ToLower(o.Type)]
+		v, err := strconv.ParseUint(strings.TrimSpace(o.Value), 10, bits)
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, bits/8)
+		for i := range data {
+			data[len(data)-1-i] = byte(v >> (8 * uint(i)))
+		}
+		return data, nil
+	case "bool":
+		v, err := strconv.ParseBool(strings.TrimSpace(o.Value))
+		if err != nil {
+			return nil, err
+		}
+		if v {
+			return []byte{1}, nil
+		}
+		return []byte{0}, nil
+	case "hex":
+		return hex.DecodeString(strings.NewReplacer(":", "", " ", "").Replace(o.Value))
+	default:
+		return nil, fmt.Errorf("不支持的选项类型: %s", o.Type)
+	}
+}
+
 // AddOrUpdateDevice 添加或更新设备信息
 func (c *Config) AddOrUpdateDevice(device DeviceInfo) {
 	for i, existing := range c.Devices {
@@ -237,25 +948,60 @@ func (c *Config) AddOrUpdateDevice(device DeviceInfo) {
 
 // SaveConfig 保存配置到文件
 func (c *Config) SaveConfig(configPath string) error {
+	// 检查配置文件是否在加载后被外部修改
+	if c.digest != "" {
+		current, err := ioutil.ReadFile(configPath)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("读取配置文件失败: %v", err)
+		}
+		if err == nil && fileDigest(current) != c.digest {
+			return ErrConfigModified
+		}
+	}
+
 	// 创建备份
 	if err := c.BackupConfig(configPath); err != nil {
 		return fmt.Errorf("创建备份失败: %v", err)
 	}
 
-	// 序列化配置为YAML
+	// 修订号递增后序列化配置为YAML
+	c.Revision++
 	data, err := yaml.Marshal(c)
 	if err != nil {
+		c.Revision--
 		return fmt.Errorf("序列化配置失败: %v", err)
 	}
 
-	// 写入文件
-	if err := ioutil.WriteFile(configPath, data, 0644); err != nil {
+	// 写入文件，配置中包含密码和令牌哈希，只允许所有者读写
+	if err := writePrivateFile(configPath, data); err != nil {
+		c.Revision--
 		return fmt.Errorf("写入配置文件失败: %v", err)
 	}
 
+	c.digest = fileDigest(data)
 	return nil
 }
 
+// InheritRevision 继承另一份配置的修订号和文件摘要，用于保存从文本解析出的新配置
+func (c *Config) InheritRevision(from *Config) {
+	c.Revision = from.Revision
+	c.digest = from.digest
+}
+
+// FollowRevision 重新加载后保证修订号大于被替换的配置，使客户端持有的旧ETag失效；
+// 重新加载的正是刚保存的文件时修订号不变
+func (c *Config) FollowRevision(previous *Config) {
+	if previous != nil && c.Revision <= previous.Revision && (c.digest == "" || c.digest != previous.digest) {
+		c.Revision = previous.Revision + 1
+	}
+}
+
+// fileDigest 计算配置文件内容摘要
+func fileDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
 // BackupConfig 备份配置文件
 func (c *Config) BackupConfig(configPath string) error {
 	// 检查原文件是否存在
@@ -265,7 +1011,7 @@ func (c *Config) BackupConfig(configPath string) error {
 
 	// 创建备份目录
 	backupDir := filepath.Join(filepath.Dir(configPath), "config_backups")
-	if err := os.MkdirAll(backupDir, 0755); err != nil {
+	if err := os.MkdirAll(backupDir, 0700); err != nil {
 		return fmt.Errorf("创建备份目录失败: %v", err)
 	}
 
@@ -280,13 +1026,21 @@ func (c *Config) BackupConfig(configPath string) error {
 	}
 
 	// 写入备份文件
-	if err := ioutil.WriteFile(backupFile, data, 0644); err != nil {
+	if err := writePrivateFile(backupFile, data); err != nil {
 		return fmt.Errorf("写入备份文件失败: %v", err)
 	}
 
 	return nil
 }
 
+// writePrivateFile 以0600权限写入文件，已存在的文件同时收紧权限
+func writePrivateFile(path string, data []byte) error {
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	return os.Chmod(path, 0600)
+}
+
 // ValidateYAML 验证YAML格式
 func ValidateYAML(yamlData string) error {
 	var tempConfig Config
@@ -0,0 +1,231 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// weekdayNames 星期名称，支持英文缩写和全称
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// scheduleWindow 解析后的每周时间段，start/end为一天中的分钟数
+type scheduleWindow struct {
+	days  [7]bool
+	start int
+	end   int
+}
+
+// contains 检查时间是否在时间段内，end小于等于start表示跨越午夜
+func (w scheduleWindow) contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	if w.start < w.end {
+		return w.days[day] && minute >= w.start && minute < w.end
+	}
+	if w.start == w.end {
+		return w.days[day]
+	}
+	prev := (day + 6) % 7
+	return (w.days[day] && minute >= w.start) || (w.days[prev] && minute < w.end)
+}
+
+// cronField 类cron表达式中的一个字段，记录允许的取值
+type cronField struct {
+	values   map[int]bool
+	wildcard bool
+}
+
+// cronExpr 五段式类cron表达式：分 时 日 月 周，匹配的每一分钟都允许访问
+type cronExpr struct {
+	minute, hour, dom, month, dow cronField
+}
+
+// matches 检查时间是否匹配表达式，日和周都有限制时满足其一即可（与cron一致）
+func (c *cronExpr) matches(t time.Time) bool {
+	if !c.minute.values[t.Minute()] || !c.hour.values[t.Hour()] || !c.month.values[int(t.Month())] {
+		return false
+	}
+	domMatch := c.dom.values[t.Day()]
+	dowMatch := c.dow.values[int(t.Weekday())]
+	if c.dom.wildcard || c.dow.wildcard {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// ScheduleRules 解析后的访问时间表规则
+type ScheduleRules struct {
+	windows  []scheduleWindow
+	crons    []*cronExpr
+	location *time.Location
+}
+
+// ParseSchedule 解析访问时间表的时区、时间段和cron表达式
+func ParseSchedule(schedule AccessSchedule) (*ScheduleRules, error) {
+	rules := &ScheduleRules{location: time.Local}
+	if schedule.Timezone != "" {
+		location, err := time.LoadLocation(schedule.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("时间表 %s 的时区无效: %s", schedule.Name, schedule.Timezone)
+		}
+		rules.location = location
+	}
+
+	for _, w := range schedule.Windows {
+		window, err := parseScheduleWindow(w)
+		if err != nil {
+			return nil, fmt.Errorf("时间表 %s: %v", schedule.Name, err)
+		}
+		rules.windows = append(rules.windows, window)
+	}
+	for _, spec := range schedule.Cron {
+		expr, err := parseCron(spec)
+		if err != nil {
+			return nil, fmt.Errorf("时间表 %s: %v", schedule.Name, err)
+		}
+		rules.crons = append(rules.crons, expr)
+	}
+
+	if len(rules.windows) == 0 && len(rules.crons) == 0 {
+		return nil, fmt.Errorf("时间表 %s 没有配置任何时间段", schedule.Name)
+	}
+	return rules, nil
+}
+
+// ValidateSchedule 检查访问时间表配置是否有效
+func ValidateSchedule(schedule AccessSchedule) error {
+	if schedule.Name == "" {
+		return fmt.Errorf("时间表名称不能为空")
+	}
+	_, err := ParseSchedule(schedule)
+	return err
+}
+
+// Allows 检查指定时间是否在允许的时间段内
+func (r *ScheduleRules) Allows(t time.Time) bool {
+	t = t.In(r.location)
+	for _, window := range r.windows {
+		if window.contains(t) {
+			return true
+		}
+	}
+	for _, expr := range r.crons {
+		if expr.matches(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseScheduleWindow 解析每周时间段
+func parseScheduleWindow(w ScheduleWindow) (scheduleWindow, error) {
+	var window scheduleWindow
+	if len(w.Days) == 0 {
+		for i := range window.days {
+			window.days[i] = true
+		}
+	}
+	for _, name := range w.Days {
+		day, ok := weekdayNames[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return window, fmt.Errorf("无效的星期: %s", name)
+		}
+		window.days[day] = true
+	}
+
+	var err error
+	if window.start, err = parseClockTime(w.Start); err != nil {
+		return window, err
+	}
+	if window.end, err = parseClockTime(w.End); err != nil {
+		return window, err
+	}
+	return window, nil
+}
+
+// parseClockTime 解析HH:MM格式的时间，返回一天中的分钟数，24:00表示午夜
+func parseClockTime(value string) (int, error) {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("无效的时间: %s", value)
+	}
+	hour, err1 := strconv.Atoi(parts[0])
+	minute, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil || hour < 0 || minute < 0 || minute > 59 || hour > 24 || (hour == 24 && minute != 0) {
+		return 0, fmt.Errorf("无效的时间: %s", value)
+	}
+	return (hour*60 + minute) % (24 * 60), nil
+}
+
+// parseCron 解析五段式类cron表达式
+func parseCron(spec string) (*cronExpr, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron表达式需要5个字段（分 时 日 月 周）: %s", spec)
+	}
+
+	expr := &cronExpr{}
+	targets := []*cronField{&expr.minute, &expr.hour, &expr.dom, &expr.month, &expr.dow}
+	bounds := [][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	for i, field := range fields {
+		parsed, err := parseCronField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("无效的cron表达式 %s: %v", spec, err)
+		}
+		*targets[i] = parsed
+	}
+
+	// 周字段中7和0都表示周日
+	if expr.dow.values[7] {
+		expr.dow.values[0] = true
+	}
+	return expr, nil
+}
+
+// parseCronField 解析cron字段，支持 *、数字、a-b 范围、逗号列表和 /n 步长
+func parseCronField(field string, min, max int) (cronField, error) {
+	result := cronField{values: make(map[int]bool), wildcard: field == "*"}
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if slash := strings.Index(part, "/"); slash >= 0 {
+			n, err := strconv.Atoi(part[slash+1:])
+			if err != nil || n <= 0 {
+				return result, fmt.Errorf("无效的步长: %s", part)
+			}
+			step = n
+			part = part[:slash]
+		}
+
+		lo, hi := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return result, fmt.Errorf("无效的值: %s", part)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return result, fmt.Errorf("无效的值: %s", part)
+				}
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return result, fmt.Errorf("取值超出范围 %d-%d: %s", min, max, part)
+		}
+		for v := lo; v <= hi; v += step {
+			result.values[v] = true
+		}
+	}
+	return result, nil
+}
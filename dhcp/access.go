@@ -0,0 +1,207 @@
+package dhcp
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"strings"
+
+	"dhcp-server/config"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+)
+
+// 访问控制模式
+const (
+	AccessModeOpen      = "open"
+	AccessModeAllowlist = "allowlist"
+	AccessModeDenylist  = "denylist"
+)
+
+// 拒绝方式
+const (
+	AccessActionIgnore     = "ignore"
+	AccessActionNAK        = "nak"
+	AccessActionQuarantine = "quarantine"
+)
+
+// accessVerdict 访问控制判定结果
+type accessVerdict int
+
+const (
+	accessAllowed     accessVerdict = iota // 正常分配
+	accessDenied                           // 拒绝，调用方停止处理
+	accessQuarantined                      // 分配隔离地址
+)
+
+// normalizeMACPattern 将MAC地址或通配规则转换为小写十六进制串，去除分隔符
+func normalizeMACPattern(pattern string) string {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	return strings.NewReplacer(":", "", "-", "", ".", "").Replace(pattern)
+}
+
+// matchMACPattern 检查MAC地址是否匹配规则
+func matchMACPattern(pattern, mac string) bool {
+	normalized := normalizeMACPattern(pattern)
+	target := normalizeMACPattern(mac)
+	if strings.HasSuffix(normalized, "*") {
+		return strings.HasPrefix(target, strings.TrimSuffix(normalized, "*"))
+	}
+	return normalized == target
+}
+
+// matchMACList 返回MAC地址匹配的第一条规则
+func matchMACList(patterns []string, mac string) (string, bool) {
+	for _, pattern := range patterns {
+		if matchMACPattern(pattern, mac) {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// accessMode 获取访问控制模式，未配置时为open
+func accessMode(cfg config.AccessConfig) string {
+	switch strings.ToLower(cfg.Mode) {
+	case AccessModeAllowlist:
+		return AccessModeAllowlist
+	case AccessModeDenylist:
+		return AccessModeDenylist
+	default:
+		return AccessModeOpen
+	}
+}
+
+// isKnownDevice 检查MAC是否已在设备管理或静态绑定中登记
+func (s *Server) isKnownDevice(mac string) bool {
+	for _, device := range s.config.Devices {
+		if matchMACPattern(device.MAC, mac) {
+			return true
+		}
+	}
+	for _, binding := range s.config.Bindings {
+		if matchMACPattern(binding.MAC, mac) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkAccess 按访问控制策略判断客户端是否允许获取地址，返回是否允许及原因
+func (s *Server) checkAccess(mac string) (bool, string) {
+	cfg := s.config.Security.Access
+
+	switch accessMode(cfg) {
+	case AccessModeAllowlist:
+		if pattern, ok := matchMACList(cfg.Deny, mac); ok {
+			return false, "匹配拒绝规则 " + pattern
+		}
+		if s.isKnownDevice(mac) {
+			return true, "已登记设备"
+		}
+		if pattern, ok := matchMACList(cfg.Allow, mac); ok {
+			return true, "匹配允许规则 " + pattern
+		}
+		return false, "不在允许列表中"
+	case AccessModeDenylist:
+		if pattern, ok := matchMACList(cfg.Deny, mac); ok {
+			return false, "匹配拒绝规则 " + pattern
+		}
+		return true, "不在拒绝列表中"
+	default:
+		return true, ""
+	}
+}
+
+// enforceAccess 在DISCOVER/REQUEST分配地址前执行访问控制，拒绝和隔离的判定记录到历史
+// 返回accessDenied时调用方应停止处理并发送返回的响应（仅REQUEST且配置为nak时非空）
+func (s *Server) enforceAccess(req *dhcpv4.DHCPv4) (accessVerdict, *dhcpv4.DHCPv4) {
+	mode := accessMode(s.config.Security.Access)
+	if mode == AccessModeOpen {
+		return accessAllowed, nil
+	}
+
+	mac := req.ClientHWAddr.String()
+	messageType := req.MessageType()
+	ip := req.RequestedIPAddress()
+	if ip == nil || ip.IsUnspecified() {
+		ip = req.ClientIPAddr
+	}
+
+	allowed, reason := s.checkAccess(mac)
+	detail := fmt.Sprintf("%s %s: %s", messageType, mode, reason)
+	if allowed {
+		return accessAllowed, nil
+	}
+
+	action := strings.ToLower(s.config.Security.Access.Action)
+	if action == AccessActionQuarantine {
+		log.Printf("访问控制隔离客户端: MAC=%s, 消息=%s, 原因=%s", mac, messageType, reason)
+		s.addHistoryDetail(ip.String(), mac, req.HostName(), "ACCESS_QUARANTINE", "", detail)
+		return accessQuarantined, nil
+	}
+
+	log.Printf("访问控制拒绝客户端: MAC=%s, 消息=%s, 原因=%s", mac, messageType, reason)
+	s.addHistoryDetail(ip.String(), mac, req.HostName(), "ACCESS_DENY", "", detail)
+
+	// DISCOVER没有NAK应答，只能忽略
+	if messageType == dhcpv4.MessageTypeRequest && action == AccessActionNAK {
+		return accessDenied, s.createNAK(req, "access denied")
+	}
+	return accessDenied, nil
+}
+
+// quarantineGateway 隔离租约使用的门户网关，未配置网关时返回nil
+func (s *Server) quarantineGateway() *config.Gateway {
+	q := s.config.Security.Quarantine
+	if q.Gateway == "" {
+		return nil
+	}
+	return &config.Gateway{Name: "quarantine", IP: q.Gateway, DNSServers: q.DNSServers}
+}
+
+// addQuarantineOptions 为隔离租约下发门户网关、门户DNS和认证门户地址，不下发正常网关
+func (s *Server) addQuarantineOptions(resp *dhcpv4.DHCPv4) {
+	q := s.config.Security.Quarantine
+
+	if gatewayIP := net.ParseIP(q.Gateway); gatewayIP != nil {
+		resp.UpdateOption(dhcpv4.OptRouter(gatewayIP.To4()))
+	}
+
+	var dnsServers []net.IP
+	for _, dns := range q.DNSServers {
+		if ip := net.ParseIP(dns); ip != nil {
+			dnsServers = append(dnsServers, ip.To4())
+		}
+	}
+	if len(dnsServers) > 0 {
+		resp.UpdateOption(dhcpv4.OptDNS(dnsServers...))
+	}
+
+	if q.PortalURL != "" {
+		// 选项114原为URL，RFC 8910重新定义为Captive-Portal
+		resp.UpdateOption(dhcpv4.OptGeneric(dhcpv4.OptionURL, []byte(q.PortalURL)))
+	}
+}
+
+// ReleaseQuarantine 设备登记后将其迁出隔离区：支持FORCERENEW的客户端立即推送续租，
+// 其余客户端在下次续租时收到NAK并重新获取正常地址。返回设备是否持有隔离租约
+func (s *Server) ReleaseQuarantine(mac string) bool {
+	hw, err := net.ParseMAC(mac)
+	if err != nil {
+		return false
+	}
+	lease, ok := s.pool.GetLeaseByMAC(hw.String())
+	if !ok || !lease.Quarantined || lease.IsExpired() {
+		return false
+	}
+
+	s.addHistoryDetail(lease.IP.String(), lease.MAC, lease.Hostname, "QUARANTINE_RELEASE", "", "设备已登记")
+	if _, ok := s.pool.ForceRenewNonce(lease); ok {
+		log.Printf("设备 %s 已登记，推送FORCERENEW迁出隔离地址 %s", lease.MAC, lease.IP)
+		go s.forceRenewWithRetry(lease)
+	} else {
+		log.Printf("设备 %s 已登记，将在下次续租（%v内）时迁出隔离地址 %s", lease.MAC, lease.RemainingTime(), lease.IP)
+	}
+	return true
+}
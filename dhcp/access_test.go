@@ -0,0 +1,137 @@
+package dhcp
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+
+	"dhcp-server/config"
+)
+
+// accessHistory 返回客户端的访问控制历史记录
+func accessHistory(s *Server, mac string) []HistoryRecord {
+	var records []HistoryRecord
+	for _, record := range s.GetHistory(100, mac, "") {
+		switch record.Action {
+		case "ACCESS_ALLOW", "ACCESS_DENY", "ACCESS_QUARANTINE":
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+func TestEnforceAccessHistory(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.Security.Access = config.AccessConfig{
+			Mode:   AccessModeAllowlist,
+			Action: AccessActionNAK,
+			Allow:  []string{"aa:bb:cc:*"},
+			Deny:   []string{"aa:bb:cc:00:00:66"},
+		}
+	})
+
+	// 放行的请求不记录历史
+	allowed := "aa:bb:cc:00:00:01"
+	resp, err := s.handleDiscover(newTestPacket(t, dhcpv4.MessageTypeDiscover, allowed))
+	if err != nil || resp == nil || resp.MessageType() != dhcpv4.MessageTypeOffer {
+		t.Fatalf("允许的客户端应收到OFFER, 实际为 %v, %v", resp, err)
+	}
+	if records := accessHistory(s, allowed); len(records) != 0 {
+		t.Errorf("放行的请求不应记录访问控制历史: %+v", records)
+	}
+
+	// 拒绝规则优先于允许规则，DISCOVER不应答，REQUEST回复NAK
+	denied := "aa:bb:cc:00:00:66"
+	if resp, _ := s.handleDiscover(newTestPacket(t, dhcpv4.MessageTypeDiscover, denied)); resp != nil {
+		t.Errorf("被拒绝的DISCOVER不应应答, 实际为 %v", resp.MessageType())
+	}
+	resp, _ = s.handleRequest(requestFor(t, denied, net.ParseIP("192.168.1.120")))
+	if resp == nil || resp.MessageType() != dhcpv4.MessageTypeNak {
+		t.Errorf("被拒绝的REQUEST应回复NAK, 实际为 %v", resp)
+	}
+	records := accessHistory(s, denied)
+	if len(records) != 2 || records[0].Action != "ACCESS_DENY" || records[0].Detail == "" {
+		t.Errorf("拒绝的判定应记录到历史: %+v", records)
+	}
+}
+
+func TestQuarantineFlow(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.Security.Access = config.AccessConfig{Mode: AccessModeAllowlist, Action: AccessActionQuarantine}
+		cfg.Security.Quarantine = config.QuarantineConfig{
+			StartIP:   "192.168.1.20",
+			EndIP:     "192.168.1.29",
+			LeaseTime: 5 * time.Minute,
+			Gateway:   "192.168.1.5",
+			PortalURL: "https://portal.local/",
+		}
+	})
+	const mac = "aa:bb:cc:37:00:01"
+	quarantineStart, quarantineEnd := net.ParseIP("192.168.1.20").To4(), net.ParseIP("192.168.1.29").To4()
+
+	// 未登记设备获得隔离地址、门户网关和认证门户地址
+	offer, err := s.handleDiscover(newTestPacket(t, dhcpv4.MessageTypeDiscover, mac))
+	if err != nil || offer == nil {
+		t.Fatalf("未登记设备应收到隔离地址, 实际为 %v, %v", offer, err)
+	}
+	quarantineIP := offer.YourIPAddr
+	if !ipInRange(quarantineIP, quarantineStart, quarantineEnd) {
+		t.Fatalf("应分配隔离范围内的地址, 实际为 %s", quarantineIP)
+	}
+	if routers := offer.Router(); len(routers) != 1 || !routers[0].Equal(net.ParseIP("192.168.1.5")) {
+		t.Errorf("隔离租约应下发门户网关, 实际为 %v", routers)
+	}
+	if portal := offer.Options.Get(dhcpv4.OptionURL); string(portal) != "https://portal.local/" {
+		t.Errorf("应通过选项114下发认证门户, 实际为 %q", portal)
+	}
+	if offer.IPAddressLeaseTime(0) != 5*time.Minute {
+		t.Errorf("隔离租期应为5分钟, 实际为 %v", offer.IPAddressLeaseTime(0))
+	}
+
+	ack, _ := s.handleRequest(requestFor(t, mac, quarantineIP))
+	if ack == nil || ack.MessageType() != dhcpv4.MessageTypeAck || !ack.YourIPAddr.Equal(quarantineIP) {
+		t.Fatalf("应确认隔离地址, 实际为 %v", ack)
+	}
+
+	// 登记设备后迁出隔离区，续租时收到NAK
+	s.config.Devices = append(s.config.Devices, config.DeviceInfo{MAC: mac, Description: "已登记"})
+	if !s.ReleaseQuarantine(mac) {
+		t.Fatal("持有隔离租约的设备应返回true")
+	}
+	nak, _ := s.handleRequest(requestFor(t, mac, quarantineIP))
+	if nak == nil || nak.MessageType() != dhcpv4.MessageTypeNak {
+		t.Fatalf("迁出隔离区后续租应收到NAK, 实际为 %v", nak)
+	}
+	if _, ok := s.pool.GetLeaseByMAC(mac); ok {
+		t.Error("NAK后应收回隔离地址")
+	}
+	if s.ReleaseQuarantine(mac) {
+		t.Error("没有隔离租约时应返回false")
+	}
+
+	// 重新DISCOVER获得正常地址和网关
+	offer, _ = s.handleDiscover(newTestPacket(t, dhcpv4.MessageTypeDiscover, mac))
+	if offer == nil || !ipInRange(offer.YourIPAddr, net.ParseIP("192.168.1.100").To4(), net.ParseIP("192.168.1.200").To4()) {
+		t.Fatalf("登记后应分配正常地址池的地址, 实际为 %v", offer)
+	}
+	if routers := offer.Router(); len(routers) != 1 || !routers[0].Equal(net.ParseIP("192.168.1.1")) {
+		t.Errorf("正常租约应下发默认网关, 实际为 %v", routers)
+	}
+	ack, _ = s.handleRequest(requestFor(t, mac, offer.YourIPAddr))
+	if ack == nil || ack.MessageType() != dhcpv4.MessageTypeAck {
+		t.Fatalf("应确认正常地址, 实际为 %v", ack)
+	}
+	if lease, ok := s.pool.GetLeaseByMAC(mac); !ok || lease.Quarantined || !lease.IP.Equal(offer.YourIPAddr) {
+		t.Errorf("应持有正常租约: %+v", lease)
+	}
+
+	actions := make(map[string]bool)
+	for _, record := range s.GetHistory(100, mac, "") {
+		actions[record.Action] = true
+	}
+	if !actions["ACCESS_QUARANTINE"] || !actions["QUARANTINE_RELEASE"] {
+		t.Errorf("历史应包含隔离和迁出记录: %v", actions)
+	}
+}
@@ -0,0 +1,41 @@
+package dhcp
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// arpReply ARP应答信息
+type arpReply struct {
+	MAC        string
+	RTT        time.Duration
+	ReceivedAt time.Time
+}
+
+// layerBroadcast 以太网广播地址
+var layerBroadcast = net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+
+// interfaceIPv4 获取接口的第一个IPv4地址
+func interfaceIPv4(iface *net.Interface) (net.IP, error) {
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("获取接口 %s 地址失败: %v", iface.Name, err)
+	}
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.To4() != nil && !ipnet.IP.IsLoopback() {
+			return ipnet.IP.To4(), nil
+		}
+	}
+	return nil, fmt.Errorf("接口 %s 没有IPv4地址", iface.Name)
+}
+
+// isZeroMAC 检查MAC地址是否全零
+func isZeroMAC(mac net.HardwareAddr) bool {
+	for _, b := range mac {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,314 @@
+//go:build linux
+
+package dhcp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const (
+	arpOpRequest = 1
+	arpOpReply   = 2
+
+	ethHeaderLen = 14
+	arpPacketLen = 28
+
+	// 邻居表属性（linux/neighbour.h）
+	ndaDst    = 1
+	ndaLLAddr = 2
+
+	nudIncomplete = 0x01
+	nudFailed     = 0x20
+	nudNoARP      = 0x40
+
+	arpSendBatch = 32 // 每发送一批ARP请求暂停一次，避免广播风暴
+)
+
+// arpScanner 基于AF_PACKET原始套接字的ARP扫描器
+type arpScanner struct {
+	fd     int
+	iface  *net.Interface
+	srcIP  net.IP
+	closed bool
+	mutex  sync.Mutex
+}
+
+// newARPScanner 在指定接口上创建ARP扫描器（需要root或CAP_NET_RAW权限）
+func newARPScanner(ifaceName string) (*arpScanner, error) {
+	iface, err := net.InterfaceByName(ifaceName)
+	if err != nil {
+		return nil, fmt.Errorf("获取接口 %s 失败: %v", ifaceName, err)
+	}
+	if len(iface.HardwareAddr) != 6 {
+		return nil, fmt.Errorf("接口 %s 不是以太网接口", ifaceName)
+	}
+
+	srcIP, err := interfaceIPv4(iface)
+	if err != nil {
+		return nil, err
+	}
+
+	fd, err := syscall.Socket(syscall.AF_PACKET, syscall.SOCK_RAW, int(htons(syscall.ETH_P_ARP)))
+	if err != nil {
+		return nil, fmt.Errorf("创建原始套接字失败: %v", err)
+	}
+
+	addr := &syscall.SockaddrLinklayer{
+		Protocol: htons(syscall.ETH_P_ARP),
+		Ifindex:  iface.Index,
+	}
+	if err := syscall.Bind(fd, addr); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("绑定接口 %s 失败: %v", ifaceName, err)
+	}
+
+	// 设置读超时，便于接收循环检查截止时间
+	tv := syscall.NsecToTimeval((100 * time.Millisecond).Nanoseconds())
+	if err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("设置套接字超时失败: %v", err)
+	}
+
+	return &arpScanner{fd: fd, iface: iface, srcIP: srcIP}, nil
+}
+
+// Close 关闭原始套接字
+func (a *arpScanner) Close() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if !a.closed {
+		syscall.Close(a.fd)
+		a.closed = true
+	}
+}
+
+// Sweep 向所有目标发送ARP请求，收集在超时时间内的应答
+func (a *arpScanner) Sweep(targets []net.IP, timeout time.Duration, onSent func(net.IP)) map[string]arpReply {
+	replies := make(map[string]arpReply)
+	sentAt := make(map[string]time.Time)
+	var mu sync.Mutex
+	done := make(chan struct{})
+	var deadline time.Time
+	var deadlineMu sync.Mutex
+
+	// 接收循环
+	go func() {
+		defer close(done)
+		buf := make([]byte, 1500)
+		for {
+			deadlineMu.Lock()
+			stop := !deadline.IsZero() && time.Now().After(deadline)
+			deadlineMu.Unlock()
+			if stop {
+				return
+			}
+
+			n, _, err := syscall.Recvfrom(a.fd, buf, 0)
+			if err != nil || n < ethHeaderLen+arpPacketLen {
+				continue
+			}
+
+			ip, mac, ok := parseARPReply(buf[:n])
+			if !ok {
+				continue
+			}
+
+			mu.Lock()
+			if _, exists := replies[ip]; !exists {
+				reply := arpReply{MAC: mac, ReceivedAt: time.Now()}
+				if sent, ok := sentAt[ip]; ok {
+					reply.RTT = reply.ReceivedAt.Sub(sent)
+				}
+				replies[ip] = reply
+			}
+			mu.Unlock()
+		}
+	}()
+
+	dst := &syscall.SockaddrLinklayer{
+		Protocol: htons(syscall.ETH_P_ARP),
+		Ifindex:  a.iface.Index,
+		Halen:    6,
+	}
+	copy(dst.Addr[:], layerBroadcast)
+
+	for i, target := range targets {
+		ip4 := target.To4()
+		if ip4 == nil {
+			continue
+		}
+
+		mu.Lock()
+		sentAt[ip4.String()] = time.Now()
+		mu.Unlock()
+
+		frame := a.buildRequest(ip4)
+		syscall.Sendto(a.fd, frame, 0, dst)
+
+		if onSent != nil {
+			onSent(ip4)
+		}
+		if (i+1)%arpSendBatch == 0 {
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	deadlineMu.Lock()
+	deadline = time.Now().Add(timeout)
+	deadlineMu.Unlock()
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	return replies
+}
+
+// buildRequest 构造ARP who-has请求帧
+func (a *arpScanner) buildRequest(target net.IP) []byte {
+	frame := make([]byte, ethHeaderLen+arpPacketLen)
+
+	// 以太网头
+	copy(frame[0:6], layerBroadcast)
+	copy(frame[6:12], a.iface.HardwareAddr)
+	binary.BigEndian.PutUint16(frame[12:14], syscall.ETH_P_ARP)
+
+	// ARP报文
+	arp := frame[ethHeaderLen:]
+	binary.BigEndian.PutUint16(arp[0:2], 1)      // 以太网
+	binary.BigEndian.PutUint16(arp[2:4], 0x0800) // IPv4
+	arp[4] = 6
+	arp[5] = 4
+	binary.BigEndian.PutUint16(arp[6:8], arpOpRequest)
+	copy(arp[8:14], a.iface.HardwareAddr)
+	copy(arp[14:18], a.srcIP)
+	// 目标MAC保持全零
+	copy(arp[24:28], target)
+
+	return frame
+}
+
+// parseARPReply 解析ARP应答，返回发送方IP和MAC
+func parseARPReply(frame []byte) (string, string, bool) {
+	if len(frame) < ethHeaderLen+arpPacketLen || binary.BigEndian.Uint16(frame[12:14]) != syscall.ETH_P_ARP {
+		return "", "", false
+	}
+	arp := frame[ethHeaderLen:]
+	if binary.BigEndian.Uint16(arp[6:8]) != arpOpReply {
+		return "", "", false
+	}
+	mac := net.HardwareAddr(append([]byte(nil), arp[8:14]...))
+	ip := net.IP(append([]byte(nil), arp[14:18]...))
+	return ip.String(), mac.String(), true
+}
+
+// readNeighborTable 读取内核邻居表，优先使用netlink，失败时读取/proc/net/arp
+func readNeighborTable() (map[string]string, error) {
+	if table, err := readNetlinkNeighbors(); err == nil {
+		return table, nil
+	}
+	return readProcNetARP("/proc/net/arp")
+}
+
+// readNetlinkNeighbors 通过RTM_GETNEIGH读取IPv4邻居表
+func readNetlinkNeighbors() (map[string]string, error) {
+	data, err := syscall.NetlinkRIB(syscall.RTM_GETNEIGH, syscall.AF_INET)
+	if err != nil {
+		return nil, fmt.Errorf("netlink请求失败: %v", err)
+	}
+
+	msgs, err := syscall.ParseNetlinkMessage(data)
+	if err != nil {
+		return nil, fmt.Errorf("解析netlink消息失败: %v", err)
+	}
+
+	const ndMsgLen = 12 // struct ndmsg
+	table := make(map[string]string)
+	for _, msg := range msgs {
+		if msg.Header.Type != syscall.RTM_NEWNEIGH || len(msg.Data) < ndMsgLen {
+			continue
+		}
+
+		state := *(*uint16)(unsafe.Pointer(&msg.Data[8]))
+		if state&(nudIncomplete|nudFailed|nudNoARP) != 0 {
+			continue
+		}
+
+		var ip net.IP
+		var mac net.HardwareAddr
+		attrs := msg.Data[ndMsgLen:]
+		for len(attrs) >= syscall.SizeofRtAttr {
+			attrLen := int(*(*uint16)(unsafe.Pointer(&attrs[0])))
+			attrType := *(*uint16)(unsafe.Pointer(&attrs[2]))
+			if attrLen < syscall.SizeofRtAttr || attrLen > len(attrs) {
+				break
+			}
+			value := attrs[syscall.SizeofRtAttr:attrLen]
+			switch attrType {
+			case ndaDst:
+				ip = net.IP(append([]byte(nil), value...))
+			case ndaLLAddr:
+				mac = net.HardwareAddr(append([]byte(nil), value...))
+			}
+			// 属性按4字节对齐
+			aligned := (attrLen + syscall.RTA_ALIGNTO - 1) &^ (syscall.RTA_ALIGNTO - 1)
+			if aligned > len(attrs) {
+				break
+			}
+			attrs = attrs[aligned:]
+		}
+
+		if ip.To4() != nil && len(mac) == 6 && !isZeroMAC(mac) {
+			table[ip.String()] = mac.String()
+		}
+	}
+
+	return table, nil
+}
+
+// readProcNetARP 解析/proc/net/arp
+func readProcNetARP(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	table := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // 跳过表头
+	for scanner.Scan() {
+		// IP address  HW type  Flags  HW address  Mask  Device
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		flags, err := strconv.ParseUint(strings.TrimPrefix(fields[2], "0x"), 16, 32)
+		if err != nil || flags&0x2 == 0 { // ATF_COM: 条目已完成
+			continue
+		}
+		mac, err := net.ParseMAC(fields[3])
+		if err != nil || isZeroMAC(mac) {
+			continue
+		}
+		table[fields[0]] = mac.String()
+	}
+
+	return table, scanner.Err()
+}
+
+// htons 主机字节序转网络字节序：按大端写入后以本机字节序读出
+func htons(v uint16) uint16 {
+	var buf [2]byte
+	binary.BigEndian.PutUint16(buf[:], v)
+	return *(*uint16)(unsafe.Pointer(&buf[0]))
+}
@@ -0,0 +1,110 @@
+//go:build linux
+
+package dhcp
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+// arpFrame 构造以太网ARP帧
+func arpFrame(etherType, op uint16, senderMAC []byte, senderIP []byte) []byte {
+	frame := make([]byte, ethHeaderLen+arpPacketLen)
+	binary.BigEndian.PutUint16(frame[12:14], etherType)
+	arp := frame[ethHeaderLen:]
+	binary.BigEndian.PutUint16(arp[0:2], 1)
+	binary.BigEndian.PutUint16(arp[2:4], 0x0800)
+	arp[4], arp[5] = 6, 4
+	binary.BigEndian.PutUint16(arp[6:8], op)
+	copy(arp[8:14], senderMAC)
+	copy(arp[14:18], senderIP)
+	return frame
+}
+
+func TestParseARPReply(t *testing.T) {
+	mac := []byte{0xaa, 0xbb, 0xcc, 0x00, 0x11, 0x22}
+	ip := []byte{192, 168, 1, 20}
+
+	cases := []struct {
+		name    string
+		frame   []byte
+		wantIP  string
+		wantMAC string
+		wantOK  bool
+	}{
+		{"应答", arpFrame(syscall.ETH_P_ARP, arpOpReply, mac, ip), "192.168.1.20", "aa:bb:cc:00:11:22", true},
+		{"请求", arpFrame(syscall.ETH_P_ARP, arpOpRequest, mac, ip), "", "", false},
+		{"非ARP帧", arpFrame(0x0800, arpOpReply, mac, ip), "", "", false},
+		{"截断的帧", arpFrame(syscall.ETH_P_ARP, arpOpReply, mac, ip)[:ethHeaderLen+10], "", "", false},
+		{"空帧", nil, "", "", false},
+	}
+
+	for _, c := range cases {
+		gotIP, gotMAC, ok := parseARPReply(c.frame)
+		if gotIP != c.wantIP || gotMAC != c.wantMAC || ok != c.wantOK {
+			t.Errorf("%s: 期望 (%q, %q, %v), 实际为 (%q, %q, %v)", c.name, c.wantIP, c.wantMAC, c.wantOK, gotIP, gotMAC, ok)
+		}
+	}
+}
+
+func TestReadProcNetARP(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		want    map[string]string
+	}{
+		{
+			name: "完成的条目",
+			content: "IP address       HW type     Flags       HW address            Mask     Device\n" +
+				"192.168.1.1      0x1         0x2         00:11:22:33:44:55     *        eth0\n" +
+				"192.168.1.20     0x1         0x6         AA:BB:CC:00:11:22     *        eth0\n",
+			want: map[string]string{"192.168.1.1": "00:11:22:33:44:55", "192.168.1.20": "aa:bb:cc:00:11:22"},
+		},
+		{
+			name: "跳过未完成、全零和格式错误的条目",
+			content: "IP address       HW type     Flags       HW address            Mask     Device\n" +
+				"192.168.1.30     0x1         0x0         00:00:00:00:00:00     *        eth0\n" +
+				"192.168.1.31     0x1         0x2         00:00:00:00:00:00     *        eth0\n" +
+				"192.168.1.32     0x1         0x2         not-a-mac             *        eth0\n" +
+				"192.168.1.33     0x1\n",
+			want: map[string]string{},
+		},
+		{
+			name:    "只有表头",
+			content: "IP address       HW type     Flags       HW address            Mask     Device\n",
+			want:    map[string]string{},
+		},
+	}
+
+	for _, c := range cases {
+		path := filepath.Join(t.TempDir(), "arp")
+		if err := os.WriteFile(path, []byte(c.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := readProcNetARP(path)
+		if err != nil {
+			t.Errorf("%s: 解析失败: %v", c.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: 期望 %v, 实际为 %v", c.name, c.want, got)
+		}
+	}
+
+	if _, err := readProcNetARP(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("文件不存在时应返回错误")
+	}
+}
+
+func TestHtons(t *testing.T) {
+	var buf [2]byte
+	*(*uint16)(unsafe.Pointer(&buf[0])) = htons(syscall.ETH_P_ARP)
+	if binary.BigEndian.Uint16(buf[:]) != syscall.ETH_P_ARP {
+		t.Errorf("htons结果在内存中应为网络字节序, 实际为 % x", buf)
+	}
+}
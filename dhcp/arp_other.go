@@ -0,0 +1,71 @@
+//go:build !linux
+
+package dhcp
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// arpScanner 非Linux平台不支持原生ARP扫描
+type arpScanner struct{}
+
+// newARPScanner 非Linux平台返回错误，扫描器回退到ICMP探测
+func newARPScanner(ifaceName string) (*arpScanner, error) {
+	return nil, fmt.Errorf("当前平台不支持原生ARP扫描")
+}
+
+// Close 关闭扫描器
+func (a *arpScanner) Close() {}
+
+// Sweep 非Linux平台不执行ARP扫描
+func (a *arpScanner) Sweep(targets []net.IP, timeout time.Duration, onSent func(net.IP)) map[string]arpReply {
+	return map[string]arpReply{}
+}
+
+// readNeighborTable 通过 arp -an 读取邻居表（BSD/macOS输出格式与语言无关）
+func readNeighborTable() (map[string]string, error) {
+	output, err := exec.Command("arp", "-an").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	table := make(map[string]string)
+	for _, line := range strings.Split(string(output), "\n") {
+		// ? (192.168.1.1) at aa:bb:cc:dd:ee:ff on en0 ifscope [ethernet]
+		start := strings.Index(line, "(")
+		end := strings.Index(line, ")")
+		if start < 0 || end <= start {
+			continue
+		}
+		ip := net.ParseIP(line[start+1 : end])
+		if ip == nil || ip.To4() == nil {
+			continue
+		}
+		for _, field := range strings.Fields(line[end+1:]) {
+			if mac, err := net.ParseMAC(normalizeBSDMAC(field)); err == nil && !isZeroMAC(mac) {
+				table[ip.String()] = mac.String()
+				break
+			}
+		}
+	}
+
+	return table, nil
+}
+
+// normalizeBSDMAC BSD的arp输出会省略前导零（如 0:1b:2c:...），补齐为两位
+func normalizeBSDMAC(field string) string {
+	parts := strings.Split(field, ":")
+	if len(parts) != 6 {
+		return field
+	}
+	for i, part := range parts {
+		if len(part) == 1 {
+			parts[i] = "0" + part
+		}
+	}
+	return strings.Join(parts, ":")
+}
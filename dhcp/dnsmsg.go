@@ -0,0 +1,180 @@
+package dhcp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// DNS记录类型（mDNS与NetBIOS名称服务均使用DNS报文格式）
+const (
+	dnsTypeA      = 1
+	dnsTypePTR    = 12
+	dnsTypeTXT    = 16
+	dnsTypeNBSTAT = 33 // 与SRV同值，NetBIOS中表示节点状态
+	dnsClassIN    = 1
+
+	dnsHeaderLen = 12
+)
+
+// dnsQuestion DNS查询问题
+type dnsQuestion struct {
+	Name  string
+	Type  uint16
+	Class uint16
+}
+
+// dnsRecord 解析后的资源记录
+type dnsRecord struct {
+	Name   string
+	Type   uint16
+	Data   []byte   // 原始RDATA
+	Target string   // PTR记录指向的名称
+	IP     net.IP   // A记录地址
+	Text   []string // TXT记录内容
+}
+
+// buildDNSQuery 构造DNS查询报文
+func buildDNSQuery(id uint16, questions []dnsQuestion) []byte {
+	msg := make([]byte, dnsHeaderLen, 512)
+	binary.BigEndian.PutUint16(msg[0:2], id)
+	binary.BigEndian.PutUint16(msg[4:6], uint16(len(questions)))
+
+	for _, q := range questions {
+		msg = append(msg, encodeDNSName(q.Name)...)
+		msg = binary.BigEndian.AppendUint16(msg, q.Type)
+		msg = binary.BigEndian.AppendUint16(msg, q.Class)
+	}
+	return msg
+}
+
+// encodeDNSName 将点分名称编码为DNS标签序列
+func encodeDNSName(name string) []byte {
+	var buf []byte
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if label == "" {
+			continue
+		}
+		buf = append(buf, byte(len(label)))
+		buf = append(buf, label...)
+	}
+	return append(buf, 0)
+}
+
+// readDNSName 读取可能带压缩指针的名称，返回名称和名称之后的偏移
+func readDNSName(msg []byte, offset int) (string, int, error) {
+	var labels []string
+	next := -1
+	for jumps := 0; jumps < 32; {
+		if offset >= len(msg) {
+			return "", 0, fmt.Errorf("名称越界")
+		}
+		length := int(msg[offset])
+		switch {
+		case length == 0:
+			if next < 0 {
+				next = offset + 1
+			}
+			return strings.Join(labels, "."), next, nil
+		case length&0xC0 == 0xC0:
+			if offset+1 >= len(msg) {
+				return "", 0, fmt.Errorf("压缩指针越界")
+			}
+			if next < 0 {
+				next = offset + 2
+			}
+			offset = int(binary.BigEndian.Uint16(msg[offset:]) & 0x3FFF)
+			jumps++
+		default:
+			if offset+1+length > len(msg) {
+				return "", 0, fmt.Errorf("标签越界")
+			}
+			labels = append(labels, string(msg[offset+1:offset+1+length]))
+			offset += 1 + length
+		}
+	}
+	return "", 0, fmt.Errorf("压缩指针循环")
+}
+
+// parseDNSMessage 解析应答报文中的所有资源记录（回答、授权和附加部分）
+func parseDNSMessage(msg []byte) ([]dnsRecord, error) {
+	if len(msg) < dnsHeaderLen {
+		return nil, fmt.Errorf("报文过短")
+	}
+	qdCount := int(binary.BigEndian.Uint16(msg[4:6]))
+	rrCount := int(binary.BigEndian.Uint16(msg[6:8])) +
+		int(binary.BigEndian.Uint16(msg[8:10])) +
+		int(binary.BigEndian.Uint16(msg[10:12]))
+
+	offset := dnsHeaderLen
+	for i := 0; i < qdCount; i++ {
+		_, next, err := readDNSName(msg, offset)
+		if err != nil {
+			return nil, err
+		}
+		offset = next + 4
+	}
+
+	records := make([]dnsRecord, 0, rrCount)
+	for i := 0; i < rrCount; i++ {
+		name, next, err := readDNSName(msg, offset)
+		if err != nil {
+			return records, err
+		}
+		if next+10 > len(msg) {
+			return records, fmt.Errorf("记录头越界")
+		}
+		rrType := binary.BigEndian.Uint16(msg[next:])
+		rdLength := int(binary.BigEndian.Uint16(msg[next+8:]))
+		dataStart := next + 10
+		if dataStart+rdLength > len(msg) {
+			return records, fmt.Errorf("记录数据越界")
+		}
+
+		record := dnsRecord{Name: name, Type: rrType, Data: msg[dataStart : dataStart+rdLength]}
+		switch rrType {
+		case dnsTypeA:
+			if rdLength == 4 {
+				record.IP = net.IP(append([]byte(nil), record.Data...))
+			}
+		case dnsTypePTR:
+			if target, _, err := readDNSName(msg, dataStart); err == nil {
+				record.Target = target
+			}
+		case dnsTypeTXT:
+			for data := record.Data; len(data) > 0; {
+				length := int(data[0])
+				if 1+length > len(data) {
+					break
+				}
+				record.Text = append(record.Text, string(data[1:1+length]))
+				data = data[1+length:]
+			}
+		}
+
+		records = append(records, record)
+		offset = dataStart + rdLength
+	}
+
+	return records, nil
+}
+
+// reverseName 生成IPv4反向解析名称
+func reverseName(ip net.IP) string {
+	ip4 := ip.To4()
+	return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa", ip4[3], ip4[2], ip4[1], ip4[0])
+}
+
+// parseReverseName 从反向解析名称还原IPv4地址
+func parseReverseName(name string) net.IP {
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	if !strings.HasSuffix(name, ".in-addr.arpa") {
+		return nil
+	}
+	parts := strings.Split(strings.TrimSuffix(name, ".in-addr.arpa"), ".")
+	if len(parts) != 4 {
+		return nil
+	}
+	return net.ParseIP(parts[3] + "." + parts[2] + "." + parts[1] + "." + parts[0]).To4()
+}
@@ -0,0 +1,162 @@
+package dhcp
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"dhcp-server/fingerprint"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+)
+
+const (
+	dhcpMagicCookieOffset = 236
+	dhcpOptionsOffset     = 240
+	optionOrderTTL        = 5 * time.Second
+	optionOrderSweepSize  = 256
+)
+
+// optionOrderConn 包装DHCP监听连接，在报文被解析前记录原始选项顺序
+// （dhcpv4库以map保存选项，解析后顺序信息会丢失）
+type optionOrderConn struct {
+	net.PacketConn
+	mutex  sync.Mutex
+	orders map[string]optionOrder
+}
+
+// optionOrder 单个报文的选项顺序
+type optionOrder struct {
+	codes      []uint8
+	receivedAt time.Time
+}
+
+// newOptionOrderConn 创建记录选项顺序的连接
+func newOptionOrderConn(conn net.PacketConn) *optionOrderConn {
+	return &optionOrderConn{
+		PacketConn: conn,
+		orders:     make(map[string]optionOrder),
+	}
+}
+
+// ReadFrom 读取报文并记录选项顺序
+func (c *optionOrderConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	n, addr, err := c.PacketConn.ReadFrom(b)
+	if err != nil {
+		return n, addr, err
+	}
+
+	if key, codes, ok := parseOptionOrder(b[:n]); ok {
+		now := time.Now()
+		c.mutex.Lock()
+		if len(c.orders) >= optionOrderSweepSize {
+			for k, order := range c.orders {
+				if now.Sub(order.receivedAt) > optionOrderTTL {
+					delete(c.orders, k)
+				}
+			}
+		}
+		c.orders[key] = optionOrder{codes: codes, receivedAt: now}
+		c.mutex.Unlock()
+	}
+
+	return n, addr, err
+}
+
+// take 取出并删除指定报文的选项顺序
+func (c *optionOrderConn) take(req *dhcpv4.DHCPv4) []uint8 {
+	key := optionOrderKey(req.TransactionID[:], req.ClientHWAddr)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	order, ok := c.orders[key]
+	if !ok || time.Since(order.receivedAt) > optionOrderTTL {
+		return nil
+	}
+	delete(c.orders, key)
+	return order.codes
+}
+
+// parseOptionOrder 从原始报文中按出现顺序提取选项代码
+func parseOptionOrder(packet []byte) (string, []uint8, bool) {
+	if len(packet) <= dhcpOptionsOffset {
+		return "", nil, false
+	}
+	if packet[dhcpMagicCookieOffset] != 99 || packet[dhcpMagicCookieOffset+1] != 130 ||
+		packet[dhcpMagicCookieOffset+2] != 83 || packet[dhcpMagicCookieOffset+3] != 99 {
+		return "", nil, false
+	}
+
+	hlen := int(packet[2])
+	if hlen > 16 {
+		return "", nil, false
+	}
+	key := optionOrderKey(packet[4:8], net.HardwareAddr(packet[28:28+hlen]))
+
+	var codes []uint8
+	options := packet[dhcpOptionsOffset:]
+	for i := 0; i < len(options); {
+		code := options[i]
+		if code == 0 { // Pad
+			i++
+			continue
+		}
+		if code == 255 { // End
+			break
+		}
+		if i+1 >= len(options) {
+			break
+		}
+		codes = append(codes, code)
+		i += 2 + int(options[i+1])
+	}
+
+	return key, codes, len(codes) > 0
+}
+
+// optionOrderKey 以事务ID和客户端MAC标识报文
+func optionOrderKey(xid []byte, mac net.HardwareAddr) string {
+	return fmt.Sprintf("%x/%s", xid, mac)
+}
+
+// captureFingerprint 提取请求中的DHCP指纹
+func (s *Server) captureFingerprint(req *dhcpv4.DHCPv4) *fingerprint.Fingerprint {
+	fp := &fingerprint.Fingerprint{
+		VendorClass: req.ClassIdentifier(),
+		MessageType: req.MessageType().String(),
+		CapturedAt:  time.Now(),
+	}
+
+	params := req.ParameterRequestList()
+	codes := make([]uint8, len(params))
+	for i, param := range params {
+		codes[i] = param.Code()
+	}
+	fp.ParamList = fingerprint.FormatOptions(codes)
+
+	if s.rawConn != nil {
+		fp.OptionOrder = fingerprint.FormatOptions(s.rawConn.take(req))
+	}
+
+	return fp
+}
+
+// applyFingerprint 将指纹记录到租约并识别设备类型
+func (s *Server) applyFingerprint(lease *IPLease, fp *fingerprint.Fingerprint) {
+	if fp.IsEmpty() {
+		return
+	}
+	lease.Fingerprint = fp
+
+	match, ok := fingerprint.Classify(fp)
+	if !ok {
+		log.Printf("未识别的DHCP指纹: MAC=%s, 55=[%s], 60=%q", lease.MAC, fp.ParamList, fp.VendorClass)
+		return
+	}
+	lease.Classification = match
+	log.Printf("DHCP指纹识别: MAC=%s -> %s (%s %s, 规则: %s)",
+		lease.MAC, match.DeviceType, match.OS, match.Model, match.Rule)
+}
@@ -0,0 +1,299 @@
+package dhcp
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"dhcp-server/events"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+)
+
+const (
+	defaultPerMACRate      = 1.0
+	defaultPerMACBurst     = 5
+	defaultGlobalRate      = 50.0
+	defaultGlobalBurst     = 100
+	defaultOfferTimeout    = 30 * time.Second
+	defaultNewMACThreshold = 10
+
+	floodStateTTL         = 10 * time.Minute // 限速和新MAC记录的保留时间
+	floodMaxTracked       = 100000           // 记录的MAC数量上限，防止伪造MAC撑爆内存
+	starvationRealert     = 5 * time.Minute  // 同一来源重复告警的最小间隔
+	starvationSampleLimit = 10               // 告警中附带的MAC样本数量
+	maxStarvationAlerts   = 50
+)
+
+// tokenBucket 令牌桶限速器
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// allow 按速率补充令牌并尝试消耗一个
+func (b *tokenBucket) allow(now time.Time, rate float64, burst int) bool {
+	if b.last.IsZero() {
+		b.tokens = float64(burst)
+	} else {
+		b.tokens += now.Sub(b.last).Seconds() * rate
+		if b.tokens > float64(burst) {
+			b.tokens = float64(burst)
+		}
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// StarvationAlert 地址耗尽攻击告警
+type StarvationAlert struct {
+	Source     string    `json:"source"`      // 来源：Option 82电路ID、中继地址或本地端口
+	NewMACs    int       `json:"new_macs"`    // 一秒内出现的新MAC数量
+	SampleMACs []string  `json:"sample_macs"` // 部分新MAC样本
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// starvationSource 单个来源的新MAC统计
+type starvationSource struct {
+	windowStart time.Time
+	newMACs     int
+	samples     []string
+	lastAlert   time.Time
+}
+
+// floodGuard DISCOVER/REQUEST限速与地址耗尽攻击检测
+type floodGuard struct {
+	server *Server
+
+	mutex         sync.Mutex
+	global        tokenBucket
+	perMAC        map[string]*tokenBucket
+	seenMACs      map[string]time.Time
+	sources       map[string]*starvationSource
+	alerts        []StarvationAlert
+	droppedMAC    uint64
+	droppedGlobal uint64
+	lastCleanup   time.Time
+}
+
+// newFloodGuard 创建防护器
+func newFloodGuard(server *Server) *floodGuard {
+	return &floodGuard{
+		server:   server,
+		perMAC:   make(map[string]*tokenBucket),
+		seenMACs: make(map[string]time.Time),
+		sources:  make(map[string]*starvationSource),
+	}
+}
+
+// floodSource 确定报文来源：优先使用Option 82电路ID，其次是中继地址，直连客户端归为本地端口
+func floodSource(req *dhcpv4.DHCPv4, peer net.Addr) string {
+	if info := req.RelayAgentInfo(); info != nil {
+		if circuit := info.Get(dhcpv4.AgentCircuitIDSubOption); len(circuit) > 0 {
+			return "circuit:" + hex.EncodeToString(circuit)
+		}
+	}
+	if req.GatewayIPAddr != nil && !req.GatewayIPAddr.IsUnspecified() {
+		return "relay:" + req.GatewayIPAddr.String()
+	}
+	if udp, ok := peer.(*net.UDPAddr); ok && !udp.IP.IsUnspecified() {
+		return "peer:" + udp.IP.String()
+	}
+	return "local"
+}
+
+// allow 检查是否处理该DISCOVER/REQUEST，同时统计新MAC用于耗尽攻击检测
+func (fg *floodGuard) allow(req *dhcpv4.DHCPv4, peer net.Addr) bool {
+	if fg == nil {
+		return true
+	}
+	cfg := fg.server.config.Security.Flood
+	mac := req.ClientHWAddr.String()
+	now := time.Now()
+
+	fg.mutex.Lock()
+	defer fg.mutex.Unlock()
+
+	fg.cleanup(now)
+	fg.trackNewMAC(req, peer, mac, now)
+
+	perMACRate, perMACBurst := cfg.PerMACRate, cfg.PerMACBurst
+	if perMACRate <= 0 {
+		perMACRate = defaultPerMACRate
+	}
+	if perMACBurst <= 0 {
+		perMACBurst = defaultPerMACBurst
+	}
+	bucket, ok := fg.perMAC[mac]
+	if !ok {
+		bucket = &tokenBucket{}
+		fg.perMAC[mac] = bucket
+	}
+	if !bucket.allow(now, perMACRate, perMACBurst) {
+		fg.droppedMAC++
+		if fg.droppedMAC%100 == 1 {
+			log.Printf("客户端 %s 请求过于频繁，丢弃 %s（累计丢弃 %d）", mac, req.MessageType(), fg.droppedMAC)
+		}
+		return false
+	}
+
+	globalRate, globalBurst := cfg.GlobalRate, cfg.GlobalBurst
+	if globalRate <= 0 {
+		globalRate = defaultGlobalRate
+	}
+	if globalBurst <= 0 {
+		globalBurst = defaultGlobalBurst
+	}
+	if !fg.global.allow(now, globalRate, globalBurst) {
+		fg.droppedGlobal++
+		if fg.droppedGlobal%100 == 1 {
+			log.Printf("DHCP请求总量超过限速，丢弃 %s 的 %s（累计丢弃 %d）", mac, req.MessageType(), fg.droppedGlobal)
+		}
+		return false
+	}
+	return true
+}
+
+// trackNewMAC 统计每个来源一秒内出现的新MAC，超过阈值时告警（调用者持有锁）
+func (fg *floodGuard) trackNewMAC(req *dhcpv4.DHCPv4, peer net.Addr, mac string, now time.Time) {
+	if _, seen := fg.seenMACs[mac]; seen {
+		fg.seenMACs[mac] = now
+		return
+	}
+	fg.seenMACs[mac] = now
+	if _, hasLease := fg.server.pool.GetLeaseByMAC(mac); hasLease {
+		return
+	}
+
+	key := floodSource(req, peer)
+	source, ok := fg.sources[key]
+	if !ok {
+		source = &starvationSource{}
+		fg.sources[key] = source
+	}
+	if now.Sub(source.windowStart) >= time.Second {
+		source.windowStart = now
+		source.newMACs = 0
+		source.samples = nil
+	}
+	source.newMACs++
+	if len(source.samples) < starvationSampleLimit {
+		source.samples = append(source.samples, mac)
+	}
+
+	threshold := fg.server.config.Security.Flood.NewMACThreshold
+	if threshold <= 0 {
+		threshold = defaultNewMACThreshold
+	}
+	if source.newMACs <= threshold || now.Sub(source.lastAlert) < starvationRealert {
+		return
+	}
+	source.lastAlert = now
+
+	alert := StarvationAlert{
+		Source:     key,
+		NewMACs:    source.newMACs,
+		SampleMACs: append([]string(nil), source.samples...),
+		Timestamp:  now,
+	}
+	fg.alerts = append(fg.alerts, alert)
+	if len(fg.alerts) > maxStarvationAlerts {
+		fg.alerts = fg.alerts[len(fg.alerts)-maxStarvationAlerts:]
+	}
+
+	message := fmt.Sprintf("疑似DHCP地址耗尽攻击：来源 %s 一秒内出现 %d 个新MAC", key, source.newMACs)
+	log.Printf("警告: %s", message)
+	fg.server.addHistoryDetail("", mac, req.HostName(), "STARVATION_ALERT", "", message)
+	events.Publish(events.Event{
+		Type:     "security.dhcp_starvation",
+		Source:   "flood_guard",
+		Severity: events.SeverityCritical,
+		Message:  message,
+		Data: map[string]interface{}{
+			"source":      key,
+			"new_macs":    source.newMACs,
+			"sample_macs": alert.SampleMACs,
+			"threshold":   threshold,
+		},
+	})
+}
+
+// cleanup 定期清理过期的限速和新MAC记录（调用者持有锁）
+func (fg *floodGuard) cleanup(now time.Time) {
+	if now.Sub(fg.lastCleanup) < time.Minute && len(fg.seenMACs) < floodMaxTracked {
+		return
+	}
+	fg.lastCleanup = now
+
+	for mac, lastSeen := range fg.seenMACs {
+		if now.Sub(lastSeen) > floodStateTTL {
+			delete(fg.seenMACs, mac)
+		}
+	}
+	for mac, bucket := range fg.perMAC {
+		if now.Sub(bucket.last) > floodStateTTL {
+			delete(fg.perMAC, mac)
+		}
+	}
+	for key, source := range fg.sources {
+		if now.Sub(source.windowStart) > floodStateTTL && now.Sub(source.lastAlert) > starvationRealert {
+			delete(fg.sources, key)
+		}
+	}
+
+	// 伪造MAC过多时直接清空，宁可少统计也不能耗尽内存
+	if len(fg.seenMACs) >= floodMaxTracked {
+		fg.seenMACs = make(map[string]time.Time)
+	}
+	if len(fg.perMAC) >= floodMaxTracked {
+		fg.perMAC = make(map[string]*tokenBucket)
+	}
+}
+
+// offerLimits 获取Offer预留时间和待确认上限
+func (s *Server) offerLimits() (time.Duration, int) {
+	cfg := s.config.Security.Flood
+	ttl := cfg.OfferTimeout
+	if ttl <= 0 {
+		ttl = defaultOfferTimeout
+	}
+	maxPending := cfg.MaxPendingOffers
+	if maxPending <= 0 {
+		maxPending = s.pool.Size() / 4
+		if maxPending < 1 {
+			maxPending = 1
+		}
+	}
+	return ttl, maxPending
+}
+
+// FloodProtectionStatus 获取限速和耗尽攻击检测状态
+func (s *Server) FloodProtectionStatus() map[string]interface{} {
+	status := map[string]interface{}{
+		"enabled":        s.flood != nil,
+		"pending_offers": s.pool.PendingOffers(),
+	}
+	if s.flood == nil {
+		return status
+	}
+
+	ttl, maxPending := s.offerLimits()
+	status["offer_timeout"] = ttl.String()
+	status["max_pending_offers"] = maxPending
+
+	s.flood.mutex.Lock()
+	defer s.flood.mutex.Unlock()
+	status["dropped_per_mac"] = s.flood.droppedMAC
+	status["dropped_global"] = s.flood.droppedGlobal
+	status["tracked_macs"] = len(s.flood.seenMACs)
+	status["alerts"] = append([]StarvationAlert{}, s.flood.alerts...)
+	return status
+}
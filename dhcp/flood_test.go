@@ -0,0 +1,159 @@
+package dhcp
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+
+	"dhcp-server/config"
+	"dhcp-server/events"
+)
+
+func TestTokenBucket(t *testing.T) {
+	var bucket tokenBucket
+	now := time.Now()
+
+	// 首次使用时装满突发上限
+	for i := 0; i < 3; i++ {
+		if !bucket.allow(now, 1, 3) {
+			t.Fatalf("第 %d 次请求应在突发上限内", i+1)
+		}
+	}
+	if bucket.allow(now, 1, 3) {
+		t.Fatal("超过突发上限应拒绝")
+	}
+
+	// 按速率补充令牌
+	if bucket.allow(now.Add(500*time.Millisecond), 1, 3) {
+		t.Error("不足一个令牌时应拒绝")
+	}
+	if !bucket.allow(now.Add(1500*time.Millisecond), 1, 3) {
+		t.Error("补充一个令牌后应允许")
+	}
+
+	// 长时间空闲后令牌不超过突发上限
+	later := now.Add(time.Hour)
+	allowed := 0
+	for i := 0; i < 10; i++ {
+		if bucket.allow(later, 1, 3) {
+			allowed++
+		}
+	}
+	if allowed != 3 {
+		t.Errorf("空闲后应最多允许突发上限 3 次, 实际为 %d", allowed)
+	}
+}
+
+func TestFloodGuardPerMAC(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.Security.Flood = config.FloodProtectionConfig{Enabled: true, PerMACRate: 0.001, PerMACBurst: 2}
+	})
+
+	discover := newTestPacket(t, dhcpv4.MessageTypeDiscover, "aa:bb:cc:40:00:01")
+	for i := 0; i < 2; i++ {
+		if !s.flood.allow(discover, nil) {
+			t.Fatalf("第 %d 个请求应在突发上限内", i+1)
+		}
+	}
+	if s.flood.allow(discover, nil) {
+		t.Error("同一MAC超过突发上限应丢弃")
+	}
+	if !s.flood.allow(newTestPacket(t, dhcpv4.MessageTypeDiscover, "aa:bb:cc:40:00:02"), nil) {
+		t.Error("其他MAC不应受影响")
+	}
+	if status := s.FloodProtectionStatus(); status["dropped_per_mac"] != uint64(1) {
+		t.Errorf("应记录1次丢弃, 实际为 %v", status["dropped_per_mac"])
+	}
+}
+
+func TestPendingOfferLimit(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.Security.Flood = config.FloodProtectionConfig{Enabled: true, OfferTimeout: time.Minute, MaxPendingOffers: 2}
+	})
+
+	first, _ := s.handleDiscover(newTestPacket(t, dhcpv4.MessageTypeDiscover, "aa:bb:cc:40:01:01"))
+	second, _ := s.handleDiscover(newTestPacket(t, dhcpv4.MessageTypeDiscover, "aa:bb:cc:40:01:02"))
+	if first == nil || second == nil {
+		t.Fatal("未达到上限时应提供地址")
+	}
+	if lt := first.IPAddressLeaseTime(0); lt != time.Minute {
+		t.Errorf("预留的Offer租期应为Offer预留时间, 实际为 %v", lt)
+	}
+	if resp, _ := s.handleDiscover(newTestPacket(t, dhcpv4.MessageTypeDiscover, "aa:bb:cc:40:01:03")); resp != nil {
+		t.Fatal("待确认的Offer达到上限时不应再提供地址")
+	}
+	if _, ok := s.pool.GetLeaseByMAC("aa:bb:cc:40:01:03"); ok {
+		t.Error("超过上限的预留不应留在地址池中")
+	}
+
+	// 客户端确认后转为正式租约，释放待确认名额
+	ack, _ := s.handleRequest(requestFor(t, "aa:bb:cc:40:01:01", first.YourIPAddr))
+	if ack == nil || ack.MessageType() != dhcpv4.MessageTypeAck || ack.IPAddressLeaseTime(0) != 24*time.Hour {
+		t.Fatalf("确认预留应收到完整租期的ACK, 实际为 %v", ack)
+	}
+	lease, _ := s.pool.GetLeaseByMAC("aa:bb:cc:40:01:01")
+	if lease.Offered || s.pool.PendingOffers() != 1 {
+		t.Fatalf("确认后应转为正式租约, 待确认数量为 %d", s.pool.PendingOffers())
+	}
+	if resp, _ := s.handleDiscover(newTestPacket(t, dhcpv4.MessageTypeDiscover, "aa:bb:cc:40:01:03")); resp == nil {
+		t.Fatal("确认后应释放待确认名额")
+	}
+
+	// 超时未确认的预留不再占用名额
+	pending, _ := s.pool.GetLeaseByMAC("aa:bb:cc:40:01:02")
+	s.pool.mutex.Lock()
+	pending.StartTime = time.Now().Add(-2 * time.Minute)
+	s.pool.mutex.Unlock()
+	if s.pool.PendingOffers() != 1 {
+		t.Errorf("过期的预留不应计入待确认数量, 实际为 %d", s.pool.PendingOffers())
+	}
+	if resp, _ := s.handleDiscover(newTestPacket(t, dhcpv4.MessageTypeDiscover, "aa:bb:cc:40:01:04")); resp == nil {
+		t.Error("预留过期后应可以提供新地址")
+	}
+}
+
+func TestStarvationAlert(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.Security.Flood = config.FloodProtectionConfig{Enabled: true, NewMACThreshold: 3}
+	})
+	relay := func(i int) *dhcpv4.DHCPv4 {
+		return newTestPacket(t, dhcpv4.MessageTypeDiscover, fmt.Sprintf("aa:bb:cc:40:02:%02x", i),
+			dhcpv4.WithGatewayIP(net.ParseIP("10.0.0.1")))
+	}
+	alerts := func() []StarvationAlert {
+		return s.FloodProtectionStatus()["alerts"].([]StarvationAlert)
+	}
+
+	// 未超过阈值不告警，重复出现的MAC不计为新MAC
+	for i := 0; i < 3; i++ {
+		s.flood.allow(relay(i), nil)
+		s.flood.allow(relay(i), nil)
+	}
+	if len(alerts()) != 0 {
+		t.Fatalf("未超过阈值不应告警: %+v", alerts())
+	}
+
+	s.flood.allow(relay(3), nil)
+	got := alerts()
+	if len(got) != 1 || got[0].Source != "relay:10.0.0.1" || got[0].NewMACs != 4 || len(got[0].SampleMACs) != 4 {
+		t.Fatalf("超过阈值应按来源告警: %+v", got)
+	}
+	published := false
+	for _, event := range events.Recent(100, "security.dhcp_starvation") {
+		if event.Data["source"] == "relay:10.0.0.1" {
+			published = true
+		}
+	}
+	if !published {
+		t.Error("告警应发布安全事件")
+	}
+
+	// 同一来源在重复告警间隔内不再告警
+	s.flood.allow(relay(4), nil)
+	if len(alerts()) != 1 {
+		t.Errorf("重复告警间隔内不应再次告警, 实际为 %d 条", len(alerts()))
+	}
+}
@@ -0,0 +1,203 @@
+package dhcp
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+
+	"dhcp-server/config"
+)
+
+// FORCERENEW相关常量（RFC 3203 / RFC 3118 / RFC 6704）
+const (
+	MessageTypeForceRenew dhcpv4.MessageType = 9
+
+	optionForceRenewNonceCapable = dhcpv4.GenericOptionCode(145)
+
+	authProtocolForceRenewNonce = 3 // RFC 6704 Forcerenew Nonce Authentication
+	authAlgorithmHMACMD5        = 1
+	authRDMMonotonic            = 0
+
+	authInfoNonceValue = 1 // ACK中携带的随机数
+	authInfoHMACDigest = 2 // FORCERENEW中携带的HMAC-MD5摘要
+
+	forceRenewNonceSize  = 16
+	authOptionHeaderSize = 11 // protocol(1) + algorithm(1) + rdm(1) + replay detection(8)
+
+	defaultForceRenewRetries  = 3
+	defaultForceRenewInterval = 4 * time.Second
+)
+
+// isForceRenewCapable 检查客户端是否声明支持HMAC-MD5的FORCERENEW随机数认证
+func isForceRenewCapable(req *dhcpv4.DHCPv4) bool {
+	algorithms := req.Options.Get(optionForceRenewNonceCapable)
+	for _, algorithm := range algorithms {
+		if algorithm == authAlgorithmHMACMD5 {
+			return true
+		}
+	}
+	return false
+}
+
+// nextReplayCounter 获取单调递增的重放检测计数器
+func (s *Server) nextReplayCounter() uint64 {
+	return atomic.AddUint64(&s.replayCounter, 1)
+}
+
+// buildAuthOption 构建认证选项（option 90）
+func (s *Server) buildAuthOption(infoType byte, info []byte) dhcpv4.Option {
+	data := make([]byte, authOptionHeaderSize+1+len(info))
+	data[0] = authProtocolForceRenewNonce
+	data[1] = authAlgorithmHMACMD5
+	data[2] = authRDMMonotonic
+	binary.BigEndian.PutUint64(data[3:11], s.nextReplayCounter())
+	data[11] = infoType
+	copy(data[12:], info)
+	return dhcpv4.OptGeneric(dhcpv4.OptionAuthentication, data)
+}
+
+// prepareForceRenew 根据客户端能力更新租约，并在ACK中下发认证随机数
+func (s *Server) prepareForceRenew(req, resp *dhcpv4.DHCPv4, lease *IPLease) {
+	if !s.config.ForceRenew.Enabled || lease == nil {
+		return
+	}
+
+	// 租约同时被续租和推送FORCERENEW的协程读取，通过地址池在锁内修改
+	ack := resp.MessageType() == dhcpv4.MessageTypeAck
+	nonce, err := s.pool.UpdateForceRenew(lease, isForceRenewCapable(req), ack)
+	if err != nil {
+		log.Printf("生成FORCERENEW随机数失败: %v", err)
+		return
+	}
+	if nonce != nil {
+		resp.UpdateOption(s.buildAuthOption(authInfoNonceValue, nonce))
+	}
+}
+
+// forceRenewLeaseTime 计算实际下发的租期，不支持FORCERENEW的客户端使用短租期
+func (s *Server) forceRenewLeaseTime(lease *IPLease, leaseTime time.Duration) time.Duration {
+	fallback := s.config.ForceRenew.FallbackLeaseTime
+	if !s.config.ForceRenew.Enabled || fallback <= 0 || s.pool.ForceRenewCapable(lease) {
+		return leaseTime
+	}
+	if leaseTime > fallback {
+		return fallback
+	}
+	return leaseTime
+}
+
+// handleGatewayStatusChange 网关变为不健康时，向使用该网关的客户端推送FORCERENEW
+func (s *Server) handleGatewayStatusChange(gw config.Gateway, healthy bool) {
+	if healthy || !s.config.ForceRenew.Enabled {
+		return
+	}
+
+	var affected []*IPLease
+	for _, lease := range s.pool.GetActiveLeases() {
+		if lease.GatewayIP == gw.IP {
+			affected = append(affected, lease)
+		}
+	}
+
+	if len(affected) == 0 {
+		return
+	}
+
+	log.Printf("网关 %s (%s) 不健康，向 %d 个客户端推送FORCERENEW", gw.Name, gw.IP, len(affected))
+	for _, lease := range affected {
+		if _, ok := s.pool.ForceRenewNonce(lease); !ok {
+			log.Printf("客户端 %s (%s) 不支持FORCERENEW，等待其在短租期内续租", lease.MAC, lease.IP)
+			continue
+		}
+		go s.forceRenewWithRetry(lease)
+	}
+}
+
+// forceRenewWithRetry 发送FORCERENEW，客户端未续租时按配置重发
+func (s *Server) forceRenewWithRetry(lease *IPLease) {
+	retries := s.config.ForceRenew.RetryCount
+	if retries <= 0 {
+		retries = defaultForceRenewRetries
+	}
+	interval := s.config.ForceRenew.RetryInterval
+	if interval <= 0 {
+		interval = defaultForceRenewInterval
+	}
+
+	sentAt := time.Now()
+	for attempt := 1; attempt <= retries; attempt++ {
+		if err := s.SendForceRenew(lease); err != nil {
+			log.Printf("发送FORCERENEW到 %s (%s) 失败: %v", lease.MAC, lease.IP, err)
+			return
+		}
+		s.addHistory(lease.IP.String(), lease.MAC, lease.Hostname, "FORCERENEW", "")
+
+		time.Sleep(interval)
+		current, renewed := s.pool.LeaseRenewedSince(lease, sentAt)
+		if !current {
+			log.Printf("客户端 %s (%s) 的租约已被收回或替换，停止FORCERENEW", lease.MAC, lease.IP)
+			return
+		}
+		if renewed {
+			log.Printf("客户端 %s (%s) 已响应FORCERENEW完成续租", lease.MAC, lease.IP)
+			return
+		}
+	}
+
+	log.Printf("客户端 %s (%s) 在 %d 次FORCERENEW后仍未续租", lease.MAC, lease.IP, retries)
+}
+
+// SendForceRenew 向指定租约的客户端单播发送带认证的FORCERENEW消息
+func (s *Server) SendForceRenew(lease *IPLease) error {
+	if s.conn == nil {
+		return fmt.Errorf("DHCP服务器未启动")
+	}
+	nonce, ok := s.pool.ForceRenewNonce(lease)
+	if !ok {
+		return fmt.Errorf("客户端 %s 没有FORCERENEW认证随机数", lease.MAC)
+	}
+
+	hwAddr, err := net.ParseMAC(lease.MAC)
+	if err != nil {
+		return fmt.Errorf("无效的MAC地址: %s", lease.MAC)
+	}
+
+	msg, err := dhcpv4.New(
+		dhcpv4.WithHwAddr(hwAddr),
+		dhcpv4.WithClientIP(lease.IP),
+		dhcpv4.WithMessageType(MessageTypeForceRenew),
+		dhcpv4.WithServerIP(s.getServerIP()),
+		dhcpv4.WithOption(dhcpv4.OptServerIdentifier(s.getServerIP())),
+	)
+	if err != nil {
+		return fmt.Errorf("创建FORCERENEW消息失败: %v", err)
+	}
+	msg.OpCode = dhcpv4.OpcodeBootReply
+
+	// 先以全零摘要序列化消息计算HMAC，再写入真实摘要（RFC 3118 第5.2节）
+	auth := s.buildAuthOption(authInfoHMACDigest, make([]byte, md5.Size))
+	msg.UpdateOption(auth)
+
+	mac := hmac.New(md5.New, nonce)
+	mac.Write(msg.ToBytes())
+	digest := mac.Sum(nil)
+
+	authData := auth.Value.ToBytes()
+	copy(authData[authOptionHeaderSize+1:], digest)
+	msg.UpdateOption(dhcpv4.OptGeneric(dhcpv4.OptionAuthentication, authData))
+
+	peer := &net.UDPAddr{IP: lease.IP, Port: dhcpv4.ClientPort}
+	if _, err := s.conn.WriteTo(msg.ToBytes(), peer); err != nil {
+		return err
+	}
+
+	log.Printf("发送FORCERENEW: MAC=%s, IP=%s", lease.MAC, lease.IP)
+	return nil
+}
@@ -0,0 +1,72 @@
+package dhcp
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+
+	"dhcp-server/config"
+)
+
+func TestPrepareForceRenew(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.ForceRenew = config.ForceRenewConfig{Enabled: true, FallbackLeaseTime: time.Hour}
+	})
+	const mac = "aa:bb:cc:26:00:01"
+	lease, err := s.pool.RequestIP(mac, nil, "forcerenew-client")
+	if err != nil {
+		t.Fatalf("分配IP失败: %v", err)
+	}
+	capable := dhcpv4.WithOption(dhcpv4.OptGeneric(optionForceRenewNonceCapable, []byte{authAlgorithmHMACMD5}))
+	ack := func(modifiers ...dhcpv4.Modifier) *dhcpv4.DHCPv4 {
+		req := newTestPacket(t, dhcpv4.MessageTypeRequest, mac, modifiers...)
+		resp, err := dhcpv4.NewReplyFromRequest(req, dhcpv4.WithMessageType(dhcpv4.MessageTypeAck))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.prepareForceRenew(req, resp, lease)
+		return resp
+	}
+
+	// 续租、推送FORCERENEW和处理REQUEST同时访问租约
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				ack(capable)
+				s.pool.RenewLease(lease)
+				s.pool.ForceRenewNonce(lease)
+				s.forceRenewLeaseTime(lease, 24*time.Hour)
+			}
+		}()
+	}
+	wg.Wait()
+
+	nonce, ok := s.pool.ForceRenewNonce(lease)
+	if !ok || len(nonce) != forceRenewNonceSize {
+		t.Fatalf("支持FORCERENEW的客户端应获得认证随机数: %x", nonce)
+	}
+	auth := ack(capable).Options.Get(dhcpv4.OptionAuthentication)
+	if !bytes.HasSuffix(auth, nonce) {
+		t.Error("ACK应携带同一个认证随机数")
+	}
+	if lt := s.forceRenewLeaseTime(lease, 24*time.Hour); lt != 24*time.Hour {
+		t.Errorf("支持FORCERENEW的客户端应使用完整租期, 实际为 %v", lt)
+	}
+
+	// 客户端不再声明支持时停止推送并使用短租期
+	if resp := ack(); resp.Options.Has(dhcpv4.OptionAuthentication) {
+		t.Error("不支持FORCERENEW的客户端不应收到认证随机数")
+	}
+	if _, ok := s.pool.ForceRenewNonce(lease); ok {
+		t.Error("客户端不再支持FORCERENEW时不应推送")
+	}
+	if lt := s.forceRenewLeaseTime(lease, 24*time.Hour); lt != time.Hour {
+		t.Errorf("不支持FORCERENEW的客户端应使用短租期, 实际为 %v", lt)
+	}
+}
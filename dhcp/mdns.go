@@ -0,0 +1,161 @@
+package dhcp
+
+import (
+	"net"
+	"strings"
+	"time"
+)
+
+var mdnsGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+const (
+	mdnsQuestionsPerPacket = 16
+	mdnsDeviceInfoService  = "_device-info._tcp.local"
+)
+
+// queryMDNS 通过mDNS反向解析查询主机名，并查询_device-info获取型号
+// 查询从临时端口发出，响应方按RFC 6762 6.7节以单播方式回复
+func (nd *nameDiscovery) queryMDNS(ips []net.IP, timeout time.Duration) map[string]nameRecord {
+	results := make(map[string]nameRecord)
+
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: nd.localIP()})
+	if err != nil {
+		nd.logf("mDNS查询失败: %v", err)
+		return results
+	}
+	defer conn.Close()
+
+	// 第一轮：反向PTR查询获取主机名
+	for start := 0; start < len(ips); start += mdnsQuestionsPerPacket {
+		end := start + mdnsQuestionsPerPacket
+		if end > len(ips) {
+			end = len(ips)
+		}
+		questions := make([]dnsQuestion, 0, end-start)
+		for _, ip := range ips[start:end] {
+			questions = append(questions, dnsQuestion{Name: reverseName(ip), Type: dnsTypePTR, Class: dnsClassIN})
+		}
+		conn.WriteTo(buildDNSQuery(0, questions), mdnsGroup)
+	}
+
+	hostIPs := make(map[string]string) // 主机名 -> IP
+	nd.readMDNS(conn, timeout/2, func(records []dnsRecord, from net.IP) {
+		for _, record := range nd.handleMDNSRecords(records, from) {
+			results[record.ip] = mergeNameRecord(results[record.ip], record.nameRecord)
+			if record.Hostname != "" {
+				hostIPs[record.Hostname] = record.ip
+			}
+		}
+	})
+
+	if len(hostIPs) == 0 {
+		return results
+	}
+
+	// 第二轮：查询_device-info获取型号（Apple等设备会发布）
+	var questions []dnsQuestion
+	for host := range hostIPs {
+		questions = append(questions, dnsQuestion{
+			Name:  mdnsInstanceName(host) + "." + mdnsDeviceInfoService,
+			Type:  dnsTypeTXT,
+			Class: dnsClassIN,
+		})
+	}
+	for start := 0; start < len(questions); start += mdnsQuestionsPerPacket {
+		end := start + mdnsQuestionsPerPacket
+		if end > len(questions) {
+			end = len(questions)
+		}
+		conn.WriteTo(buildDNSQuery(0, questions[start:end]), mdnsGroup)
+	}
+
+	nd.readMDNS(conn, timeout/2, func(records []dnsRecord, from net.IP) {
+		for _, record := range nd.handleMDNSRecords(records, from) {
+			results[record.ip] = mergeNameRecord(results[record.ip], record.nameRecord)
+		}
+	})
+
+	return results
+}
+
+// readMDNS 在超时时间内读取mDNS应答
+func (nd *nameDiscovery) readMDNS(conn *net.UDPConn, timeout time.Duration, handle func([]dnsRecord, net.IP)) {
+	deadline := time.Now().Add(timeout)
+	conn.SetReadDeadline(deadline)
+	buf := make([]byte, 9000)
+	for time.Now().Before(deadline) {
+		n, addr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		records, _ := parseDNSMessage(buf[:n])
+		if len(records) > 0 {
+			handle(records, addr.IP)
+		}
+	}
+}
+
+// ipNameRecord 与IP关联的名称记录
+type ipNameRecord struct {
+	nameRecord
+	ip string
+}
+
+// handleMDNSRecords 从mDNS记录中提取IP与主机名、型号的对应关系
+func (nd *nameDiscovery) handleMDNSRecords(records []dnsRecord, from net.IP) []ipNameRecord {
+	var result []ipNameRecord
+	hostIPs := make(map[string]string)
+
+	for _, record := range records {
+		switch record.Type {
+		case dnsTypePTR:
+			if ip := parseReverseName(record.Name); ip != nil && record.Target != "" {
+				host := strings.TrimSuffix(record.Target, ".")
+				hostIPs[mdnsInstanceName(host)] = ip.String()
+				result = append(result, ipNameRecord{
+					nameRecord: nameRecord{Hostname: host, Source: nameSourceMDNS, SeenAt: time.Now()},
+					ip:         ip.String(),
+				})
+			}
+		case dnsTypeA:
+			if record.IP != nil && strings.HasSuffix(strings.ToLower(record.Name), ".local") {
+				hostIPs[mdnsInstanceName(record.Name)] = record.IP.String()
+				result = append(result, ipNameRecord{
+					nameRecord: nameRecord{Hostname: record.Name, Source: nameSourceMDNS, SeenAt: time.Now()},
+					ip:         record.IP.String(),
+				})
+			}
+		}
+	}
+
+	// TXT记录中的model字段，如 model=MacBookPro18,3
+	for _, record := range records {
+		if record.Type != dnsTypeTXT || !strings.HasSuffix(strings.ToLower(record.Name), mdnsDeviceInfoService) {
+			continue
+		}
+		instance := strings.TrimSuffix(record.Name, "."+mdnsDeviceInfoService)
+		ip, ok := hostIPs[instance]
+		if !ok && from != nil {
+			ip = from.String()
+		}
+		for _, text := range record.Text {
+			if strings.HasPrefix(strings.ToLower(text), "model=") {
+				result = append(result, ipNameRecord{
+					nameRecord: nameRecord{Model: text[len("model="):], Source: nameSourceMDNS, SeenAt: time.Now()},
+					ip:         ip,
+				})
+			}
+		}
+	}
+
+	return result
+}
+
+// mdnsInstanceName 去除.local后缀得到实例名
+func mdnsInstanceName(host string) string {
+	host = strings.TrimSuffix(host, ".")
+	if strings.HasSuffix(strings.ToLower(host), ".local") {
+		host = host[:len(host)-len(".local")]
+	}
+	return host
+}
@@ -0,0 +1,84 @@
+package dhcp
+
+import (
+	"strings"
+	"time"
+
+	"dhcp-server/metrics"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+)
+
+// DHCP消息处理结果
+const (
+	resultOffer       = "offer"
+	resultAck         = "ack"
+	resultNak         = "nak"
+	resultDropped     = "dropped"     // 被限速丢弃
+	resultIgnored     = "ignored"     // 处理后未响应，如访问控制拒绝或不是发给本服务器的请求
+	resultProcessed   = "processed"   // 无需响应的消息已处理，如RELEASE、DECLINE
+	resultError       = "error"       // 处理出错
+	resultSendError   = "send_error"  // 发送响应失败
+	resultUnsupported = "unsupported" // 不支持的消息类型
+)
+
+// scanDurationBuckets 扫描耗时直方图分桶（秒）
+var scanDurationBuckets = []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800}
+
+var (
+	dhcpMessages = metrics.NewCounterVec("dhcp_messages_total",
+		"按消息类型和处理结果统计的DHCP消息数", "type", "result")
+	dhcpHandlerDuration = metrics.NewHistogramVec("dhcp_handler_duration_seconds",
+		"DHCP消息处理耗时", nil, "type")
+
+	scannerScans = metrics.NewCounterVec("dhcp_scanner_scans_total",
+		"按扫描方案和结束状态统计的扫描任务数", "profile", "status")
+	scannerDuration = metrics.NewHistogramVec("dhcp_scanner_duration_seconds",
+		"扫描任务耗时", scanDurationBuckets, "profile")
+	scannerFoundDevices = metrics.NewGaugeVec("dhcp_scanner_found_devices",
+		"各扫描方案最近一次完成的扫描发现的设备数", "profile")
+	scannerLastScan = metrics.NewGaugeVec("dhcp_scanner_last_scan_timestamp_seconds",
+		"各扫描方案最近一次完成扫描的时间（Unix秒）", "profile")
+)
+
+// messageTypeLabel DHCP消息类型的指标标签，如 discover、request
+func messageTypeLabel(t dhcpv4.MessageType) string {
+	name := strings.ToLower(t.String())
+	if strings.HasPrefix(name, "unknown") {
+		return "unknown"
+	}
+	return name
+}
+
+// responseResult 根据响应消息类型确定处理结果
+func responseResult(response *dhcpv4.DHCPv4, fallback string) string {
+	if response == nil {
+		return fallback
+	}
+	switch response.MessageType() {
+	case dhcpv4.MessageTypeOffer:
+		return resultOffer
+	case dhcpv4.MessageTypeAck:
+		return resultAck
+	case dhcpv4.MessageTypeNak:
+		return resultNak
+	}
+	return messageTypeLabel(response.MessageType())
+}
+
+// observeMessage 记录一条DHCP消息的处理结果和耗时
+func observeMessage(t dhcpv4.MessageType, result string, start time.Time) {
+	label := messageTypeLabel(t)
+	dhcpMessages.Inc(label, result)
+	dhcpHandlerDuration.Observe(time.Since(start).Seconds(), label)
+}
+
+// observeScan 记录一次已开始执行的扫描任务的结果
+func observeScan(profile, status string, duration time.Duration, found int, finishedAt time.Time) {
+	scannerScans.Inc(profile, status)
+	scannerDuration.Observe(duration.Seconds(), profile)
+	if status == ScanJobCompleted {
+		scannerFoundDevices.Set(float64(found), profile)
+		scannerLastScan.Set(float64(finishedAt.Unix()), profile)
+	}
+}
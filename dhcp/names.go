@@ -0,0 +1,429 @@
+package dhcp
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 名称来源
+const (
+	nameSourceDNS     = "dns"
+	nameSourceMDNS    = "mdns"
+	nameSourceNetBIOS = "netbios"
+	nameSourceSSDP    = "ssdp"
+
+	defaultNameTimeout  = 2 * time.Second
+	passiveNameTTL      = 24 * time.Hour
+	passiveExpireEvery  = time.Minute // 被动监听记录的清理间隔
+	descriptionFetchMax = 8           // 并发获取UPnP描述的上限
+)
+
+// hostnamePriority 主机名来源优先级，靠前的优先
+var hostnamePriority = []string{nameSourceDNS, nameSourceMDNS, nameSourceNetBIOS, nameSourceSSDP}
+
+// modelPriority 型号来源优先级
+var modelPriority = []string{nameSourceMDNS, nameSourceSSDP}
+
+// nameRecord 单个来源发现的名称信息
+type nameRecord struct {
+	Hostname     string
+	Model        string
+	Manufacturer string
+	Workgroup    string
+	Source       string
+	SeenAt       time.Time
+}
+
+// mergeNameRecord 用新记录补全旧记录中为空的字段
+func mergeNameRecord(old, record nameRecord) nameRecord {
+	if old.Source == "" {
+		return record
+	}
+	if old.Hostname == "" {
+		old.Hostname = record.Hostname
+	}
+	if old.Model == "" {
+		old.Model = record.Model
+	}
+	if old.Manufacturer == "" {
+		old.Manufacturer = record.Manufacturer
+	}
+	if old.Workgroup == "" {
+		old.Workgroup = record.Workgroup
+	}
+	if record.SeenAt.After(old.SeenAt) {
+		old.SeenAt = record.SeenAt
+	}
+	return old
+}
+
+// passiveLocation 被动监听到的UPnP描述地址
+type passiveLocation struct {
+	URL    string
+	SeenAt time.Time
+}
+
+// nameDiscovery 基于mDNS、NetBIOS和SSDP的设备名称发现
+type nameDiscovery struct {
+	iface      string
+	logf       func(format string, args ...interface{})
+	mutex      sync.Mutex
+	passive    map[string]map[string]nameRecord // IP -> 来源 -> 被动监听到的记录
+	locations  map[string]passiveLocation       // IP -> 被动监听到的UPnP描述地址
+	lastExpire time.Time                        // 上次清理过期记录的时间
+}
+
+// newNameDiscovery 创建名称发现器
+func newNameDiscovery(iface string, logf func(format string, args ...interface{})) *nameDiscovery {
+	return &nameDiscovery{
+		iface:     iface,
+		logf:      logf,
+		passive:   make(map[string]map[string]nameRecord),
+		locations: make(map[string]passiveLocation),
+	}
+}
+
+// localIP 返回DHCP接口的IPv4地址，组播查询从该地址发出
+func (nd *nameDiscovery) localIP() net.IP {
+	iface, err := net.InterfaceByName(nd.iface)
+	if err != nil {
+		return nil
+	}
+	ip, err := interfaceIPv4(iface)
+	if err != nil {
+		return nil
+	}
+	return ip
+}
+
+// startPassive 启动mDNS和SSDP组播监听，收到stop信号后关闭
+func (nd *nameDiscovery) startPassive(sources map[string]bool, stop <-chan struct{}, wg *sync.WaitGroup) {
+	iface, _ := net.InterfaceByName(nd.iface)
+
+	var conns []*net.UDPConn
+	if sources[nameSourceMDNS] {
+		if conn, err := net.ListenMulticastUDP("udp4", iface, mdnsGroup); err != nil {
+			nd.logf("mDNS被动监听不可用: %v", err)
+		} else {
+			conns = append(conns, conn)
+			wg.Add(1)
+			go nd.passiveLoop(conn, wg, nd.handlePassiveMDNS)
+		}
+	}
+	if sources[nameSourceSSDP] {
+		if conn, err := net.ListenMulticastUDP("udp4", iface, ssdpGroup); err != nil {
+			nd.logf("SSDP被动监听不可用: %v", err)
+		} else {
+			conns = append(conns, conn)
+			wg.Add(1)
+			go nd.passiveLoop(conn, wg, nd.handlePassiveSSDP)
+		}
+	}
+
+	if len(conns) == 0 {
+		return
+	}
+	go func() {
+		<-stop
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+}
+
+// passiveLoop 读取组播报文直到连接关闭
+func (nd *nameDiscovery) passiveLoop(conn *net.UDPConn, wg *sync.WaitGroup, handle func([]byte, net.IP)) {
+	defer wg.Done()
+	buf := make([]byte, 9000)
+	for {
+		n, addr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		handle(buf[:n], addr.IP)
+	}
+}
+
+// handlePassiveMDNS 记录mDNS通告中的主机名和型号
+func (nd *nameDiscovery) handlePassiveMDNS(packet []byte, from net.IP) {
+	// 只处理应答报文（QR位）
+	if len(packet) < dnsHeaderLen || packet[2]&0x80 == 0 {
+		return
+	}
+	records, _ := parseDNSMessage(packet)
+	if len(records) == 0 {
+		return
+	}
+
+	nd.mutex.Lock()
+	defer nd.mutex.Unlock()
+	nd.expirePassive(time.Now(), false)
+	for _, record := range nd.handleMDNSRecords(records, from) {
+		if record.ip == "" {
+			continue
+		}
+		if nd.passive[record.ip] == nil {
+			nd.passive[record.ip] = make(map[string]nameRecord)
+		}
+		// 通告中的新信息覆盖旧信息
+		nd.passive[record.ip][nameSourceMDNS] = mergeNameRecord(record.nameRecord, nd.passive[record.ip][nameSourceMDNS])
+	}
+}
+
+// handlePassiveSSDP 记录SSDP NOTIFY中的描述文件地址
+func (nd *nameDiscovery) handlePassiveSSDP(packet []byte, from net.IP) {
+	if location := parseSSDPLocation(packet); location != "" {
+		now := time.Now()
+		nd.mutex.Lock()
+		nd.expirePassive(now, false)
+		nd.locations[from.String()] = passiveLocation{URL: location, SeenAt: now}
+		nd.mutex.Unlock()
+	}
+}
+
+// expirePassive 清理超过passiveNameTTL的被动监听记录，非强制时每passiveExpireEvery最多执行一次
+// （内部方法，调用者持有锁）
+func (nd *nameDiscovery) expirePassive(now time.Time, force bool) {
+	if !force && now.Sub(nd.lastExpire) < passiveExpireEvery {
+		return
+	}
+	nd.lastExpire = now
+
+	for ip, bySource := range nd.passive {
+		for source, record := range bySource {
+			if now.Sub(record.SeenAt) > passiveNameTTL {
+				delete(bySource, source)
+			}
+		}
+		if len(bySource) == 0 {
+			delete(nd.passive, ip)
+		}
+	}
+	for ip, location := range nd.locations {
+		if now.Sub(location.SeenAt) > passiveNameTTL {
+			delete(nd.locations, ip)
+		}
+	}
+}
+
+// resolve 主动查询并合并被动监听结果，返回 IP -> 来源 -> 记录
+func (nd *nameDiscovery) resolve(ips []net.IP, sources map[string]bool, timeout time.Duration) map[string]map[string]nameRecord {
+	results := make(map[string]map[string]nameRecord)
+	add := func(ip string, record nameRecord) {
+		if results[ip] == nil {
+			results[ip] = make(map[string]nameRecord)
+		}
+		results[ip][record.Source] = mergeNameRecord(results[ip][record.Source], record)
+	}
+
+	wanted := make(map[string]bool, len(ips))
+	for _, ip := range ips {
+		wanted[ip.String()] = true
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var mdnsResults, netbiosResults map[string]nameRecord
+	var locations map[string]string
+
+	if sources[nameSourceMDNS] {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := nd.queryMDNS(ips, timeout)
+			mu.Lock()
+			mdnsResults = r
+			mu.Unlock()
+		}()
+	}
+	if sources[nameSourceNetBIOS] {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := nd.queryNetBIOS(ips, timeout)
+			mu.Lock()
+			netbiosResults = r
+			mu.Unlock()
+		}()
+	}
+	if sources[nameSourceSSDP] {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := nd.querySSDP(timeout)
+			mu.Lock()
+			locations = r
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	for ip, record := range mdnsResults {
+		if wanted[ip] {
+			add(ip, record)
+		}
+	}
+	for ip, record := range netbiosResults {
+		if wanted[ip] {
+			add(ip, record)
+		}
+	}
+
+	// 清理过期记录后合并被动监听结果
+	nd.mutex.Lock()
+	nd.expirePassive(time.Now(), true)
+	for ip, bySource := range nd.passive {
+		for source, record := range bySource {
+			if wanted[ip] && sources[source] {
+				add(ip, record)
+			}
+		}
+	}
+	if locations == nil {
+		locations = make(map[string]string)
+	}
+	if sources[nameSourceSSDP] {
+		for ip, location := range nd.locations {
+			if _, ok := locations[ip]; !ok {
+				locations[ip] = location.URL
+			}
+		}
+	}
+	nd.mutex.Unlock()
+
+	// 获取UPnP设备描述
+	semaphore := make(chan struct{}, descriptionFetchMax)
+	for ip, location := range locations {
+		if !wanted[ip] {
+			continue
+		}
+		wg.Add(1)
+		go func(ip, location string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			if record, ok := nd.fetchUPnPDescription(ip, location, timeout); ok {
+				mu.Lock()
+				add(ip, record)
+				mu.Unlock()
+			}
+		}(ip, location)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// nameSources 获取启用的名称发现来源，未配置时全部启用
+func (scanner *NetworkScanner) nameSources() map[string]bool {
+	sources := make(map[string]bool)
+	configured := scanner.config.Scanner.NameSources
+	if len(configured) == 0 {
+		configured = []string{nameSourceMDNS, nameSourceNetBIOS, nameSourceSSDP}
+	}
+	for _, source := range configured {
+		switch strings.ToLower(source) {
+		case nameSourceMDNS, nameSourceNetBIOS, nameSourceSSDP:
+			sources[strings.ToLower(source)] = true
+		case "none":
+			return map[string]bool{}
+		}
+	}
+	return sources
+}
+
+// discoverNames 通过mDNS、NetBIOS和SSDP补充设备名称和型号
+func (scanner *NetworkScanner) discoverNames(devices map[string]*DeviceInfo) {
+	sources := scanner.nameSources()
+	if len(sources) == 0 || len(devices) == 0 {
+		return
+	}
+
+	timeout := time.Duration(scanner.config.Scanner.NameTimeout) * time.Millisecond
+	if timeout <= 0 {
+		timeout = defaultNameTimeout
+	}
+
+	byIP := make(map[string]*DeviceInfo, len(devices))
+	ips := make([]net.IP, 0, len(devices))
+	for _, device := range devices {
+		if ip := net.ParseIP(device.IP).To4(); ip != nil {
+			byIP[ip.String()] = device
+			ips = append(ips, ip)
+		}
+	}
+
+	results := scanner.names.resolve(ips, sources, timeout)
+
+	named := 0
+	for ip, bySource := range results {
+		device, ok := byIP[ip]
+		if !ok {
+			continue
+		}
+		if applyNameRecords(device, bySource) {
+			named++
+		}
+	}
+
+	scanner.addScanLog(fmt.Sprintf("名称发现完成，%d 个设备获得名称或型号", named))
+}
+
+// applyNameRecords 按来源优先级合并名称和型号，返回是否有更新
+func applyNameRecords(device *DeviceInfo, bySource map[string]nameRecord) bool {
+	if device.Names == nil {
+		device.Names = make(map[string]string)
+	}
+
+	updated := false
+	for source, record := range bySource {
+		if record.Hostname != "" {
+			device.Names[source] = record.Hostname
+		}
+		if record.Workgroup != "" {
+			device.Workgroup = record.Workgroup
+		}
+	}
+
+	if device.Hostname == "" {
+		for _, source := range hostnamePriority {
+			if record, ok := bySource[source]; ok && record.Hostname != "" {
+				device.Hostname = record.Hostname
+				device.HostnameSource = source
+				updated = true
+				break
+			}
+		}
+	}
+
+	for _, source := range modelPriority {
+		record, ok := bySource[source]
+		if !ok || record.Model == "" {
+			continue
+		}
+		model := record.Model
+		if record.Manufacturer != "" && !strings.HasPrefix(strings.ToLower(model), strings.ToLower(record.Manufacturer)) {
+			model = record.Manufacturer + " " + model
+		}
+		device.Model = model
+		device.ModelSource = source
+		updated = true
+		break
+	}
+
+	if len(device.Names) == 0 {
+		device.Names = nil
+	}
+	return updated
+}
+
+// logNameDiscovery 名称发现日志同时写入程序日志和扫描日志
+func (scanner *NetworkScanner) logNameDiscovery(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	log.Println(message)
+	scanner.addScanLog(message)
+}
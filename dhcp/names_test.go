@@ -0,0 +1,53 @@
+package dhcp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpirePassive(t *testing.T) {
+	nd := newNameDiscovery("lo", func(string, ...interface{}) {})
+	now := time.Now()
+	old := now.Add(-passiveNameTTL - time.Minute)
+
+	nd.passive["192.168.1.10"] = map[string]nameRecord{
+		nameSourceMDNS: {Source: nameSourceMDNS, Hostname: "printer", SeenAt: old},
+	}
+	nd.passive["192.168.1.11"] = map[string]nameRecord{
+		nameSourceMDNS: {Source: nameSourceMDNS, Hostname: "nas", SeenAt: now},
+	}
+	nd.locations["192.168.1.10"] = passiveLocation{URL: "http://192.168.1.10/desc.xml", SeenAt: old}
+	nd.locations["192.168.1.11"] = passiveLocation{URL: "http://192.168.1.11/desc.xml", SeenAt: now}
+
+	// 距上次清理不足间隔时不执行
+	nd.lastExpire = now
+	nd.expirePassive(now, false)
+	if len(nd.passive) != 2 || len(nd.locations) != 2 {
+		t.Fatalf("清理间隔内不应删除记录, passive=%d locations=%d", len(nd.passive), len(nd.locations))
+	}
+
+	nd.expirePassive(now, true)
+	if _, ok := nd.passive["192.168.1.10"]; ok {
+		t.Error("过期的mDNS记录应被删除")
+	}
+	if _, ok := nd.locations["192.168.1.10"]; ok {
+		t.Error("过期的UPnP描述地址应被删除")
+	}
+	if _, ok := nd.passive["192.168.1.11"]; !ok {
+		t.Error("未过期的mDNS记录不应被删除")
+	}
+	if _, ok := nd.locations["192.168.1.11"]; !ok {
+		t.Error("未过期的UPnP描述地址不应被删除")
+	}
+
+	// 被动监听到新的SSDP通告时按间隔顺带清理
+	nd.locations["192.168.1.12"] = passiveLocation{URL: "http://192.168.1.12/desc.xml", SeenAt: old}
+	nd.lastExpire = now.Add(-passiveExpireEvery - time.Second)
+	nd.handlePassiveSSDP([]byte("NOTIFY * HTTP/1.1\r\nLOCATION: http://192.168.1.13/desc.xml\r\nNTS: ssdp:alive\r\n\r\n"), []byte{192, 168, 1, 13})
+	if _, ok := nd.locations["192.168.1.12"]; ok {
+		t.Error("处理SSDP通告时应清理过期的描述地址")
+	}
+	if nd.locations["192.168.1.13"].URL != "http://192.168.1.13/desc.xml" {
+		t.Errorf("应记录新的描述地址, 实际为 %+v", nd.locations["192.168.1.13"])
+	}
+}
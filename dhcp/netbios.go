@@ -0,0 +1,101 @@
+package dhcp
+
+import (
+	"encoding/binary"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	netbiosPort      = 137
+	netbiosNameLen   = 16
+	netbiosGroupFlag = 0x8000
+	netbiosSuffixWS  = 0x00 // 工作站服务
+)
+
+// netbiosWildcardName 节点状态查询使用的通配名称"*"的一级编码
+var netbiosWildcardName = func() string {
+	raw := make([]byte, netbiosNameLen)
+	raw[0] = '*'
+	encoded := make([]byte, 0, netbiosNameLen*2)
+	for _, b := range raw {
+		encoded = append(encoded, 'A'+(b>>4), 'A'+(b&0x0F))
+	}
+	return string(encoded)
+}()
+
+// queryNetBIOS 向每个IP发送NetBIOS节点状态查询（NBSTAT），获取计算机名和工作组
+func (nd *nameDiscovery) queryNetBIOS(ips []net.IP, timeout time.Duration) map[string]nameRecord {
+	results := make(map[string]nameRecord)
+
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: nd.localIP()})
+	if err != nil {
+		nd.logf("NetBIOS查询失败: %v", err)
+		return results
+	}
+	defer conn.Close()
+
+	for i, ip := range ips {
+		query := buildDNSQuery(uint16(i), []dnsQuestion{{Name: netbiosWildcardName, Type: dnsTypeNBSTAT, Class: dnsClassIN}})
+		conn.WriteTo(query, &net.UDPAddr{IP: ip, Port: netbiosPort})
+	}
+
+	deadline := time.Now().Add(timeout)
+	conn.SetReadDeadline(deadline)
+	buf := make([]byte, 1500)
+	for time.Now().Before(deadline) && len(results) < len(ips) {
+		n, addr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			break
+		}
+		if record, ok := parseNetBIOSNodeStatus(buf[:n]); ok {
+			results[addr.IP.String()] = record
+		}
+	}
+
+	return results
+}
+
+// parseNetBIOSNodeStatus 解析节点状态应答中的名称表
+func parseNetBIOSNodeStatus(msg []byte) (nameRecord, bool) {
+	records, err := parseDNSMessage(msg)
+	if err != nil {
+		return nameRecord{}, false
+	}
+
+	for _, record := range records {
+		if record.Type != dnsTypeNBSTAT || len(record.Data) < 1 {
+			continue
+		}
+		count := int(record.Data[0])
+		table := record.Data[1:]
+		if len(table) < count*18 {
+			continue
+		}
+
+		result := nameRecord{Source: nameSourceNetBIOS, SeenAt: time.Now()}
+		for i := 0; i < count; i++ {
+			entry := table[i*18 : (i+1)*18]
+			name := strings.TrimRight(string(entry[:15]), " \x00")
+			suffix := entry[15]
+			flags := binary.BigEndian.Uint16(entry[16:18])
+			if suffix != netbiosSuffixWS || name == "" {
+				continue
+			}
+			if flags&netbiosGroupFlag != 0 {
+				if result.Workgroup == "" {
+					result.Workgroup = name
+				}
+			} else if result.Hostname == "" {
+				result.Hostname = name
+			}
+		}
+
+		if result.Hostname != "" {
+			return result, true
+		}
+	}
+
+	return nameRecord{}, false
+}
@@ -1,6 +1,7 @@
 package dhcp
 
 import (
+	"crypto/rand"
 	"encoding/binary"
 	"fmt"
 	"log"
@@ -9,18 +10,27 @@ import (
 	"time"
 
 	"dhcp-server/config"
+	"dhcp-server/fingerprint"
 )
 
 // IPLease IP租约信息
 type IPLease struct {
-	IP        net.IP
-	MAC       string
-	Hostname  string
-	StartTime time.Time
-	LeaseTime time.Duration
-	IsStatic  bool
-	Gateway   string // 配置中的网关名称
-	GatewayIP string // 实际响应的网关IP地址
+	IP          net.IP
+	MAC         string
+	Hostname    string
+	StartTime   time.Time
+	LeaseTime   time.Duration
+	IsStatic    bool
+	Quarantined bool   // 是否为隔离地址池中的租约
+	Offered     bool   // 已提供但客户端尚未REQUEST确认的短期预留
+	Gateway     string // 配置中的网关名称
+	GatewayIP   string // 实际响应的网关IP地址
+
+	ForceRenewCapable bool   // 客户端是否声明支持FORCERENEW认证（RFC 6704）
+	ForceRenewNonce   []byte // 下发给客户端的FORCERENEW认证随机数
+
+	Fingerprint    *fingerprint.Fingerprint // 最近一次DISCOVER/REQUEST的DHCP指纹
+	Classification *fingerprint.Match       // 指纹识别结果，未识别时为nil
 }
 
 // IsExpired 检查租约是否过期
@@ -121,15 +131,84 @@ func (pool *IPPool) initStaticBindings() error {
 	return nil
 }
 
+// defaultQuarantineLeaseTime 隔离租约默认时长，短租期使设备登记后能尽快迁出
+const defaultQuarantineLeaseTime = 5 * time.Minute
+
+// quarantineRange 获取隔离地址范围，未配置时返回false
+func (pool *IPPool) quarantineRange() (net.IP, net.IP, bool) {
+	start := net.ParseIP(pool.config.Security.Quarantine.StartIP).To4()
+	end := net.ParseIP(pool.config.Security.Quarantine.EndIP).To4()
+	if start == nil || end == nil {
+		return nil, nil, false
+	}
+	return start, end, true
+}
+
 // RequestIP 请求IP地址
 func (pool *IPPool) RequestIP(clientMAC string, requestedIP net.IP, hostname string) (*IPLease, error) {
 	pool.mutex.Lock()
 	defer pool.mutex.Unlock()
 
+	lease, _, err := pool.requestIP(clientMAC, requestedIP, hostname)
+	return lease, err
+}
+
+// ReserveIP 为DISCOVER预留地址：新分配的地址只保留ttl，客户端REQUEST确认后才转为正式租约；
+// 待确认的预留达到maxPending时不再为新客户端分配
+func (pool *IPPool) ReserveIP(clientMAC string, requestedIP net.IP, hostname string, ttl time.Duration, maxPending int) (*IPLease, error) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	pending := pool.pendingOffers()
+	lease, isNew, err := pool.requestIP(clientMAC, requestedIP, hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	if isNew {
+		if maxPending > 0 && pending >= maxPending {
+			delete(pool.leases, lease.IP.String())
+			delete(pool.macToIP, lease.MAC)
+			return nil, fmt.Errorf("待确认的Offer已达上限 %d", maxPending)
+		}
+		lease.Offered = true
+	}
+	if lease.Offered {
+		lease.LeaseTime = ttl
+	}
+	return lease, nil
+}
+
+// pendingOffers 统计尚未确认且未过期的预留（内部方法，不获取锁）
+func (pool *IPPool) pendingOffers() int {
+	count := 0
+	for _, lease := range pool.leases {
+		if lease.Offered && !lease.IsExpired() {
+			count++
+		}
+	}
+	return count
+}
+
+// PendingOffers 获取尚未确认的预留数量
+func (pool *IPPool) PendingOffers() int {
+	pool.mutex.RLock()
+	defer pool.mutex.RUnlock()
+
+	return pool.pendingOffers()
+}
+
+// Size 获取地址池的地址数量
+func (pool *IPPool) Size() int {
+	return int(binary.BigEndian.Uint32(pool.endIP) - binary.BigEndian.Uint32(pool.startIP) + 1)
+}
+
+// requestIP 分配或续租地址，返回租约以及是否为新分配（内部方法，调用者持有锁）
+func (pool *IPPool) requestIP(clientMAC string, requestedIP net.IP, hostname string) (*IPLease, bool, error) {
 	// 标准化MAC地址格式
 	mac, err := net.ParseMAC(clientMAC)
 	if err != nil {
-		return nil, fmt.Errorf("无效的MAC地址: %s", clientMAC)
+		return nil, false, fmt.Errorf("无效的MAC地址: %s", clientMAC)
 	}
 	macStr := mac.String()
 
@@ -140,15 +219,28 @@ func (pool *IPPool) RequestIP(clientMAC string, requestedIP net.IP, hostname str
 	if existingIP, exists := pool.macToIP[macStr]; exists {
 		if lease, ok := pool.leases[existingIP]; ok && lease.IsStatic {
 			log.Printf("返回静态绑定IP: %s -> %s", macStr, existingIP)
-			return lease, nil
+			return lease, false, nil
 		}
 	}
 
+	// 设备组可以指定独立的分配范围和租期
+	policy := pool.config.ResolvePolicy(macStr)
+	startIP, endIP := pool.policyRange(policy)
+
 	// 检查是否已有动态租约
 	if existingIP, exists := pool.macToIP[macStr]; exists {
 		if lease, ok := pool.leases[existingIP]; ok && !lease.IsExpired() {
-			// 检查现有IP是否在冲突列表中（直接访问，因为已经持有锁）
-			if _, conflictExists := pool.conflictIPs[existingIP]; conflictExists {
+			if !lease.Quarantined && !ipInRange(lease.IP, startIP, endIP) {
+				log.Printf("现有IP %s 不在设备组 %s 的分配范围内，释放租约并分配新IP", existingIP, policy.Group)
+				delete(pool.leases, existingIP)
+				delete(pool.macToIP, macStr)
+			} else if lease.Quarantined {
+				// 隔离租约不能续用，设备已通过访问控制
+				log.Printf("设备已解除隔离，释放隔离地址 %s 并从地址池分配", existingIP)
+				delete(pool.leases, existingIP)
+				delete(pool.macToIP, macStr)
+			} else if _, conflictExists := pool.conflictIPs[existingIP]; conflictExists {
+				// 检查现有IP是否在冲突列表中（直接访问，因为已经持有锁）
 				log.Printf("现有IP %s 在冲突列表中，释放租约并分配新IP", existingIP)
 				// 释放冲突的IP租约
 				delete(pool.leases, existingIP)
@@ -157,8 +249,11 @@ func (pool *IPPool) RequestIP(clientMAC string, requestedIP net.IP, hostname str
 				// 续租现有IP
 				lease.StartTime = time.Now()
 				lease.Hostname = hostname
+				if policy.LeaseTime > 0 {
+					lease.LeaseTime = policy.LeaseTime
+				}
 				log.Printf("续租IP: %s -> %s", macStr, existingIP)
-				return lease, nil
+				return lease, false, nil
 			}
 		} else {
 			// 清理过期租约
@@ -170,12 +265,12 @@ func (pool *IPPool) RequestIP(clientMAC string, requestedIP net.IP, hostname str
 	// 如果客户端请求特定IP，检查是否可用
 	if requestedIP != nil && !requestedIP.IsUnspecified() {
 		log.Printf("检查请求的IP: %s", requestedIP)
-		log.Printf("IP在范围内: %v", pool.isIPInRange(requestedIP))
+		log.Printf("IP在范围内: %v", ipInRange(requestedIP, startIP, endIP))
 		log.Printf("IP可用: %v", pool.isIPAvailable(requestedIP.String()))
 
-		if pool.isIPInRange(requestedIP) && pool.isIPAvailable(requestedIP.String()) {
+		if ipInRange(requestedIP, startIP, endIP) && pool.isIPAvailable(requestedIP.String()) {
 			log.Printf("分配请求的IP: %s", requestedIP)
-			return pool.allocateIP(requestedIP, macStr, hostname), nil
+			return pool.allocatePolicyIP(requestedIP, macStr, hostname, policy), true, nil
 		} else {
 			log.Printf("请求的IP不可用: %s", requestedIP)
 		}
@@ -183,14 +278,97 @@ func (pool *IPPool) RequestIP(clientMAC string, requestedIP net.IP, hostname str
 
 	// 分配新的IP地址
 	log.Printf("查找可用IP地址...")
-	newIP := pool.findAvailableIP()
+	newIP := pool.findAvailableIP(startIP, endIP)
 	if newIP == nil {
 		log.Printf("错误: 地址池已满，无法分配新IP")
-		return nil, fmt.Errorf("地址池已满，无法分配新IP")
+		return nil, false, fmt.Errorf("地址池已满，无法分配新IP")
 	}
 
 	log.Printf("找到可用IP: %s", newIP)
-	return pool.allocateIP(newIP, macStr, hostname), nil
+	return pool.allocatePolicyIP(newIP, macStr, hostname, policy), true, nil
+}
+
+// policyRange 获取策略指定的分配范围，未指定或无效时使用全局地址池
+func (pool *IPPool) policyRange(policy config.DevicePolicy) (net.IP, net.IP) {
+	start := net.ParseIP(policy.StartIP).To4()
+	end := net.ParseIP(policy.EndIP).To4()
+	if start == nil || end == nil {
+		return pool.startIP, pool.endIP
+	}
+	return start, end
+}
+
+// InPolicyRange 检查IP是否在设备策略的分配范围内
+func (pool *IPPool) InPolicyRange(ip net.IP, policy config.DevicePolicy) bool {
+	start, end := pool.policyRange(policy)
+	return ipInRange(ip, start, end)
+}
+
+// allocatePolicyIP 分配IP地址并应用设备策略的租期
+func (pool *IPPool) allocatePolicyIP(ip net.IP, mac, hostname string, policy config.DevicePolicy) *IPLease {
+	lease := pool.allocateIP(ip, mac, hostname)
+	if policy.LeaseTime > 0 {
+		lease.LeaseTime = policy.LeaseTime
+	}
+	return lease
+}
+
+// RequestQuarantineIP 为未通过访问控制的设备分配隔离地址，已有的正常租约会被收回
+func (pool *IPPool) RequestQuarantineIP(clientMAC string, requestedIP net.IP, hostname string) (*IPLease, error) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	mac, err := net.ParseMAC(clientMAC)
+	if err != nil {
+		return nil, fmt.Errorf("无效的MAC地址: %s", clientMAC)
+	}
+	macStr := mac.String()
+
+	start, end, ok := pool.quarantineRange()
+	if !ok {
+		return nil, fmt.Errorf("未配置隔离地址范围")
+	}
+
+	if existingIP, exists := pool.macToIP[macStr]; exists {
+		lease, ok := pool.leases[existingIP]
+		if ok && lease.Quarantined && !lease.IsExpired() {
+			lease.StartTime = time.Now()
+			lease.Hostname = hostname
+			log.Printf("续租隔离IP: %s -> %s", macStr, existingIP)
+			return lease, nil
+		}
+		if ok && lease.IsStatic {
+			return nil, fmt.Errorf("设备 %s 存在静态绑定，不能隔离", macStr)
+		}
+		if ok && !lease.Quarantined {
+			log.Printf("设备被隔离，收回地址池租约: %s -> %s", macStr, existingIP)
+		}
+		delete(pool.leases, existingIP)
+		delete(pool.macToIP, macStr)
+	}
+
+	if requestedIP != nil && !requestedIP.IsUnspecified() &&
+		ipInRange(requestedIP, start, end) && pool.isIPAvailable(requestedIP.String()) {
+		return pool.allocateQuarantineIP(requestedIP, macStr, hostname), nil
+	}
+
+	newIP := pool.findAvailableIP(start, end)
+	if newIP == nil {
+		return nil, fmt.Errorf("隔离地址池已满，无法分配新IP")
+	}
+	return pool.allocateQuarantineIP(newIP, macStr, hostname), nil
+}
+
+// allocateQuarantineIP 分配隔离地址，使用隔离租期
+func (pool *IPPool) allocateQuarantineIP(ip net.IP, mac, hostname string) *IPLease {
+	lease := pool.allocateIP(ip, mac, hostname)
+	lease.Quarantined = true
+	lease.LeaseTime = pool.config.Security.Quarantine.LeaseTime
+	if lease.LeaseTime <= 0 {
+		lease.LeaseTime = defaultQuarantineLeaseTime
+	}
+	log.Printf("分配隔离IP: %s -> %s, 租期: %v", mac, ip, lease.LeaseTime)
+	return lease
 }
 
 // allocateIP 分配IP地址
@@ -206,6 +384,10 @@ func (pool *IPPool) allocateIP(ip net.IP, mac, hostname string) *IPLease {
 	}
 
 	ipStr := ip.String()
+	// 复用其他客户端过期的租约或预留时，移除旧的MAC映射
+	if previous, ok := pool.leases[ipStr]; ok && previous.MAC != mac && pool.macToIP[previous.MAC] == ipStr {
+		delete(pool.macToIP, previous.MAC)
+	}
 	pool.leases[ipStr] = lease
 	pool.macToIP[mac] = ipStr
 
@@ -213,12 +395,12 @@ func (pool *IPPool) allocateIP(ip net.IP, mac, hostname string) *IPLease {
 	return lease
 }
 
-// findAvailableIP 查找可用的IP地址
-func (pool *IPPool) findAvailableIP() net.IP {
-	start := binary.BigEndian.Uint32(pool.startIP)
-	end := binary.BigEndian.Uint32(pool.endIP)
+// findAvailableIP 在指定范围内查找可用的IP地址
+func (pool *IPPool) findAvailableIP(startIP, endIP net.IP) net.IP {
+	start := binary.BigEndian.Uint32(startIP)
+	end := binary.BigEndian.Uint32(endIP)
 
-	log.Printf("开始查找可用IP，范围: %s - %s", pool.startIP, pool.endIP)
+	log.Printf("开始查找可用IP，范围: %s - %s", startIP, endIP)
 	log.Printf("当前租约数量: %d", len(pool.leases))
 	log.Printf("当前冲突IP数量: %d", len(pool.conflictIPs))
 	log.Printf("start值: %d, end值: %d", start, end)
@@ -270,9 +452,18 @@ func (pool *IPPool) isIPAvailable(ip string) bool {
 
 // isIPInRange 检查IP是否在范围内
 func (pool *IPPool) isIPInRange(ip net.IP) bool {
-	start := binary.BigEndian.Uint32(pool.startIP)
-	end := binary.BigEndian.Uint32(pool.endIP)
-	target := binary.BigEndian.Uint32(ip.To4())
+	return ipInRange(ip, pool.startIP, pool.endIP)
+}
+
+// ipInRange 检查IP是否在闭区间内
+func ipInRange(ip, startIP, endIP net.IP) bool {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return false
+	}
+	start := binary.BigEndian.Uint32(startIP)
+	end := binary.BigEndian.Uint32(endIP)
+	target := binary.BigEndian.Uint32(ip4)
 
 	return target >= start && target <= end
 }
@@ -333,6 +524,95 @@ func (pool *IPPool) GetLeaseByMAC(mac string) (*IPLease, bool) {
 	return lease, ok
 }
 
+// RenewLease 客户端续租，刷新租约开始时间
+func (pool *IPPool) RenewLease(lease *IPLease) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	lease.StartTime = time.Now()
+}
+
+// ConfirmOffer 客户端REQUEST确认预留，改为正常租约并应用设备组租期，返回之前是否为预留
+func (pool *IPPool) ConfirmOffer(lease *IPLease, leaseTime time.Duration) bool {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	if leaseTime > 0 {
+		lease.LeaseTime = leaseTime
+	}
+	offered := lease.Offered
+	lease.Offered = false
+	return offered
+}
+
+// LeaseDuration 获取租约的租期
+func (pool *IPPool) LeaseDuration(lease *IPLease) time.Duration {
+	pool.mutex.RLock()
+	defer pool.mutex.RUnlock()
+
+	return lease.LeaseTime
+}
+
+// SetLeaseTime 更新租约的租期
+func (pool *IPPool) SetLeaseTime(lease *IPLease, leaseTime time.Duration) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	lease.LeaseTime = leaseTime
+}
+
+// UpdateForceRenew 记录客户端是否支持FORCERENEW；支持且issueNonce为true时返回下发的认证随机数，
+// 尚未生成时先生成，生成失败时标记为不支持
+func (pool *IPPool) UpdateForceRenew(lease *IPLease, capable, issueNonce bool) ([]byte, error) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	lease.ForceRenewCapable = capable
+	if !capable || !issueNonce {
+		return nil, nil
+	}
+	if len(lease.ForceRenewNonce) != forceRenewNonceSize {
+		nonce := make([]byte, forceRenewNonceSize)
+		if _, err := rand.Read(nonce); err != nil {
+			lease.ForceRenewCapable = false
+			return nil, err
+		}
+		lease.ForceRenewNonce = nonce
+	}
+	return append([]byte(nil), lease.ForceRenewNonce...), nil
+}
+
+// ForceRenewCapable 客户端是否声明支持FORCERENEW
+func (pool *IPPool) ForceRenewCapable(lease *IPLease) bool {
+	pool.mutex.RLock()
+	defer pool.mutex.RUnlock()
+
+	return lease.ForceRenewCapable
+}
+
+// ForceRenewNonce 获取可以用于FORCERENEW认证的随机数，客户端不支持或尚未下发时返回false
+func (pool *IPPool) ForceRenewNonce(lease *IPLease) ([]byte, bool) {
+	pool.mutex.RLock()
+	defer pool.mutex.RUnlock()
+
+	if !lease.ForceRenewCapable || len(lease.ForceRenewNonce) != forceRenewNonceSize {
+		return nil, false
+	}
+	return append([]byte(nil), lease.ForceRenewNonce...), true
+}
+
+// LeaseRenewedSince 检查租约是否仍属于该客户端，以及之后是否续租过
+func (pool *IPPool) LeaseRenewedSince(lease *IPLease, since time.Time) (current bool, renewed bool) {
+	pool.mutex.RLock()
+	defer pool.mutex.RUnlock()
+
+	ipStr, exists := pool.macToIP[lease.MAC]
+	if !exists || pool.leases[ipStr] != lease {
+		return false, false
+	}
+	return true, lease.StartTime.After(since)
+}
+
 // CleanupExpiredLeases 清理过期租约
 func (pool *IPPool) CleanupExpiredLeases() {
 	pool.mutex.Lock()
@@ -395,9 +675,15 @@ func (pool *IPPool) GetPoolStats() map[string]interface{} {
 	staticCount := 0
 	dynamicCount := 0
 	expiredCount := 0
+	quarantineCount := 0
 
 	for _, lease := range pool.leases {
-		if lease.IsStatic {
+		if lease.Quarantined {
+			// 隔离地址不占用正常地址池
+			if !lease.IsExpired() {
+				quarantineCount++
+			}
+		} else if lease.IsStatic {
 			staticCount++
 		} else if lease.IsExpired() {
 			expiredCount++
@@ -409,15 +695,66 @@ func (pool *IPPool) GetPoolStats() map[string]interface{} {
 	available := total - staticCount - dynamicCount
 
 	return map[string]interface{}{
-		"total_ips":      total,
-		"static_leases":  staticCount,
-		"dynamic_leases": dynamicCount,
-		"expired_leases": expiredCount,
-		"available_ips":  available,
-		"utilization":    float64(staticCount+dynamicCount) / float64(total) * 100,
+		"total_ips":         total,
+		"static_leases":     staticCount,
+		"dynamic_leases":    dynamicCount,
+		"expired_leases":    expiredCount,
+		"quarantine_leases": quarantineCount,
+		"pending_offers":    pool.pendingOffers(),
+		"available_ips":     available,
+		"utilization":       float64(staticCount+dynamicCount) / float64(total) * 100,
 	}
 }
 
+// PoolUsage 一个地址范围的使用情况
+type PoolUsage struct {
+	Name      string `json:"name"` // main 或 quarantine
+	Size      int    `json:"size"`
+	Used      int    `json:"used"` // 未过期的租约数，包括静态绑定
+	Static    int    `json:"static"`
+	Conflicts int    `json:"conflicts"`
+}
+
+// Usage 获取主地址池和隔离地址池（已配置时）的使用情况
+func (pool *IPPool) Usage() []PoolUsage {
+	pool.mutex.RLock()
+	defer pool.mutex.RUnlock()
+
+	main := PoolUsage{Name: "main", Size: pool.Size()}
+	quarantine := PoolUsage{Name: "quarantine"}
+	qStart, qEnd, hasQuarantine := pool.quarantineRange()
+	if hasQuarantine {
+		quarantine.Size = int(binary.BigEndian.Uint32(qEnd) - binary.BigEndian.Uint32(qStart) + 1)
+	}
+
+	for _, lease := range pool.leases {
+		if !lease.IsStatic && lease.IsExpired() {
+			continue
+		}
+		if lease.Quarantined {
+			quarantine.Used++
+			continue
+		}
+		main.Used++
+		if lease.IsStatic {
+			main.Static++
+		}
+	}
+	for ip := range pool.conflictIPs {
+		parsed := net.ParseIP(ip)
+		if pool.isIPInRange(parsed) {
+			main.Conflicts++
+		} else if hasQuarantine && ipInRange(parsed, qStart, qEnd) {
+			quarantine.Conflicts++
+		}
+	}
+
+	if !hasQuarantine {
+		return []PoolUsage{main}
+	}
+	return []PoolUsage{main, quarantine}
+}
+
 // StartCleanupTask 启动清理任务
 func (pool *IPPool) StartCleanupTask() {
 	go func() {
@@ -460,6 +797,20 @@ func (pool *IPPool) GetActiveLeases() []*IPLease {
 	return activeLeases
 }
 
+// GetQuarantinedLeases 获取所有有效的隔离租约
+func (pool *IPPool) GetQuarantinedLeases() []*IPLease {
+	pool.mutex.RLock()
+	defer pool.mutex.RUnlock()
+
+	var leases []*IPLease
+	for _, lease := range pool.leases {
+		if lease.Quarantined && !lease.IsExpired() {
+			leases = append(leases, lease)
+		}
+	}
+	return leases
+}
+
 // MarkIPAsConflict 将IP标记为冲突状态
 func (pool *IPPool) MarkIPAsConflict(ip string) {
 	pool.mutex.Lock()
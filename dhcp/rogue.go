@@ -0,0 +1,405 @@
+package dhcp
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"dhcp-server/events"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/insomniacslk/dhcp/dhcpv4/server4"
+)
+
+const (
+	dhcpClientPort         = 68
+	defaultProbeInterval   = 5 * time.Minute
+	rogueRealertInterval   = time.Hour // 同一服务器重复告警的最小间隔
+	rogueMACResolveTimeout = time.Second
+)
+
+// 检测方式
+const (
+	rogueDetectedByProbe   = "probe"   // 主动探测DISCOVER收到的OFFER
+	rogueDetectedByPassive = "passive" // 被动监听到的OFFER/ACK
+	rogueDetectedByRequest = "request" // 客户端REQUEST中选择了其他服务器
+)
+
+// RogueServer 检测到的非法DHCP服务器
+type RogueServer struct {
+	ServerID    string    `json:"server_id"`  // 服务器标识（option 54）
+	SourceIP    string    `json:"source_ip"`  // 报文源地址
+	MAC         string    `json:"mac"`        // 服务器MAC地址（通过邻居表或ARP解析）
+	OfferedIP   string    `json:"offered_ip"` // 分配给客户端的地址
+	Router      string    `json:"router"`     // 下发的网关
+	DNSServers  []string  `json:"dns_servers"`
+	MessageType string    `json:"message_type"`
+	DetectedBy  string    `json:"detected_by"` // probe、passive、request
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+	Count       int       `json:"count"`
+
+	lastAlert time.Time
+}
+
+// rogueDetector 非法DHCP服务器检测器
+type rogueDetector struct {
+	server    *Server
+	probeMAC  net.HardwareAddr
+	ownIP     net.IP // 本机服务器地址，启动时确定
+	conn      net.PacketConn
+	stopChan  chan struct{}
+	mutex     sync.RWMutex
+	servers   map[string]*RogueServer
+	probeXID  dhcpv4.TransactionID
+	lastProbe time.Time
+}
+
+// newRogueDetector 创建检测器，探测使用随机的本地管理MAC地址
+func newRogueDetector(server *Server) *rogueDetector {
+	mac := make(net.HardwareAddr, 6)
+	rand.Read(mac)
+	mac[0] = (mac[0] | 0x02) &^ 0x01
+
+	return &rogueDetector{
+		server:   server,
+		probeMAC: mac,
+		stopChan: make(chan struct{}),
+		servers:  make(map[string]*RogueServer),
+	}
+}
+
+// isProbe 判断报文是否来自本检测器的探测
+func (rd *rogueDetector) isProbe(mac net.HardwareAddr) bool {
+	return rd != nil && mac.String() == rd.probeMAC.String()
+}
+
+// Start 在客户端端口监听OFFER/ACK并定期发送探测
+func (rd *rogueDetector) Start() error {
+	cfg := rd.server.config.Security.RogueDetection
+	laddr := &net.UDPAddr{IP: net.IPv4zero, Port: dhcpClientPort}
+	conn, err := server4.NewIPv4UDPConn(rd.server.config.Server.Interface, laddr)
+	if err != nil {
+		return fmt.Errorf("监听DHCP客户端端口失败: %v", err)
+	}
+	rd.conn = conn
+	rd.ownIP = rd.server.getServerIP()
+
+	go rd.listenLoop()
+
+	interval := cfg.ProbeInterval
+	if interval <= 0 {
+		interval = defaultProbeInterval
+	}
+	go rd.probeLoop(interval)
+
+	log.Printf("非法DHCP服务器检测已启动，探测间隔: %v, 探测MAC: %s", interval, rd.probeMAC)
+	return nil
+}
+
+// Stop 停止检测
+func (rd *rogueDetector) Stop() {
+	select {
+	case <-rd.stopChan:
+		return
+	default:
+		close(rd.stopChan)
+	}
+	if rd.conn != nil {
+		rd.conn.Close()
+	}
+}
+
+// probeLoop 定期发送探测DISCOVER
+func (rd *rogueDetector) probeLoop(interval time.Duration) {
+	// 等待本服务器启动完成后再进行首次探测
+	select {
+	case <-time.After(5 * time.Second):
+		rd.sendProbe()
+	case <-rd.stopChan:
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-rd.stopChan:
+			return
+		case <-ticker.C:
+			rd.sendProbe()
+		}
+	}
+}
+
+// sendProbe 广播探测DISCOVER
+func (rd *rogueDetector) sendProbe() {
+	probe, err := dhcpv4.NewDiscovery(rd.probeMAC, dhcpv4.WithBroadcast(true))
+	if err != nil {
+		log.Printf("构造探测DISCOVER失败: %v", err)
+		return
+	}
+
+	rd.mutex.Lock()
+	rd.probeXID = probe.TransactionID
+	rd.lastProbe = time.Now()
+	rd.mutex.Unlock()
+
+	dst := &net.UDPAddr{IP: net.IPv4bcast, Port: dhcpServerPort(rd.server.config.Server.Port)}
+	if _, err := rd.conn.WriteTo(probe.ToBytes(), dst); err != nil {
+		log.Printf("发送探测DISCOVER失败: %v", err)
+	}
+}
+
+// listenLoop 接收发往客户端端口的OFFER/ACK
+func (rd *rogueDetector) listenLoop() {
+	buf := make([]byte, 4096)
+	for {
+		n, peer, err := rd.conn.ReadFrom(buf)
+		if err != nil {
+			select {
+			case <-rd.stopChan:
+			default:
+				log.Printf("非法DHCP服务器检测监听出错: %v", err)
+			}
+			return
+		}
+
+		msg, err := dhcpv4.FromBytes(buf[:n])
+		if err != nil || msg.OpCode != dhcpv4.OpcodeBootReply {
+			continue
+		}
+		msgType := msg.MessageType()
+		if msgType != dhcpv4.MessageTypeOffer && msgType != dhcpv4.MessageTypeAck {
+			continue
+		}
+
+		sourceIP := net.IPv4zero
+		if udpAddr, ok := peer.(*net.UDPAddr); ok {
+			sourceIP = udpAddr.IP
+		}
+
+		rd.mutex.RLock()
+		isProbeReply := msg.TransactionID == rd.probeXID && rd.isProbe(msg.ClientHWAddr)
+		rd.mutex.RUnlock()
+
+		detectedBy := rogueDetectedByPassive
+		if isProbeReply {
+			detectedBy = rogueDetectedByProbe
+		}
+		rd.inspect(msg, sourceIP, detectedBy)
+	}
+}
+
+// inspect 检查应答是否来自其他服务器
+func (rd *rogueDetector) inspect(msg *dhcpv4.DHCPv4, sourceIP net.IP, detectedBy string) {
+	serverID := msg.ServerIdentifier()
+	if serverID == nil {
+		serverID = sourceIP
+	}
+	if rd.isTrusted(serverID, sourceIP) {
+		return
+	}
+
+	record := &RogueServer{
+		ServerID:    serverID.String(),
+		SourceIP:    sourceIP.String(),
+		OfferedIP:   msg.YourIPAddr.String(),
+		MessageType: msg.MessageType().String(),
+		DetectedBy:  detectedBy,
+	}
+	if routers := msg.Router(); len(routers) > 0 {
+		record.Router = routers[0].String()
+	}
+	for _, dns := range msg.DNS() {
+		record.DNSServers = append(record.DNSServers, dns.String())
+	}
+
+	rd.record(record)
+}
+
+// recordForeignRequest 客户端REQUEST选择了其他服务器，说明网络中存在其他服务器
+func (rd *rogueDetector) recordForeignRequest(req *dhcpv4.DHCPv4, serverID net.IP) {
+	if rd == nil || rd.isTrusted(serverID, nil) {
+		return
+	}
+	// 解析MAC可能耗时，不阻塞DHCP处理
+	go rd.record(&RogueServer{
+		ServerID:    serverID.String(),
+		SourceIP:    serverID.String(),
+		OfferedIP:   req.RequestedIPAddress().String(),
+		MessageType: req.MessageType().String(),
+		DetectedBy:  rogueDetectedByRequest,
+	})
+}
+
+// isTrusted 判断服务器是否为本机或在信任列表中
+func (rd *rogueDetector) isTrusted(serverID, sourceIP net.IP) bool {
+	if serverID.Equal(rd.ownIP) || (sourceIP != nil && sourceIP.Equal(rd.ownIP)) {
+		return true
+	}
+	for _, allowed := range rd.server.config.Security.RogueDetection.AllowedServers {
+		if allowed == serverID.String() || (sourceIP != nil && allowed == sourceIP.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// record 记录检测结果，新发现或超过告警间隔时发布事件
+func (rd *rogueDetector) record(record *RogueServer) {
+	now := time.Now()
+
+	rd.mutex.Lock()
+	existing, exists := rd.servers[record.ServerID]
+	if !exists {
+		record.FirstSeen = now
+		existing = record
+		rd.servers[record.ServerID] = existing
+	} else {
+		existing.OfferedIP = record.OfferedIP
+		existing.MessageType = record.MessageType
+		// REQUEST推断的信息不覆盖直接收到应答得到的源地址
+		if record.DetectedBy != rogueDetectedByRequest || existing.DetectedBy == rogueDetectedByRequest {
+			existing.SourceIP = record.SourceIP
+			existing.DetectedBy = record.DetectedBy
+		}
+		if record.Router != "" {
+			existing.Router = record.Router
+			existing.DNSServers = record.DNSServers
+		}
+	}
+	existing.LastSeen = now
+	existing.Count++
+
+	alert := now.Sub(existing.lastAlert) >= rogueRealertInterval
+	if alert {
+		existing.lastAlert = now
+	}
+	needMAC := existing.MAC == ""
+	snapshot := *existing
+	rd.mutex.Unlock()
+
+	if needMAC {
+		if mac := rd.resolveMAC(net.ParseIP(snapshot.SourceIP)); mac != "" {
+			rd.mutex.Lock()
+			existing.MAC = mac
+			rd.mutex.Unlock()
+			snapshot.MAC = mac
+		}
+	}
+
+	if !alert {
+		return
+	}
+
+	log.Printf("检测到非法DHCP服务器: %s (源地址: %s, MAC: %s, 网关: %s, 方式: %s)",
+		snapshot.ServerID, snapshot.SourceIP, snapshot.MAC, snapshot.Router, snapshot.DetectedBy)
+	rd.server.addHistory(snapshot.OfferedIP, snapshot.MAC, "", "ROGUE_SERVER", snapshot.ServerID)
+
+	events.Publish(events.Event{
+		Type:     "security.rogue_dhcp",
+		Source:   "rogue_detector",
+		Severity: events.SeverityCritical,
+		Message:  fmt.Sprintf("检测到非法DHCP服务器 %s", snapshot.ServerID),
+		Data: map[string]interface{}{
+			"server_id":    snapshot.ServerID,
+			"source_ip":    snapshot.SourceIP,
+			"mac":          snapshot.MAC,
+			"offered_ip":   snapshot.OfferedIP,
+			"router":       snapshot.Router,
+			"dns_servers":  snapshot.DNSServers,
+			"message_type": snapshot.MessageType,
+			"detected_by":  snapshot.DetectedBy,
+			"count":        snapshot.Count,
+		},
+	})
+}
+
+// resolveMAC 通过邻居表或ARP解析服务器MAC地址
+func (rd *rogueDetector) resolveMAC(ip net.IP) string {
+	if ip == nil || ip.IsUnspecified() {
+		return ""
+	}
+	if neighbors, err := readNeighborTable(); err == nil {
+		if mac, ok := neighbors[ip.String()]; ok {
+			return mac
+		}
+	}
+
+	arp, err := newARPScanner(rd.server.config.Server.Interface)
+	if err != nil {
+		return ""
+	}
+	defer arp.Close()
+	if reply, ok := arp.Sweep([]net.IP{ip}, rogueMACResolveTimeout, nil)[ip.String()]; ok {
+		return reply.MAC
+	}
+	return ""
+}
+
+// list 按最后发现时间倒序返回检测结果
+func (rd *rogueDetector) list() []RogueServer {
+	rd.mutex.RLock()
+	defer rd.mutex.RUnlock()
+
+	result := make([]RogueServer, 0, len(rd.servers))
+	for _, server := range rd.servers {
+		result = append(result, *server)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].LastSeen.After(result[j].LastSeen) })
+	return result
+}
+
+// remove 删除检测记录（确认处理后）
+func (rd *rogueDetector) remove(serverID string) bool {
+	rd.mutex.Lock()
+	defer rd.mutex.Unlock()
+	if _, ok := rd.servers[serverID]; !ok {
+		return false
+	}
+	delete(rd.servers, serverID)
+	return true
+}
+
+// dhcpServerPort 返回服务器端口，未配置时使用67
+func dhcpServerPort(port int) int {
+	if port <= 0 {
+		return 67
+	}
+	return port
+}
+
+// GetRogueServers 获取检测到的非法DHCP服务器
+func (s *Server) GetRogueServers() []RogueServer {
+	if s.rogue == nil {
+		return []RogueServer{}
+	}
+	return s.rogue.list()
+}
+
+// RemoveRogueServer 删除非法DHCP服务器记录
+func (s *Server) RemoveRogueServer(serverID string) bool {
+	if s.rogue == nil {
+		return false
+	}
+	return s.rogue.remove(serverID)
+}
+
+// RogueDetectionStatus 获取检测状态
+func (s *Server) RogueDetectionStatus() map[string]interface{} {
+	status := map[string]interface{}{
+		"enabled": s.rogue != nil,
+	}
+	if s.rogue != nil {
+		s.rogue.mutex.RLock()
+		status["probe_mac"] = s.rogue.probeMAC.String()
+		status["last_probe"] = s.rogue.lastProbe
+		s.rogue.mutex.RUnlock()
+	}
+	return status
+}
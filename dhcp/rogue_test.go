@@ -0,0 +1,130 @@
+package dhcp
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+
+	"dhcp-server/config"
+	"dhcp-server/events"
+)
+
+// rogueOffer 构造其他服务器发出的OFFER
+func rogueOffer(t *testing.T, serverID, offered string) *dhcpv4.DHCPv4 {
+	t.Helper()
+	offer, err := dhcpv4.New(
+		dhcpv4.WithMessageType(dhcpv4.MessageTypeOffer),
+		dhcpv4.WithYourIP(net.ParseIP(offered)),
+		dhcpv4.WithOption(dhcpv4.OptServerIdentifier(net.ParseIP(serverID))),
+		dhcpv4.WithRouter(net.ParseIP("10.0.0.1")),
+		dhcpv4.WithDNS(net.ParseIP("10.0.0.53")),
+	)
+	if err != nil {
+		t.Fatalf("构造OFFER失败: %v", err)
+	}
+	return offer
+}
+
+// rogueAlerts 统计指定服务器的告警事件数
+func rogueAlerts(serverID string) int {
+	count := 0
+	for _, event := range events.Recent(500, "security.rogue_dhcp") {
+		if event.Data["server_id"] == serverID {
+			count++
+		}
+	}
+	return count
+}
+
+func TestRogueDetectorInspect(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.Security.RogueDetection.Enabled = true
+		cfg.Security.RogueDetection.AllowedServers = []string{"192.168.1.2"}
+	})
+	rd := s.rogue
+	rd.ownIP = s.getServerIP()
+
+	// 本机和受信任服务器的应答不记录
+	rd.inspect(rogueOffer(t, "192.168.1.1", "192.168.1.150"), net.ParseIP("192.168.1.1"), rogueDetectedByPassive)
+	rd.inspect(rogueOffer(t, "192.168.1.2", "192.168.1.151"), net.ParseIP("192.168.1.2"), rogueDetectedByPassive)
+	if servers := s.GetRogueServers(); len(servers) != 0 {
+		t.Fatalf("本机和受信任服务器不应被记录, 实际为 %+v", servers)
+	}
+
+	rd.inspect(rogueOffer(t, "10.0.0.250", "10.0.0.20"), net.ParseIP("10.0.0.250"), rogueDetectedByProbe)
+	servers := s.GetRogueServers()
+	if len(servers) != 1 {
+		t.Fatalf("应记录1个非法服务器, 实际为 %d", len(servers))
+	}
+	got := servers[0]
+	if got.ServerID != "10.0.0.250" || got.OfferedIP != "10.0.0.20" || got.Router != "10.0.0.1" ||
+		len(got.DNSServers) != 1 || got.DNSServers[0] != "10.0.0.53" || got.DetectedBy != rogueDetectedByProbe ||
+		got.MessageType != dhcpv4.MessageTypeOffer.String() || got.Count != 1 {
+		t.Errorf("记录内容不正确: %+v", got)
+	}
+	if rogueAlerts("10.0.0.250") != 1 {
+		t.Errorf("首次发现应发布告警事件")
+	}
+
+	// 告警间隔内再次发现只更新计数，不重复告警
+	rd.inspect(rogueOffer(t, "10.0.0.250", "10.0.0.21"), net.ParseIP("10.0.0.250"), rogueDetectedByPassive)
+	got = s.GetRogueServers()[0]
+	if got.Count != 2 || got.OfferedIP != "10.0.0.21" || got.DetectedBy != rogueDetectedByPassive {
+		t.Errorf("再次发现应更新记录: %+v", got)
+	}
+	if rogueAlerts("10.0.0.250") != 1 {
+		t.Errorf("告警间隔内不应重复告警")
+	}
+
+	if !s.RemoveRogueServer("10.0.0.250") || len(s.GetRogueServers()) != 0 {
+		t.Error("删除检测记录失败")
+	}
+	if s.RemoveRogueServer("10.0.0.250") {
+		t.Error("删除不存在的记录应返回false")
+	}
+}
+
+func TestRogueDetectorForeignRequest(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.Security.RogueDetection.Enabled = true
+	})
+	rd := s.rogue
+	rd.ownIP = s.getServerIP()
+
+	// 客户端REQUEST选择了其他服务器，不应答并记录该服务器
+	req := newTestPacket(t, dhcpv4.MessageTypeRequest, "aa:bb:cc:00:00:01",
+		dhcpv4.WithOption(dhcpv4.OptRequestedIPAddress(net.ParseIP("10.0.1.20"))),
+		dhcpv4.WithOption(dhcpv4.OptServerIdentifier(net.ParseIP("10.0.1.250"))))
+	resp, err := s.handleRequest(req)
+	if err != nil || resp != nil {
+		t.Fatalf("发给其他服务器的REQUEST不应应答, 实际为 %v, %v", resp, err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(s.GetRogueServers()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	servers := s.GetRogueServers()
+	if len(servers) != 1 || servers[0].ServerID != "10.0.1.250" || servers[0].DetectedBy != rogueDetectedByRequest ||
+		servers[0].OfferedIP != "10.0.1.20" {
+		t.Fatalf("应通过REQUEST记录其他服务器, 实际为 %+v", servers)
+	}
+
+	// 收到该服务器的应答后使用实际源地址，之后REQUEST推断的信息不再覆盖
+	rd.inspect(rogueOffer(t, "10.0.1.250", "10.0.1.21"), net.ParseIP("10.0.1.249"), rogueDetectedByPassive)
+	rd.record(&RogueServer{ServerID: "10.0.1.250", SourceIP: "10.0.1.250", DetectedBy: rogueDetectedByRequest})
+	got := s.GetRogueServers()[0]
+	if got.SourceIP != "10.0.1.249" || got.DetectedBy != rogueDetectedByPassive || got.Count != 3 {
+		t.Errorf("REQUEST推断的信息不应覆盖应答中的源地址: %+v", got)
+	}
+
+	// 本机探测报文不作为客户端请求处理
+	if !rd.isProbe(rd.probeMAC) {
+		t.Error("应识别本机探测MAC")
+	}
+	if rd.isProbe(req.ClientHWAddr) {
+		t.Error("普通客户端不应被识别为探测")
+	}
+}
@@ -0,0 +1,470 @@
+package dhcp
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"dhcp-server/config"
+)
+
+// 扫描任务状态
+const (
+	ScanJobPending   = "pending"
+	ScanJobRunning   = "running"
+	ScanJobCompleted = "completed"
+	ScanJobFailed    = "failed"
+	ScanJobCancelled = "cancelled"
+)
+
+// 扫描任务触发方式
+const (
+	ScanTriggerSchedule = "schedule"
+	ScanTriggerAPI      = "api"
+)
+
+const (
+	defaultScanProfile    = "default"
+	defaultScanConcurrent = 50
+	maxScanJobs           = 50 // 保留的历史任务数
+)
+
+// defaultTCPProbePorts 未配置端口时TCP探测使用的常见端口
+var defaultTCPProbePorts = []int{22, 80, 443, 445, 3389, 8080}
+
+// ScanJob 扫描任务，每次定时或按需扫描都对应一个任务
+type ScanJob struct {
+	ID           string       `json:"id"`
+	Profile      string       `json:"profile"`
+	Trigger      string       `json:"trigger"` // schedule、api
+	Status       string       `json:"status"`  // pending、running、completed、failed、cancelled
+	Targets      []string     `json:"targets"`
+	Excludes     []string     `json:"excludes,omitempty"`
+	Methods      []string     `json:"methods"`
+	TCPPorts     []int        `json:"tcp_ports,omitempty"`
+	CreatedAt    time.Time    `json:"created_at"`
+	StartedAt    time.Time    `json:"started_at"`
+	FinishedAt   time.Time    `json:"finished_at"`
+	TotalIPs     int          `json:"total_ips"`
+	ScannedIPs   int          `json:"scanned_ips"`
+	Progress     int          `json:"progress"` // 0-100
+	FoundDevices int          `json:"found_devices"`
+	Error        string       `json:"error,omitempty"`
+	Results      []DeviceInfo `json:"results,omitempty"`
+
+	ips         []net.IP
+	methods     map[string]bool
+	concurrency int
+	interval    int
+	cancel      chan struct{}
+}
+
+// Profiles 获取生效的扫描方案，未配置时根据扫描器设置生成default方案
+func (scanner *NetworkScanner) Profiles() []config.ScanProfile {
+	if len(scanner.config.Scanner.Profiles) > 0 {
+		profiles := make([]config.ScanProfile, len(scanner.config.Scanner.Profiles))
+		copy(profiles, scanner.config.Scanner.Profiles)
+		return profiles
+	}
+	return []config.ScanProfile{scanner.defaultProfile()}
+}
+
+// FindProfile 按名称查找扫描方案，名称为空时返回第一个方案
+func (scanner *NetworkScanner) FindProfile(name string) (config.ScanProfile, bool) {
+	profiles := scanner.Profiles()
+	if name == "" {
+		return profiles[0], true
+	}
+	for _, profile := range profiles {
+		if profile.Name == name {
+			return profile, true
+		}
+	}
+	return config.ScanProfile{}, false
+}
+
+// defaultProfile 根据scanner.start_ip/end_ip生成默认方案，未配置时使用DHCP地址池范围
+func (scanner *NetworkScanner) defaultProfile() config.ScanProfile {
+	cfg := scanner.config.Scanner
+
+	var target string
+	switch {
+	case cfg.StartIP != "" && cfg.EndIP != "":
+		target = cfg.StartIP + "-" + cfg.EndIP
+	case scanner.config.Network.StartIP != "" && scanner.config.Network.EndIP != "":
+		target = scanner.config.Network.StartIP + "-" + scanner.config.Network.EndIP
+	default:
+		target = scanner.config.Network.Subnet
+	}
+
+	var methods []string
+	switch scanner.scanMethod() {
+	case "icmp":
+		methods = []string{"icmp"}
+	case "arp":
+		methods = []string{"arp"}
+	default:
+		methods = []string{"arp", "icmp"}
+	}
+
+	return config.ScanProfile{
+		Name:           defaultScanProfile,
+		Targets:        []string{target},
+		Methods:        methods,
+		Interval:       cfg.ScanInterval,
+		MaxConcurrency: cfg.MaxConcurrency,
+	}
+}
+
+// profileMethods 解析方案的探测方式
+func (scanner *NetworkScanner) profileMethods(profile config.ScanProfile) (map[string]bool, error) {
+	methods := make(map[string]bool)
+	configured := profile.Methods
+	if len(configured) == 0 {
+		configured = []string{scanner.scanMethod()}
+	}
+	for _, method := range configured {
+		switch strings.ToLower(strings.TrimSpace(method)) {
+		case "icmp", "ping":
+			methods["icmp"] = true
+		case "arp":
+			methods["arp"] = true
+		case "tcp":
+			methods["tcp"] = true
+		case "both":
+			methods["arp"] = true
+			methods["icmp"] = true
+		default:
+			return nil, fmt.Errorf("不支持的探测方式: %s", method)
+		}
+	}
+	return methods, nil
+}
+
+// SubmitScan 提交扫描任务，任务按提交顺序依次执行
+func (scanner *NetworkScanner) SubmitScan(profile config.ScanProfile, trigger string) (*ScanJob, error) {
+	ips, err := expandTargets(profile.Targets, profile.Excludes)
+	if err != nil {
+		return nil, err
+	}
+	methods, err := scanner.profileMethods(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := profile.MaxConcurrency
+	if concurrency <= 0 {
+		concurrency = scanner.config.Scanner.MaxConcurrency
+	}
+	if concurrency <= 0 {
+		concurrency = defaultScanConcurrent
+	}
+
+	ports := profile.TCPPorts
+	if methods["tcp"] && len(ports) == 0 {
+		ports = defaultTCPProbePorts
+	}
+
+	job := &ScanJob{
+		Profile:     profile.Name,
+		Trigger:     trigger,
+		Status:      ScanJobPending,
+		Targets:     profile.Targets,
+		Excludes:    profile.Excludes,
+		Methods:     sortedKeys(methods),
+		TCPPorts:    ports,
+		CreatedAt:   time.Now(),
+		TotalIPs:    len(ips),
+		ips:         ips,
+		methods:     methods,
+		concurrency: concurrency,
+		interval:    profile.Interval,
+		cancel:      make(chan struct{}),
+	}
+
+	scanner.jobMutex.Lock()
+	// 定时任务在同一方案仍有未完成任务时跳过，避免任务堆积
+	if trigger == ScanTriggerSchedule {
+		for _, existing := range scanner.jobs {
+			if existing.Profile == profile.Name && (existing.Status == ScanJobPending || existing.Status == ScanJobRunning) {
+				scanner.jobMutex.Unlock()
+				return existing, nil
+			}
+		}
+	}
+
+	scanner.jobSeq++
+	job.ID = strconv.Itoa(scanner.jobSeq)
+	scanner.jobs = append(scanner.jobs, job)
+	scanner.trimJobs()
+	scanner.jobQueue = append(scanner.jobQueue, job)
+	if !scanner.jobWorkerRunning {
+		scanner.jobWorkerRunning = true
+		go scanner.jobWorker()
+	}
+	scanner.jobMutex.Unlock()
+
+	scanner.addScanLog(fmt.Sprintf("扫描任务 #%s 已提交，方案: %s，%d 个IP地址", job.ID, job.Profile, job.TotalIPs))
+	return job, nil
+}
+
+// trimJobs 清理超出保留数量的已结束任务（调用方需持有jobMutex）
+func (scanner *NetworkScanner) trimJobs() {
+	for len(scanner.jobs) > maxScanJobs {
+		removed := false
+		for i, job := range scanner.jobs {
+			if job.Status != ScanJobPending && job.Status != ScanJobRunning {
+				scanner.jobs = append(scanner.jobs[:i], scanner.jobs[i+1:]...)
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			return
+		}
+	}
+}
+
+// jobWorker 依次执行队列中的扫描任务，队列为空时退出
+func (scanner *NetworkScanner) jobWorker() {
+	for {
+		scanner.jobMutex.Lock()
+		if len(scanner.jobQueue) == 0 {
+			scanner.jobWorkerRunning = false
+			scanner.jobMutex.Unlock()
+			return
+		}
+		job := scanner.jobQueue[0]
+		scanner.jobQueue = scanner.jobQueue[1:]
+		if job.Status != ScanJobPending {
+			scanner.jobMutex.Unlock()
+			continue
+		}
+		job.Status = ScanJobRunning
+		job.StartedAt = time.Now()
+		scanner.jobMutex.Unlock()
+
+		scanner.runJob(job)
+	}
+}
+
+// CancelScan 取消排队中或正在执行的扫描任务
+func (scanner *NetworkScanner) CancelScan(id string) error {
+	scanner.jobMutex.Lock()
+	defer scanner.jobMutex.Unlock()
+
+	for _, job := range scanner.jobs {
+		if job.ID != id {
+			continue
+		}
+		switch job.Status {
+		case ScanJobPending:
+			job.Status = ScanJobCancelled
+			job.FinishedAt = time.Now()
+		case ScanJobRunning:
+			job.Status = ScanJobCancelled
+			close(job.cancel)
+		default:
+			return fmt.Errorf("扫描任务 #%s 已结束", id)
+		}
+		return nil
+	}
+	return fmt.Errorf("扫描任务不存在: %s", id)
+}
+
+// GetScanJobs 获取所有扫描任务（不含扫描结果），按提交时间倒序
+func (scanner *NetworkScanner) GetScanJobs() []ScanJob {
+	scanner.jobMutex.Lock()
+	defer scanner.jobMutex.Unlock()
+
+	jobs := make([]ScanJob, 0, len(scanner.jobs))
+	for i := len(scanner.jobs) - 1; i >= 0; i-- {
+		job := *scanner.jobs[i]
+		job.Results = nil
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+// GetScanJob 获取单个扫描任务及其扫描结果
+func (scanner *NetworkScanner) GetScanJob(id string) (ScanJob, bool) {
+	scanner.jobMutex.Lock()
+	defer scanner.jobMutex.Unlock()
+
+	for _, job := range scanner.jobs {
+		if job.ID == id {
+			return *job, true
+		}
+	}
+	return ScanJob{}, false
+}
+
+// isCancelled 检查任务是否已取消
+func (job *ScanJob) isCancelled() bool {
+	select {
+	case <-job.cancel:
+		return true
+	default:
+		return false
+	}
+}
+
+// runJob 执行扫描任务
+func (scanner *NetworkScanner) runJob(job *ScanJob) {
+	log.Printf("开始网络扫描，方案: %s", job.Profile)
+	scanner.addScanLog(fmt.Sprintf("开始扫描任务 #%s，方案: %s", job.ID, job.Profile))
+	startTime := time.Now()
+
+	// 更新状态（需要锁保护）
+	scanner.scanMutex.Lock()
+	scanner.status.TotalIPs = len(job.ips)
+	scanner.status.ScannedIPs = 0
+	scanner.status.FoundDevices = 0
+	scanner.status.ScanProgress = 0
+	scanner.scanMutex.Unlock()
+
+	scanner.addScanLog(fmt.Sprintf("准备扫描 %d 个IP地址", len(job.ips)))
+
+	// 扫描网络中的设备
+	devices := scanner.scanNetworkWithProgress(job)
+
+	if job.isCancelled() {
+		scanner.finishJob(job, devices, ScanJobCancelled, "")
+		scanner.scanMutex.Lock()
+		scanner.status.CurrentScan = ""
+		scanner.scanMutex.Unlock()
+		scanner.addScanLog(fmt.Sprintf("扫描任务 #%s 已取消", job.ID))
+		return
+	}
+
+	// 补充mDNS、NetBIOS、SSDP名称
+	scanner.discoverNames(devices)
+
+	// 探测开放端口和服务banner
+	scanner.scanServices(devices)
+
+	// 更新扫描结果，只有本次扫描范围内的设备会被标记为非活跃
+	scanned := make(map[string]bool, len(job.ips))
+	for _, ip := range job.ips {
+		scanned[ip.String()] = true
+	}
+	scanner.updateScanResults(devices, scanned)
+
+	// 检查IP冲突
+	conflictedCount := scanner.checkIPConflicts()
+
+	// 清理过期设备
+	scanner.cleanupInactiveDevices()
+
+	// 更新最终状态（需要锁保护）
+	scanner.scanMutex.Lock()
+	scanner.status.LastScanTime = time.Now()
+	if job.Trigger == ScanTriggerSchedule && job.interval > 0 {
+		scanner.status.NextScanTime = time.Now().Add(time.Duration(job.interval) * time.Second)
+	}
+	scanner.status.ConflictedIPs = conflictedCount
+	scanner.status.ScanProgress = 100
+	scanner.status.CurrentScan = ""
+	scanner.scanMutex.Unlock()
+
+	scanner.finishJob(job, devices, ScanJobCompleted, "")
+
+	duration := time.Since(startTime)
+	log.Printf("网络扫描完成，耗时: %v，发现 %d 个设备，冲突IP: %d", duration, len(devices), conflictedCount)
+	scanner.addScanLog(fmt.Sprintf("扫描任务 #%s 完成，耗时: %v，发现 %d 个设备，冲突IP: %d", job.ID, duration, len(devices), conflictedCount))
+}
+
+// finishJob 保存任务结果并更新任务状态
+func (scanner *NetworkScanner) finishJob(job *ScanJob, devices map[string]*DeviceInfo, status, errMsg string) {
+	results := make([]DeviceInfo, 0, len(devices))
+	for _, device := range devices {
+		results = append(results, *device)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return bytesToUint32(net.ParseIP(results[i].IP)) < bytesToUint32(net.ParseIP(results[j].IP))
+	})
+
+	scanner.jobMutex.Lock()
+	job.Status = status
+	job.Error = errMsg
+	job.Results = results
+	job.FoundDevices = len(results)
+	job.FinishedAt = time.Now()
+	if status == ScanJobCompleted {
+		job.Progress = 100
+	}
+	profile, duration, finishedAt := job.Profile, job.FinishedAt.Sub(job.StartedAt), job.FinishedAt
+	scanner.jobMutex.Unlock()
+
+	observeScan(profile, status, duration, len(results), finishedAt)
+}
+
+// updateProgress 更新任务和扫描器的扫描进度
+func (scanner *NetworkScanner) updateProgress(job *ScanJob, ip net.IP) {
+	scanner.jobMutex.Lock()
+	job.ScannedIPs++
+	if job.TotalIPs > 0 {
+		job.Progress = (job.ScannedIPs * 100) / job.TotalIPs
+	}
+	scanner.jobMutex.Unlock()
+
+	scanner.scanMutex.Lock()
+	scanner.status.ScannedIPs++
+	if scanner.status.TotalIPs > 0 {
+		scanner.status.ScanProgress = (scanner.status.ScannedIPs * 100) / scanner.status.TotalIPs
+	}
+	scanner.status.CurrentScan = ip.String()
+	scanner.scanMutex.Unlock()
+}
+
+// profileLoop 按方案的扫描间隔定时提交扫描任务
+func (scanner *NetworkScanner) profileLoop(profile config.ScanProfile, stop <-chan struct{}) {
+	defer scanner.wg.Done()
+
+	ticker := time.NewTicker(time.Duration(profile.Interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if _, err := scanner.SubmitScan(profile, ScanTriggerSchedule); err != nil {
+				log.Printf("提交定时扫描失败（方案 %s）: %v", profile.Name, err)
+				scanner.addScanLog(fmt.Sprintf("提交定时扫描失败（方案 %s）: %v", profile.Name, err))
+			}
+		}
+	}
+}
+
+// tcpProbe 依次连接TCP端口探测主机是否在线，连接成功或被拒绝（RST）都说明主机存在
+func tcpProbe(ip net.IP, ports []int, timeout time.Duration) (time.Duration, bool) {
+	for _, port := range ports {
+		startTime := time.Now()
+		conn, err := net.DialTimeout("tcp4", net.JoinHostPort(ip.String(), strconv.Itoa(port)), timeout)
+		if err == nil {
+			conn.Close()
+			return time.Since(startTime), true
+		}
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return time.Since(startTime), true
+		}
+	}
+	return 0, false
+}
+
+// sortedKeys 返回排序后的键列表
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
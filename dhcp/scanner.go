@@ -11,6 +11,8 @@ import (
 	"time"
 
 	"dhcp-server/config"
+	"dhcp-server/oui"
+	"dhcp-server/presence"
 )
 
 // ScanStatus 扫描状态
@@ -37,7 +39,14 @@ type NetworkScanner struct {
 	logMutex   sync.Mutex // 独立的日志锁
 	status     ScanStatus
 	scanResult map[string]*DeviceInfo
-	scanLog    []string // 扫描日志
+	scanLog    []string       // 扫描日志
+	names      *nameDiscovery // mDNS、NetBIOS、SSDP名称发现
+
+	jobMutex         sync.Mutex
+	jobs             []*ScanJob // 扫描任务历史
+	jobQueue         []*ScanJob // 等待执行的任务
+	jobSeq           int
+	jobWorkerRunning bool
 }
 
 // DeviceInfo 设备信息
@@ -49,11 +58,25 @@ type DeviceInfo struct {
 	IsActive bool      `json:"is_active"`
 	Vendor   string    `json:"vendor"`
 	Response string    `json:"response"` // 响应时间
+	Method   string    `json:"method"`   // 发现方式：arp、icmp
+
+	LocallyAdministered bool `json:"locally_administered"` // 本地管理MAC地址
+	Randomized          bool `json:"randomized"`           // 疑似随机化MAC地址
+
+	HostnameSource string            `json:"hostname_source,omitempty"` // 主机名来源：dns、mdns、netbios、ssdp
+	Model          string            `json:"model,omitempty"`           // 型号（mDNS device-info或UPnP描述）
+	ModelSource    string            `json:"model_source,omitempty"`    // 型号来源
+	Workgroup      string            `json:"workgroup,omitempty"`       // NetBIOS工作组
+	Names          map[string]string `json:"names,omitempty"`           // 各来源发现的名称
+
+	OpenPorts     []int         `json:"open_ports,omitempty"`     // 开放的TCP端口
+	Services      []ServiceInfo `json:"services,omitempty"`       // 端口上识别到的服务和banner
+	SuggestedType string        `json:"suggested_type,omitempty"` // 根据服务推测的设备类型
 }
 
 // NewNetworkScanner 创建网络扫描器
 func NewNetworkScanner(cfg *config.Config, pool *IPPool) *NetworkScanner {
-	return &NetworkScanner{
+	scanner := &NetworkScanner{
 		config:     cfg,
 		pool:       pool,
 		stopChan:   make(chan struct{}),
@@ -63,6 +86,8 @@ func NewNetworkScanner(cfg *config.Config, pool *IPPool) *NetworkScanner {
 			IsEnabled: cfg.Scanner.Enabled,
 		},
 	}
+	scanner.names = newNameDiscovery(cfg.Server.Interface, scanner.logNameDiscovery)
+	return scanner
 }
 
 // Start 启动扫描器
@@ -79,12 +104,22 @@ func (scanner *NetworkScanner) Start() {
 	scanner.status.IsRunning = true
 	scanner.status.IsEnabled = true
 
-	// 立即执行一次扫描
-	go scanner.performScan()
+	// 每个配置了扫描间隔的方案立即执行一次扫描，并启动定时扫描任务
+	for _, profile := range scanner.Profiles() {
+		if profile.Interval <= 0 {
+			continue
+		}
+		if _, err := scanner.SubmitScan(profile, ScanTriggerSchedule); err != nil {
+			log.Printf("扫描方案 %s 无效: %v", profile.Name, err)
+			scanner.addScanLog(fmt.Sprintf("扫描方案 %s 无效: %v", profile.Name, err))
+			continue
+		}
+		scanner.wg.Add(1)
+		go scanner.profileLoop(profile, scanner.stopChan)
+	}
 
-	// 启动定时扫描任务
-	scanner.wg.Add(1)
-	go scanner.scanLoop()
+	// 被动监听mDNS、SSDP通告
+	scanner.names.startPassive(scanner.nameSources(), scanner.stopChan, &scanner.wg)
 
 	// 在锁外添加日志
 	scanner.addScanLog("扫描器已启动")
@@ -128,242 +163,191 @@ func (scanner *NetworkScanner) GetStatus() ScanStatus {
 	return scanner.status
 }
 
-// scanLoop 扫描循环
-func (scanner *NetworkScanner) scanLoop() {
-	defer scanner.wg.Done()
-
-	ticker := time.NewTicker(time.Duration(scanner.config.Scanner.ScanInterval) * time.Second)
-	defer ticker.Stop()
+// scanNetworkWithProgress 带进度跟踪的网络扫描，按任务的探测方式依次尝试ARP、ICMP和TCP
+func (scanner *NetworkScanner) scanNetworkWithProgress(job *ScanJob) map[string]*DeviceInfo {
+	devices := make(map[string]*DeviceInfo)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
 
-	for {
-		select {
-		case <-scanner.stopChan:
-			return
-		case <-ticker.C:
-			scanner.performScan()
-		}
+	// 先进行ARP扫描，可发现屏蔽ICMP的静默设备
+	arpResults := make(map[string]arpReply)
+	if job.methods["arp"] {
+		arpResults = scanner.arpSweep(job.ips)
 	}
-}
 
-// performScan 执行网络扫描
-func (scanner *NetworkScanner) performScan() {
-	scanner.scanMutex.Lock()
-	if !scanner.status.IsRunning {
-		scanner.scanMutex.Unlock()
-		return
+	// ICMP或TCP探测成功但尚未获取MAC的IP
+	type probeResult struct {
+		rtt    time.Duration
+		method string
 	}
-	scanner.scanMutex.Unlock()
-
-	log.Println("开始网络扫描...")
-	scanner.addScanLog("开始网络扫描")
-	startTime := time.Now()
+	probed := make(map[string]probeResult)
 
-	// 获取网络范围
-	network := scanner.config.Network.Subnet
-	ipNet, err := scanner.parseNetwork(network)
-	if err != nil {
-		log.Printf("解析网络地址失败: %v", err)
-		scanner.addScanLog(fmt.Sprintf("解析网络地址失败: %v", err))
-		return
+	tcpTimeout := time.Duration(scanner.config.Scanner.PingTimeout) * time.Millisecond
+	if tcpTimeout <= 0 {
+		tcpTimeout = time.Second
 	}
 
-	// 生成要扫描的IP列表
-	ips := scanner.generateIPList(ipNet)
-
-	// 更新状态（需要锁保护）
-	scanner.scanMutex.Lock()
-	scanner.status.TotalIPs = len(ips)
-	scanner.status.ScannedIPs = 0
-	scanner.status.FoundDevices = 0
-	scanner.status.ScanProgress = 0
-	scanner.scanMutex.Unlock()
-
-	scanner.addScanLog(fmt.Sprintf("准备扫描 %d 个IP地址", len(ips)))
-
-	// 扫描网络中的设备
-	devices := scanner.scanNetworkWithProgress(ips)
-
-	// 更新扫描结果
-	scanner.updateScanResults(devices)
-
-	// 检查IP冲突
-	conflictedCount := scanner.checkIPConflicts()
-
-	// 清理过期设备
-	scanner.cleanupInactiveDevices()
-
-	// 更新最终状态（需要锁保护）
-	scanner.scanMutex.Lock()
-	scanner.status.LastScanTime = time.Now()
-	scanner.status.NextScanTime = time.Now().Add(time.Duration(scanner.config.Scanner.ScanInterval) * time.Second)
-	scanner.status.ConflictedIPs = conflictedCount
-	scanner.status.ScanProgress = 100
-	scanner.status.CurrentScan = ""
-	scanner.scanMutex.Unlock()
-
-	duration := time.Since(startTime)
-	log.Printf("网络扫描完成，耗时: %v，发现 %d 个设备，冲突IP: %d", duration, len(devices), conflictedCount)
-	scanner.addScanLog(fmt.Sprintf("扫描完成，耗时: %v，发现 %d 个设备，冲突IP: %d", duration, len(devices), conflictedCount))
-}
-
-// scanNetworkWithProgress 带进度跟踪的网络扫描
-func (scanner *NetworkScanner) scanNetworkWithProgress(ips []net.IP) map[string]*DeviceInfo {
-	devices := make(map[string]*DeviceInfo)
-	var wg sync.WaitGroup
-	var mu sync.Mutex
-
 	// 限制并发数
-	semaphore := make(chan struct{}, scanner.config.Scanner.MaxConcurrency)
+	semaphore := make(chan struct{}, job.concurrency)
 
-	for i, ip := range ips {
+	for _, ip := range job.ips {
 		wg.Add(1)
-		go func(targetIP net.IP, index int) {
+		go func(targetIP net.IP) {
 			defer wg.Done()
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
 
+			if job.isCancelled() {
+				return
+			}
+
 			// 更新进度
-			scanner.scanMutex.Lock()
-			scanner.status.ScannedIPs++
-			scanner.status.ScanProgress = (scanner.status.ScannedIPs * 100) / scanner.status.TotalIPs
-			scanner.status.CurrentScan = targetIP.String()
-			scanner.scanMutex.Unlock()
+			scanner.updateProgress(job, targetIP)
 
-			if device := scanner.pingDevice(targetIP); device != nil {
+			if reply, ok := arpResults[targetIP.String()]; ok {
+				device := scanner.buildDevice(targetIP, reply.MAC, reply.RTT, "arp")
 				mu.Lock()
 				devices[device.MAC] = device
 				mu.Unlock()
+				scanner.recordFoundDevice(device)
+				return
+			}
 
-				// 更新发现设备计数
-				scanner.scanMutex.Lock()
-				scanner.status.FoundDevices++
-				scanner.scanMutex.Unlock()
+			if job.methods["icmp"] {
+				if responseTime, ok := scanner.pingDevice(targetIP); ok {
+					mu.Lock()
+					probed[targetIP.String()] = probeResult{rtt: responseTime, method: "icmp"}
+					mu.Unlock()
+					return
+				}
+			}
 
-				scanner.addScanLog(fmt.Sprintf("发现设备: %s (%s) - %s", device.MAC, device.IP, device.Hostname))
+			if job.methods["tcp"] {
+				if responseTime, ok := tcpProbe(targetIP, job.TCPPorts, tcpTimeout); ok {
+					mu.Lock()
+					probed[targetIP.String()] = probeResult{rtt: responseTime, method: "tcp"}
+					mu.Unlock()
+				}
 			}
-		}(ip, i)
+		}(ip)
 	}
 
 	wg.Wait()
+
+	// ping和TCP连接会填充内核邻居表，统一读取一次获取MAC地址
+	// 跨网段的目标没有MAC地址，以IP作为键记录
+	if len(probed) > 0 {
+		neighbors, err := readNeighborTable()
+		if err != nil {
+			log.Printf("读取邻居表失败: %v", err)
+			scanner.addScanLog(fmt.Sprintf("读取邻居表失败: %v", err))
+		}
+		for ipStr, result := range probed {
+			device := scanner.buildDevice(net.ParseIP(ipStr), neighbors[ipStr], result.rtt, result.method)
+			devices[deviceKey(device)] = device
+			scanner.recordFoundDevice(device)
+		}
+	}
+
 	return devices
 }
 
-// parseNetwork 解析网络地址
-func (scanner *NetworkScanner) parseNetwork(network string) (*net.IPNet, error) {
-	_, ipNet, err := net.ParseCIDR(network)
-	if err != nil {
-		return nil, fmt.Errorf("无效的网络地址: %s", network)
+// deviceKey 扫描结果的键：优先使用MAC地址，无法获取MAC时使用IP
+func deviceKey(device *DeviceInfo) string {
+	if device.MAC != "" {
+		return device.MAC
 	}
-	return ipNet, nil
+	return device.IP
 }
 
-// generateIPList 生成要扫描的IP列表
-func (scanner *NetworkScanner) generateIPList(ipNet *net.IPNet) []net.IP {
-	var ips []net.IP
-
-	// 获取网络地址
-	networkIP := ipNet.IP.To4()
-	if networkIP == nil {
-		return ips
+// scanMethod 获取探测方式
+func (scanner *NetworkScanner) scanMethod() string {
+	switch strings.ToLower(scanner.config.Scanner.Method) {
+	case "icmp", "ping":
+		return "icmp"
+	case "arp":
+		return "arp"
+	default:
+		return "both"
 	}
+}
 
-	// 计算网络掩码
-	mask := ipNet.Mask
-	ones, bits := mask.Size()
-
-	// 计算可用的IP数量
-	hosts := 1 << uint(bits-ones)
-
-	// 生成IP列表（跳过网络地址和广播地址）
-	for i := 1; i < hosts-1; i++ {
-		ip := make(net.IP, 4)
-		copy(ip, networkIP)
-
-		// 计算IP地址
-		for j := 0; j < 4; j++ {
-			ip[j] = networkIP[j] + byte((i>>(8*(3-j)))&0xFF)
-		}
+// arpSweep 在DHCP接口上执行ARP扫描，不可用时返回空结果
+func (scanner *NetworkScanner) arpSweep(ips []net.IP) map[string]arpReply {
+	arp, err := newARPScanner(scanner.config.Server.Interface)
+	if err != nil {
+		log.Printf("ARP扫描不可用，仅使用ICMP探测: %v", err)
+		scanner.addScanLog(fmt.Sprintf("ARP扫描不可用: %v", err))
+		return map[string]arpReply{}
+	}
+	defer arp.Close()
 
-		// 检查是否在扫描范围内
-		if scanner.isInScanRange(ip) {
-			ips = append(ips, ip)
-		}
+	timeout := time.Duration(scanner.config.Scanner.ARPTimeout) * time.Millisecond
+	if timeout <= 0 {
+		timeout = time.Second
 	}
 
-	return ips
+	replies := arp.Sweep(ips, timeout, nil)
+	scanner.addScanLog(fmt.Sprintf("ARP扫描完成，%d 个设备应答", len(replies)))
+	return replies
 }
 
-// isInScanRange 检查IP是否在扫描范围内
-func (scanner *NetworkScanner) isInScanRange(ip net.IP) bool {
-	// 直接使用DHCP可分配的IP范围
-	dhcpStartIP := net.ParseIP(scanner.config.Network.StartIP)
-	dhcpEndIP := net.ParseIP(scanner.config.Network.EndIP)
-
-	if dhcpStartIP != nil && dhcpEndIP != nil {
-		return bytesToUint32(ip) >= bytesToUint32(dhcpStartIP) &&
-			bytesToUint32(ip) <= bytesToUint32(dhcpEndIP)
-	}
+// recordFoundDevice 更新发现设备计数并记录日志
+func (scanner *NetworkScanner) recordFoundDevice(device *DeviceInfo) {
+	scanner.scanMutex.Lock()
+	scanner.status.FoundDevices++
+	scanner.scanMutex.Unlock()
 
-	return true
+	scanner.addScanLog(fmt.Sprintf("发现设备: %s (%s) - %s [%s]", device.MAC, device.IP, device.Hostname, device.Method))
 }
 
-// pingDevice 探测设备
-func (scanner *NetworkScanner) pingDevice(ip net.IP) *DeviceInfo {
+// pingDevice 使用ICMP探测设备，返回响应时间
+func (scanner *NetworkScanner) pingDevice(ip net.IP) (time.Duration, bool) {
 	startTime := time.Now()
 
 	// 使用ping命令探测设备
 	cmd := exec.Command("ping", "-c", "1", "-W", strconv.Itoa(scanner.config.Scanner.PingTimeout/1000), ip.String())
-	err := cmd.Run()
-
-	responseTime := time.Since(startTime)
-
-	if err != nil {
-		return nil
-	}
-
-	// 获取MAC地址
-	mac, err := scanner.getMACAddress(ip)
-	if err != nil {
-		return nil
+	if err := cmd.Run(); err != nil {
+		return 0, false
 	}
 
-	// 获取主机名
-	hostname := scanner.reverseDNSLookup(ip)
-
-	// 获取厂商信息
-	vendor := scanner.getVendorInfo(mac)
+	return time.Since(startTime), true
+}
 
-	return &DeviceInfo{
+// buildDevice 根据探测结果构建设备信息
+func (scanner *NetworkScanner) buildDevice(ip net.IP, mac string, responseTime time.Duration, method string) *DeviceInfo {
+	vendor := oui.Lookup(mac)
+	device := &DeviceInfo{
 		MAC:      mac,
 		IP:       ip.String(),
-		Hostname: hostname,
+		Hostname: scanner.reverseDNSLookup(ip),
 		LastSeen: time.Now(),
 		IsActive: true,
-		Vendor:   vendor,
+		Vendor:   vendor.Vendor,
 		Response: responseTime.String(),
+		Method:   method,
+
+		LocallyAdministered: vendor.LocallyAdministered,
+		Randomized:          vendor.Randomized,
+	}
+	if device.Vendor == "" {
+		device.Vendor = "Unknown"
+	}
+	if device.Hostname != "" {
+		device.HostnameSource = nameSourceDNS
 	}
+	return device
 }
 
-// getMACAddress 获取MAC地址
+// getMACAddress 从内核邻居表获取MAC地址
 func (scanner *NetworkScanner) getMACAddress(ip net.IP) (string, error) {
-	// 使用arp命令获取MAC地址
-	cmd := exec.Command("arp", "-n", ip.String())
-	output, err := cmd.Output()
+	neighbors, err := readNeighborTable()
 	if err != nil {
 		return "", err
 	}
 
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		if strings.Contains(line, ip.String()) {
-			fields := strings.Fields(line)
-			if len(fields) >= 3 {
-				mac := fields[2]
-				if mac != "<incomplete>" && mac != "incomplete" {
-					return mac, nil
-				}
-			}
-		}
+	if mac, ok := neighbors[ip.String()]; ok {
+		return mac, nil
 	}
 
 	return "", fmt.Errorf("无法获取MAC地址")
@@ -385,59 +369,61 @@ func (scanner *NetworkScanner) reverseDNSLookup(ip net.IP) string {
 	return hostname
 }
 
-// getVendorInfo 获取厂商信息
-func (scanner *NetworkScanner) getVendorInfo(mac string) string {
-	// 简单的MAC地址厂商识别
-	if strings.HasPrefix(mac, "00:50:56") {
-		return "VMware"
-	} else if strings.HasPrefix(mac, "00:0c:29") {
-		return "VMware"
-	} else if strings.HasPrefix(mac, "00:1a:11") {
-		return "Google"
-	} else if strings.HasPrefix(mac, "00:16:3e") {
-		return "Xen"
-	} else if strings.HasPrefix(mac, "52:54:00") {
-		return "QEMU"
-	}
-	return "Unknown"
-}
-
-// updateScanResults 更新扫描结果
-func (scanner *NetworkScanner) updateScanResults(devices map[string]*DeviceInfo) {
+// updateScanResults 更新扫描结果，scanned为本次扫描的IP集合
+func (scanner *NetworkScanner) updateScanResults(devices map[string]*DeviceInfo, scanned map[string]bool) {
 	now := time.Now()
 
 	// 更新现有设备状态
 	for mac, device := range devices {
+		if device.MAC != "" {
+			presence.Observe(device.MAC, device.IP, device.Hostname, presence.SourceScan)
+		}
 		if existing, exists := scanner.scanResult[mac]; exists {
 			existing.IP = device.IP
 			existing.Hostname = device.Hostname
+			existing.HostnameSource = device.HostnameSource
+			existing.Workgroup = device.Workgroup
+			existing.Names = device.Names
+			if device.Model != "" {
+				existing.Model = device.Model
+				existing.ModelSource = device.ModelSource
+			}
 			existing.LastSeen = now
 			existing.IsActive = true
 			existing.Response = device.Response
+			existing.Method = device.Method
+			// 厂商数据库可能已重新加载
+			existing.Vendor = device.Vendor
+			existing.LocallyAdministered = device.LocallyAdministered
+			existing.Randomized = device.Randomized
+			if scanner.config.Scanner.ServiceScan.Enabled {
+				existing.OpenPorts = device.OpenPorts
+				existing.Services = device.Services
+				existing.SuggestedType = device.SuggestedType
+			}
 		} else {
 			scanner.scanResult[mac] = device
 		}
 	}
 
-	// 标记未响应的设备为非活跃
+	// 标记本次扫描范围内未响应的设备为非活跃
 	for mac, device := range scanner.scanResult {
-		if _, exists := devices[mac]; !exists {
+		if _, exists := devices[mac]; !exists && scanned[device.IP] {
 			device.IsActive = false
 		}
 	}
 
 	// 自动更新设备管理中的设备状态
-	scanner.updateDeviceManagementStatus(devices)
+	scanner.updateDeviceManagementStatus(devices, scanned)
 }
 
-// updateDeviceManagementStatus 更新设备管理中的设备状态
-func (scanner *NetworkScanner) updateDeviceManagementStatus(devices map[string]*DeviceInfo) {
-	// 创建活跃设备的MAC地址映射
+// updateDeviceManagementStatus 根据本次扫描更新设备管理中的设备状态，
+// devices为本次扫描响应的设备，scanned为本次扫描的IP集合，范围外的设备保持原状态
+func (scanner *NetworkScanner) updateDeviceManagementStatus(devices map[string]*DeviceInfo, scanned map[string]bool) {
+	// 创建本次扫描响应设备的MAC地址映射
 	activeMacs := make(map[string]bool)
-	for mac, device := range devices {
-		if device.IsActive {
-			activeMacs[mac] = true
-		}
+	for mac := range devices {
+		activeMacs[mac] = true
 	}
 
 	// 更新设备管理中的设备状态
@@ -447,7 +433,20 @@ func (scanner *NetworkScanner) updateDeviceManagementStatus(devices map[string]*
 			scanner.config.Devices[i].IsActive = true
 			scanner.config.Devices[i].LastSeen = time.Now()
 			log.Printf("网络扫描器检测到设备在线: %s (%s)", device.MAC, device.Hostname)
+
+			// 根据服务识别结果给出设备类型建议，不覆盖手工填写的类型
+			if suggested := devices[device.MAC].SuggestedType; suggested != "" && suggested != device.SuggestedType {
+				scanner.config.Devices[i].SuggestedType = suggested
+				if device.DeviceType == "" {
+					log.Printf("设备 %s 建议类型: %s", device.MAC, suggested)
+				}
+			}
 		} else {
+			// 设备最后已知的IP不在本次扫描范围内，无法判断是否离线
+			if !scanned[scanner.knownDeviceIP(device)] {
+				continue
+			}
+
 			// 如果设备不在扫描结果中，检查是否有活跃的DHCP租约
 			// 如果没有活跃租约，则设置为离线
 			hasActiveLease := false
@@ -469,6 +468,17 @@ func (scanner *NetworkScanner) updateDeviceManagementStatus(devices map[string]*
 
 }
 
+// knownDeviceIP 返回管理设备最后已知的IP：扫描结果、租约、静态绑定依次查找
+func (scanner *NetworkScanner) knownDeviceIP(device config.DeviceInfo) string {
+	if result, exists := scanner.scanResult[device.MAC]; exists && result.IP != "" {
+		return result.IP
+	}
+	if lease, exists := scanner.pool.GetLeaseByMAC(device.MAC); exists {
+		return lease.IP.String()
+	}
+	return device.StaticIP
+}
+
 // checkIPConflicts 检查IP冲突
 func (scanner *NetworkScanner) checkIPConflicts() int {
 	conflictCount := 0
@@ -478,6 +488,9 @@ func (scanner *NetworkScanner) checkIPConflicts() int {
 	for _, lease := range leases {
 		// 检查租约中的IP是否被其他设备使用
 		for deviceMAC, device := range scanner.scanResult {
+			if device.MAC == "" {
+				continue
+			}
 			if device.IP == lease.IP.String() && deviceMAC != lease.MAC {
 				log.Printf("检测到IP冲突: %s 被 %s 使用，但租约分配给 %s",
 					device.IP, deviceMAC, lease.MAC)
@@ -0,0 +1,155 @@
+package dhcp
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxScanTargets 单次扫描的IP数量上限，防止误配置的大网段（如/8）拖垮扫描器
+const maxScanTargets = 65536
+
+// ipRange 闭区间形式的IPv4地址范围
+type ipRange struct {
+	start uint32
+	end   uint32
+}
+
+// contains 检查IP是否在范围内
+func (r ipRange) contains(ip uint32) bool {
+	return ip >= r.start && ip <= r.end
+}
+
+// size 范围内的地址数量
+func (r ipRange) size() uint64 {
+	return uint64(r.end) - uint64(r.start) + 1
+}
+
+// parseTargetSpec 解析单个扫描目标，支持以下格式：
+//
+//	192.168.1.0/24              CIDR（/31以下跳过网络地址和广播地址）
+//	192.168.1.10-192.168.1.50   完整范围
+//	192.168.1.10-50             末段简写范围
+//	192.168.1.10                单个IP
+func parseTargetSpec(spec string) (ipRange, error) {
+	spec = strings.TrimSpace(spec)
+
+	if strings.Contains(spec, "/") {
+		_, ipNet, err := net.ParseCIDR(spec)
+		if err != nil || ipNet.IP.To4() == nil {
+			return ipRange{}, fmt.Errorf("无效的CIDR: %s", spec)
+		}
+		ones, bits := ipNet.Mask.Size()
+		start := bytesToUint32(ipNet.IP)
+		end := start | uint32(uint64(1)<<uint(bits-ones)-1)
+		if bits-ones >= 2 {
+			start++
+			end--
+		}
+		return ipRange{start: start, end: end}, nil
+	}
+
+	if dash := strings.Index(spec, "-"); dash > 0 {
+		startIP := net.ParseIP(strings.TrimSpace(spec[:dash])).To4()
+		if startIP == nil {
+			return ipRange{}, fmt.Errorf("无效的IP范围: %s", spec)
+		}
+		endPart := strings.TrimSpace(spec[dash+1:])
+		endIP := net.ParseIP(endPart).To4()
+		if endIP == nil {
+			// 末段简写：192.168.1.10-50
+			last, err := strconv.Atoi(endPart)
+			if err != nil || last < 0 || last > 255 {
+				return ipRange{}, fmt.Errorf("无效的IP范围: %s", spec)
+			}
+			endIP = net.IPv4(startIP[0], startIP[1], startIP[2], byte(last)).To4()
+		}
+		r := ipRange{start: bytesToUint32(startIP), end: bytesToUint32(endIP)}
+		if r.start > r.end {
+			return ipRange{}, fmt.Errorf("IP范围起始地址大于结束地址: %s", spec)
+		}
+		return r, nil
+	}
+
+	ip := net.ParseIP(spec).To4()
+	if ip == nil {
+		return ipRange{}, fmt.Errorf("无效的扫描目标: %s", spec)
+	}
+	return ipRange{start: bytesToUint32(ip), end: bytesToUint32(ip)}, nil
+}
+
+// parseTargetSpecs 解析多个扫描目标
+func parseTargetSpecs(specs []string) ([]ipRange, error) {
+	ranges := make([]ipRange, 0, len(specs))
+	for _, spec := range specs {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		r, err := parseTargetSpec(spec)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+// expandTargets 展开扫描目标并去除排除项，返回去重排序后的IP列表
+func expandTargets(targets, excludes []string) ([]net.IP, error) {
+	include, err := parseTargetSpecs(targets)
+	if err != nil {
+		return nil, err
+	}
+	if len(include) == 0 {
+		return nil, fmt.Errorf("未配置扫描目标")
+	}
+	exclude, err := parseTargetSpecs(excludes)
+	if err != nil {
+		return nil, err
+	}
+
+	var total uint64
+	for _, r := range include {
+		total += r.size()
+	}
+	if total > maxScanTargets {
+		return nil, fmt.Errorf("扫描目标过大: %d 个地址，上限 %d", total, maxScanTargets)
+	}
+
+	seen := make(map[uint32]bool, total)
+	values := make([]uint32, 0, total)
+	for _, r := range include {
+		for v := uint64(r.start); v <= uint64(r.end); v++ {
+			ip := uint32(v)
+			if seen[ip] || isExcluded(ip, exclude) {
+				continue
+			}
+			seen[ip] = true
+			values = append(values, ip)
+		}
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	ips := make([]net.IP, len(values))
+	for i, v := range values {
+		ips[i] = uint32ToIP(v)
+	}
+	return ips, nil
+}
+
+// isExcluded 检查IP是否在排除范围内
+func isExcluded(ip uint32, exclude []ipRange) bool {
+	for _, r := range exclude {
+		if r.contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// uint32ToIP 将uint32转换为IP地址
+func uint32ToIP(v uint32) net.IP {
+	return net.IPv4(byte(v>>24), byte(v>>16), byte(v>>8), byte(v)).To4()
+}
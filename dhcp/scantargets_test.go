@@ -0,0 +1,178 @@
+package dhcp
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"dhcp-server/config"
+)
+
+// ipValue 测试用：IP字符串转为uint32
+func ipValue(s string) uint32 {
+	return bytesToUint32(net.ParseIP(s).To4())
+}
+
+func TestParseTargetSpec(t *testing.T) {
+	cases := []struct {
+		spec    string
+		start   string
+		end     string
+		wantErr bool
+	}{
+		{spec: "192.168.1.0/24", start: "192.168.1.1", end: "192.168.1.254"},
+		{spec: "192.168.1.77/24", start: "192.168.1.1", end: "192.168.1.254"},
+		{spec: "10.0.0.0/30", start: "10.0.0.1", end: "10.0.0.2"},
+		{spec: "10.0.0.4/31", start: "10.0.0.4", end: "10.0.0.5"},
+		{spec: "10.0.0.9/32", start: "10.0.0.9", end: "10.0.0.9"},
+		{spec: "192.168.1.10-192.168.1.50", start: "192.168.1.10", end: "192.168.1.50"},
+		{spec: "192.168.1.250-192.168.2.5", start: "192.168.1.250", end: "192.168.2.5"},
+		{spec: "192.168.1.10-50", start: "192.168.1.10", end: "192.168.1.50"},
+		{spec: " 192.168.1.10 - 20 ", start: "192.168.1.10", end: "192.168.1.20"},
+		{spec: "192.168.1.10", start: "192.168.1.10", end: "192.168.1.10"},
+		{spec: "192.168.1.50-10", wantErr: true},
+		{spec: "192.168.1.10-256", wantErr: true},
+		{spec: "192.168.1.10-abc", wantErr: true},
+		{spec: "192.168.1.0/33", wantErr: true},
+		{spec: "fd00::/64", wantErr: true},
+		{spec: "fd00::1", wantErr: true},
+		{spec: "not-an-ip", wantErr: true},
+	}
+
+	for _, c := range cases {
+		r, err := parseTargetSpec(c.spec)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%q: 应返回错误, 实际为 %+v", c.spec, r)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: 解析失败: %v", c.spec, err)
+			continue
+		}
+		if r.start != ipValue(c.start) || r.end != ipValue(c.end) {
+			t.Errorf("%q: 期望 %s-%s, 实际为 %s-%s", c.spec, c.start, c.end, uint32ToIP(r.start), uint32ToIP(r.end))
+		}
+	}
+}
+
+func TestExpandTargets(t *testing.T) {
+	cases := []struct {
+		name     string
+		targets  []string
+		excludes []string
+		want     []string
+		errMatch string
+	}{
+		{
+			name:    "去重并排序",
+			targets: []string{"10.0.0.5-7", "10.0.0.1", "10.0.0.6"},
+			want:    []string{"10.0.0.1", "10.0.0.5", "10.0.0.6", "10.0.0.7"},
+		},
+		{
+			name:     "排除单个地址和范围",
+			targets:  []string{"10.0.0.0/29"},
+			excludes: []string{"10.0.0.2", "10.0.0.4-5"},
+			want:     []string{"10.0.0.1", "10.0.0.3", "10.0.0.6"},
+		},
+		{
+			name:     "忽略空白项",
+			targets:  []string{"", "10.0.0.1", "  "},
+			excludes: []string{""},
+			want:     []string{"10.0.0.1"},
+		},
+		{
+			name:     "全部被排除",
+			targets:  []string{"10.0.0.1-3"},
+			excludes: []string{"10.0.0.0/24"},
+			want:     []string{},
+		},
+		{
+			name:    "正好达到上限",
+			targets: []string{"10.0.0.0-10.0.255.255"},
+		},
+		{
+			name:     "超过上限",
+			targets:  []string{"10.0.0.0/15"},
+			errMatch: "扫描目标过大",
+		},
+		{
+			name:     "多个目标合计超过上限",
+			targets:  []string{"10.0.0.0-10.0.255.255", "10.1.0.1"},
+			errMatch: "扫描目标过大",
+		},
+		{
+			name:     "没有目标",
+			targets:  []string{" "},
+			errMatch: "未配置扫描目标",
+		},
+		{
+			name:     "目标格式错误",
+			targets:  []string{"10.0.0.x"},
+			errMatch: "无效的扫描目标",
+		},
+		{
+			name:     "排除项格式错误",
+			targets:  []string{"10.0.0.1"},
+			excludes: []string{"10.0.0.9-1"},
+			errMatch: "起始地址大于结束地址",
+		},
+	}
+
+	for _, c := range cases {
+		ips, err := expandTargets(c.targets, c.excludes)
+		if c.errMatch != "" {
+			if err == nil || !strings.Contains(err.Error(), c.errMatch) {
+				t.Errorf("%s: 期望包含 %q 的错误, 实际为 %v", c.name, c.errMatch, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: 展开失败: %v", c.name, err)
+			continue
+		}
+		if c.want == nil {
+			if len(ips) != maxScanTargets {
+				t.Errorf("%s: 期望 %d 个地址, 实际为 %d", c.name, maxScanTargets, len(ips))
+			}
+			continue
+		}
+		got := make([]string, len(ips))
+		for i, ip := range ips {
+			got[i] = ip.String()
+		}
+		if strings.Join(got, ",") != strings.Join(c.want, ",") {
+			t.Errorf("%s: 期望 %v, 实际为 %v", c.name, c.want, got)
+		}
+	}
+}
+
+func TestUpdateDeviceManagementStatusScope(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.Devices = []config.DeviceInfo{
+			{MAC: "aa:bb:cc:00:00:01", Hostname: "inside", IsActive: false},
+			{MAC: "aa:bb:cc:00:00:02", Hostname: "outside", IsActive: true, StaticIP: "192.168.2.20"},
+			{MAC: "aa:bb:cc:00:00:03", Hostname: "stale", IsActive: false},
+			{MAC: "aa:bb:cc:00:00:04", Hostname: "gone", IsActive: true},
+		}
+	})
+	scanner := NewNetworkScanner(s.config, s.pool)
+	// 历史扫描结果：stale在本次扫描范围外且残留为活跃，gone在范围内
+	scanner.scanResult["aa:bb:cc:00:00:03"] = &DeviceInfo{MAC: "aa:bb:cc:00:00:03", IP: "192.168.2.30", IsActive: true}
+	scanner.scanResult["aa:bb:cc:00:00:04"] = &DeviceInfo{MAC: "aa:bb:cc:00:00:04", IP: "192.168.1.30", IsActive: true}
+
+	devices := map[string]*DeviceInfo{
+		"aa:bb:cc:00:00:01": {MAC: "aa:bb:cc:00:00:01", IP: "192.168.1.10", IsActive: true},
+	}
+	scanned := map[string]bool{"192.168.1.10": true, "192.168.1.30": true}
+	scanner.updateScanResults(devices, scanned)
+
+	// 范围外的设备保持原状态，不受残留扫描结果影响
+	want := map[string]bool{"inside": true, "outside": true, "stale": false, "gone": false}
+	for _, device := range s.config.Devices {
+		if device.IsActive != want[device.Hostname] {
+			t.Errorf("设备 %s 在线状态应为 %v, 实际为 %v", device.Hostname, want[device.Hostname], device.IsActive)
+		}
+	}
+}
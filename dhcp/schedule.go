@@ -0,0 +1,224 @@
+package dhcp
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"dhcp-server/config"
+)
+
+// scheduleLookahead 计算时间段边界时向后查找的最长时间，超过该时间视为一直允许
+const scheduleLookahead = 8 * 24 * time.Hour
+
+// Clock 时间来源，测试时可替换为固定时间
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock 使用系统时间
+type SystemClock struct{}
+
+// Now 返回当前系统时间
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Schedule 编译后的访问时间表
+type Schedule struct {
+	Name  string
+	rules *config.ScheduleRules
+}
+
+// CompileSchedule 解析访问时间表配置
+func CompileSchedule(cfg config.AccessSchedule) (*Schedule, error) {
+	rules, err := config.ParseSchedule(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Schedule{Name: cfg.Name, rules: rules}, nil
+}
+
+// scheduleCache 按当前配置编译的访问时间表，时间表列表被整体替换后重新编译
+type scheduleCache struct {
+	mutex     sync.Mutex
+	source    []config.AccessSchedule
+	compiled  map[string]*Schedule
+	errs      map[string]error
+	populated bool
+}
+
+// sameScheduleList 判断两个时间表列表是否为同一个切片
+func sameScheduleList(a, b []config.AccessSchedule) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
+
+// lookup 返回编译后的时间表，时间表不存在或无效时返回错误
+func (c *scheduleCache) lookup(schedules []config.AccessSchedule, name string) (*Schedule, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !c.populated || !sameScheduleList(c.source, schedules) {
+		c.source = schedules
+		c.compiled = make(map[string]*Schedule, len(schedules))
+		c.errs = make(map[string]error)
+		c.populated = true
+		for _, cfg := range schedules {
+			schedule, err := CompileSchedule(cfg)
+			if err != nil {
+				c.errs[cfg.Name] = err
+				continue
+			}
+			c.compiled[cfg.Name] = schedule
+		}
+	}
+
+	if err, ok := c.errs[name]; ok {
+		return nil, err
+	}
+	if schedule, ok := c.compiled[name]; ok {
+		return schedule, nil
+	}
+	return nil, fmt.Errorf("时间表不存在: %s", name)
+}
+
+// Allows 检查指定时间是否在允许的时间段内
+func (s *Schedule) Allows(t time.Time) bool {
+	return s.rules.Allows(t)
+}
+
+// WindowEnd 返回包含t的允许时间段的结束时间；t不在允许时间段内时返回t，
+// 在查找范围内一直允许时返回false
+func (s *Schedule) WindowEnd(t time.Time) (time.Time, bool) {
+	return s.windowEnd(t, scheduleLookahead)
+}
+
+// windowEnd 在t之后lookahead范围内查找允许时间段的结束时间
+func (s *Schedule) windowEnd(t time.Time, lookahead time.Duration) (time.Time, bool) {
+	if !s.Allows(t) {
+		return t, true
+	}
+	// 时间段和cron都以分钟为粒度，按分钟向后查找第一个不允许的时刻
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(lookahead)
+	for ; next.Before(limit); next = next.Add(time.Minute) {
+		if !s.Allows(next) {
+			return next, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// NextAllowed 返回t之后第一个允许的时刻，查找范围内没有时返回false
+func (s *Schedule) NextAllowed(t time.Time) (time.Time, bool) {
+	if s.Allows(t) {
+		return t, true
+	}
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(scheduleLookahead)
+	for ; next.Before(limit); next = next.Add(time.Minute) {
+		if s.Allows(next) {
+			return next, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ScheduleStatus 设备当前的时间表状态
+type ScheduleStatus struct {
+	Schedule    string     `json:"schedule,omitempty"`     // 生效的时间表名称，为空表示不受限制
+	Allowed     bool       `json:"allowed"`                // 当前是否允许获取地址
+	Until       *time.Time `json:"until,omitempty"`        // 当前允许时间段的结束时间
+	NextAllowed *time.Time `json:"next_allowed,omitempty"` // 不允许时下一次允许的时间
+}
+
+// SetClock 替换服务器使用的时间来源
+func (s *Server) SetClock(clock Clock) {
+	s.clock = clock
+}
+
+// deviceSchedule 返回设备策略中的时间表名称和编译结果，未引用时间表时返回nil
+func (s *Server) deviceSchedule(mac string) (string, *Schedule, error) {
+	name := s.config.ResolvePolicy(mac).Schedule
+	if name == "" {
+		return "", nil, nil
+	}
+	schedule, err := s.schedules.lookup(s.config.Schedules, name)
+	return name, schedule, err
+}
+
+// CheckSchedule 按设备策略中的时间表检查设备当前能否获取地址，时间表不存在或无效时拒绝
+func (s *Server) CheckSchedule(mac string) ScheduleStatus {
+	status := s.checkSchedule(mac)
+	if status.Allowed && status.Schedule != "" {
+		if _, schedule, _ := s.deviceSchedule(mac); schedule != nil {
+			if end, ok := schedule.WindowEnd(s.clock.Now()); ok {
+				status.Until = &end
+			}
+		}
+	}
+	return status
+}
+
+// checkSchedule 检查设备当前是否允许获取地址，不计算允许时间段的结束时间
+func (s *Server) checkSchedule(mac string) ScheduleStatus {
+	name, schedule, err := s.deviceSchedule(mac)
+	status := ScheduleStatus{Schedule: name, Allowed: true}
+	if name == "" {
+		return status
+	}
+	if err != nil {
+		log.Printf("设备 %s 的时间表无效，拒绝访问: %v", mac, err)
+		status.Allowed = false
+		return status
+	}
+
+	now := s.clock.Now()
+	if !schedule.Allows(now) {
+		status.Allowed = false
+		if next, ok := schedule.NextAllowed(now); ok {
+			status.NextAllowed = &next
+		}
+	}
+	return status
+}
+
+// enforceSchedule 在DISCOVER/REQUEST分配地址前检查时间表，不允许时记录历史并返回false
+func (s *Server) enforceSchedule(mac, hostname string) (bool, ScheduleStatus) {
+	status := s.checkSchedule(mac)
+	if status.Allowed {
+		return true, status
+	}
+
+	detail := fmt.Sprintf("时间表 %s 不允许", status.Schedule)
+	if status.NextAllowed != nil {
+		detail += "，下次允许: " + status.NextAllowed.Format("2006-01-02 15:04")
+	}
+	log.Printf("设备 %s 不在允许时间段内: %s", mac, detail)
+	s.addHistoryDetail("", mac, hostname, "SCHEDULE_DENY", "", detail)
+	return false, status
+}
+
+// scheduleLeaseTime 将租期截断到允许时间段结束，只在租期范围内查找结束时间
+func (s *Server) scheduleLeaseTime(mac string, leaseTime time.Duration) time.Duration {
+	_, schedule, _ := s.deviceSchedule(mac)
+	if schedule == nil {
+		return leaseTime
+	}
+	lookahead := leaseTime
+	if lookahead > scheduleLookahead {
+		lookahead = scheduleLookahead
+	}
+	now := s.clock.Now()
+	end, ok := schedule.windowEnd(now, lookahead)
+	if !ok {
+		return leaseTime
+	}
+	remaining := end.Sub(now)
+	if remaining > 0 && remaining < leaseTime {
+		log.Printf("设备 %s 的租期截断到时间段结束: %s", mac, end.Format("15:04"))
+		return remaining
+	}
+	return leaseTime
+}
@@ -0,0 +1,128 @@
+package dhcp
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+
+	"dhcp-server/config"
+)
+
+// testClock 测试用的可调时钟
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func TestHandleRequestSchedule(t *testing.T) {
+	const mac = "aa:bb:cc:39:00:01"
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.Schedules = []config.AccessSchedule{{
+			Name:     "weekdays",
+			Timezone: "UTC",
+			Windows:  []config.ScheduleWindow{{Days: []string{"mon", "tue", "wed", "thu", "fri"}, Start: "07:00", End: "21:00"}},
+		}}
+		cfg.Devices = []config.DeviceInfo{{MAC: mac, Schedule: "weekdays"}}
+	})
+	clock := &testClock{now: time.Date(2026, 10, 19, 20, 30, 0, 0, time.UTC)} // 周一
+	s.SetClock(clock)
+
+	// 允许时间段内租期截断到时间段结束
+	offer, err := s.handleDiscover(newTestPacket(t, dhcpv4.MessageTypeDiscover, mac))
+	if err != nil || offer == nil {
+		t.Fatalf("允许时间段内应收到OFFER, 实际为 %v, %v", offer, err)
+	}
+	if lt := offer.IPAddressLeaseTime(0); lt != 30*time.Minute {
+		t.Errorf("OFFER租期应截断为30分钟, 实际为 %v", lt)
+	}
+	ack, _ := s.handleRequest(requestFor(t, mac, offer.YourIPAddr))
+	if ack == nil || ack.MessageType() != dhcpv4.MessageTypeAck {
+		t.Fatalf("允许时间段内应收到ACK, 实际为 %v", ack)
+	}
+	if lt := ack.IPAddressLeaseTime(0); lt != 30*time.Minute {
+		t.Errorf("ACK租期应截断为30分钟, 实际为 %v", lt)
+	}
+
+	// 时间段结束后续租收到NAK，DISCOVER不应答
+	clock.now = time.Date(2026, 10, 19, 21, 0, 0, 0, time.UTC)
+	nak, _ := s.handleRequest(requestFor(t, mac, offer.YourIPAddr))
+	if nak == nil || nak.MessageType() != dhcpv4.MessageTypeNak {
+		t.Fatalf("时间段结束后续租应收到NAK, 实际为 %v", nak)
+	}
+	if resp, _ := s.handleDiscover(newTestPacket(t, dhcpv4.MessageTypeDiscover, mac)); resp != nil {
+		t.Errorf("时间段外的DISCOVER不应应答, 实际为 %v", resp.MessageType())
+	}
+	denied := false
+	for _, record := range s.GetHistory(100, mac, "") {
+		if record.Action == "SCHEDULE_DENY" {
+			denied = true
+		}
+	}
+	if !denied {
+		t.Error("拒绝应记录SCHEDULE_DENY历史")
+	}
+
+	// 不受时间表限制的设备使用完整租期
+	other := "aa:bb:cc:39:00:02"
+	offer, _ = s.handleDiscover(newTestPacket(t, dhcpv4.MessageTypeDiscover, other))
+	if offer == nil || offer.IPAddressLeaseTime(0) != 24*time.Hour {
+		t.Errorf("未引用时间表的设备应使用完整租期, 实际为 %v", offer)
+	}
+}
+
+func TestScheduleFailsClosed(t *testing.T) {
+	const mac = "aa:bb:cc:39:00:03"
+	s := newTestServer(t, func(cfg *config.Config) {
+		// 绕过配置验证，模拟无效的时间表
+		cfg.Schedules = []config.AccessSchedule{{Name: "broken", Cron: []string{"61 * * * *"}}}
+		cfg.Devices = []config.DeviceInfo{{MAC: mac, Schedule: "broken"}}
+	})
+
+	if status := s.CheckSchedule(mac); status.Allowed || status.Schedule != "broken" {
+		t.Errorf("无效的时间表应拒绝访问: %+v", status)
+	}
+	resp, _ := s.handleRequest(requestFor(t, mac, net.ParseIP("192.168.1.120")))
+	if resp == nil || resp.MessageType() != dhcpv4.MessageTypeNak {
+		t.Errorf("无效的时间表应回复NAK, 实际为 %v", resp)
+	}
+
+	// 引用的时间表不存在时同样拒绝
+	s.config.Devices = []config.DeviceInfo{{MAC: mac, Schedule: "missing"}}
+	if status := s.CheckSchedule(mac); status.Allowed {
+		t.Errorf("不存在的时间表应拒绝访问: %+v", status)
+	}
+
+	// 时间表列表整体替换后重新编译
+	s.config.Schedules = []config.AccessSchedule{{Name: "missing", Cron: []string{"* * * * *"}}}
+	if status := s.CheckSchedule(mac); !status.Allowed {
+		t.Errorf("替换时间表后应重新编译: %+v", status)
+	}
+}
+
+func TestScheduleCompiledOnce(t *testing.T) {
+	schedules := []config.AccessSchedule{{Name: "always", Cron: []string{"* * * * *"}}}
+	var cache scheduleCache
+
+	first, err := cache.lookup(schedules, "always")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := cache.lookup(schedules, "always")
+	if first != second {
+		t.Error("同一个时间表列表不应重复编译")
+	}
+
+	replaced := append([]config.AccessSchedule(nil), schedules...)
+	third, _ := cache.lookup(replaced, "always")
+	if third == first {
+		t.Error("时间表列表替换后应重新编译")
+	}
+	if _, err := cache.lookup(replaced, "none"); err == nil {
+		t.Error("不存在的时间表应返回错误")
+	}
+}
@@ -12,6 +12,7 @@ import (
 
 	"dhcp-server/config"
 	"dhcp-server/gateway"
+	"dhcp-server/presence"
 )
 
 // HistoryRecord 历史记录
@@ -23,6 +24,7 @@ type HistoryRecord struct {
 	Timestamp time.Time `json:"timestamp"`
 	Gateway   string    `json:"gateway"`
 	ServerIP  string    `json:"server_ip"`
+	Detail    string    `json:"detail,omitempty"` // 附加说明，如访问控制的判定原因
 }
 
 // Server DHCP服务器
@@ -31,6 +33,13 @@ type Server struct {
 	pool          *IPPool
 	healthChecker *gateway.HealthChecker
 	server        *server4.Server
+	conn          net.PacketConn   // DHCP监听连接，用于主动发送FORCERENEW
+	rawConn       *optionOrderConn // 记录原始选项顺序，用于DHCP指纹
+	rogue         *rogueDetector   // 非法DHCP服务器检测，未启用时为nil
+	flood         *floodGuard      // 限速和地址耗尽攻击检测，未启用时为nil
+	replayCounter uint64           // RFC 3118重放检测计数器
+	clock         Clock            // 访问时间表使用的时间来源
+	schedules     scheduleCache    // 编译后的访问时间表
 	startTime     time.Time
 	history       []HistoryRecord
 	historyMutex  sync.RWMutex
@@ -56,6 +65,29 @@ func NewServer(cfg *config.Config) (*Server, error) {
 		startTime:     time.Now(),
 		history:       make([]HistoryRecord, 0),
 		maxHistory:    1000, // 保留最近1000条记录
+		replayCounter: uint64(time.Now().UnixNano()),
+		clock:         SystemClock{},
+	}
+
+	for _, schedule := range cfg.Schedules {
+		if err := config.ValidateSchedule(schedule); err != nil {
+			log.Printf("警告: %v，引用该时间表的设备将被拒绝", err)
+		}
+	}
+	for _, group := range cfg.Groups {
+		if err := s.ValidateGroupRange(group); err != nil {
+			log.Printf("警告: %v", err)
+		}
+	}
+
+	// 网关故障时推送FORCERENEW
+	healthChecker.AddStatusListener(s.handleGatewayStatusChange)
+
+	if cfg.Security.RogueDetection.Enabled {
+		s.rogue = newRogueDetector(s)
+	}
+	if cfg.Security.Flood.Enabled {
+		s.flood = newFloodGuard(s)
 	}
 
 	// 添加一些示例历史记录用于测试（如果没有真实的DHCP活动）
@@ -66,6 +98,11 @@ func NewServer(cfg *config.Config) (*Server, error) {
 
 // addHistory 添加历史记录
 func (s *Server) addHistory(ip, mac, hostname, action, gateway string) {
+	s.addHistoryDetail(ip, mac, hostname, action, gateway, "")
+}
+
+// addHistoryDetail 添加带附加说明的历史记录
+func (s *Server) addHistoryDetail(ip, mac, hostname, action, gateway, detail string) {
 	s.historyMutex.Lock()
 	defer s.historyMutex.Unlock()
 
@@ -77,6 +114,7 @@ func (s *Server) addHistory(ip, mac, hostname, action, gateway string) {
 		Timestamp: time.Now(),
 		Gateway:   gateway,
 		ServerIP:  s.getServerIP().String(),
+		Detail:    detail,
 	}
 
 	s.history = append(s.history, record)
@@ -130,11 +168,28 @@ func (s *Server) Start() error {
 		Port: s.config.Server.Port,
 	}
 
-	server, err := server4.NewServer(s.config.Server.Interface, laddr, s.handleDHCPPacket)
+	conn, err := server4.NewIPv4UDPConn(s.config.Server.Interface, laddr)
 	if err != nil {
 		return err
 	}
 
+	// 包装连接以记录原始选项顺序，用于DHCP指纹识别
+	rawConn := newOptionOrderConn(conn)
+	server, err := server4.NewServer(s.config.Server.Interface, laddr, s.handleDHCPPacket, server4.WithConn(rawConn))
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	s.conn = rawConn
+	s.rawConn = rawConn
+
+	// 启动非法DHCP服务器检测
+	if s.rogue != nil {
+		if err := s.rogue.Start(); err != nil {
+			log.Printf("非法DHCP服务器检测启动失败: %v", err)
+		}
+	}
 	s.server = server
 
 	log.Println("DHCP服务器启动成功")
@@ -149,6 +204,10 @@ func (s *Server) Stop() {
 		s.healthChecker.Stop()
 	}
 
+	if s.rogue != nil {
+		s.rogue.Stop()
+	}
+
 	if s.server != nil {
 		s.server.Close()
 	}
@@ -158,10 +217,23 @@ func (s *Server) Stop() {
 
 // handleDHCPPacket 处理DHCP数据包
 func (s *Server) handleDHCPPacket(conn net.PacketConn, peer net.Addr, m *dhcpv4.DHCPv4) {
+	// 忽略本机非法服务器检测发出的探测报文
+	if s.rogue.isProbe(m.ClientHWAddr) {
+		return
+	}
+
 	log.Printf("收到来自 %s 的DHCP请求: %s", peer, m.MessageType())
+	start := time.Now()
+
+	// 限速，超出的DISCOVER/REQUEST直接丢弃
+	if t := m.MessageType(); (t == dhcpv4.MessageTypeDiscover || t == dhcpv4.MessageTypeRequest) && !s.flood.allow(m, peer) {
+		observeMessage(t, resultDropped, start)
+		return
+	}
 
 	var response *dhcpv4.DHCPv4
 	var err error
+	noResponse := resultIgnored
 
 	switch m.MessageType() {
 	case dhcpv4.MessageTypeDiscover:
@@ -170,29 +242,36 @@ func (s *Server) handleDHCPPacket(conn net.PacketConn, peer net.Addr, m *dhcpv4.
 		response, err = s.handleRequest(m)
 	case dhcpv4.MessageTypeRelease:
 		err = s.handleRelease(m)
+		noResponse = resultProcessed
 	case dhcpv4.MessageTypeDecline:
 		err = s.handleDecline(m)
+		noResponse = resultProcessed
 	case dhcpv4.MessageTypeInform:
 		response, err = s.handleInform(m)
 	default:
 		log.Printf("不支持的DHCP消息类型: %s", m.MessageType())
+		observeMessage(m.MessageType(), resultUnsupported, start)
 		return
 	}
 
 	if err != nil {
 		log.Printf("处理DHCP请求出错: %v", err)
+		observeMessage(m.MessageType(), resultError, start)
 		return
 	}
 
+	result := responseResult(response, noResponse)
 	if response != nil {
 		// 发送响应
 		if _, err := conn.WriteTo(response.ToBytes(), peer); err != nil {
 			log.Printf("发送DHCP响应失败: %v", err)
+			result = resultSendError
 		} else {
 			log.Printf("发送DHCP响应到 %s: %s (IP: %s)",
 				peer, response.MessageType(), response.YourIPAddr)
 		}
 	}
+	observeMessage(m.MessageType(), result, start)
 }
 
 // handleDiscover 处理DHCP Discover消息
@@ -206,16 +285,38 @@ func (s *Server) handleDiscover(req *dhcpv4.DHCPv4) (*dhcpv4.DHCPv4, error) {
 	log.Printf("DHCP Discover: MAC=%s, Hostname=%s, RequestedIP=%s",
 		clientMAC, hostname, requestedIP)
 
+	// 访问控制
+	verdict, _ := s.enforceAccess(req)
+	if verdict == accessDenied {
+		return nil, nil
+	}
+
+	// 访问时间表，DISCOVER不在允许时间段内时不提供地址
+	if allowed, _ := s.enforceSchedule(clientMAC, hostname); !allowed {
+		return nil, nil
+	}
+
 	log.Printf("准备调用 s.pool.RequestIP...")
 
-	// 尝试分配IP地址
-	lease, err := s.pool.RequestIP(clientMAC, requestedIP, hostname)
+	// 尝试分配IP地址，未通过访问控制的设备分配隔离地址
+	var lease *IPLease
+	var err error
+	if verdict == accessQuarantined {
+		lease, err = s.pool.RequestQuarantineIP(clientMAC, requestedIP, hostname)
+	} else if s.flood != nil {
+		// 新客户端只获得短期预留，收到REQUEST后才转为正式租约
+		ttl, maxPending := s.offerLimits()
+		lease, err = s.pool.ReserveIP(clientMAC, requestedIP, hostname, ttl, maxPending)
+	} else {
+		lease, err = s.pool.RequestIP(clientMAC, requestedIP, hostname)
+	}
 	if err != nil {
 		log.Printf("无法分配IP地址: %v", err)
 		return nil, err
 	}
 
 	log.Printf("IP分配成功: %s", lease.IP)
+	s.applyFingerprint(lease, s.captureFingerprint(req))
 
 	// 记录历史和更新租约网关信息
 	gateway := s.selectGateway(lease)
@@ -228,6 +329,8 @@ func (s *Server) handleDiscover(req *dhcpv4.DHCPv4) (*dhcpv4.DHCPv4, error) {
 		log.Printf("警告: 没有找到可用的网关")
 	}
 	s.addHistory(lease.IP.String(), clientMAC, hostname, "DISCOVER", gatewayName)
+	// 提供的地址尚未确认，只记录设备出现
+	presence.Observe(clientMAC, "", hostname, presence.SourceDHCP)
 
 	log.Printf("准备创建DHCP Offer响应...")
 
@@ -255,9 +358,22 @@ func (s *Server) handleRequest(req *dhcpv4.DHCPv4) (*dhcpv4.DHCPv4, error) {
 	// 检查是否是对我们的响应
 	if serverIP != nil && !s.isOurServerIP(serverIP) && !s.config.Server.AllowAnyServerIP {
 		log.Printf("DHCP Request不是发给我们的服务器 (ServerIP: %s)，已拒绝。若需允许响应任意ServerIP，请在配置中开启该选项。", serverIP)
+		s.rogue.recordForeignRequest(req, serverIP)
 		return nil, nil
 	}
 
+	// 访问控制
+	verdict, nak := s.enforceAccess(req)
+	if verdict == accessDenied {
+		return nak, nil
+	}
+	quarantined := verdict == accessQuarantined
+
+	// 访问时间表，不在允许时间段内时拒绝获取或续租
+	if allowed, _ := s.enforceSchedule(clientMAC, req.HostName()); !allowed {
+		return s.createNAK(req, "outside allowed schedule"), nil
+	}
+
 	// 验证请求的IP地址
 	if requestedIP == nil {
 		requestedIP = req.ClientIPAddr
@@ -270,28 +386,51 @@ func (s *Server) handleRequest(req *dhcpv4.DHCPv4) (*dhcpv4.DHCPv4, error) {
 
 	// 优先查找MAC的租约
 	lease, existsByMAC := s.pool.GetLeaseByMAC(clientMAC)
+	if existsByMAC && !lease.IsStatic && lease.Quarantined != quarantined {
+		// 设备登记或被移出登记后隔离状态改变，收回原地址并让客户端重新DISCOVER
+		log.Printf("DHCP Request: MAC=%s 隔离状态已改变，收回地址 %s", clientMAC, lease.IP)
+		s.pool.ReleaseIP(clientMAC)
+		return s.createNAK(req, "address pool changed"), nil
+	}
+	if existsByMAC && !lease.IsStatic && !lease.Quarantined {
+		policy := s.config.ResolvePolicy(lease.MAC)
+		if !s.pool.InPolicyRange(lease.IP, policy) {
+			// 设备组分配范围改变，收回原地址并让客户端重新DISCOVER
+			log.Printf("DHCP Request: %s 不在设备组 %s 的分配范围内，收回地址", lease.IP, policy.Group)
+			s.pool.ReleaseIP(clientMAC)
+			return s.createNAK(req, "address pool changed"), nil
+		}
+		if s.pool.ConfirmOffer(lease, policy.LeaseTime) {
+			log.Printf("DHCP Request: 客户端确认预留地址 %s", lease.IP)
+		}
+	}
 	if existsByMAC {
 		if lease.IP.Equal(requestedIP) {
 			log.Printf("DHCP Request: MAC和IP都匹配，续租")
 			if !lease.IsStatic {
-				lease.StartTime = time.Now()
+				s.pool.RenewLease(lease)
 			}
 		} else {
 			log.Printf("DHCP Request: 设备请求IP %s，但分配的IP是 %s，使用分配的IP", requestedIP, lease.IP)
 			if !lease.IsStatic {
-				lease.StartTime = time.Now()
+				s.pool.RenewLease(lease)
 			}
 		}
 	} else {
 		// 没有MAC租约，尝试分配新IP
 		var err error
-		lease, err = s.pool.RequestIP(clientMAC, nil, req.HostName())
+		if quarantined {
+			lease, err = s.pool.RequestQuarantineIP(clientMAC, nil, req.HostName())
+		} else {
+			lease, err = s.pool.RequestIP(clientMAC, nil, req.HostName())
+		}
 		if err != nil {
 			log.Printf("DHCP Request: 无法分配新IP: %v", err)
 			return s.createNAK(req, "无法分配新IP"), nil
 		}
 		log.Printf("DHCP Request: 为MAC=%s分配新IP: %s", clientMAC, lease.IP)
 	}
+	s.applyFingerprint(lease, s.captureFingerprint(req))
 
 	// 记录历史和更新租约网关信息
 	gateway := s.selectGateway(lease)
@@ -301,6 +440,7 @@ func (s *Server) handleRequest(req *dhcpv4.DHCPv4) (*dhcpv4.DHCPv4, error) {
 		lease.GatewayIP = gateway.IP // 记录实际响应的网关IP
 	}
 	s.addHistory(lease.IP.String(), clientMAC, req.HostName(), "REQUEST", gatewayName)
+	presence.Observe(clientMAC, lease.IP.String(), req.HostName(), presence.SourceDHCP)
 
 	// 创建DHCP ACK响应
 	ack := s.createResponse(req, dhcpv4.MessageTypeAck, lease)
@@ -318,6 +458,7 @@ func (s *Server) handleRelease(req *dhcpv4.DHCPv4) error {
 
 	// 记录历史
 	s.addHistory(clientIP.String(), clientMAC, req.HostName(), "RELEASE", "")
+	presence.MarkOffline(clientMAC, "dhcp_release")
 
 	if err := s.pool.ReleaseIP(clientMAC); err != nil {
 		log.Printf("释放IP地址失败: %v", err)
@@ -366,6 +507,7 @@ func (s *Server) handleInform(req *dhcpv4.DHCPv4) (*dhcpv4.DHCPv4, error) {
 
 	// 记录历史
 	s.addHistory(clientIP.String(), clientMAC, req.HostName(), "INFORM", "")
+	presence.Observe(clientMAC, clientIP.String(), req.HostName(), presence.SourceDHCP)
 
 	// 为DHCP Inform创建响应（只提供配置信息，不分配IP）
 	resp, err := dhcpv4.NewReplyFromRequest(req)
@@ -374,7 +516,11 @@ func (s *Server) handleInform(req *dhcpv4.DHCPv4) (*dhcpv4.DHCPv4, error) {
 	}
 
 	resp.UpdateOption(dhcpv4.OptMessageType(dhcpv4.MessageTypeAck))
-	s.addNetworkOptions(resp, nil) // 不指定特定网关
+	if lease, ok := s.pool.GetLeaseByMAC(clientMAC); ok && lease.Quarantined {
+		s.addNetworkOptions(resp, lease) // 隔离设备同样只获得门户配置
+	} else {
+		s.addNetworkOptions(resp, nil) // 不指定特定网关
+	}
 
 	log.Printf("发送DHCP ACK (Inform): MAC=%s", clientMAC)
 	return resp, nil
@@ -397,11 +543,16 @@ func (s *Server) createResponse(req *dhcpv4.DHCPv4, msgType dhcpv4.MessageType,
 	// 设置服务器标识符
 	resp.UpdateOption(dhcpv4.OptServerIdentifier(s.getServerIP()))
 
-	// 设置租期时间
+	// 记录客户端FORCERENEW能力，ACK中下发认证随机数
+	s.prepareForceRenew(req, resp, lease)
+
+	// 设置租期时间，受访问时间表限制的设备截断到允许时间段结束
 	if !lease.IsStatic {
-		resp.UpdateOption(dhcpv4.OptIPAddressLeaseTime(lease.LeaseTime))
+		leaseTime := s.scheduleLeaseTime(lease.MAC, s.forceRenewLeaseTime(lease, s.pool.LeaseDuration(lease)))
+		s.pool.SetLeaseTime(lease, leaseTime)
+		resp.UpdateOption(dhcpv4.OptIPAddressLeaseTime(leaseTime))
 	} else {
-		resp.UpdateOption(dhcpv4.OptIPAddressLeaseTime(time.Hour * 24 * 365)) // 静态地址设置长租期
+		resp.UpdateOption(dhcpv4.OptIPAddressLeaseTime(s.scheduleLeaseTime(lease.MAC, time.Hour*24*365))) // 静态地址设置长租期
 	}
 
 	// 添加网络配置选项
@@ -435,6 +586,19 @@ func (s *Server) addNetworkOptions(resp *dhcpv4.DHCPv4, lease *IPLease) {
 		resp.UpdateOption(dhcpv4.OptSubnetMask(net.IPMask(netmask.To4())))
 	}
 
+	// 隔离租约只下发认证门户配置
+	if lease != nil && lease.Quarantined {
+		s.addQuarantineOptions(resp)
+		return
+	}
+
+	// 按 设备 → 设备组 → 全局 解析策略
+	mac := resp.ClientHWAddr.String()
+	if lease != nil {
+		mac = lease.MAC
+	}
+	policy := s.config.ResolvePolicy(mac)
+
 	// 网关 - 根据租约选择合适的网关
 	gateway := s.selectGateway(lease)
 	if gateway != nil {
@@ -455,11 +619,18 @@ func (s *Server) addNetworkOptions(resp *dhcpv4.DHCPv4, lease *IPLease) {
 		}
 	}
 
-	// DNS服务器 - 优先使用网关的DNS，然后合并网络配置的DNS
+	// DNS服务器 - 设备或设备组指定了DNS时直接使用，否则优先使用网关的DNS，然后合并网络配置的DNS
 	var dnsServers []net.IP
 
-	// 如果网关配置了DNS，优先使用网关的DNS
-	if gateway != nil && len(gateway.DNSServers) > 0 {
+	if len(policy.DNSServers) > 0 {
+		for _, dns := range policy.DNSServers {
+			if ip := net.ParseIP(dns); ip != nil {
+				dnsServers = append(dnsServers, ip.To4())
+			}
+		}
+		log.Printf("为客户端分配%s策略DNS: %v", policy.Sources["dns_servers"], policy.DNSServers)
+	} else if gateway != nil && len(gateway.DNSServers) > 0 {
+		// 如果网关配置了DNS，优先使用网关的DNS
 		for _, dns := range gateway.DNSServers {
 			if ip := net.ParseIP(dns); ip != nil {
 				dnsServers = append(dnsServers, ip.To4())
@@ -469,7 +640,7 @@ func (s *Server) addNetworkOptions(resp *dhcpv4.DHCPv4, lease *IPLease) {
 	}
 
 	// 合并网络配置中的DNS（避免重复）
-	if len(s.config.Network.DNSServers) > 0 {
+	if len(policy.DNSServers) == 0 && len(s.config.Network.DNSServers) > 0 {
 		for _, dns := range s.config.Network.DNSServers {
 			if ip := net.ParseIP(dns); ip != nil {
 				// 检查是否已经存在
@@ -496,6 +667,16 @@ func (s *Server) addNetworkOptions(resp *dhcpv4.DHCPv4, lease *IPLease) {
 		resp.UpdateOption(dhcpv4.OptDomainName(s.config.Network.DomainName))
 	}
 
+	// 设备和设备组的自定义选项，最后写入以覆盖上面的默认值
+	for _, option := range policy.Options {
+		data, err := option.Bytes()
+		if err != nil {
+			log.Printf("跳过无效的DHCP选项 %d: %v", option.Code, err)
+			continue
+		}
+		resp.UpdateOption(dhcpv4.OptGeneric(dhcpv4.GenericOptionCode(option.Code), data))
+	}
+
 	// 续租时间 (T1) - 使用租期的一半
 	// resp.UpdateOption(dhcpv4.OptRenewalTime(s.config.Server.LeaseTime / 2))
 
@@ -510,16 +691,21 @@ func (s *Server) selectGateway(lease *IPLease) *config.Gateway {
 		return s.healthChecker.GetHealthyGateway("")
 	}
 
+	// 隔离租约使用认证门户网关
+	if lease.Quarantined {
+		return s.quarantineGateway()
+	}
+
 	// 优先检查静态绑定的网关配置
 	if lease.IsStatic && lease.Gateway != "" {
 		// 静态绑定指定了网关，优先使用指定的网关（如果健康的话）
 		return s.healthChecker.GetHealthyGateway(lease.Gateway)
 	}
 
-	// 检查设备配置中的网关
-	if device := s.config.FindDeviceByMAC(lease.MAC); device != nil && device.Gateway != "" {
-		// 设备配置了网关，使用设备指定的网关（如果健康的话）
-		return s.healthChecker.GetHealthyGateway(device.Gateway)
+	// 检查设备或设备组配置中的网关
+	if policy := s.config.ResolvePolicy(lease.MAC); policy.Gateway != "" {
+		// 使用设备或设备组指定的网关（如果健康的话）
+		return s.healthChecker.GetHealthyGateway(policy.Gateway)
 	}
 
 	// 动态分配或没有指定网关，使用默认网关
@@ -566,6 +752,19 @@ func (s *Server) getServerIP() net.IP {
 	return net.ParseIP("192.168.1.1") // 最后的默认值
 }
 
+// ValidateGroupRange 检查设备组的分配范围是否包含服务器在DHCP接口上的地址
+func (s *Server) ValidateGroupRange(group config.DeviceGroup) error {
+	start := net.ParseIP(group.StartIP).To4()
+	end := net.ParseIP(group.EndIP).To4()
+	if start == nil || end == nil {
+		return nil
+	}
+	if serverIP := s.getServerIP(); ipInRange(serverIP, start, end) {
+		return fmt.Errorf("设备组 %s 的分配范围包含服务器地址 %s", group.Name, serverIP)
+	}
+	return nil
+}
+
 // isOurServerIP 检查是否是我们的服务器IP
 func (s *Server) isOurServerIP(ip net.IP) bool {
 	serverIP := s.getServerIP()
@@ -0,0 +1,80 @@
+package dhcp
+
+import (
+	"io"
+	"log"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+
+	"dhcp-server/config"
+)
+
+func TestMain(m *testing.M) {
+	// 抑制测试期间的日志输出
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// testServerIP 测试接口不存在，服务器地址取第一个网关
+var testServerIP = net.ParseIP("192.168.1.1").To4()
+
+// newTestServer 创建不监听网络的测试服务器，modify可在创建前调整配置
+func newTestServer(t *testing.T, modify func(cfg *config.Config)) *Server {
+	t.Helper()
+	cfg := &config.Config{
+		Server: config.ServerConfig{
+			Interface: "dhcptest0",
+			Port:      67,
+			LeaseTime: 24 * time.Hour,
+		},
+		Network: config.NetworkConfig{
+			Subnet:           "192.168.1.0/24",
+			Netmask:          "255.255.255.0",
+			StartIP:          "192.168.1.100",
+			EndIP:            "192.168.1.200",
+			DNSServers:       []string{"8.8.8.8"},
+			DefaultGateway:   "192.168.1.1",
+			LeaseTime:        86400,
+			BroadcastAddress: "192.168.1.255",
+		},
+		Gateways: []config.Gateway{
+			{Name: "main_gateway", IP: "192.168.1.1", IsDefault: true},
+		},
+	}
+	if modify != nil {
+		modify(cfg)
+	}
+
+	s, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("创建DHCP服务器失败: %v", err)
+	}
+	return s
+}
+
+// newTestPacket 构造客户端发出的DHCP报文
+func newTestPacket(t *testing.T, msgType dhcpv4.MessageType, mac string, modifiers ...dhcpv4.Modifier) *dhcpv4.DHCPv4 {
+	t.Helper()
+	hwaddr, err := net.ParseMAC(mac)
+	if err != nil {
+		t.Fatalf("无效的MAC地址 %s: %v", mac, err)
+	}
+	modifiers = append([]dhcpv4.Modifier{dhcpv4.WithMessageType(msgType), dhcpv4.WithHwAddr(hwaddr)}, modifiers...)
+	packet, err := dhcpv4.New(modifiers...)
+	if err != nil {
+		t.Fatalf("构造DHCP报文失败: %v", err)
+	}
+	return packet
+}
+
+// requestFor 构造确认指定地址的REQUEST
+func requestFor(t *testing.T, mac string, ip net.IP) *dhcpv4.DHCPv4 {
+	t.Helper()
+	return newTestPacket(t, dhcpv4.MessageTypeRequest, mac,
+		dhcpv4.WithOption(dhcpv4.OptRequestedIPAddress(ip)),
+		dhcpv4.WithOption(dhcpv4.OptServerIdentifier(testServerIP)))
+}
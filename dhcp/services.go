@@ -0,0 +1,256 @@
+package dhcp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultServiceTimeout     = time.Second
+	defaultServiceConcurrency = 32
+	serviceBannerMax          = 120  // 保存的banner最大长度
+	serviceReadMax            = 2048 // 读取应答的最大字节数
+)
+
+// defaultServicePorts 未配置端口时探测的常用端口
+var defaultServicePorts = []int{21, 22, 23, 80, 135, 139, 443, 445, 515, 548, 554, 631, 1883, 3389, 8080, 8443, 8554, 9100, 62078}
+
+// wellKnownServices 常用端口对应的服务名称
+var wellKnownServices = map[int]string{
+	21:    "ftp",
+	22:    "ssh",
+	23:    "telnet",
+	25:    "smtp",
+	53:    "dns",
+	80:    "http",
+	135:   "msrpc",
+	139:   "netbios-ssn",
+	443:   "https",
+	445:   "smb",
+	515:   "lpd",
+	548:   "afp",
+	554:   "rtsp",
+	631:   "ipp",
+	1883:  "mqtt",
+	3389:  "rdp",
+	5900:  "vnc",
+	8000:  "http",
+	8080:  "http",
+	8443:  "https",
+	8554:  "rtsp",
+	9100:  "jetdirect",
+	62078: "iphone-sync",
+}
+
+// ServiceInfo 开放端口上识别到的服务
+type ServiceInfo struct {
+	Port    int    `json:"port"`
+	Service string `json:"service,omitempty"`
+	Banner  string `json:"banner,omitempty"` // SSH版本、HTTP/RTSP Server头等
+}
+
+// serviceTarget 单个待探测的主机端口
+type serviceTarget struct {
+	device *DeviceInfo
+	port   int
+}
+
+// scanServices 对发现的主机探测配置的TCP端口，记录开放端口和banner并推测设备类型
+func (scanner *NetworkScanner) scanServices(devices map[string]*DeviceInfo) {
+	cfg := scanner.config.Scanner.ServiceScan
+	if !cfg.Enabled || len(devices) == 0 {
+		return
+	}
+
+	ports := cfg.Ports
+	if len(ports) == 0 {
+		ports = defaultServicePorts
+	}
+	timeout := time.Duration(cfg.Timeout) * time.Millisecond
+	if timeout <= 0 {
+		timeout = defaultServiceTimeout
+	}
+	concurrency := cfg.MaxConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultServiceConcurrency
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	semaphore := make(chan struct{}, concurrency)
+	found := make(map[*DeviceInfo][]ServiceInfo)
+
+	for _, device := range devices {
+		for _, port := range ports {
+			wg.Add(1)
+			go func(target serviceTarget) {
+				defer wg.Done()
+				semaphore <- struct{}{}
+				defer func() { <-semaphore }()
+
+				if info, ok := probeService(target.device.IP, target.port, timeout); ok {
+					mu.Lock()
+					found[target.device] = append(found[target.device], info)
+					mu.Unlock()
+				}
+			}(serviceTarget{device: device, port: port})
+		}
+	}
+	wg.Wait()
+
+	openCount := 0
+	for _, device := range devices {
+		services := found[device]
+		sort.Slice(services, func(i, j int) bool { return services[i].Port < services[j].Port })
+
+		device.Services = services
+		device.OpenPorts = nil
+		for _, service := range services {
+			device.OpenPorts = append(device.OpenPorts, service.Port)
+		}
+		device.SuggestedType = suggestDeviceType(device)
+		openCount += len(services)
+	}
+
+	scanner.addScanLog(fmt.Sprintf("服务识别完成，%d 个设备共 %d 个开放端口", len(devices), openCount))
+}
+
+// probeService 连接端口并尝试获取banner，连接失败返回false
+func probeService(ip string, port int, timeout time.Duration) (ServiceInfo, bool) {
+	conn, err := net.DialTimeout("tcp4", net.JoinHostPort(ip, strconv.Itoa(port)), timeout)
+	if err != nil {
+		return ServiceInfo{}, false
+	}
+	defer conn.Close()
+
+	info := ServiceInfo{Port: port, Service: wellKnownServices[port]}
+
+	var request string
+	switch info.Service {
+	case "http":
+		request = "HEAD / HTTP/1.0\r\nHost: " + ip + "\r\nUser-Agent: dhcp-server-scanner\r\n\r\n"
+	case "rtsp":
+		request = "OPTIONS rtsp://" + net.JoinHostPort(ip, strconv.Itoa(port)) + "/ RTSP/1.0\r\nCSeq: 1\r\n\r\n"
+	case "https", "smb", "msrpc", "rdp", "ipp", "jetdirect", "iphone-sync", "mqtt", "lpd", "afp", "netbios-ssn":
+		// 二进制或加密协议，只记录端口开放
+		return info, true
+	}
+
+	if request != "" {
+		conn.SetWriteDeadline(time.Now().Add(timeout))
+		if _, err := conn.Write([]byte(request)); err != nil {
+			return info, true
+		}
+	}
+
+	// 等待服务端主动发送的banner（SSH、FTP、SMTP等）或请求的应答
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	buf := make([]byte, serviceReadMax)
+	n, _ := conn.Read(buf)
+	if n == 0 {
+		return info, true
+	}
+
+	service, banner := parseServiceBanner(buf[:n])
+	if service != "" {
+		info.Service = service
+	}
+	info.Banner = banner
+	return info, true
+}
+
+// parseServiceBanner 根据应答内容识别服务并提取banner
+func parseServiceBanner(data []byte) (string, string) {
+	firstLine := data
+	if idx := bytes.IndexByte(data, '\n'); idx >= 0 {
+		firstLine = data[:idx]
+	}
+	line := sanitizeBanner(string(firstLine))
+
+	switch {
+	case bytes.HasPrefix(data, []byte("SSH-")):
+		return "ssh", line
+	case bytes.HasPrefix(data, []byte("HTTP/")):
+		return "http", headerOrStatus(data, line)
+	case bytes.HasPrefix(data, []byte("RTSP/")):
+		return "rtsp", headerOrStatus(data, line)
+	case bytes.HasPrefix(data, []byte("220")):
+		// FTP和SMTP都以220开头，交给端口名称区分
+		return "", line
+	}
+	return "", line
+}
+
+// headerOrStatus 提取HTTP/RTSP应答的Server头，不存在时使用状态行
+func headerOrStatus(data []byte, status string) string {
+	reader := bufio.NewReader(bytes.NewReader(data))
+	reader.ReadString('\n') // 跳过状态行
+	for {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if colon := strings.Index(line, ":"); colon > 0 {
+			if strings.EqualFold(strings.TrimSpace(line[:colon]), "Server") {
+				return sanitizeBanner(line[colon+1:])
+			}
+		}
+		if err != nil || line == "" {
+			break
+		}
+	}
+	return status
+}
+
+// sanitizeBanner 去除不可打印字符并截断
+func sanitizeBanner(banner string) string {
+	var b strings.Builder
+	for _, r := range banner {
+		if r >= 0x20 && r < 0x7f {
+			b.WriteRune(r)
+		}
+	}
+	result := strings.TrimSpace(b.String())
+	if len(result) > serviceBannerMax {
+		result = result[:serviceBannerMax]
+	}
+	return result
+}
+
+// suggestDeviceType 根据开放端口和banner推测设备类型，无法判断时返回空
+func suggestDeviceType(device *DeviceInfo) string {
+	open := make(map[int]bool, len(device.OpenPorts))
+	for _, port := range device.OpenPorts {
+		open[port] = true
+	}
+	banners := make(map[string]string)
+	for _, service := range device.Services {
+		if service.Banner != "" {
+			banners[service.Service] = strings.ToLower(service.Banner)
+		}
+	}
+	_, hasSSH := banners["ssh"]
+
+	switch {
+	case open[554] || open[8554] || banners["rtsp"] != "":
+		return "Camera"
+	case open[9100] || open[631] || open[515]:
+		return "Printer"
+	case open[62078]:
+		return "iPhone"
+	case open[3389] || open[135] || (open[445] && !hasSSH && !open[548]):
+		return "Windows"
+	case open[548]:
+		return "MacOS"
+	case strings.Contains(banners["ssh"], "dropbear") || open[1883]:
+		return "IoT"
+	case hasSSH:
+		return "Linux"
+	}
+	return ""
+}
@@ -0,0 +1,97 @@
+package dhcp
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseServiceBanner(t *testing.T) {
+	cases := []struct {
+		name    string
+		data    string
+		service string
+		banner  string
+	}{
+		{"SSH", "SSH-2.0-OpenSSH_9.6p1 Ubuntu-3ubuntu13\r\n", "ssh", "SSH-2.0-OpenSSH_9.6p1 Ubuntu-3ubuntu13"},
+		{"HTTP Server头", "HTTP/1.1 200 OK\r\nContent-Type: text/html\r\nserver: lighttpd/1.4.59\r\n\r\n<html>", "http", "lighttpd/1.4.59"},
+		{"HTTP无Server头", "HTTP/1.0 401 Unauthorized\r\nWWW-Authenticate: Basic\r\n\r\n", "http", "HTTP/1.0 401 Unauthorized"},
+		{"Server头在正文之后不识别", "HTTP/1.1 200 OK\r\n\r\nServer: fake\r\n", "http", "HTTP/1.1 200 OK"},
+		{"RTSP", "RTSP/1.0 200 OK\r\nCSeq: 1\r\nServer: Hikvision-Webs\r\n\r\n", "rtsp", "Hikvision-Webs"},
+		{"FTP/SMTP交给端口区分", "220 (vsFTPd 3.0.3)\r\n", "", "220 (vsFTPd 3.0.3)"},
+		{"去除不可打印字符", "SSH-2.0-dropbear\x00\x01\xff_2022\n", "ssh", "SSH-2.0-dropbear_2022"},
+		{"未知协议", "\x16\x03\x01garbage", "", "garbage"},
+		{"截断过长的banner", "SSH-2.0-" + strings.Repeat("x", 200), "ssh", ("SSH-2.0-" + strings.Repeat("x", 200))[:serviceBannerMax]},
+	}
+
+	for _, c := range cases {
+		service, banner := parseServiceBanner([]byte(c.data))
+		if service != c.service || banner != c.banner {
+			t.Errorf("%s: 期望 (%q, %q), 实际为 (%q, %q)", c.name, c.service, c.banner, service, banner)
+		}
+	}
+}
+
+func TestSuggestDeviceType(t *testing.T) {
+	ssh := func(banner string) ServiceInfo { return ServiceInfo{Port: 22, Service: "ssh", Banner: banner} }
+
+	cases := []struct {
+		name     string
+		ports    []int
+		services []ServiceInfo
+		want     string
+	}{
+		{name: "RTSP端口", ports: []int{80, 554}, want: "Camera"},
+		{name: "RTSP banner", ports: []int{8000}, services: []ServiceInfo{{Port: 8000, Service: "rtsp", Banner: "RTSP/1.0 200 OK"}}, want: "Camera"},
+		{name: "摄像头优先于Linux", ports: []int{22, 554}, services: []ServiceInfo{ssh("SSH-2.0-OpenSSH_8.0")}, want: "Camera"},
+		{name: "JetDirect", ports: []int{80, 9100}, want: "Printer"},
+		{name: "IPP", ports: []int{631}, want: "Printer"},
+		{name: "iPhone同步", ports: []int{62078}, want: "iPhone"},
+		{name: "RDP", ports: []int{3389}, want: "Windows"},
+		{name: "只有SMB", ports: []int{139, 445}, want: "Windows"},
+		{name: "SMB加SSH的NAS", ports: []int{22, 445}, services: []ServiceInfo{ssh("SSH-2.0-OpenSSH_9.0")}, want: "Linux"},
+		{name: "SMB加AFP", ports: []int{445, 548}, want: "MacOS"},
+		{name: "Dropbear", ports: []int{22}, services: []ServiceInfo{ssh("SSH-2.0-dropbear_2020.81")}, want: "IoT"},
+		{name: "MQTT", ports: []int{1883}, want: "IoT"},
+		{name: "OpenSSH", ports: []int{22}, services: []ServiceInfo{ssh("SSH-2.0-OpenSSH_9.6")}, want: "Linux"},
+		{name: "只开放HTTP", ports: []int{80, 443}, want: ""},
+		{name: "没有开放端口", want: ""},
+	}
+
+	for _, c := range cases {
+		device := &DeviceInfo{OpenPorts: c.ports, Services: c.services}
+		if got := suggestDeviceType(device); got != c.want {
+			t.Errorf("%s: 期望 %q, 实际为 %q", c.name, c.want, got)
+		}
+	}
+}
+
+func TestProbeService(t *testing.T) {
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("无法监听本地端口: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte("SSH-2.0-OpenSSH_9.6\r\n"))
+			conn.Close()
+		}
+	}()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	info, ok := probeService("127.0.0.1", port, time.Second)
+	if !ok || info.Port != port || info.Service != "ssh" || info.Banner != "SSH-2.0-OpenSSH_9.6" {
+		t.Errorf("应通过banner识别SSH服务, 实际为 %+v, %v", info, ok)
+	}
+
+	listener.Close()
+	if _, ok := probeService("127.0.0.1", port, 200*time.Millisecond); ok {
+		t.Error("端口关闭时应返回false")
+	}
+}
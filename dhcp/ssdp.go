@@ -0,0 +1,137 @@
+package dhcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ssdpGroup = &net.UDPAddr{IP: net.IPv4(239, 255, 255, 250), Port: 1900}
+
+const ssdpDescriptionLimit = 64 * 1024 // 设备描述文件大小上限
+
+// upnpDescription UPnP设备描述（仅解析需要的字段）
+type upnpDescription struct {
+	Device struct {
+		FriendlyName string `xml:"friendlyName"`
+		Manufacturer string `xml:"manufacturer"`
+		ModelName    string `xml:"modelName"`
+		ModelNumber  string `xml:"modelNumber"`
+	} `xml:"device"`
+}
+
+// querySSDP 发送M-SEARCH并收集各设备的描述文件地址
+func (nd *nameDiscovery) querySSDP(timeout time.Duration) map[string]string {
+	locations := make(map[string]string)
+
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: nd.localIP()})
+	if err != nil {
+		nd.logf("SSDP查询失败: %v", err)
+		return locations
+	}
+	defer conn.Close()
+
+	mx := int(timeout / time.Second)
+	if mx < 1 {
+		mx = 1
+	}
+	search := "M-SEARCH * HTTP/1.1\r\n" +
+		"HOST: 239.255.255.250:1900\r\n" +
+		"MAN: \"ssdp:discover\"\r\n" +
+		"MX: " + strconv.Itoa(mx) + "\r\n" +
+		"ST: upnp:rootdevice\r\n\r\n"
+	conn.WriteTo([]byte(search), ssdpGroup)
+
+	deadline := time.Now().Add(timeout)
+	conn.SetReadDeadline(deadline)
+	buf := make([]byte, 2048)
+	for time.Now().Before(deadline) {
+		n, addr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			break
+		}
+		if location := parseSSDPLocation(buf[:n]); location != "" {
+			locations[addr.IP.String()] = location
+		}
+	}
+
+	return locations
+}
+
+// parseSSDPLocation 从M-SEARCH应答或NOTIFY报文中提取LOCATION
+func parseSSDPLocation(packet []byte) string {
+	reader := bufio.NewReader(bytes.NewReader(packet))
+	startLine, err := reader.ReadString('\n')
+	if err != nil {
+		return ""
+	}
+	startLine = strings.TrimSpace(startLine)
+	if !strings.HasPrefix(startLine, "HTTP/1.1 200") && !strings.HasPrefix(startLine, "NOTIFY ") {
+		return ""
+	}
+
+	location := ""
+	alive := true
+	for {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if colon := strings.Index(line, ":"); colon > 0 {
+			key := strings.ToUpper(strings.TrimSpace(line[:colon]))
+			value := strings.TrimSpace(line[colon+1:])
+			switch key {
+			case "LOCATION":
+				location = value
+			case "NTS":
+				alive = value != "ssdp:byebye"
+			}
+		}
+		if err != nil || line == "" {
+			break
+		}
+	}
+
+	if !alive {
+		return ""
+	}
+	return location
+}
+
+// fetchUPnPDescription 获取设备描述，只允许访问应答方自身的地址
+func (nd *nameDiscovery) fetchUPnPDescription(ip, location string, timeout time.Duration) (nameRecord, bool) {
+	parsed, err := url.Parse(location)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() != ip {
+		return nameRecord{}, false
+	}
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(location)
+	if err != nil {
+		return nameRecord{}, false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nameRecord{}, false
+	}
+
+	var desc upnpDescription
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, ssdpDescriptionLimit)).Decode(&desc); err != nil {
+		return nameRecord{}, false
+	}
+
+	record := nameRecord{
+		Hostname:     strings.TrimSpace(desc.Device.FriendlyName),
+		Manufacturer: strings.TrimSpace(desc.Device.Manufacturer),
+		Model:        strings.TrimSpace(strings.TrimSpace(desc.Device.ModelName) + " " + strings.TrimSpace(desc.Device.ModelNumber)),
+		Source:       nameSourceSSDP,
+		SeenAt:       time.Now(),
+	}
+	return record, record.Hostname != "" || record.Model != ""
+}
@@ -7,15 +7,25 @@ import (
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"regexp"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"dhcp-server/api"
+	"dhcp-server/audit"
 	"dhcp-server/config"
 	"dhcp-server/dhcp"
+	"dhcp-server/events"
 	"dhcp-server/gateway"
+	"dhcp-server/oui"
+	"dhcp-server/presence"
+
+	"gopkg.in/yaml.v2"
 )
 
 // 测试用例1-3: 配置管理测试
@@ -109,6 +119,55 @@ func TestConfigValidation(t *testing.T) {
 	}
 }
 
+func TestConfigValidationAccessPatterns(t *testing.T) {
+	cases := []struct {
+		allow   []string
+		deny    []string
+		wantErr bool
+	}{
+		{allow: []string{"AA:BB:CC:DD:EE:FF", "aa-bb-cc-*"}, deny: []string{"aabb.ccdd.eeff", "00:11:*"}},
+		{allow: []string{"aa:bb:cc"}, wantErr: true},
+		{deny: []string{"aa:bb:*:dd"}, wantErr: true},
+		{deny: []string{"zz:bb:cc:dd:ee:ff"}, wantErr: true},
+		{allow: []string{"aa:bb:cc:dd:ee:ff:*"}, wantErr: true},
+	}
+
+	for _, c := range cases {
+		cfg := createTestConfig()
+		cfg.Security.Access = config.AccessConfig{Mode: "allowlist", Allow: c.allow, Deny: c.deny}
+		err := cfg.Validate()
+		if (err != nil) != c.wantErr {
+			t.Errorf("allow=%v deny=%v: 期望出错=%v, 实际为 %v", c.allow, c.deny, c.wantErr, err)
+		}
+	}
+}
+
+func TestConfigValidationRoles(t *testing.T) {
+	cases := []struct {
+		name    string
+		users   []config.AuthUser
+		tokens  []config.APIToken
+		wantErr bool
+	}{
+		{name: "用户设置角色", users: []config.AuthUser{{Username: "alice", Role: config.RoleOperator}}},
+		{name: "用户未设置角色", users: []config.AuthUser{{Username: "alice"}}, wantErr: true},
+		{name: "用户角色无效", users: []config.AuthUser{{Username: "alice", Role: "root"}}, wantErr: true},
+		{name: "令牌设置角色", tokens: []config.APIToken{{Name: "ops", Role: config.RoleViewer}}},
+		{name: "令牌只设置权限范围", tokens: []config.APIToken{{Name: "backup", Scopes: []string{"leases:read"}}}},
+		{name: "令牌未设置角色和权限范围", tokens: []config.APIToken{{Name: "ops"}}, wantErr: true},
+	}
+
+	for _, c := range cases {
+		cfg := createTestConfig()
+		cfg.Auth.Users = c.users
+		cfg.Auth.Tokens = c.tokens
+		err := cfg.Validate()
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: 期望出错=%v, 实际为 %v", c.name, c.wantErr, err)
+		}
+	}
+}
+
 func TestConfigBackup(t *testing.T) {
 	cfg := &config.Config{
 		Server: config.ServerConfig{Interface: "eth0", Port: 67, APIPort: 8080},
@@ -500,6 +559,221 @@ func TestHealthCheckerMultipleGateways(t *testing.T) {
 }
 
 // 测试用例23-35: HTTP API测试
+// 测试用例: 事件通知
+func TestEventWebhookRetry(t *testing.T) {
+	var attempts int32
+	var apiKey string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey = r.Header.Get("X-Api-Key")
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	sink := events.NewWebhookSink(config.WebhookConfig{
+		Name:          "ops",
+		URL:           ts.URL,
+		Headers:       map[string]string{"X-Api-Key": "secret"},
+		RetryCount:    3,
+		RetryInterval: time.Millisecond,
+	})
+	if err := sink.Send(events.Event{Type: "gateway.down", Message: "test"}); err != nil {
+		t.Fatalf("第3次尝试应成功: %v", err)
+	}
+	if attempts != 3 || apiKey != "secret" {
+		t.Errorf("期望尝试3次并携带请求头, 实际为 %d 次, X-Api-Key=%q", attempts, apiKey)
+	}
+
+	atomic.StoreInt32(&attempts, -10)
+	sink = events.NewWebhookSink(config.WebhookConfig{URL: ts.URL, RetryCount: 2, RetryInterval: time.Millisecond})
+	if err := sink.Send(events.Event{Type: "gateway.down"}); err == nil {
+		t.Error("重试次数用完后应返回错误")
+	}
+}
+
+func TestEventFilter(t *testing.T) {
+	received := make(chan string, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event events.Event
+		json.NewDecoder(r.Body).Decode(&event)
+		received <- event.Type
+	}))
+	defer ts.Close()
+
+	err := events.Configure(config.EventsConfig{Webhooks: []config.WebhookConfig{{Name: "gw", URL: ts.URL, Events: []string{"gateway.*"}}}})
+	if err != nil {
+		t.Fatalf("配置事件通知失败: %v", err)
+	}
+	defer events.Configure(config.EventsConfig{})
+
+	events.Publish(events.Event{Type: "device.online"})
+	events.Publish(events.Event{Type: "gateway.down", Severity: events.SeverityCritical})
+
+	select {
+	case eventType := <-received:
+		if eventType != "gateway.down" {
+			t.Errorf("webhook只应收到gateway.*事件, 实际为 %s", eventType)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook未收到事件")
+	}
+	select {
+	case eventType := <-received:
+		t.Errorf("不应收到未订阅的事件 %s", eventType)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	recent := events.Recent(10, "gateway.*")
+	if len(recent) == 0 || recent[0].Type != "gateway.down" || recent[0].Timestamp.IsZero() {
+		t.Errorf("最近事件过滤不正确: %+v", recent)
+	}
+}
+
+// 测试用例: 设备在线记录
+func TestPresenceDailyUptime(t *testing.T) {
+	const mac = "aa:bb:cc:34:00:01"
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	at := func(daysAgo, hour, minute int) *time.Time {
+		v := today.AddDate(0, 0, -daysAgo).Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+		return &v
+	}
+
+	// 跨午夜的会话分摊到两天，统计范围之外的会话不计入
+	records := []presence.Record{{
+		MAC:       strings.ToUpper(mac),
+		Hostname:  "laptop",
+		FirstSeen: *at(10, 9, 0),
+		LastSeen:  *at(2, 10, 30),
+		Sessions: []presence.Session{
+			{Start: *at(10, 9, 0), End: at(10, 18, 0), Source: presence.SourceDHCP},
+			{Start: *at(3, 22, 0), End: at(2, 1, 0), Source: presence.SourceScan},
+			{Start: *at(2, 10, 0), End: at(2, 10, 30), Source: presence.SourceDHCP},
+		},
+	}}
+	data, _ := json.Marshal(records)
+	file := t.TempDir() + "/presence.json"
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	critical := func(m string) bool { return m == mac }
+	if err := presence.Configure(config.PresenceConfig{File: file}, critical); err != nil {
+		t.Fatalf("加载在线记录失败: %v", err)
+	}
+	defer presence.Configure(config.PresenceConfig{}, nil)
+
+	timeline, ok := presence.Get(mac, 4)
+	if !ok {
+		t.Fatal("应能按小写MAC查到加载的记录")
+	}
+	want := []int64{7200, 3600 + 1800, 0, 0}
+	if len(timeline.Daily) != len(want) {
+		t.Fatalf("期望 %d 天的统计, 实际为 %+v", len(want), timeline.Daily)
+	}
+	for i, seconds := range want {
+		if timeline.Daily[i].Seconds != seconds {
+			t.Errorf("第%d天 (%s) 期望在线 %d 秒, 实际为 %d", i, timeline.Daily[i].Date, seconds, timeline.Daily[i].Seconds)
+		}
+	}
+	if timeline.Daily[0].Date != today.AddDate(0, 0, -3).Format("2006-01-02") {
+		t.Errorf("统计应从3天前开始, 实际为 %s", timeline.Daily[0].Date)
+	}
+	if timeline.TotalUptime != 12600 || len(timeline.Sessions) != 2 {
+		t.Errorf("期望总计12600秒和2个会话, 实际为 %d 秒, %d 个会话", timeline.TotalUptime, len(timeline.Sessions))
+	}
+
+	// 离线设备再次出现时上线，主动离开时结束会话并按关键设备告警
+	presence.Observe(mac, "192.168.1.150", "", presence.SourceDHCP)
+	presence.MarkOffline(mac, "dhcp_release")
+	var online, offline *events.Event
+	for _, event := range events.Recent(50, "device.*") {
+		event := event
+		if event.Data["mac"] != mac {
+			continue
+		}
+		if event.Type == "device.online" && online == nil {
+			online = &event
+		}
+		if event.Type == "device.offline" && offline == nil {
+			offline = &event
+		}
+	}
+	if online == nil || online.Data["ip"] != "192.168.1.150" || online.Data["hostname"] != "laptop" {
+		t.Errorf("应发布device.online事件: %+v", online)
+	}
+	if offline == nil || offline.Severity != events.SeverityCritical || offline.Data["reason"] != "dhcp_release" {
+		t.Errorf("关键设备离开应发布critical级别的device.offline事件: %+v", offline)
+	}
+
+	timeline, _ = presence.Get(mac, 1)
+	if timeline.Online || len(timeline.Sessions) != 1 || timeline.Sessions[0].End == nil || timeline.IP != "192.168.1.150" {
+		t.Errorf("今天应有1个已结束的会话: %+v", timeline)
+	}
+}
+
+// 测试用例: OUI厂商数据库
+func TestOUIRegistry(t *testing.T) {
+	csvPath := t.TempDir() + "/oui.csv"
+	data := "Registry,Assignment,Organization Name,Organization Address\n" +
+		"MA-L,70B3D5,IEEE Registration Authority,\"445 Hoes Lane, Piscataway\"\n" +
+		"MA-M,70B3D51,Medium Block Vendor,Somewhere\n" +
+		"MA-S,70B3D51F0,Small Block Vendor,Somewhere\n" +
+		"MA-L,005056,Overridden VMware,Somewhere\n"
+	if err := os.WriteFile(csvPath, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	registry, err := oui.NewRegistry(csvPath)
+	if err != nil {
+		t.Fatalf("加载OUI数据失败: %v", err)
+	}
+
+	cases := []struct {
+		mac      string
+		vendor   string
+		registry string
+		prefix   string
+	}{
+		{"70:b3:d5:1f:01:23", "Small Block Vendor", oui.RegistryMAS, "70:B3:D5:1F:0"},
+		{"70-B3-D5-1A-00-00", "Medium Block Vendor", oui.RegistryMAM, "70:B3:D5:1"},
+		{"70b3d5200000", "IEEE Registration Authority", oui.RegistryMAL, "70:B3:D5"},
+		{"00:50:56:01:02:03", "Overridden VMware", oui.RegistryMAL, "00:50:56"},
+		{"00:0c:29:01:02:03", "VMware, Inc.", "builtin", "00:0C:29"},
+		{"fe:ff:ff:00:00:01", "", "", ""},
+	}
+	for _, c := range cases {
+		info := registry.Lookup(c.mac)
+		if info.Vendor != c.vendor || info.Registry != c.registry || info.Prefix != c.prefix {
+			t.Errorf("%s: 期望 %q %s %s, 实际为 %q %s %s", c.mac, c.vendor, c.registry, c.prefix, info.Vendor, info.Registry, info.Prefix)
+		}
+	}
+
+	if info := registry.Lookup("02:11:22:33:44:55"); !info.LocallyAdministered || !info.Randomized || info.Multicast {
+		t.Errorf("本地管理的单播地址应标记为随机化: %+v", info)
+	}
+	if info := registry.Lookup("03:11:22:33:44:55"); !info.Multicast || info.Randomized {
+		t.Errorf("组播地址不应标记为随机化: %+v", info)
+	}
+
+	stats := registry.Stats()
+	if stats.Entries[oui.RegistryMAM] != 1 || stats.Entries[oui.RegistryMAS] != 1 || len(stats.Sources) != 2 {
+		t.Errorf("统计信息不正确: %+v", stats)
+	}
+
+	badPath := t.TempDir() + "/bad.csv"
+	os.WriteFile(badPath, []byte("MA-L,70B3D5,Vendor\nMA-L,70B3,Short Prefix\n"), 0644)
+	if _, err := oui.NewRegistry(badPath); err == nil || !strings.Contains(err.Error(), "第2行") {
+		t.Errorf("无效前缀应返回带行号的错误, 实际为 %v", err)
+	}
+	if _, err := oui.NewRegistry(t.TempDir() + "/missing.csv"); err == nil {
+		t.Error("文件不存在时应返回错误")
+	}
+}
+
 func TestAPIServerCreation(t *testing.T) {
 	cfg := createTestConfig()
 	server, _ := dhcp.NewServer(cfg)
@@ -510,6 +784,7 @@ func TestAPIServerCreation(t *testing.T) {
 		cfg,
 		"test.yaml",
 		server,
+		nil,
 		8080,
 	)
 
@@ -528,6 +803,7 @@ func TestAPIHealthEndpoint(t *testing.T) {
 		cfg,
 		"test.yaml",
 		server,
+		nil,
 		8080,
 	)
 
@@ -554,6 +830,7 @@ func TestAPILeasesEndpoint(t *testing.T) {
 		cfg,
 		"test.yaml",
 		server,
+		nil,
 		8080,
 	)
 
@@ -594,6 +871,7 @@ func TestAPIActiveLeasesEndpoint(t *testing.T) {
 		cfg,
 		"test.yaml",
 		server,
+		nil,
 		8080,
 	)
 
@@ -619,6 +897,7 @@ func TestAPIHistoryEndpoint(t *testing.T) {
 		cfg,
 		"test.yaml",
 		server,
+		nil,
 		8080,
 	)
 
@@ -655,6 +934,7 @@ func TestAPIStatsEndpoint(t *testing.T) {
 		cfg,
 		"test.yaml",
 		server,
+		nil,
 		8080,
 	)
 
@@ -691,6 +971,7 @@ func TestAPIGatewaysEndpoint(t *testing.T) {
 		cfg,
 		"test.yaml",
 		server,
+		nil,
 		8080,
 	)
 
@@ -716,6 +997,7 @@ func TestAPIDevicesEndpoint(t *testing.T) {
 		cfg,
 		"test.yaml",
 		server,
+		nil,
 		8080,
 	)
 
@@ -741,6 +1023,7 @@ func TestAPIBindingsEndpoint(t *testing.T) {
 		cfg,
 		"test.yaml",
 		server,
+		nil,
 		8080,
 	)
 
@@ -766,6 +1049,7 @@ func TestAPIConfigEndpoint(t *testing.T) {
 		cfg,
 		"test.yaml",
 		server,
+		nil,
 		8080,
 	)
 
@@ -791,6 +1075,7 @@ func TestAPIConfigValidateEndpoint(t *testing.T) {
 		cfg,
 		"test.yaml",
 		server,
+		nil,
 		8080,
 	)
 
@@ -823,6 +1108,7 @@ func TestAPIDeviceAddEndpoint(t *testing.T) {
 		cfg,
 		"test.yaml",
 		server,
+		nil,
 		8080,
 	)
 
@@ -836,6 +1122,7 @@ func TestAPIDeviceAddEndpoint(t *testing.T) {
 
 	req := httptest.NewRequest("POST", "/api/devices", strings.NewReader(deviceData))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"0"`)
 	w := httptest.NewRecorder()
 
 	mux := http.NewServeMux()
@@ -857,6 +1144,7 @@ func TestAPIStaticBindingAddEndpoint(t *testing.T) {
 		cfg,
 		"test.yaml",
 		server,
+		nil,
 		8080,
 	)
 
@@ -870,6 +1158,7 @@ func TestAPIStaticBindingAddEndpoint(t *testing.T) {
 
 	req := httptest.NewRequest("POST", "/api/bindings", strings.NewReader(bindingData))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"0"`)
 	w := httptest.NewRecorder()
 
 	mux := http.NewServeMux()
@@ -881,7 +1170,879 @@ func TestAPIStaticBindingAddEndpoint(t *testing.T) {
 	}
 }
 
+// 测试用例: 路由权限
+type recordingMux struct {
+	patterns []string
+}
+
+func (m *recordingMux) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	m.patterns = append(m.patterns, pattern)
+}
+
+func TestAPIRoutesDeclarePermission(t *testing.T) {
+	cfg := createTestConfig()
+	server, _ := dhcp.NewServer(cfg)
+	apiServer := api.NewAPIServer(server.GetPool(), server.GetChecker(), cfg, t.TempDir()+"/config.yaml", server, nil, 8080)
+
+	mux := &recordingMux{}
+	apiServer.RegisterRoutes(mux)
+	permissions := apiServer.RoutePermissions()
+
+	if len(mux.patterns) == 0 {
+		t.Fatal("没有注册任何路由")
+	}
+	for _, pattern := range mux.patterns {
+		if permissions[pattern] == "" {
+			t.Errorf("路由 %s 没有声明所需权限", pattern)
+		}
+	}
+}
+
+func TestAPIRolePermissions(t *testing.T) {
+	cfg := createTestConfig()
+	server, _ := dhcp.NewServer(cfg)
+
+	tokens := make(map[string]string)
+	for _, role := range []string{config.RoleViewer, config.RoleOperator, config.RoleAdmin} {
+		token, hash, err := api.GenerateToken()
+		if err != nil {
+			t.Fatalf("生成令牌失败: %v", err)
+		}
+		tokens[role] = token
+		cfg.Auth.Tokens = append(cfg.Auth.Tokens, config.APIToken{Name: role, TokenHash: hash, Role: role})
+	}
+
+	apiServer := api.NewAPIServer(server.GetPool(), server.GetChecker(), cfg, t.TempDir()+"/config.yaml", server, nil, 8080)
+	handler := apiServer.Handler()
+
+	cases := []struct {
+		role   string
+		method string
+		path   string
+		want   int
+	}{
+		{"", "GET", "/api/stats", http.StatusUnauthorized},
+		{"", "GET", "/api/health", http.StatusOK},
+		{config.RoleViewer, "GET", "/api/stats", http.StatusOK},
+		{config.RoleViewer, "GET", "/api/leases/history", http.StatusOK},
+		{config.RoleViewer, "GET", "/api/devices", http.StatusForbidden},
+		{config.RoleViewer, "POST", "/api/scanner/start", http.StatusForbidden},
+		{config.RoleOperator, "GET", "/api/devices", http.StatusOK},
+		{config.RoleOperator, "GET", "/api/bindings", http.StatusOK},
+		{config.RoleOperator, "GET", "/api/config", http.StatusForbidden},
+		{config.RoleOperator, "POST", "/api/server/restart", http.StatusForbidden},
+		{config.RoleAdmin, "GET", "/api/config", http.StatusOK},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, c.path, nil)
+		if c.role != "" {
+			req.Header.Set("Authorization", "Bearer "+tokens[c.role])
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != c.want {
+			t.Errorf("%s %s %s: 期望状态码%d, 实际为 %d", c.role, c.method, c.path, c.want, w.Code)
+		}
+	}
+}
+
+// loginRequest 构造登录请求
+func loginRequest(username, password, remoteAddr string) *http.Request {
+	body, _ := json.Marshal(api.LoginRequest{Username: username, Password: password})
+	req := httptest.NewRequest("POST", "/api/auth/login", strings.NewReader(string(body)))
+	req.RemoteAddr = remoteAddr
+	return req
+}
+
+func TestAuthBootstrapAdmin(t *testing.T) {
+	cfg := createTestConfig()
+	configPath := t.TempDir() + "/config.yaml"
+
+	var logOutput, out strings.Builder
+	log.SetOutput(&logOutput)
+	err := api.EnsureAdminUser(cfg, configPath, &out)
+	log.SetOutput(io.Discard)
+	if err != nil {
+		t.Fatalf("创建初始管理员失败: %v", err)
+	}
+
+	match := regexp.MustCompile(`密码=(\S+)`).FindStringSubmatch(out.String())
+	if match == nil {
+		t.Fatalf("初始密码应输出一次, 实际输出: %q", out.String())
+	}
+	password := match[1]
+	if strings.Contains(logOutput.String(), password) {
+		t.Error("初始密码不应写入日志")
+	}
+	if len(cfg.Auth.Users) != 1 || cfg.Auth.Users[0].Username != "admin" || cfg.Auth.Users[0].Role != config.RoleAdmin {
+		t.Fatalf("应创建admin管理员: %+v", cfg.Auth.Users)
+	}
+
+	// 配置文件包含密码哈希，只允许所有者读写
+	info, err := os.Stat(configPath)
+	if err != nil {
+		t.Fatalf("配置文件未保存: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("配置文件权限应为0600, 实际为 %o", info.Mode().Perm())
+	}
+	saved, err := config.LoadConfig(configPath)
+	if err != nil || len(saved.Auth.Users) != 1 || saved.Auth.Users[0].PasswordHash == "" {
+		t.Fatalf("重新加载后应保留管理员: %v", err)
+	}
+
+	// 已有账号时不重复创建
+	out.Reset()
+	if err := api.EnsureAdminUser(cfg, configPath, &out); err != nil || len(cfg.Auth.Users) != 1 || out.Len() != 0 {
+		t.Errorf("已有账号时不应再次创建: %v, %+v", err, cfg.Auth.Users)
+	}
+
+	// 备份同样只允许所有者读写
+	if err := cfg.SaveConfig(configPath); err != nil {
+		t.Fatalf("保存配置失败: %v", err)
+	}
+	backups, err := config.GetBackupList(configPath)
+	if err != nil || len(backups) == 0 {
+		t.Fatalf("保存时应创建备份: %v", err)
+	}
+	for _, backup := range backups {
+		if info, err := os.Stat(backup.Path); err != nil || info.Mode().Perm() != 0600 {
+			t.Errorf("备份 %s 权限应为0600: %v", backup.Filename, info.Mode())
+		}
+	}
+
+	// 使用初始密码登录
+	server, _ := dhcp.NewServer(cfg)
+	handler := api.NewAPIServer(server.GetPool(), server.GetChecker(), cfg, configPath, server, nil, 8080).Handler()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, loginRequest("admin", password, "192.0.2.1:1234"))
+	if w.Code != http.StatusOK {
+		t.Errorf("初始密码应能登录, 实际状态码 %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestAuthLoginSession(t *testing.T) {
+	cfg := createTestConfig()
+	hash, err := api.HashPassword("correct-horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Auth.Users = []config.AuthUser{{Username: "alice", PasswordHash: hash, Role: config.RoleOperator}}
+	server, _ := dhcp.NewServer(cfg)
+	handler := api.NewAPIServer(server.GetPool(), server.GetChecker(), cfg, t.TempDir()+"/config.yaml", server, nil, 8080).Handler()
+
+	for _, c := range []struct{ username, password string }{{"alice", "wrong-password"}, {"bob", "correct-horse"}} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, loginRequest(c.username, c.password, "192.0.2.2:1234"))
+		if w.Code != http.StatusUnauthorized || len(w.Result().Cookies()) != 0 {
+			t.Errorf("%s 登录应失败, 实际状态码 %d", c.username, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, loginRequest("alice", "correct-horse", "192.0.2.2:1234"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("登录失败: %d %s", w.Code, w.Body.String())
+	}
+	var session *http.Cookie
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == "dhcp_session" {
+			session = cookie
+		}
+	}
+	if session == nil || !session.HttpOnly || session.SameSite != http.SameSiteStrictMode {
+		t.Fatalf("应设置HttpOnly、SameSite=Strict的会话Cookie: %+v", session)
+	}
+
+	me := func() (int, api.Principal) {
+		req := httptest.NewRequest("GET", "/api/auth/me", nil)
+		req.AddCookie(session)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		var p api.Principal
+		json.NewDecoder(w.Body).Decode(&p)
+		return w.Code, p
+	}
+	if code, p := me(); code != http.StatusOK || p.Username != "alice" || p.Kind != "session" || p.Role != config.RoleOperator {
+		t.Errorf("会话应识别为alice: %d %+v", code, p)
+	}
+
+	// 修改自己的密码不需要携带配置修订号
+	body, _ := json.Marshal(api.ChangePasswordRequest{OldPassword: "correct-horse", NewPassword: "battery-staple"})
+	change := httptest.NewRequest("POST", "/api/auth/password", strings.NewReader(string(body)))
+	change.AddCookie(session)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, change)
+	if w.Code != http.StatusOK {
+		t.Fatalf("修改密码失败: %d %s", w.Code, w.Body.String())
+	}
+
+	// 注销后会话失效
+	req := httptest.NewRequest("POST", "/api/auth/logout", nil)
+	req.AddCookie(session)
+	req.Header.Set("If-Match", "*")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if code, _ := me(); code != http.StatusUnauthorized {
+		t.Errorf("注销后会话应失效, 实际状态码 %d", code)
+	}
+
+	// 会话过期后失效
+	cfg.Auth.SessionTTL = 50 * time.Millisecond
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, loginRequest("alice", "battery-staple", "192.0.2.2:1234"))
+	session = w.Result().Cookies()[0]
+	if code, _ := me(); code != http.StatusOK {
+		t.Fatalf("新会话应有效, 实际状态码 %d", code)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if code, _ := me(); code != http.StatusUnauthorized {
+		t.Errorf("过期的会话应失效, 实际状态码 %d", code)
+	}
+}
+
+func TestAuthLoginLockout(t *testing.T) {
+	cfg := createTestConfig()
+	hash, err := api.HashPassword("correct-horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Auth.Users = []config.AuthUser{{Username: "alice", PasswordHash: hash, Role: config.RoleAdmin}}
+	server, _ := dhcp.NewServer(cfg)
+	handler := api.NewAPIServer(server.GetPool(), server.GetChecker(), cfg, t.TempDir()+"/config.yaml", server, nil, 8080).Handler()
+
+	login := func(password, remoteAddr string) int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, loginRequest("alice", password, remoteAddr))
+		return w.Code
+	}
+
+	// 成功登录清除失败计数
+	for i := 0; i < 4; i++ {
+		login("wrong-password", "192.0.2.3:1000")
+	}
+	if code := login("correct-horse", "192.0.2.3:1000"); code != http.StatusOK {
+		t.Fatalf("未达到失败上限时应能登录, 实际状态码 %d", code)
+	}
+
+	// 连续失败5次后锁定该地址，正确密码也被拒绝
+	for i := 0; i < 5; i++ {
+		if code := login("wrong-password", "192.0.2.3:1000"); code != http.StatusUnauthorized {
+			t.Fatalf("第 %d 次失败应返回401, 实际为 %d", i+1, code)
+		}
+	}
+	if code := login("correct-horse", "192.0.2.3:2000"); code != http.StatusTooManyRequests {
+		t.Errorf("锁定期间应返回429, 实际为 %d", code)
+	}
+
+	// 其他地址不受影响
+	if code := login("correct-horse", "192.0.2.4:1000"); code != http.StatusOK {
+		t.Errorf("其他地址不应被锁定, 实际状态码 %d", code)
+	}
+}
+
+func TestAPITokenScopes(t *testing.T) {
+	cfg := createTestConfig()
+	server, _ := dhcp.NewServer(cfg)
+
+	adminToken, adminHash, _ := api.GenerateToken()
+	cfg.Auth.Tokens = []config.APIToken{{Name: "admin", TokenHash: adminHash, Role: config.RoleAdmin}}
+
+	apiServer := api.NewAPIServer(server.GetPool(), server.GetChecker(), cfg, t.TempDir()+"/config.yaml", server, nil, 8080)
+	handler := apiServer.Handler()
+
+	do := func(token, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.RemoteAddr = "192.168.1.50:40000"
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("If-Match", "*")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	create := func(body string) string {
+		w := do(adminToken, "POST", "/api/auth/tokens", body)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("创建令牌失败: %d %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Token string `json:"token"`
+		}
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp.Token
+	}
+
+	scoped := create(`{"name":"automation","scopes":["leases:read","bindings:write"],"expires_in":"1h"}`)
+	if w := do(scoped, "GET", "/api/leases", ""); w.Code != http.StatusOK {
+		t.Errorf("leases:read 应允许读取租约, 实际为 %d", w.Code)
+	}
+	if w := do(scoped, "GET", "/api/bindings", ""); w.Code != http.StatusOK {
+		t.Errorf("bindings:write 应包含读取权限, 实际为 %d", w.Code)
+	}
+	if w := do(scoped, "GET", "/api/stats", ""); w.Code != http.StatusForbidden {
+		t.Errorf("未授予stats:read, 期望403, 实际为 %d", w.Code)
+	}
+	if w := do(scoped, "POST", "/api/auth/tokens", `{"name":"escalate","scopes":["*"]}`); w.Code != http.StatusForbidden {
+		t.Errorf("令牌不应能创建令牌, 期望403, 实际为 %d", w.Code)
+	}
+	if w := do(adminToken, "POST", "/api/auth/tokens", `{"name":"norole"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("未设置角色和权限范围, 期望400, 实际为 %d", w.Code)
+	}
+
+	// 整个配置包含用户和令牌，拥有config:write的令牌也不能保存或恢复
+	configWriter := create(`{"name":"config-writer","scopes":["config:write"]}`)
+	escalate := `{"content": "auth:\n  tokens:\n  - name: root\n    token_hash: x\n    role: admin\n"}`
+	if w := do(configWriter, "POST", "/api/config", escalate); w.Code != http.StatusForbidden {
+		t.Errorf("非admin保存整个配置, 期望403, 实际为 %d", w.Code)
+	}
+	if w := do(configWriter, "POST", "/api/config/restore", `{"filename": "config_backup.yaml"}`); w.Code != http.StatusForbidden {
+		t.Errorf("非admin恢复配置, 期望403, 实际为 %d", w.Code)
+	}
+	if w := do(configWriter, "PUT", "/api/config/server", `{}`); w.Code == http.StatusForbidden {
+		t.Error("config:write 应允许修改单项配置")
+	}
+
+	// 非admin读取原始配置时替换令牌哈希
+	raw := func(token string) string {
+		var resp api.RawConfigResponse
+		json.NewDecoder(do(token, "GET", "/api/config?raw=true", "").Body).Decode(&resp)
+		return resp.Content
+	}
+	if content := raw(configWriter); !strings.Contains(content, "[REDACTED]") || strings.Contains(content, adminHash) {
+		t.Errorf("非admin读取的原始配置不应包含令牌哈希: %s", content)
+	}
+	if content := raw(adminToken); !strings.Contains(content, adminHash) {
+		t.Error("admin应能读取完整的原始配置")
+	}
+
+	restricted := create(`{"name":"remote","scopes":["leases:read"],"source_cidrs":["10.0.0.0/8"]}`)
+	if w := do(restricted, "GET", "/api/leases", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("来源不在允许网段内, 期望401, 实际为 %d", w.Code)
+	}
+
+	var tokens []map[string]interface{}
+	json.NewDecoder(do(adminToken, "GET", "/api/auth/tokens", "").Body).Decode(&tokens)
+	if len(tokens) != 4 {
+		t.Fatalf("期望4个令牌, 实际为 %d", len(tokens))
+	}
+	for _, token := range tokens {
+		if _, ok := token["token_hash"]; ok {
+			t.Error("令牌列表不应包含哈希")
+		}
+		if token["name"] == "automation" && token["last_used"] == nil {
+			t.Error("令牌应记录最后使用时间")
+		}
+	}
+
+	past := time.Now().Add(-time.Minute)
+	for i := range cfg.Auth.Tokens {
+		if cfg.Auth.Tokens[i].Name == "automation" {
+			cfg.Auth.Tokens[i].ExpiresAt = &past
+		}
+	}
+	if w := do(scoped, "GET", "/api/leases", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("令牌已过期, 期望401, 实际为 %d", w.Code)
+	}
+
+	if w := do(adminToken, "DELETE", "/api/auth/tokens?name=remote", ""); w.Code != http.StatusOK {
+		t.Errorf("吊销令牌失败: %d", w.Code)
+	}
+	if len(cfg.Auth.Tokens) != 3 {
+		t.Errorf("吊销后期望3个令牌, 实际为 %d", len(cfg.Auth.Tokens))
+	}
+}
+
+func TestAPITokenLastUsedPersisted(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.Gateways = append(cfg.Gateways, config.Gateway{Name: "backup", IP: "192.168.1.2"})
+	server, _ := dhcp.NewServer(cfg)
+	token, hash, _ := api.GenerateToken()
+	_, monitorHash, _ := api.GenerateToken()
+	cfg.Auth.Tokens = []config.APIToken{
+		{Name: "ops", TokenHash: hash, Role: config.RoleAdmin},
+		{Name: "monitor", TokenHash: monitorHash, Role: config.RoleViewer},
+	}
+
+	configPath := t.TempDir() + "/config.yaml"
+	handler := api.NewAPIServer(server.GetPool(), server.GetChecker(), cfg, configPath, server, nil, 8080).Handler()
+	req := httptest.NewRequest("DELETE", "/api/gateways", strings.NewReader(`{"name": "backup"}`))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("If-Match", "*")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("删除网关失败: %d %s", w.Code, w.Body.String())
+	}
+
+	// 与令牌无关的配置修改同样写回使用时间
+	saved, err := config.LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("加载保存的配置失败: %v", err)
+	}
+	for _, token := range saved.Auth.Tokens {
+		if token.Name == "ops" && token.LastUsed == nil {
+			t.Error("保存配置时应写回令牌的最后使用时间")
+		}
+		if token.Name == "monitor" && token.LastUsed != nil {
+			t.Error("未使用的令牌不应有最后使用时间")
+		}
+	}
+}
+
+func TestAPIAuditLog(t *testing.T) {
+	cfg := createTestConfig()
+	server, _ := dhcp.NewServer(cfg)
+
+	token, hash, _ := api.GenerateToken()
+	cfg.Auth.Tokens = []config.APIToken{{Name: "ops", TokenHash: hash, Role: config.RoleAdmin}}
+
+	configPath := t.TempDir() + "/config.yaml"
+	if err := cfg.SaveConfig(configPath); err != nil {
+		t.Fatalf("保存配置失败: %v", err)
+	}
+	apiServer := api.NewAPIServer(server.GetPool(), server.GetChecker(), cfg, configPath, server, nil, 8080)
+	handler := apiServer.Handler()
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.RemoteAddr = "192.168.1.60:50000"
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("If-Match", "*")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	binding := `{"alias": "audit-binding", "mac": "aa:bb:cc:00:11:22", "ip": "192.168.1.151", "gateway": "main_gateway"}`
+	if w := do("POST", "/api/bindings", binding); w.Code != http.StatusCreated {
+		t.Fatalf("添加绑定失败: %d %s", w.Code, w.Body.String())
+	}
+	do("POST", "/api/bindings", `{"mac": "bad"}`)
+
+	var resp struct {
+		Total   int           `json:"total"`
+		Entries []audit.Entry `json:"entries"`
+	}
+	w := do("GET", "/api/audit?endpoint=/api/bindings&actor=token:ops&section=bindings", "")
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("解析审计日志失败: %v", err)
+	}
+	if resp.Total != 1 {
+		t.Fatalf("期望1条修改bindings的审计记录, 实际为 %d", resp.Total)
+	}
+	entry := resp.Entries[0]
+	if entry.SourceIP != "192.168.1.60" || entry.Result != audit.ResultSuccess || entry.Status != http.StatusCreated {
+		t.Errorf("审计记录内容不正确: %+v", entry)
+	}
+	found := false
+	for _, change := range entry.Changes {
+		if change.Section == "bindings" && change.Before == nil && change.After != nil {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("审计记录缺少新增绑定的差异: %+v", entry.Changes)
+	}
+
+	w = do("GET", "/api/audit?endpoint=/api/bindings&result=failure", "")
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Total < 1 || resp.Entries[0].Error == "" {
+		t.Errorf("失败的请求应记录错误信息: %+v", resp.Entries)
+	}
+
+	// 认证失败和权限不足的修改请求同样记录
+	viewer, viewerHash, _ := api.GenerateToken()
+	cfg.Auth.Tokens = append(cfg.Auth.Tokens, config.APIToken{Name: "monitor", TokenHash: viewerHash, Role: config.RoleViewer})
+	for _, bearer := range []string{viewer, "invalid-token"} {
+		req := httptest.NewRequest("DELETE", "/api/gateways", strings.NewReader(`{"name": "main_gateway"}`))
+		req.Header.Set("Authorization", "Bearer "+bearer)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	w = do("GET", "/api/audit?endpoint=/api/gateways&result=failure", "")
+	json.NewDecoder(w.Body).Decode(&resp)
+	statuses := map[string]int{}
+	for _, entry := range resp.Entries {
+		statuses[entry.Actor] = entry.Status
+	}
+	if statuses["token:monitor"] != http.StatusForbidden || statuses["anonymous"] != http.StatusUnauthorized {
+		t.Errorf("被拒绝的请求应记录操作者和状态码: %+v", resp.Entries)
+	}
+}
+
+func TestAPIMetrics(t *testing.T) {
+	cfg := createTestConfig()
+	server, _ := dhcp.NewServer(cfg)
+
+	token, hash, _ := api.GenerateToken()
+	cfg.Auth.Tokens = []config.APIToken{{Name: "prometheus", TokenHash: hash, Role: config.RoleViewer}}
+
+	if _, err := server.GetPool().RequestIP("aa:bb:cc:00:33:44", nil, "metrics-client"); err != nil {
+		t.Fatalf("分配IP失败: %v", err)
+	}
+
+	apiServer := api.NewAPIServer(server.GetPool(), server.GetChecker(), cfg, t.TempDir()+"/config.yaml", server, nil, 8080)
+	handler := apiServer.Handler()
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("未认证的抓取应返回401, 实际为 %d", w.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("只读令牌抓取指标失败: %d %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type不正确: %s", ct)
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{
+		"# TYPE dhcp_messages_total counter",
+		"# TYPE dhcp_handler_duration_seconds histogram",
+		`dhcp_pool_used{pool="main"} 1`,
+		`dhcp_gateway_up{gateway="main_gateway",ip="192.168.1.1"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("指标输出缺少 %q", want)
+		}
+	}
+}
+
+func TestAPIV1(t *testing.T) {
+	cfg := createTestConfig()
+	server, _ := dhcp.NewServer(cfg)
+
+	token, hash, _ := api.GenerateToken()
+	cfg.Auth.Tokens = []config.APIToken{{Name: "ops", TokenHash: hash, Role: config.RoleAdmin}}
+
+	configPath := t.TempDir() + "/config.yaml"
+	if err := cfg.SaveConfig(configPath); err != nil {
+		t.Fatalf("保存配置失败: %v", err)
+	}
+	apiServer := api.NewAPIServer(server.GetPool(), server.GetChecker(), cfg, configPath, server, nil, 8080)
+	handler := apiServer.Handler()
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("If-Match", "*")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	expectError := func(w *httptest.ResponseRecorder, status int, code string) {
+		t.Helper()
+		var resp api.ErrorResponse
+		if w.Code != status || json.Unmarshal(w.Body.Bytes(), &resp) != nil || resp.Error.Code != code || resp.Error.Message == "" {
+			t.Errorf("期望 %d %s 错误响应, 实际为 %d %s", status, code, w.Code, w.Body.String())
+		}
+	}
+
+	if w := do("GET", "/api/v1/leases", ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"items":[]`) {
+		t.Errorf("空租约列表应返回空数组: %d %s", w.Code, w.Body.String())
+	}
+
+	for i, ip := range []string{"192.168.1.160", "192.168.1.20", "192.168.1.3"} {
+		body := fmt.Sprintf(`{"alias": "host-%d", "mac": "aa:bb:cc:00:22:0%d", "ip": "%s"}`, i, i, ip)
+		if w := do("POST", "/api/v1/bindings", body); w.Code != http.StatusCreated {
+			t.Fatalf("创建绑定失败: %d %s", w.Code, w.Body.String())
+		}
+	}
+	expectError(do("POST", "/api/v1/bindings", `{"alias": "host-0", "mac": "aa:bb:cc:00:22:09", "ip": "192.168.1.9"}`), http.StatusConflict, api.ErrCodeConflict)
+	expectError(do("POST", "/api/v1/bindings", `{`), http.StatusBadRequest, api.ErrCodeInvalidRequest)
+
+	// 按IP数值排序分页
+	var ips []string
+	cursor := ""
+	for page := 0; page < 3; page++ {
+		var list api.BindingList
+		w := do("GET", "/api/v1/bindings?sort=ip&limit=2&cursor="+cursor, "")
+		if err := json.NewDecoder(w.Body).Decode(&list); err != nil || list.Total != 3 {
+			t.Fatalf("分页查询失败: %d %+v", w.Code, list)
+		}
+		for _, b := range list.Items {
+			ips = append(ips, b.IP)
+		}
+		if cursor = list.NextCursor; cursor == "" {
+			break
+		}
+	}
+	if strings.Join(ips, ",") != "192.168.1.3,192.168.1.20,192.168.1.160" {
+		t.Errorf("分页结果顺序不正确: %v", ips)
+	}
+	expectError(do("GET", "/api/v1/bindings?sort=-ip&cursor="+url.QueryEscape("not-a-cursor"), ""), http.StatusBadRequest, api.ErrCodeInvalidRequest)
+	expectError(do("GET", "/api/v1/bindings?sort=gateway_ip", ""), http.StatusBadRequest, api.ErrCodeInvalidRequest)
+
+	if w := do("PUT", "/api/v1/bindings/host-1", `{"mac": "aa:bb:cc:00:22:01", "ip": "192.168.1.21", "hostname": "renamed"}`); w.Code != http.StatusOK {
+		t.Fatalf("更新绑定失败: %d %s", w.Code, w.Body.String())
+	}
+	var binding api.BindingInfo
+	json.NewDecoder(do("GET", "/api/v1/bindings/host-1", "").Body).Decode(&binding)
+	if binding.IP != "192.168.1.21" || binding.Hostname != "renamed" {
+		t.Errorf("绑定未更新: %+v", binding)
+	}
+	if w := do("DELETE", "/api/v1/bindings/host-1", ""); w.Code != http.StatusNoContent {
+		t.Errorf("删除绑定失败: %d %s", w.Code, w.Body.String())
+	}
+	expectError(do("GET", "/api/v1/bindings/host-1", ""), http.StatusNotFound, api.ErrCodeNotFound)
+	expectError(do("DELETE", "/api/v1/bindings/host-1", ""), http.StatusNotFound, api.ErrCodeNotFound)
+	expectError(do("GET", "/api/v1/unknown", ""), http.StatusNotFound, api.ErrCodeNotFound)
+
+	// 设备路径中的MAC不区分大小写和分隔符
+	if w := do("POST", "/api/v1/devices", `{"mac": "AA:BB:CC:00:23:01", "hostname": "printer"}`); w.Code != http.StatusCreated {
+		t.Fatalf("创建设备失败: %d %s", w.Code, w.Body.String())
+	}
+	var device config.DeviceInfo
+	if w := do("GET", "/api/v1/devices/aa-bb-cc-00-23-01", ""); w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &device) != nil || device.Hostname != "printer" {
+		t.Errorf("应按标准化的MAC查找设备: %d %s", w.Code, w.Body.String())
+	}
+	if w := do("PUT", "/api/v1/devices/aa:bb:cc:00:23:01", `{"mac": "aabb.cc00.2301", "hostname": "office-printer"}`); w.Code != http.StatusOK {
+		t.Errorf("请求体和路径的MAC写法不同应视为同一设备: %d %s", w.Code, w.Body.String())
+	}
+	if found := cfg.FindDeviceByMAC("AA:BB:CC:00:23:01"); found == nil || found.Hostname != "office-printer" {
+		t.Errorf("设备未更新: %+v", cfg.Devices)
+	}
+	expectError(do("PUT", "/api/v1/devices/aa:bb:cc:00:23:01", `{"mac": "aa:bb:cc:00:23:02"}`), http.StatusBadRequest, api.ErrCodeInvalidRequest)
+	expectError(do("GET", "/api/v1/devices/not-a-mac", ""), http.StatusBadRequest, api.ErrCodeInvalidRequest)
+	if w := do("DELETE", "/api/v1/devices/aa:bb:cc:00:23:01", ""); w.Code != http.StatusOK || len(cfg.Devices) != 0 {
+		t.Errorf("删除设备失败: %d %s", w.Code, w.Body.String())
+	}
+
+	// 认证错误同样使用统一格式
+	req := httptest.NewRequest("GET", "/api/v1/devices", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	expectError(w, http.StatusUnauthorized, api.ErrCodeUnauthenticated)
+}
+
+func TestOpenAPISpecMatchesRoutes(t *testing.T) {
+	cfg := createTestConfig()
+	server, _ := dhcp.NewServer(cfg)
+	scanner := dhcp.NewNetworkScanner(cfg, server.GetPool())
+
+	configPath := t.TempDir() + "/config.yaml"
+	if err := cfg.SaveConfig(configPath); err != nil {
+		t.Fatalf("保存配置失败: %v", err)
+	}
+	apiServer := api.NewAPIServer(server.GetPool(), server.GetChecker(), cfg, configPath, server, scanner, 8080)
+	mux := http.NewServeMux()
+	apiServer.RegisterRoutes(mux)
+
+	routes := apiServer.RoutePermissions()
+	documented := apiServer.DocumentedMethods()
+	delete(routes, "/api/v1/") // 未定义路径的兜底路由
+
+	for pattern := range routes {
+		if _, ok := documented[pattern]; !ok {
+			t.Errorf("路由 %s 未在OpenAPI文档中描述", pattern)
+		}
+	}
+	for pattern := range documented {
+		if _, ok := routes[pattern]; !ok {
+			t.Errorf("OpenAPI文档中的 %s 没有对应的路由", pattern)
+		}
+	}
+
+	// 文档未列出的方法必须被处理函数拒绝
+	for pattern, methods := range documented {
+		if pattern == "/" || pattern == "/login" {
+			continue
+		}
+		for _, method := range []string{"GET", "POST", "PUT", "DELETE"} {
+			listed := false
+			for _, m := range methods {
+				listed = listed || m == method
+			}
+			if listed {
+				continue
+			}
+			path := pattern
+			if strings.HasSuffix(path, "/") {
+				path += "x"
+			}
+			// 修改配置的路由先检查If-Match，使用*跳过修订号检查
+			req := httptest.NewRequest(method, path, strings.NewReader("{}"))
+			req.Header.Set("If-Match", "*")
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+			if w.Code != http.StatusMethodNotAllowed {
+				t.Errorf("%s %s 未在文档中描述，应返回405，实际为 %d", method, path, w.Code)
+			}
+		}
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("获取OpenAPI文档失败: %d", w.Code)
+	}
+	var doc struct {
+		OpenAPI    string                                       `json:"openapi"`
+		Paths      map[string]map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("解析OpenAPI文档失败: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Errorf("OpenAPI版本不正确: %s", doc.OpenAPI)
+	}
+	for _, name := range []string{"LeaseInfo", "StatsResponse", "BindingInfo", "BindingUpdateRequest", "ConfigSaveRequest", "ServerConfig"} {
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("文档缺少类型 %s", name)
+		}
+	}
+	// 所有引用的类型都必须已定义
+	for _, ref := range regexp.MustCompile(`"#/components/schemas/([^"]+)"`).FindAllStringSubmatch(w.Body.String(), -1) {
+		if _, ok := doc.Components.Schemas[ref[1]]; !ok {
+			t.Errorf("引用了未定义的类型 %s", ref[1])
+		}
+	}
+	if scope := doc.Paths["/api/bindings"]["put"]["x-required-scope"]; scope != "bindings:write" {
+		t.Errorf("PUT /api/bindings 的权限应为 bindings:write, 实际为 %v", scope)
+	}
+	if _, ok := doc.Paths["/api/v1/leases/{ip}"]["get"]; !ok {
+		t.Error("文档缺少 GET /api/v1/leases/{ip}")
+	}
+	if params := fmt.Sprint(doc.Paths["/api/bindings"]["put"]["parameters"]); !strings.Contains(params, "If-Match") {
+		t.Errorf("PUT /api/bindings 应声明If-Match请求头, 实际为 %s", params)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/docs", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "/api/openapi.json") {
+		t.Errorf("文档页面不正确: %d", w.Code)
+	}
+}
+
 // 测试用例36-42: 高级功能测试
+func TestConfigRevisionETag(t *testing.T) {
+	cfg := createTestConfig()
+	server, _ := dhcp.NewServer(cfg)
+
+	token, hash, _ := api.GenerateToken()
+	cfg.Auth.Tokens = []config.APIToken{{Name: "ops", TokenHash: hash, Role: config.RoleAdmin}}
+
+	configPath := t.TempDir() + "/config.yaml"
+	if err := cfg.SaveConfig(configPath); err != nil {
+		t.Fatalf("保存配置失败: %v", err)
+	}
+	apiServer := api.NewAPIServer(server.GetPool(), server.GetChecker(), cfg, configPath, server, nil, 8080)
+	handler := apiServer.Handler()
+
+	do := func(method, path, ifMatch, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	etag := do("GET", "/api/bindings", "", "").Header().Get("ETag")
+	if etag != `"1"` {
+		t.Fatalf("首次保存后ETag应为\"1\", 实际为 %q", etag)
+	}
+
+	binding := `{"alias": "nas", "mac": "aa:bb:cc:00:88:01", "ip": "192.168.1.152", "gateway": "main_gateway"}`
+	if w := do("POST", "/api/bindings", "", binding); w.Code != http.StatusPreconditionRequired {
+		t.Errorf("缺少If-Match应返回428, 实际为 %d", w.Code)
+	}
+	if w := do("POST", "/api/bindings", `"0"`, binding); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("过期的If-Match应返回412, 实际为 %d", w.Code)
+	}
+	w := do("POST", "/api/bindings", etag, binding)
+	if w.Code != http.StatusCreated || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("添加绑定失败: %d %q %s", w.Code, w.Header().Get("ETag"), w.Body.String())
+	}
+	if data, _ := os.ReadFile(configPath); !strings.Contains(string(data), "revision: 2") {
+		t.Error("配置文件应记录修订号")
+	}
+
+	// 另一位管理员仍持有旧ETag
+	update := `{"old_alias": "nas", "alias": "nas", "mac": "aa:bb:cc:00:88:01", "ip": "192.168.1.153"}`
+	if w := do("PUT", "/api/bindings", etag, update); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("并发修改应返回412, 实际为 %d", w.Code)
+	}
+	var resp api.ErrorResponse
+	w = do("PUT", "/api/v1/bindings/nas", etag, update)
+	if json.Unmarshal(w.Body.Bytes(), &resp); w.Code != http.StatusPreconditionFailed || resp.Error.Code != api.ErrCodePreconditionFailed {
+		t.Errorf("v1接口应返回precondition_failed, 实际为 %d %s", w.Code, w.Body.String())
+	}
+
+	// 外部修改配置文件后拒绝覆盖，重新加载后修订号继续递增
+	f, _ := os.OpenFile(configPath, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString("# edited by hand\n")
+	f.Close()
+	if w := do("DELETE", "/api/bindings", `"2"`, `{"alias": "nas"}`); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("配置文件被外部修改后保存应返回412, 实际为 %d %s", w.Code, w.Body.String())
+	}
+	reloaded, err := config.LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("重新加载配置失败: %v", err)
+	}
+	apiServer.UpdateReferences(server.GetPool(), server.GetChecker(), reloaded, server, nil)
+	etag = do("GET", "/api/bindings", "", "").Header().Get("ETag")
+	if etag != `"3"` {
+		t.Errorf("重新加载后ETag应为\"3\", 实际为 %q", etag)
+	}
+	if w := do("DELETE", "/api/bindings", etag, `{"alias": "nas"}`); w.Code != http.StatusNoContent {
+		t.Errorf("重新加载后删除绑定失败: %d %s", w.Code, w.Body.String())
+	}
+
+	// 并发使用同一ETag修改，只有一个请求成功
+	etag = do("GET", "/api/bindings", "", "").Header().Get("ETag")
+	var wg sync.WaitGroup
+	var created, rejected int32
+	start := make(chan struct{})
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			body := fmt.Sprintf(`{"alias": "race-%d", "mac": "aa:bb:cc:00:89:%02x", "ip": "192.168.1.%d", "gateway": "main_gateway"}`, i, i, 160+i)
+			switch do("POST", "/api/bindings", etag, body).Code {
+			case http.StatusCreated:
+				atomic.AddInt32(&created, 1)
+			case http.StatusPreconditionFailed:
+				atomic.AddInt32(&rejected, 1)
+			}
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	if created != 1 || rejected != 19 {
+		t.Errorf("同一ETag的并发修改应只有一个成功: 成功 %d, 412 %d", created, rejected)
+	}
+
+	// 保存整个配置后返回的ETag可以直接用于下一次修改
+	data, _ := os.ReadFile(configPath)
+	body, _ := json.Marshal(api.ConfigSaveRequest{Content: string(data)})
+	etag = do("GET", "/api/config", "", "").Header().Get("ETag")
+	w = do("POST", "/api/config", etag, string(body))
+	saved := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || saved == etag {
+		t.Fatalf("保存配置失败: %d %q %s", w.Code, saved, w.Body.String())
+	}
+	if current := do("GET", "/api/config", "", "").Header().Get("ETag"); current != saved {
+		t.Errorf("保存后读取的ETag应为 %s, 实际为 %s", saved, current)
+	}
+	if w := do("POST", "/api/bindings", saved, binding); w.Code != http.StatusCreated {
+		t.Errorf("使用保存配置返回的ETag修改失败: %d %s", w.Code, w.Body.String())
+	}
+}
+
 func TestConfigDeviceManagement(t *testing.T) {
 	cfg := createTestConfig()
 
@@ -931,6 +2092,163 @@ func TestConfigGatewayManagement(t *testing.T) {
 	}
 }
 
+func TestResolvePolicyPrecedence(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.Gateways = append(cfg.Gateways, config.Gateway{Name: "backup", IP: "192.168.1.2"}, config.Gateway{Name: "lab", IP: "192.168.1.3"})
+	cfg.Groups = []config.DeviceGroup{
+		{Name: "first", Tags: []string{"camera"}, Gateway: "backup", DNSServers: []string{"192.168.1.53"}, LeaseTime: 2 * time.Hour,
+			StartIP: "192.168.1.50", EndIP: "192.168.1.69",
+			Options: []config.DHCPOption{{Code: 42, Type: "ip", Value: "192.168.1.1"}, {Code: 15, Value: "cam.local"}}},
+		{Name: "second", Tags: []string{"camera", "kids"}, Gateway: "lab"},
+	}
+	cfg.Devices = []config.DeviceInfo{
+		{MAC: "aa:bb:cc:38:00:01", Tags: []string{"camera"}},
+		{MAC: "aa:bb:cc:38:00:02", Tags: []string{"camera"}, Gateway: "lab", LeaseTime: time.Hour,
+			Options: []config.DHCPOption{{Code: 15, Value: "override.local"}}},
+		{MAC: "aa:bb:cc:38:00:03", Tags: []string{"kids"}},
+		{MAC: "aa:bb:cc:38:00:04"},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("配置应有效: %v", err)
+	}
+
+	// 组策略覆盖全局，多个组匹配时取第一个
+	policy := cfg.ResolvePolicy("aa:bb:cc:38:00:01")
+	if policy.Group != "first" || policy.Gateway != "backup" || policy.LeaseTime != 2*time.Hour || policy.StartIP != "192.168.1.50" ||
+		len(policy.DNSServers) != 1 || len(policy.Options) != 2 {
+		t.Errorf("应使用第一个匹配的设备组策略: %+v", policy)
+	}
+	for _, item := range []string{"gateway", "dns_servers", "lease_time", "range"} {
+		if policy.Sources[item] != "group:first" {
+			t.Errorf("%s 的来源应为group:first, 实际为 %s", item, policy.Sources[item])
+		}
+	}
+
+	// 设备设置覆盖组策略，同代码的选项由设备覆盖
+	policy = cfg.ResolvePolicy("aa:bb:cc:38:00:02")
+	if policy.Gateway != "lab" || policy.Sources["gateway"] != "device" || policy.LeaseTime != time.Hour || policy.Sources["lease_time"] != "device" {
+		t.Errorf("设备设置应优先于组策略: %+v", policy)
+	}
+	if policy.Sources["dns_servers"] != "group:first" || policy.Sources["range"] != "group:first" {
+		t.Errorf("设备未设置的项应继承组策略: %+v", policy.Sources)
+	}
+	options := make(map[int]string)
+	for _, option := range policy.Options {
+		options[option.Code] = option.Value
+	}
+	if len(options) != 2 || options[15] != "override.local" || options[42] != "192.168.1.1" {
+		t.Errorf("设备选项应按代码覆盖组选项: %+v", policy.Options)
+	}
+
+	policy = cfg.ResolvePolicy("aa:bb:cc:38:00:03")
+	if policy.Group != "second" || policy.Gateway != "lab" || policy.LeaseTime != cfg.Server.LeaseTime || policy.Sources["lease_time"] != "global" {
+		t.Errorf("第二个组只覆盖设置的项: %+v", policy)
+	}
+
+	// 不属于任何组的设备和未登记设备使用全局配置
+	for _, mac := range []string{"aa:bb:cc:38:00:04", "aa:bb:cc:38:00:99"} {
+		policy = cfg.ResolvePolicy(mac)
+		if policy.Group != "" || policy.Gateway != "" || policy.StartIP != "" || policy.LeaseTime != cfg.Server.LeaseTime {
+			t.Errorf("%s 应使用全局策略: %+v", mac, policy)
+		}
+		for item, source := range policy.Sources {
+			if source != "global" {
+				t.Errorf("%s 的 %s 来源应为global, 实际为 %s", mac, item, source)
+			}
+		}
+	}
+}
+
+func TestGroupRangeValidation(t *testing.T) {
+	cases := []struct {
+		start, end string
+		errMatch   string
+	}{
+		{start: "192.168.1.50", end: "192.168.1.69"},
+		{start: "192.168.1.0", end: "192.168.1.20", errMatch: "网络地址"},
+		{start: "192.168.1.240", end: "192.168.1.255", errMatch: "广播地址"},
+		{start: "192.168.1.1", end: "192.168.1.10", errMatch: "网关地址"},
+		{start: "192.168.1.5", end: "192.168.1.9", errMatch: "网关地址 192.168.1.7"},
+		{start: "192.168.1.35", end: "192.168.1.45", errMatch: "隔离地址范围"},
+		{start: "192.168.2.10", end: "192.168.2.20", errMatch: "不在子网"},
+		{start: "192.168.1.60", end: "192.168.1.50", errMatch: "分配范围无效"},
+	}
+
+	for _, c := range cases {
+		cfg := createTestConfig()
+		cfg.Gateways = append(cfg.Gateways, config.Gateway{Name: "backup", IP: "192.168.1.7"})
+		cfg.Security.Quarantine = config.QuarantineConfig{StartIP: "192.168.1.30", EndIP: "192.168.1.40"}
+		err := cfg.ValidateGroup(config.DeviceGroup{Name: "g", StartIP: c.start, EndIP: c.end})
+		if c.errMatch == "" {
+			if err != nil {
+				t.Errorf("%s-%s: 应有效, 实际为 %v", c.start, c.end, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), c.errMatch) {
+			t.Errorf("%s-%s: 期望包含 %q 的错误, 实际为 %v", c.start, c.end, c.errMatch, err)
+		}
+	}
+}
+
+func TestAPIGatewayGroupReferences(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.Gateways = append(cfg.Gateways, config.Gateway{Name: "backup", IP: "192.168.1.2"})
+	cfg.Groups = []config.DeviceGroup{{Name: "cameras", Tags: []string{"camera"}, Gateway: "backup"}}
+	server, _ := dhcp.NewServer(cfg)
+	token, hash, _ := api.GenerateToken()
+	cfg.Auth.Tokens = []config.APIToken{{Name: "ops", TokenHash: hash, Role: config.RoleAdmin}}
+
+	configPath := t.TempDir() + "/config.yaml"
+	if err := cfg.SaveConfig(configPath); err != nil {
+		t.Fatalf("保存配置失败: %v", err)
+	}
+	handler := api.NewAPIServer(server.GetPool(), server.GetChecker(), cfg, configPath, server, nil, 8080).Handler()
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("If-Match", "*")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// 网关改名时同步更新设备组，保存的配置重新加载后仍然有效
+	w := do("PUT", "/api/gateways", `{"old_name": "backup", "name": "standby", "ip": "192.168.1.2"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("网关改名失败: %d %s", w.Code, w.Body.String())
+	}
+	if cfg.Groups[0].Gateway != "standby" {
+		t.Errorf("设备组应引用新的网关名称, 实际为 %s", cfg.Groups[0].Gateway)
+	}
+	if _, err := config.LoadConfig(configPath); err != nil {
+		t.Errorf("网关改名后保存的配置应能加载: %v", err)
+	}
+
+	// 被设备组引用的网关不能删除
+	w = do("DELETE", "/api/gateways", `{"name": "standby"}`)
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "cameras") {
+		t.Errorf("删除被引用的网关应返回409, 实际为 %d %s", w.Code, w.Body.String())
+	}
+	if len(cfg.Gateways) != 2 {
+		t.Errorf("被引用的网关不应被删除")
+	}
+
+	// 包含网关地址的分配范围被拒绝
+	w = do("POST", "/api/groups", `{"name": "lab", "start_ip": "192.168.1.230", "end_ip": "192.168.1.250"}`)
+	if w.Code != http.StatusCreated {
+		t.Errorf("有效的分配范围应保存成功: %d %s", w.Code, w.Body.String())
+	}
+	w = do("POST", "/api/groups", `{"name": "bad", "start_ip": "192.168.1.1", "end_ip": "192.168.1.9"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("包含网关地址的分配范围应返回400, 实际为 %d %s", w.Code, w.Body.String())
+	}
+
+	// 移除引用后可以删除
+	cfg.Groups = nil
+	if w := do("DELETE", "/api/gateways", `{"name": "standby"}`); w.Code != http.StatusOK {
+		t.Errorf("未被引用的网关应能删除: %d %s", w.Code, w.Body.String())
+	}
+}
+
 func TestConfigBindingManagement(t *testing.T) {
 	cfg := createTestConfig()
 	cfg.Bindings = []config.MACBinding{
@@ -1042,6 +2360,34 @@ func TestLeaseRenewal(t *testing.T) {
 	}
 }
 
+func TestLeaseRenewedSince(t *testing.T) {
+	cfg := createTestConfig()
+	pool, err := dhcp.NewIPPool(cfg)
+	if err != nil {
+		t.Fatalf("创建IP地址池失败: %v", err)
+	}
+
+	lease, err := pool.RequestIP("aa:bb:cc:dd:ee:01", nil, "forcerenew-client")
+	if err != nil {
+		t.Fatalf("分配IP失败: %v", err)
+	}
+	sentAt := time.Now()
+	if current, renewed := pool.LeaseRenewedSince(lease, sentAt); !current || renewed {
+		t.Errorf("发送FORCERENEW后尚未续租: current=%v renewed=%v", current, renewed)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	pool.RenewLease(lease)
+	if _, renewed := pool.LeaseRenewedSince(lease, sentAt); !renewed {
+		t.Error("续租后应检测到租约已更新")
+	}
+
+	pool.ReleaseIP(lease.MAC)
+	if current, _ := pool.LeaseRenewedSince(lease, sentAt); current {
+		t.Error("租约释放后不应再视为当前租约")
+	}
+}
+
 func TestConcurrentIPAllocation(t *testing.T) {
 	cfg := createTestConfig()
 	pool, err := dhcp.NewIPPool(cfg)
@@ -1089,6 +2435,121 @@ func TestConcurrentIPAllocation(t *testing.T) {
 	}
 }
 
+// 测试用例: 访问时间表
+func TestScheduleWeeklyWindow(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.Schedules = []config.AccessSchedule{{
+		Name:     "school_nights",
+		Timezone: "UTC",
+		Windows: []config.ScheduleWindow{
+			{Days: []string{"mon", "tue", "wed", "thu", "fri"}, Start: "07:00", End: "21:00"},
+			{Days: []string{"sat", "sun"}, Start: "22:00", End: "01:00"}, // 跨越午夜
+		},
+	}}
+	cfg.Devices = []config.DeviceInfo{{MAC: "aa:bb:cc:dd:ee:01", Tags: []string{"kids"}}}
+	cfg.Groups = []config.DeviceGroup{{Name: "kids", Tags: []string{"kids"}, Schedule: "school_nights"}}
+
+	server, err := dhcp.NewServer(cfg)
+	if err != nil {
+		t.Fatalf("创建DHCP服务器失败: %v", err)
+	}
+	clock := &fakeClock{now: time.Date(2026, 10, 19, 20, 30, 0, 0, time.UTC)} // 周一
+	server.SetClock(clock)
+
+	status := server.CheckSchedule("aa:bb:cc:dd:ee:01")
+	if !status.Allowed || status.Until == nil {
+		t.Fatalf("周一20:30应该允许: %+v", status)
+	}
+	if want := time.Date(2026, 10, 19, 21, 0, 0, 0, time.UTC); !status.Until.Equal(want) {
+		t.Errorf("时间段结束时间错误: %v, 期望 %v", status.Until, want)
+	}
+
+	clock.now = time.Date(2026, 10, 19, 21, 0, 0, 0, time.UTC)
+	status = server.CheckSchedule("aa:bb:cc:dd:ee:01")
+	if status.Allowed {
+		t.Error("周一21:00应该不允许")
+	}
+	if status.NextAllowed == nil || !status.NextAllowed.Equal(time.Date(2026, 10, 20, 7, 0, 0, 0, time.UTC)) {
+		t.Errorf("下次允许时间错误: %v", status.NextAllowed)
+	}
+
+	// 周日00:30属于周六22:00开始的跨午夜时间段
+	clock.now = time.Date(2026, 10, 25, 0, 30, 0, 0, time.UTC)
+	status = server.CheckSchedule("aa:bb:cc:dd:ee:01")
+	if !status.Allowed || status.Until == nil || !status.Until.Equal(time.Date(2026, 10, 25, 1, 0, 0, 0, time.UTC)) {
+		t.Errorf("周日00:30应该允许到01:00: %+v", status)
+	}
+
+	// 未引用时间表的设备不受限制
+	if status := server.CheckSchedule("aa:bb:cc:dd:ee:02"); !status.Allowed || status.Schedule != "" {
+		t.Errorf("未配置时间表的设备不应受限制: %+v", status)
+	}
+}
+
+func TestScheduleCron(t *testing.T) {
+	schedule, err := dhcp.CompileSchedule(config.AccessSchedule{
+		Name:     "homework",
+		Timezone: "UTC",
+		Cron:     []string{"* 16-17 * * 1-5", "0-29 18 * * 1-5"},
+	})
+	if err != nil {
+		t.Fatalf("解析时间表失败: %v", err)
+	}
+
+	now := time.Date(2026, 10, 21, 16, 15, 0, 0, time.UTC) // 周三
+	if !schedule.Allows(now) {
+		t.Error("周三16:15应该允许")
+	}
+	end, ok := schedule.WindowEnd(now)
+	if !ok || !end.Equal(time.Date(2026, 10, 21, 18, 30, 0, 0, time.UTC)) {
+		t.Errorf("时间段结束时间错误: %v", end)
+	}
+	if schedule.Allows(time.Date(2026, 10, 24, 16, 15, 0, 0, time.UTC)) {
+		t.Error("周六不应该允许")
+	}
+
+	for _, spec := range []string{"* * *", "60 * * * *", "* 5-3 * * *", "*/0 * * * *"} {
+		if _, err := dhcp.CompileSchedule(config.AccessSchedule{Name: "bad", Cron: []string{spec}}); err == nil {
+			t.Errorf("无效的cron表达式应该报错: %s", spec)
+		}
+	}
+}
+
+func TestConfigValidationSchedules(t *testing.T) {
+	cases := []struct {
+		schedule config.AccessSchedule
+		wantErr  bool
+	}{
+		{schedule: config.AccessSchedule{Name: "ok", Windows: []config.ScheduleWindow{{Days: []string{"mon"}, Start: "07:00", End: "24:00"}}, Cron: []string{"*/15 8 * * 1-5"}}},
+		{schedule: config.AccessSchedule{Name: "bad_day", Windows: []config.ScheduleWindow{{Days: []string{"someday"}, Start: "07:00", End: "21:00"}}}, wantErr: true},
+		{schedule: config.AccessSchedule{Name: "bad_time", Windows: []config.ScheduleWindow{{Start: "7", End: "25:00"}}}, wantErr: true},
+		{schedule: config.AccessSchedule{Name: "bad_cron", Cron: []string{"* 25 * * *"}}, wantErr: true},
+		{schedule: config.AccessSchedule{Name: "bad_zone", Timezone: "Mars/Base", Cron: []string{"* * * * *"}}, wantErr: true},
+		{schedule: config.AccessSchedule{Name: "empty"}, wantErr: true},
+	}
+
+	for _, c := range cases {
+		cfg := createTestConfig()
+		cfg.Schedules = []config.AccessSchedule{c.schedule}
+		err := cfg.Validate()
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: 期望出错=%v, 实际为 %v", c.schedule.Name, c.wantErr, err)
+		}
+		if yamlData, _ := yaml.Marshal(cfg); c.wantErr && config.ValidateYAML(string(yamlData)) == nil {
+			t.Errorf("%s: 保存的配置内容也应验证时间表", c.schedule.Name)
+		}
+	}
+}
+
+// fakeClock 测试用的固定时钟
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
 // 辅助函数
 func createTestConfig() *config.Config {
 	return &config.Config{
@@ -0,0 +1,223 @@
+package events
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"dhcp-server/config"
+)
+
+// 事件级别
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Event 结构化事件
+type Event struct {
+	Type      string                 `json:"type"`     // 事件类型，如 gateway.down
+	Source    string                 `json:"source"`   // 事件来源模块
+	Severity  string                 `json:"severity"` // info, warning, critical
+	Message   string                 `json:"message"`
+	Timestamp time.Time              `json:"timestamp"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
+// Sink 事件输出目标
+type Sink interface {
+	Name() string
+	Send(event Event) error
+}
+
+// filteredSink 带事件类型过滤的输出目标
+type filteredSink struct {
+	sink    Sink
+	filters []string
+}
+
+// matches 检查事件类型是否匹配过滤规则，支持 gateway.* 形式的前缀匹配
+func (fs filteredSink) matches(eventType string) bool {
+	if len(fs.filters) == 0 {
+		return true
+	}
+	for _, filter := range fs.filters {
+		if filter == "*" || filter == eventType {
+			return true
+		}
+		if strings.HasSuffix(filter, ".*") && strings.HasPrefix(eventType, strings.TrimSuffix(filter, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// Bus 事件总线，将事件异步分发到所有输出目标
+type Bus struct {
+	mutex       sync.RWMutex
+	sinks       []filteredSink
+	subscribers []func(Event)
+	recent      []Event
+	maxRecent   int
+	queue       chan Event
+}
+
+// NewBus 创建事件总线
+func NewBus() *Bus {
+	bus := &Bus{
+		recent:    make([]Event, 0),
+		maxRecent: 500,
+		queue:     make(chan Event, 256),
+	}
+	go bus.dispatchLoop()
+	return bus
+}
+
+// setSinks 替换所有输出目标（用于配置热重载）
+func (bus *Bus) setSinks(sinks []filteredSink) {
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+	bus.sinks = sinks
+}
+
+// Subscribe 注册进程内订阅者，订阅者不受配置重载影响
+func (bus *Bus) Subscribe(subscriber func(Event)) {
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+	bus.subscribers = append(bus.subscribers, subscriber)
+}
+
+// Publish 发布事件，不会阻塞调用者
+func (bus *Bus) Publish(event Event) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	if event.Severity == "" {
+		event.Severity = SeverityInfo
+	}
+
+	bus.mutex.Lock()
+	bus.recent = append(bus.recent, event)
+	if len(bus.recent) > bus.maxRecent {
+		bus.recent = bus.recent[len(bus.recent)-bus.maxRecent:]
+	}
+	bus.mutex.Unlock()
+
+	select {
+	case bus.queue <- event:
+	default:
+		log.Printf("事件队列已满，丢弃事件: %s", event.Type)
+	}
+}
+
+// Recent 获取最近的事件，按时间倒序返回
+func (bus *Bus) Recent(limit int, typeFilter string) []Event {
+	bus.mutex.RLock()
+	defer bus.mutex.RUnlock()
+
+	filter := filteredSink{}
+	if typeFilter != "" {
+		filter.filters = []string{typeFilter}
+	}
+
+	result := make([]Event, 0)
+	for i := len(bus.recent) - 1; i >= 0 && len(result) < limit; i-- {
+		if filter.matches(bus.recent[i].Type) {
+			result = append(result, bus.recent[i])
+		}
+	}
+	return result
+}
+
+// dispatchLoop 分发事件到订阅者和输出目标
+func (bus *Bus) dispatchLoop() {
+	for event := range bus.queue {
+		bus.mutex.RLock()
+		sinks := make([]filteredSink, len(bus.sinks))
+		copy(sinks, bus.sinks)
+		subscribers := make([]func(Event), len(bus.subscribers))
+		copy(subscribers, bus.subscribers)
+		bus.mutex.RUnlock()
+
+		for _, subscriber := range subscribers {
+			subscriber(event)
+		}
+
+		for _, fs := range sinks {
+			if !fs.matches(event.Type) {
+				continue
+			}
+			// 每个输出目标独立发送，慢速webhook不影响其他目标
+			go func(sink Sink, e Event) {
+				if err := sink.Send(e); err != nil {
+					log.Printf("事件 %s 发送到 %s 失败: %v", e.Type, sink.Name(), err)
+				}
+			}(fs.sink, event)
+		}
+	}
+}
+
+// 默认事件总线
+var (
+	defaultBus   = NewBus()
+	streamWriter func(string)
+	streamMutex  sync.RWMutex
+)
+
+// Publish 向默认事件总线发布事件
+func Publish(event Event) {
+	defaultBus.Publish(event)
+}
+
+// Subscribe 订阅默认事件总线
+func Subscribe(subscriber func(Event)) {
+	defaultBus.Subscribe(subscriber)
+}
+
+// Recent 获取默认事件总线的最近事件
+func Recent(limit int, typeFilter string) []Event {
+	return defaultBus.Recent(limit, typeFilter)
+}
+
+// SetStreamWriter 设置日志流写入函数，由API模块提供SSE广播通道
+func SetStreamWriter(writer func(string)) {
+	streamMutex.Lock()
+	defer streamMutex.Unlock()
+	streamWriter = writer
+}
+
+// Configure 根据配置重建默认事件总线的输出目标
+func Configure(cfg config.EventsConfig) error {
+	var sinks []filteredSink
+
+	for _, hook := range cfg.Webhooks {
+		if hook.URL == "" {
+			return fmt.Errorf("webhook %s 未配置URL", hook.Name)
+		}
+		sinks = append(sinks, filteredSink{sink: NewWebhookSink(hook), filters: hook.Events})
+	}
+
+	for _, hook := range cfg.ExecHooks {
+		if hook.Command == "" {
+			return fmt.Errorf("脚本钩子 %s 未配置命令", hook.Name)
+		}
+		sinks = append(sinks, filteredSink{sink: NewExecSink(hook), filters: hook.Events})
+	}
+
+	if cfg.Stream {
+		streamMutex.RLock()
+		writer := streamWriter
+		streamMutex.RUnlock()
+		if writer != nil {
+			sinks = append(sinks, filteredSink{sink: &StreamSink{write: writer}})
+		}
+	}
+
+	defaultBus.setSinks(sinks)
+	log.Printf("事件通知已配置: %d 个webhook, %d 个脚本钩子, 日志流: %v",
+		len(cfg.Webhooks), len(cfg.ExecHooks), cfg.Stream)
+	return nil
+}
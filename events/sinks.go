@@ -0,0 +1,191 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"time"
+
+	"dhcp-server/config"
+)
+
+const (
+	defaultWebhookTimeout  = 5 * time.Second
+	defaultWebhookRetries  = 3
+	defaultWebhookInterval = 2 * time.Second
+	defaultExecTimeout     = 10 * time.Second
+)
+
+// WebhookSink 以JSON格式POST事件到HTTP地址
+type WebhookSink struct {
+	config config.WebhookConfig
+	client *http.Client
+}
+
+// NewWebhookSink 创建webhook输出目标
+func NewWebhookSink(cfg config.WebhookConfig) *WebhookSink {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	return &WebhookSink{
+		config: cfg,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Name 返回输出目标名称
+func (ws *WebhookSink) Name() string {
+	if ws.config.Name != "" {
+		return "webhook:" + ws.config.Name
+	}
+	return "webhook:" + ws.config.URL
+}
+
+// Send 发送事件，失败时按指数退避重试
+func (ws *WebhookSink) Send(event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("序列化事件失败: %v", err)
+	}
+
+	retries := ws.config.RetryCount
+	if retries <= 0 {
+		retries = defaultWebhookRetries
+	}
+	interval := ws.config.RetryInterval
+	if interval <= 0 {
+		interval = defaultWebhookInterval
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(interval)
+			interval *= 2
+		}
+
+		lastErr = ws.post(payload)
+		if lastErr == nil {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("重试 %d 次后仍失败: %v", retries, lastErr)
+}
+
+// post 执行单次HTTP POST
+func (ws *WebhookSink) post(payload []byte) error {
+	req, err := http.NewRequest(http.MethodPost, ws.config.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "dhcp-server-events/1.0")
+	for key, value := range ws.config.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := ws.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// ExecSink 执行外部脚本处理事件
+type ExecSink struct {
+	config config.ExecHookConfig
+}
+
+// NewExecSink 创建脚本钩子输出目标
+func NewExecSink(cfg config.ExecHookConfig) *ExecSink {
+	return &ExecSink{config: cfg}
+}
+
+// Name 返回输出目标名称
+func (es *ExecSink) Name() string {
+	if es.config.Name != "" {
+		return "exec:" + es.config.Name
+	}
+	return "exec:" + es.config.Command
+}
+
+// Send 执行脚本，事件JSON通过标准输入传递，关键字段同时写入环境变量
+func (es *ExecSink) Send(event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("序列化事件失败: %v", err)
+	}
+
+	timeout := es.config.Timeout
+	if timeout <= 0 {
+		timeout = defaultExecTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, es.config.Command, es.config.Args...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(),
+		"DHCP_EVENT_TYPE="+event.Type,
+		"DHCP_EVENT_SOURCE="+event.Source,
+		"DHCP_EVENT_SEVERITY="+event.Severity,
+		"DHCP_EVENT_MESSAGE="+event.Message,
+		"DHCP_EVENT_TIME="+event.Timestamp.Format(time.RFC3339),
+	)
+	for key, value := range event.Data {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("DHCP_EVENT_DATA_%s=%v", envKey(key), value))
+	}
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, bytes.TrimSpace(output))
+	}
+	return nil
+}
+
+// envKey 将数据字段名转换为环境变量格式
+func envKey(key string) string {
+	result := make([]byte, 0, len(key))
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			result = append(result, c-'a'+'A')
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			result = append(result, c)
+		default:
+			result = append(result, '_')
+		}
+	}
+	return string(result)
+}
+
+// StreamSink 将事件推送到SSE日志流
+type StreamSink struct {
+	write func(string)
+}
+
+// Name 返回输出目标名称
+func (ss *StreamSink) Name() string {
+	return "stream"
+}
+
+// Send 以单行JSON写入日志流
+func (ss *StreamSink) Send(event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	ss.write("[EVENT] " + string(payload))
+	return nil
+}
@@ -0,0 +1,41 @@
+package fingerprint
+
+// builtinRules 内置指纹规则，来源于常见操作系统DHCP客户端的默认行为
+var builtinRules = []Rule{
+	// Windows
+	{Name: "windows-10", OS: "Windows", DeviceType: "Windows", Model: "Windows 10/11",
+		ParamList: "1,3,6,15,31,33,43,44,46,47,119,121,249,252", VendorClass: "MSFT 5.0"},
+	{Name: "windows-8", OS: "Windows", DeviceType: "Windows", Model: "Windows 8",
+		ParamList: "1,3,6,15,31,33,43,44,46,47,121,249,252", VendorClass: "MSFT 5.0"},
+	{Name: "windows-7", OS: "Windows", DeviceType: "Windows", Model: "Windows 7",
+		ParamList: "1,15,3,6,44,46,47,31,33,121,249,43", VendorClass: "MSFT 5.0"},
+	{Name: "windows-xp", OS: "Windows", DeviceType: "Windows", Model: "Windows XP",
+		ParamList: "1,15,3,6,44,46,47,31,33,249,43", VendorClass: "MSFT 5.0"},
+	{Name: "windows", OS: "Windows", DeviceType: "Windows", VendorClass: "MSFT"},
+
+	// Apple
+	{Name: "macos", OS: "macOS", DeviceType: "MacOS", Model: "Mac",
+		ParamList: "1,121,3,6,15,119,252,95,44,46"},
+	{Name: "macos-11", OS: "macOS", DeviceType: "MacOS", Model: "Mac (macOS 11+)",
+		ParamList: "1,121,3,6,15,108,114,119,252,95,44,46"},
+	{Name: "ios", OS: "iOS", DeviceType: "iPhone", Model: "iPhone/iPad",
+		ParamList: "1,121,3,6,15,119,252"},
+	{Name: "ios-14", OS: "iOS", DeviceType: "iPhone", Model: "iPhone/iPad (iOS 14+)",
+		ParamList: "1,121,3,6,15,108,114,119,252"},
+
+	// Android
+	{Name: "android", OS: "Android", DeviceType: "Android", VendorClass: "android-dhcp"},
+	{Name: "android-legacy", OS: "Android", DeviceType: "Android", ParamPrefix: "1,3,6,15,26,28,51,58,59"},
+
+	// Linux
+	{Name: "linux-dhclient", OS: "Linux", DeviceType: "Linux", Model: "ISC dhclient",
+		ParamList: "1,28,2,3,15,6,119,12,44,47,26,121,42"},
+	{Name: "linux-dhcpcd", OS: "Linux", DeviceType: "Linux", Model: "dhcpcd", VendorClass: "dhcpcd"},
+	{Name: "linux-udhcpc", OS: "Linux", DeviceType: "IoT", Model: "BusyBox udhcpc", VendorClass: "udhcp"},
+
+	// 打印机、电话和网络启动
+	{Name: "hp-jetdirect", OS: "JetDirect", DeviceType: "Printer", Model: "HP Printer", VendorClass: "Hewlett-Packard JetDirect"},
+	{Name: "cisco-ip-phone", OS: "Cisco", DeviceType: "VoIP Phone", Model: "Cisco IP Phone", VendorClass: "Cisco Systems, Inc. IP Phone"},
+	{Name: "polycom", OS: "Polycom", DeviceType: "VoIP Phone", Model: "Polycom Phone", VendorClass: "Polycom"},
+	{Name: "pxe", OS: "PXE", DeviceType: "Network Boot", Model: "PXE Client", VendorClass: "PXEClient"},
+}
@@ -0,0 +1,215 @@
+package fingerprint
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Fingerprint 客户端DHCP指纹原始数据
+type Fingerprint struct {
+	ParamList   string    `json:"param_list"`   // 参数请求列表（option 55），逗号分隔
+	VendorClass string    `json:"vendor_class"` // 厂商类标识（option 60）
+	OptionOrder string    `json:"option_order"` // 报文中选项出现的顺序，逗号分隔
+	MessageType string    `json:"message_type"` // 采集指纹的报文类型
+	CapturedAt  time.Time `json:"captured_at"`
+}
+
+// IsEmpty 判断指纹是否没有可用信息
+func (fp *Fingerprint) IsEmpty() bool {
+	return fp == nil || (fp.ParamList == "" && fp.VendorClass == "" && fp.OptionOrder == "")
+}
+
+// Rule 指纹识别规则，已配置的条件必须全部满足
+type Rule struct {
+	Name        string `yaml:"name" json:"name"`
+	OS          string `yaml:"os" json:"os"`                     // 操作系统
+	DeviceType  string `yaml:"device_type" json:"device_type"`   // 设备类型，与config.DeviceInfo.DeviceType一致
+	Model       string `yaml:"model" json:"model"`               // 型号或设备族
+	ParamList   string `yaml:"param_list" json:"param_list"`     // option 55完全匹配
+	ParamPrefix string `yaml:"param_prefix" json:"param_prefix"` // option 55前缀匹配
+	VendorClass string `yaml:"vendor_class" json:"vendor_class"` // option 60前缀匹配，不区分大小写
+	OptionOrder string `yaml:"option_order" json:"option_order"` // 选项顺序完全匹配
+}
+
+// Match 指纹匹配结果
+type Match struct {
+	Rule       string `json:"rule"`
+	OS         string `json:"os"`
+	DeviceType string `json:"device_type"`
+	Model      string `json:"model"`
+	Score      int    `json:"score"` // 匹配得分，越高越可信
+}
+
+// 各条件的匹配得分
+const (
+	scoreParamList   = 4
+	scoreOptionOrder = 3
+	scoreVendorClass = 3
+	scoreParamPrefix = 2
+)
+
+// score 计算规则对指纹的匹配得分，返回0表示不匹配
+func (rule *Rule) score(fp *Fingerprint) int {
+	score := 0
+
+	if rule.ParamList != "" {
+		if normalizeList(rule.ParamList) != fp.ParamList {
+			return 0
+		}
+		score += scoreParamList
+	}
+
+	if rule.ParamPrefix != "" {
+		prefix := normalizeList(rule.ParamPrefix)
+		if fp.ParamList != prefix && !strings.HasPrefix(fp.ParamList, prefix+",") {
+			return 0
+		}
+		score += scoreParamPrefix
+	}
+
+	if rule.VendorClass != "" {
+		if !strings.HasPrefix(strings.ToLower(fp.VendorClass), strings.ToLower(rule.VendorClass)) {
+			return 0
+		}
+		score += scoreVendorClass
+	}
+
+	if rule.OptionOrder != "" {
+		if normalizeList(rule.OptionOrder) != fp.OptionOrder {
+			return 0
+		}
+		score += scoreOptionOrder
+	}
+
+	return score
+}
+
+// Database 指纹数据库
+type Database struct {
+	rules  []Rule
+	source string
+}
+
+// NewDatabase 创建指纹数据库，文件中的规则优先于内置规则
+func NewDatabase(path string) (*Database, error) {
+	db := &Database{source: "builtin"}
+
+	if path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取指纹数据库失败: %v", err)
+		}
+
+		var rules []Rule
+		if err := yaml.Unmarshal(data, &rules); err != nil {
+			return nil, fmt.Errorf("解析指纹数据库失败: %v", err)
+		}
+		for i, rule := range rules {
+			if rule.ParamList == "" && rule.ParamPrefix == "" && rule.VendorClass == "" && rule.OptionOrder == "" {
+				return nil, fmt.Errorf("指纹规则 %d (%s) 未配置任何匹配条件", i+1, rule.Name)
+			}
+		}
+
+		db.rules = append(db.rules, rules...)
+		db.source = path
+	}
+
+	db.rules = append(db.rules, builtinRules...)
+	return db, nil
+}
+
+// Match 返回得分最高的规则，同分时取靠前的规则
+func (db *Database) Match(fp *Fingerprint) (*Match, bool) {
+	if fp.IsEmpty() {
+		return nil, false
+	}
+
+	var best *Rule
+	bestScore := 0
+	for i := range db.rules {
+		if score := db.rules[i].score(fp); score > bestScore {
+			best = &db.rules[i]
+			bestScore = score
+		}
+	}
+	if best == nil {
+		return nil, false
+	}
+
+	return &Match{
+		Rule:       best.Name,
+		OS:         best.OS,
+		DeviceType: best.DeviceType,
+		Model:      best.Model,
+		Score:      bestScore,
+	}, true
+}
+
+// Rules 返回所有规则
+func (db *Database) Rules() []Rule {
+	return db.rules
+}
+
+// Source 返回数据来源
+func (db *Database) Source() string {
+	return db.source
+}
+
+// FormatOptions 将选项代码列表格式化为逗号分隔字符串
+func FormatOptions(codes []uint8) string {
+	parts := make([]string, len(codes))
+	for i, code := range codes {
+		parts[i] = strconv.Itoa(int(code))
+	}
+	return strings.Join(parts, ",")
+}
+
+// normalizeList 去除列表中的空白，便于与采集到的指纹比较
+func normalizeList(list string) string {
+	fields := strings.FieldsFunc(list, func(r rune) bool { return r == ',' || r == ' ' })
+	return strings.Join(fields, ",")
+}
+
+// 默认指纹数据库
+var (
+	defaultDB    *Database
+	defaultMutex sync.RWMutex
+)
+
+func init() {
+	defaultDB, _ = NewDatabase("")
+}
+
+// Default 获取默认指纹数据库
+func Default() *Database {
+	defaultMutex.RLock()
+	defer defaultMutex.RUnlock()
+	return defaultDB
+}
+
+// Load 从文件重新加载默认指纹数据库，失败时保留原数据库
+func Load(path string) error {
+	db, err := NewDatabase(path)
+	if err != nil {
+		return err
+	}
+
+	defaultMutex.Lock()
+	defaultDB = db
+	defaultMutex.Unlock()
+
+	log.Printf("DHCP指纹数据库已加载: %d 条规则, 来源: %s", len(db.rules), db.source)
+	return nil
+}
+
+// Classify 使用默认指纹数据库识别设备
+func Classify(fp *Fingerprint) (*Match, bool) {
+	return Default().Match(fp)
+}
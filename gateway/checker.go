@@ -7,21 +7,30 @@ import (
 	"net"
 	"net/http"
 	"os/exec"
+	"regexp"
+	"strconv"
 	"sync"
 	"time"
 
 	"dhcp-server/config"
+	"dhcp-server/events"
 )
 
 // HealthChecker 网关健康检查器
 type HealthChecker struct {
 	config     *config.Config
-	status     map[string]bool // 网关状态缓存
+	status     map[string]bool          // 网关状态缓存
+	rtt        map[string]time.Duration // 最近一次成功检查的往返时间
 	statusLock sync.RWMutex
 	ctx        context.Context
 	cancel     context.CancelFunc
+	listeners  []StatusListener // 状态变更监听器
+	listenLock sync.RWMutex
 }
 
+// StatusListener 网关状态变更回调
+type StatusListener func(gw config.Gateway, healthy bool)
+
 // NewHealthChecker 创建健康检查器
 func NewHealthChecker(cfg *config.Config) *HealthChecker {
 	ctx, cancel := context.WithCancel(context.Background())
@@ -29,6 +38,7 @@ func NewHealthChecker(cfg *config.Config) *HealthChecker {
 	hc := &HealthChecker{
 		config: cfg,
 		status: make(map[string]bool),
+		rtt:    make(map[string]time.Duration),
 		ctx:    ctx,
 		cancel: cancel,
 	}
@@ -69,6 +79,25 @@ func (hc *HealthChecker) Stop() {
 	hc.cancel()
 }
 
+// AddStatusListener 注册网关状态变更监听器
+func (hc *HealthChecker) AddStatusListener(listener StatusListener) {
+	hc.listenLock.Lock()
+	defer hc.listenLock.Unlock()
+	hc.listeners = append(hc.listeners, listener)
+}
+
+// notifyStatusChange 通知所有监听器（调用者不能持有statusLock）
+func (hc *HealthChecker) notifyStatusChange(gw config.Gateway, healthy bool) {
+	hc.listenLock.RLock()
+	listeners := make([]StatusListener, len(hc.listeners))
+	copy(listeners, hc.listeners)
+	hc.listenLock.RUnlock()
+
+	for _, listener := range listeners {
+		listener(gw, healthy)
+	}
+}
+
 // IsGatewayHealthy 检查网关是否健康
 func (hc *HealthChecker) IsGatewayHealthy(gatewayName string) bool {
 	hc.statusLock.RLock()
@@ -117,10 +146,11 @@ func (hc *HealthChecker) checkAllGateways() {
 		wg.Add(1)
 		go func(gateway config.Gateway) {
 			defer wg.Done()
-			healthy := hc.checkSingleGateway(gateway.IP)
+			healthy, rtt := hc.checkSingleGateway(gateway.IP)
 			results <- gatewayResult{
 				name:    gateway.Name,
 				healthy: healthy,
+				rtt:     rtt,
 			}
 		}(gw)
 	}
@@ -132,12 +162,16 @@ func (hc *HealthChecker) checkAllGateways() {
 	}()
 
 	// 更新状态
+	var changed []gatewayResult
 	hc.statusLock.Lock()
-	defer hc.statusLock.Unlock()
-
 	for result := range results {
 		oldStatus := hc.status[result.name]
 		hc.status[result.name] = result.healthy
+		if result.healthy && result.rtt > 0 {
+			hc.rtt[result.name] = result.rtt
+		} else {
+			delete(hc.rtt, result.name)
+		}
 
 		if oldStatus != result.healthy {
 			status := "健康"
@@ -145,19 +179,70 @@ func (hc *HealthChecker) checkAllGateways() {
 				status = "不健康"
 			}
 			log.Printf("网关 %s 状态变更为: %s", result.name, status)
+			changed = append(changed, result)
 		}
 	}
-
 	hc.logCurrentStatus()
+	hc.statusLock.Unlock()
+
+	// 在锁外通知监听器，避免回调中查询网关状态时死锁
+	for _, result := range changed {
+		if gw := hc.config.FindGatewayByName(result.name); gw != nil {
+			hc.publishStatusEvent(*gw, result.healthy)
+			hc.notifyStatusChange(*gw, result.healthy)
+		}
+	}
+}
+
+// publishStatusEvent 发布网关状态变更事件
+func (hc *HealthChecker) publishStatusEvent(gw config.Gateway, healthy bool) {
+	event := events.Event{
+		Type:   "gateway.up",
+		Source: "gateway",
+		Data: map[string]interface{}{
+			"gateway":    gw.Name,
+			"ip":         gw.IP,
+			"is_default": gw.IsDefault,
+			"healthy":    healthy,
+			"method":     hc.config.HealthCheck.Method,
+		},
+	}
+
+	if healthy {
+		event.Severity = events.SeverityInfo
+		event.Message = fmt.Sprintf("网关 %s (%s) 已恢复健康", gw.Name, gw.IP)
+	} else {
+		event.Type = "gateway.down"
+		event.Severity = events.SeverityCritical
+		event.Message = fmt.Sprintf("网关 %s (%s) 不健康", gw.Name, gw.IP)
+
+		// 记录故障切换目标，便于值班人员判断影响范围
+		if failover := hc.GetHealthyGateway(""); failover != nil && failover.Name != gw.Name {
+			event.Data["failover_gateway"] = failover.Name
+			event.Data["failover_ip"] = failover.IP
+		}
+	}
+
+	healthyCount := 0
+	for _, status := range hc.GetGatewayStatus() {
+		if status {
+			healthyCount++
+		}
+	}
+	event.Data["healthy_gateways"] = healthyCount
+	event.Data["total_gateways"] = len(hc.config.Gateways)
+
+	events.Publish(event)
 }
 
 type gatewayResult struct {
 	name    string
 	healthy bool
+	rtt     time.Duration
 }
 
-// checkSingleGateway 检查单个网关
-func (hc *HealthChecker) checkSingleGateway(gatewayIP string) bool {
+// checkSingleGateway 检查单个网关，健康时同时返回成功那次检查的耗时
+func (hc *HealthChecker) checkSingleGateway(gatewayIP string) (bool, time.Duration) {
 	retryCount := hc.config.HealthCheck.RetryCount
 	if retryCount < 1 {
 		retryCount = 1
@@ -168,43 +253,60 @@ func (hc *HealthChecker) checkSingleGateway(gatewayIP string) bool {
 			time.Sleep(time.Second) // 重试间隔
 		}
 
+		start := time.Now()
+		var ok bool
+		var rtt time.Duration
 		switch hc.config.HealthCheck.Method {
-		case "ping":
-			if hc.pingCheck(gatewayIP) {
-				return true
-			}
 		case "tcp":
-			if hc.tcpCheck(gatewayIP, hc.config.HealthCheck.TCPPort) {
-				return true
-			}
+			ok = hc.tcpCheck(gatewayIP, hc.config.HealthCheck.TCPPort)
+			rtt = time.Since(start)
 		case "http":
-			if hc.httpCheck(gatewayIP, hc.config.HealthCheck.HTTPPath) {
-				return true
-			}
+			ok = hc.httpCheck(gatewayIP, hc.config.HealthCheck.HTTPPath)
+			rtt = time.Since(start)
 		default:
-			// 默认使用ping检查
-			if hc.pingCheck(gatewayIP) {
-				return true
-			}
+			// 默认使用ping检查，往返时间取ping输出的结果，不包含进程启动时间
+			ok, rtt = hc.pingCheck(gatewayIP)
+		}
+		if ok {
+			return true, rtt
 		}
 	}
 
-	return false
+	return false, 0
 }
 
-// pingCheck ping检查
-func (hc *HealthChecker) pingCheck(ip string) bool {
+// pingTimePattern 匹配ping输出中的往返时间，如 time=1.23 ms、time<1 ms
+var pingTimePattern = regexp.MustCompile(`time[=<]\s*([0-9.]+)\s*ms`)
+
+// parsePingRTT 从ping输出中解析往返时间，无法解析时返回0
+func parsePingRTT(output []byte) time.Duration {
+	match := pingTimePattern.FindSubmatch(output)
+	if match == nil {
+		return 0
+	}
+	ms, err := strconv.ParseFloat(string(match[1]), 64)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// pingCheck ping检查，返回是否可达以及ping报告的往返时间
+func (hc *HealthChecker) pingCheck(ip string) (bool, time.Duration) {
 	ctx, cancel := context.WithTimeout(hc.ctx, hc.config.HealthCheck.Timeout)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, "ping", "-c", "1", "-W", "3000", ip)
-	err := cmd.Run()
-	return err == nil
+	output, err := cmd.Output()
+	if err != nil {
+		return false, 0
+	}
+	return true, parsePingRTT(output)
 }
 
 // tcpCheck TCP端口检查
 func (hc *HealthChecker) tcpCheck(ip string, port int) bool {
-	address := fmt.Sprintf("%s:%d", ip, port)
+	address := net.JoinHostPort(ip, strconv.Itoa(port))
 
 	conn, err := net.DialTimeout("tcp", address, hc.config.HealthCheck.Timeout)
 	if err != nil {
@@ -254,3 +356,15 @@ func (hc *HealthChecker) GetGatewayStatus() map[string]bool {
 	}
 	return status
 }
+
+// GetGatewayRTT 获取健康网关最近一次检查的往返时间，不健康、尚未检查或无法获得往返时间的网关不包含在内
+func (hc *HealthChecker) GetGatewayRTT() map[string]time.Duration {
+	hc.statusLock.RLock()
+	defer hc.statusLock.RUnlock()
+
+	rtt := make(map[string]time.Duration, len(hc.rtt))
+	for k, v := range hc.rtt {
+		rtt[k] = v
+	}
+	return rtt
+}
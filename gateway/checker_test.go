@@ -0,0 +1,27 @@
+package gateway
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePingRTT(t *testing.T) {
+	cases := []struct {
+		name   string
+		output string
+		want   time.Duration
+	}{
+		{"iputils", "PING 192.168.1.1 (192.168.1.1) 56(84) bytes of data.\n64 bytes from 192.168.1.1: icmp_seq=1 ttl=64 time=1.23 ms\n", 1230 * time.Microsecond},
+		{"busybox", "64 bytes from 10.0.0.1: seq=0 ttl=64 time=0.456 ms\n", 456 * time.Microsecond},
+		{"macOS", "64 bytes from 10.0.0.1: icmp_seq=0 ttl=64 time=12.000 ms\n", 12 * time.Millisecond},
+		{"小于1毫秒", "Reply from 10.0.0.1: bytes=32 time<1ms TTL=64\n", time.Millisecond},
+		{"整数毫秒", "64 bytes from 10.0.0.1: icmp_seq=1 ttl=64 time=3 ms\n", 3 * time.Millisecond},
+		{"没有往返时间", "PING 10.0.0.1 (10.0.0.1) 56(84) bytes of data.\n", 0},
+	}
+
+	for _, c := range cases {
+		if got := parsePingRTT([]byte(c.output)); got != c.want {
+			t.Errorf("%s: 期望 %v, 实际为 %v", c.name, c.want, got)
+		}
+	}
+}
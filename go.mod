@@ -4,6 +4,7 @@ go 1.19
 
 require (
 	github.com/insomniacslk/dhcp v0.0.0-20231016090811-6a2c8fbdcc1c
+	golang.org/x/crypto v0.14.0
 	gopkg.in/yaml.v2 v2.4.0
 )
 
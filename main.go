@@ -8,19 +8,27 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"dhcp-server/api"
+	"dhcp-server/audit"
 	"dhcp-server/config"
 	"dhcp-server/dhcp"
+	"dhcp-server/events"
+	"dhcp-server/fingerprint"
+	"dhcp-server/oui"
+	"dhcp-server/presence"
 )
 
 var (
 	configPath = flag.String("config", "config.yaml", "配置文件路径")
 	version    = flag.Bool("version", false, "显示版本信息")
 	help       = flag.Bool("help", false, "显示帮助信息")
+	hashPass   = flag.Bool("hash-password", false, "从标准输入读取密码并输出bcrypt哈希，用于配置auth.users")
+	genToken   = flag.Bool("gen-token", false, "生成API令牌并输出令牌和哈希，用于配置auth.tokens")
 
 	// 全局变量，用于热重载
 	globalConfig  *config.Config
@@ -50,6 +58,16 @@ func main() {
 		return
 	}
 
+	if *hashPass {
+		printPasswordHash()
+		return
+	}
+
+	if *genToken {
+		printNewToken()
+		return
+	}
+
 	// 设置日志同时输出到控制台和文件
 	logFile, err := os.OpenFile("dhcp.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
 	if err != nil {
@@ -71,6 +89,35 @@ func main() {
 	globalConfig = cfg
 	log.Printf("配置文件加载成功: %s", *configPath)
 
+	// 配置事件通知
+	if err := events.Configure(cfg.Events); err != nil {
+		log.Fatalf("事件通知配置失败: %v", err)
+	}
+
+	// 配置设备在线记录
+	if err := presence.Configure(cfg.Presence, isCriticalDevice); err != nil {
+		log.Printf("设备在线记录加载失败: %v", err)
+	}
+
+	// 配置审计日志
+	if err := audit.Configure(cfg.Audit); err != nil {
+		log.Printf("审计日志加载失败: %v", err)
+	}
+
+	// 加载厂商数据库，失败时继续使用内置数据
+	if len(cfg.OUI.Files) > 0 {
+		if err := oui.Load(cfg.OUI.Files); err != nil {
+			log.Printf("OUI数据库加载失败，使用内置数据: %v", err)
+		}
+	}
+
+	// 加载DHCP指纹数据库
+	if cfg.Fingerprint.DatabaseFile != "" {
+		if err := fingerprint.Load(cfg.Fingerprint.DatabaseFile); err != nil {
+			log.Printf("DHCP指纹数据库加载失败，使用内置规则: %v", err)
+		}
+	}
+
 	// 初始化DHCP服务器
 	if err := initializeDHCPServer(cfg); err != nil {
 		log.Fatalf("DHCP服务器初始化失败: %v", err)
@@ -81,6 +128,11 @@ func main() {
 		log.Fatalf("网络扫描器初始化失败: %v", err)
 	}
 
+	// 启用认证但没有任何账号时创建初始管理员
+	if err := api.EnsureAdminUser(globalConfig, *configPath, os.Stderr); err != nil {
+		log.Fatalf("初始化管理员账号失败: %v", err)
+	}
+
 	// 创建HTTP API服务器
 	globalAPI = api.NewAPIServer(
 		globalServer.GetPool(),
@@ -133,9 +185,27 @@ func main() {
 	// 停止DHCP服务器
 	globalServer.Stop()
 
+	// 保存设备在线记录
+	if err := presence.Save(); err != nil {
+		log.Printf("保存设备在线记录失败: %v", err)
+	}
+
 	log.Println("服务器已成功关闭")
 }
 
+// isCriticalDevice 检查设备是否在设备管理中标记为关键设备
+func isCriticalDevice(mac string) bool {
+	serverMutex.RLock()
+	defer serverMutex.RUnlock()
+
+	for _, device := range globalConfig.Devices {
+		if strings.EqualFold(device.MAC, mac) {
+			return device.Critical
+		}
+	}
+	return false
+}
+
 // initializeDHCPServer 初始化DHCP服务器
 func initializeDHCPServer(cfg *config.Config) error {
 	serverMutex.Lock()
@@ -202,6 +272,28 @@ func reloadConfig(newConfig *config.Config) error {
 		return err
 	}
 
+	// 重新配置事件通知
+	if err := events.Configure(newConfig.Events); err != nil {
+		log.Printf("事件通知配置失败: %v", err)
+		return err
+	}
+
+	if err := presence.Configure(newConfig.Presence, isCriticalDevice); err != nil {
+		log.Printf("设备在线记录加载失败: %v", err)
+	}
+
+	if err := audit.Configure(newConfig.Audit); err != nil {
+		log.Printf("审计日志加载失败: %v", err)
+	}
+
+	// 重新加载厂商数据库
+	if err := oui.Load(newConfig.OUI.Files); err != nil {
+		log.Printf("OUI数据库加载失败，保留原数据: %v", err)
+	}
+	if err := fingerprint.Load(newConfig.Fingerprint.DatabaseFile); err != nil {
+		log.Printf("DHCP指纹数据库加载失败，保留原数据: %v", err)
+	}
+
 	// 更新全局引用
 	serverMutex.Lock()
 	globalConfig = newConfig
@@ -340,6 +432,46 @@ func printHelp() {
 	fmt.Println("示例:")
 	fmt.Printf("  %s -config config.yaml\n", os.Args[0])
 	fmt.Printf("  %s -version\n", os.Args[0])
+	fmt.Printf("  echo 'secret-password' | %s -hash-password\n", os.Args[0])
+	fmt.Printf("  %s -gen-token\n", os.Args[0])
+}
+
+// printPasswordHash 从标准输入读取密码并输出bcrypt哈希
+func printPasswordHash() {
+	reader := bufio.NewReader(os.Stdin)
+	password, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		fmt.Fprintf(os.Stderr, "读取密码失败: %v\n", err)
+		os.Exit(1)
+	}
+	password = strings.TrimRight(password, "\r\n")
+	if password == "" {
+		fmt.Fprintln(os.Stderr, "密码不能为空")
+		os.Exit(1)
+	}
+
+	hash, err := api.HashPassword(password)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "生成密码哈希失败: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(hash)
+}
+
+// printNewToken 生成API令牌，明文只显示一次，配置文件中只保存哈希
+func printNewToken() {
+	token, hash, err := api.GenerateToken()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "生成令牌失败: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("令牌: %s\n", token)
+	fmt.Printf("哈希: %s\n", hash)
+	fmt.Println()
+	fmt.Println("添加到配置文件的 auth.tokens（必须设置 role 或 scopes）:")
+	fmt.Println("  - name: \"automation\"")
+	fmt.Printf("    token_hash: \"%s\"\n", hash)
+	fmt.Println("    role: \"viewer\"   # viewer、operator、admin")
 }
 
 // init 初始化函数
@@ -0,0 +1,345 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 指标类型
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// DefaultBuckets 处理耗时直方图的默认分桶（秒）
+var DefaultBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}
+
+// Label 标签
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample 一个样本，Suffix用于直方图的 _bucket、_sum、_count
+type Sample struct {
+	Suffix string
+	Labels []Label
+	Value  float64
+}
+
+// Family 同名指标的所有样本
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+// collector 已注册的指标
+type collector interface {
+	family() Family
+}
+
+// registry 指标注册表
+type registry struct {
+	mutex      sync.Mutex
+	collectors map[string]collector
+}
+
+var defaultRegistry = &registry{collectors: make(map[string]collector)}
+
+// register 注册指标，同名指标重复注册时panic，属于编程错误
+func (r *registry) register(name string, c collector) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.collectors[name]; exists {
+		panic("metrics: 重复注册指标 " + name)
+	}
+	r.collectors[name] = c
+}
+
+// Gather 获取所有已注册指标的当前值
+func Gather() []Family {
+	defaultRegistry.mutex.Lock()
+	collectors := make([]collector, 0, len(defaultRegistry.collectors))
+	for _, c := range defaultRegistry.collectors {
+		collectors = append(collectors, c)
+	}
+	defaultRegistry.mutex.Unlock()
+
+	families := make([]Family, 0, len(collectors))
+	for _, c := range collectors {
+		families = append(families, c.family())
+	}
+	return families
+}
+
+// vec 按标签值分组的指标基础结构
+type vec struct {
+	name   string
+	help   string
+	labels []string
+	mutex  sync.Mutex
+}
+
+// key 标签值组合的键
+func (v *vec) key(values []string) string {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: 指标 %s 需要 %d 个标签值，实际为 %d", v.name, len(v.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// labelPairs 组合标签名和标签值
+func (v *vec) labelPairs(values []string) []Label {
+	labels := make([]Label, len(values))
+	for i, value := range values {
+		labels[i] = Label{Name: v.labels[i], Value: value}
+	}
+	return labels
+}
+
+// valueEntry 计数器或仪表的一个标签组合
+type valueEntry struct {
+	labels []string
+	value  float64
+}
+
+// CounterVec 只增不减的计数器
+type CounterVec struct {
+	vec
+	values map[string]*valueEntry
+}
+
+// NewCounterVec 创建并注册计数器
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{vec: vec{name: name, help: help, labels: labels}, values: make(map[string]*valueEntry)}
+	defaultRegistry.register(name, c)
+	return c
+}
+
+// Inc 计数加一
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add 增加计数，负数被忽略
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		return
+	}
+	key := c.key(labelValues)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry, ok := c.values[key]
+	if !ok {
+		entry = &valueEntry{labels: append([]string(nil), labelValues...)}
+		c.values[key] = entry
+	}
+	entry.value += delta
+}
+
+func (c *CounterVec) family() Family {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	f := Family{Name: c.name, Help: c.help, Type: TypeCounter}
+	for _, entry := range c.values {
+		f.Samples = append(f.Samples, Sample{Labels: c.labelPairs(entry.labels), Value: entry.value})
+	}
+	return f
+}
+
+// GaugeVec 可增可减的仪表
+type GaugeVec struct {
+	vec
+	values map[string]*valueEntry
+}
+
+// NewGaugeVec 创建并注册仪表
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{vec: vec{name: name, help: help, labels: labels}, values: make(map[string]*valueEntry)}
+	defaultRegistry.register(name, g)
+	return g
+}
+
+// Set 设置当前值
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	key := g.key(labelValues)
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	entry, ok := g.values[key]
+	if !ok {
+		entry = &valueEntry{labels: append([]string(nil), labelValues...)}
+		g.values[key] = entry
+	}
+	entry.value = value
+}
+
+func (g *GaugeVec) family() Family {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	f := Family{Name: g.name, Help: g.help, Type: TypeGauge}
+	for _, entry := range g.values {
+		f.Samples = append(f.Samples, Sample{Labels: g.labelPairs(entry.labels), Value: entry.value})
+	}
+	return f
+}
+
+// histogramEntry 直方图的一个标签组合
+type histogramEntry struct {
+	labels []string
+	counts []uint64 // 每个分桶的计数（非累计）
+	sum    float64
+	count  uint64
+}
+
+// HistogramVec 直方图
+type HistogramVec struct {
+	vec
+	buckets []float64
+	values  map[string]*histogramEntry
+}
+
+// NewHistogramVec 创建并注册直方图，buckets为空时使用DefaultBuckets
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	h := &HistogramVec{vec: vec{name: name, help: help, labels: labels}, buckets: sorted, values: make(map[string]*histogramEntry)}
+	defaultRegistry.register(name, h)
+	return h
+}
+
+// Observe 记录一次观测值
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	key := h.key(labelValues)
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	entry, ok := h.values[key]
+	if !ok {
+		entry = &histogramEntry{labels: append([]string(nil), labelValues...), counts: make([]uint64, len(h.buckets))}
+		h.values[key] = entry
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			entry.counts[i]++
+			break
+		}
+	}
+	entry.sum += value
+	entry.count++
+}
+
+func (h *HistogramVec) family() Family {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	f := Family{Name: h.name, Help: h.help, Type: TypeHistogram}
+	for _, entry := range h.values {
+		labels := h.labelPairs(entry.labels)
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += entry.counts[i]
+			f.Samples = append(f.Samples, Sample{
+				Suffix: "_bucket",
+				Labels: append(append([]Label(nil), labels...), Label{Name: "le", Value: formatFloat(bound)}),
+				Value:  float64(cumulative),
+			})
+		}
+		f.Samples = append(f.Samples,
+			Sample{Suffix: "_bucket", Labels: append(append([]Label(nil), labels...), Label{Name: "le", Value: "+Inf"}), Value: float64(entry.count)},
+			Sample{Suffix: "_sum", Labels: labels, Value: entry.sum},
+			Sample{Suffix: "_count", Labels: labels, Value: float64(entry.count)},
+		)
+	}
+	return f
+}
+
+// formatFloat 按Prometheus文本格式输出数值
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// escapeLabel 转义标签值中的反斜杠、双引号和换行
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// escapeHelp 转义帮助文本中的反斜杠和换行
+func escapeHelp(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(value)
+}
+
+// labelString 样本标签的文本形式
+func labelString(labels []Label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	parts := make([]string, len(labels))
+	for i, label := range labels {
+		parts[i] = fmt.Sprintf(`%s="%s"`, label.Name, escapeLabel(label.Value))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// ContentType Prometheus文本格式的Content-Type
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// WriteText 以Prometheus文本格式输出指标，按名称排序，样本按标签排序保证输出稳定
+func WriteText(w io.Writer, families []Family) error {
+	sort.Slice(families, func(i, j int) bool { return families[i].Name < families[j].Name })
+
+	buf := bufio.NewWriter(w)
+	for _, f := range families {
+		samples := append([]Sample(nil), f.Samples...)
+		sort.SliceStable(samples, func(i, j int) bool {
+			a, b := labelString(samples[i].Labels), labelString(samples[j].Labels)
+			if f.Type == TypeHistogram {
+				// 直方图按标签组合分组，组内保持 _bucket、_sum、_count 顺序
+				a, b = labelString(withoutLE(samples[i].Labels)), labelString(withoutLE(samples[j].Labels))
+			}
+			return a < b
+		})
+
+		fmt.Fprintf(buf, "# HELP %s %s\n", f.Name, escapeHelp(f.Help))
+		fmt.Fprintf(buf, "# TYPE %s %s\n", f.Name, f.Type)
+		for _, s := range samples {
+			fmt.Fprintf(buf, "%s%s%s %s\n", f.Name, s.Suffix, labelString(s.Labels), formatFloat(s.Value))
+		}
+	}
+	return buf.Flush()
+}
+
+// withoutLE 去掉直方图分桶的le标签
+func withoutLE(labels []Label) []Label {
+	result := make([]Label, 0, len(labels))
+	for _, label := range labels {
+		if label.Name != "le" {
+			result = append(result, label)
+		}
+	}
+	return result
+}
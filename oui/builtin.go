@@ -0,0 +1,168 @@
+package oui
+
+// builtinEntry 内置OUI条目
+type builtinEntry struct {
+	prefix string
+	vendor string
+}
+
+// builtinEntries 内置的常见厂商前缀，未加载IEEE数据文件时使用
+var builtinEntries = []builtinEntry{
+	// 虚拟化平台
+	{"00:50:56", "VMware, Inc."},
+	{"00:0C:29", "VMware, Inc."},
+	{"00:1A:11", "Google, Inc."},
+	{"00:16:3E", "Xensource, Inc."},
+	{"52:54:00", "QEMU virtual NIC"},
+
+	// Apple
+	{"00:03:93", "Apple, Inc."}, {"00:05:02", "Apple, Inc."}, {"00:0A:95", "Apple, Inc."},
+	{"00:0D:93", "Apple, Inc."}, {"00:11:24", "Apple, Inc."}, {"00:14:51", "Apple, Inc."},
+	{"00:16:CB", "Apple, Inc."}, {"00:17:F2", "Apple, Inc."}, {"00:19:E3", "Apple, Inc."},
+	{"00:1B:63", "Apple, Inc."}, {"00:1C:B3", "Apple, Inc."}, {"00:1E:C2", "Apple, Inc."},
+	{"00:1F:F3", "Apple, Inc."}, {"00:21:E9", "Apple, Inc."}, {"00:22:41", "Apple, Inc."},
+	{"00:23:12", "Apple, Inc."}, {"00:23:DF", "Apple, Inc."}, {"00:24:36", "Apple, Inc."},
+	{"00:25:00", "Apple, Inc."}, {"00:25:4B", "Apple, Inc."}, {"00:25:BC", "Apple, Inc."},
+	{"00:26:08", "Apple, Inc."}, {"00:26:4A", "Apple, Inc."}, {"00:26:B0", "Apple, Inc."},
+	{"00:26:BB", "Apple, Inc."}, {"3C:15:C2", "Apple, Inc."}, {"40:A6:D9", "Apple, Inc."},
+	{"40:B3:95", "Apple, Inc."}, {"44:00:10", "Apple, Inc."}, {"48:A1:95", "Apple, Inc."},
+	{"4C:8D:79", "Apple, Inc."}, {"50:EA:D6", "Apple, Inc."}, {"58:55:CA", "Apple, Inc."},
+	{"5C:59:48", "Apple, Inc."}, {"5C:95:AE", "Apple, Inc."}, {"60:03:08", "Apple, Inc."},
+	{"60:33:4B", "Apple, Inc."}, {"60:A3:7D", "Apple, Inc."}, {"60:C5:47", "Apple, Inc."},
+	{"64:20:0C", "Apple, Inc."}, {"64:B9:E8", "Apple, Inc."}, {"68:AB:BC", "Apple, Inc."},
+	{"6C:72:20", "Apple, Inc."}, {"6C:8D:C1", "Apple, Inc."}, {"70:11:24", "Apple, Inc."},
+	{"70:73:CB", "Apple, Inc."}, {"70:CD:60", "Apple, Inc."}, {"74:F0:6D", "Apple, Inc."},
+	{"78:31:C1", "Apple, Inc."}, {"78:4F:43", "Apple, Inc."}, {"7C:6D:62", "Apple, Inc."},
+	{"7C:C3:A1", "Apple, Inc."}, {"80:BE:05", "Apple, Inc."}, {"80:E6:50", "Apple, Inc."},
+	{"84:38:35", "Apple, Inc."}, {"84:85:06", "Apple, Inc."}, {"88:63:DF", "Apple, Inc."},
+	{"8C:2D:AA", "Apple, Inc."}, {"8C:7C:92", "Apple, Inc."}, {"90:72:40", "Apple, Inc."},
+	{"90:B0:ED", "Apple, Inc."}, {"94:F6:A3", "Apple, Inc."}, {"98:01:A7", "Apple, Inc."},
+	{"98:5A:EB", "Apple, Inc."}, {"9C:04:EB", "Apple, Inc."}, {"9C:84:BF", "Apple, Inc."},
+	{"A0:99:9B", "Apple, Inc."}, {"A4:5E:60", "Apple, Inc."}, {"A4:B1:97", "Apple, Inc."},
+	{"A8:86:DD", "Apple, Inc."}, {"A8:BB:CF", "Apple, Inc."}, {"AC:BC:32", "Apple, Inc."},
+	{"B0:65:BD", "Apple, Inc."}, {"B0:CA:68", "Apple, Inc."}, {"B4:F0:AB", "Apple, Inc."},
+	{"B8:8D:12", "Apple, Inc."}, {"B8:C7:5A", "Apple, Inc."}, {"BC:52:B7", "Apple, Inc."},
+	{"BC:67:1C", "Apple, Inc."}, {"C0:84:7A", "Apple, Inc."}, {"C4:2C:03", "Apple, Inc."},
+	{"C8:BC:C8", "Apple, Inc."}, {"C8:E0:EB", "Apple, Inc."}, {"CC:25:EF", "Apple, Inc."},
+	{"D0:23:DB", "Apple, Inc."}, {"D0:A6:37", "Apple, Inc."}, {"D4:90:9C", "Apple, Inc."},
+	{"D8:00:4D", "Apple, Inc."}, {"D8:30:62", "Apple, Inc."}, {"D8:96:95", "Apple, Inc."},
+	{"DC:37:45", "Apple, Inc."}, {"DC:A9:04", "Apple, Inc."}, {"E0:AC:CB", "Apple, Inc."},
+	{"E0:B9:4D", "Apple, Inc."}, {"E4:8B:7F", "Apple, Inc."}, {"E4:C6:3D", "Apple, Inc."},
+	{"E8:06:88", "Apple, Inc."}, {"EC:35:86", "Apple, Inc."}, {"F0:B4:79", "Apple, Inc."},
+	{"F0:DB:E2", "Apple, Inc."}, {"F4:F1:5A", "Apple, Inc."}, {"F8:27:93", "Apple, Inc."},
+	{"F8:A9:D0", "Apple, Inc."}, {"FC:25:3F", "Apple, Inc."},
+
+	// Samsung
+	{"00:07:AB", "Samsung Electronics Co.,Ltd"}, {"00:12:FB", "Samsung Electronics Co.,Ltd"}, {"00:13:77", "Samsung Electronics Co.,Ltd"},
+	{"00:15:99", "Samsung Electronics Co.,Ltd"}, {"00:16:32", "Samsung Electronics Co.,Ltd"}, {"00:17:C9", "Samsung Electronics Co.,Ltd"},
+	{"00:17:D5", "Samsung Electronics Co.,Ltd"}, {"00:18:AF", "Samsung Electronics Co.,Ltd"}, {"00:1A:8A", "Samsung Electronics Co.,Ltd"},
+	{"00:1B:98", "Samsung Electronics Co.,Ltd"}, {"00:1C:43", "Samsung Electronics Co.,Ltd"}, {"00:1D:25", "Samsung Electronics Co.,Ltd"},
+	{"00:1E:7D", "Samsung Electronics Co.,Ltd"}, {"00:1F:CC", "Samsung Electronics Co.,Ltd"}, {"00:21:19", "Samsung Electronics Co.,Ltd"},
+	{"00:21:D1", "Samsung Electronics Co.,Ltd"}, {"00:23:39", "Samsung Electronics Co.,Ltd"}, {"00:24:54", "Samsung Electronics Co.,Ltd"},
+	{"00:26:37", "Samsung Electronics Co.,Ltd"}, {"00:E3:B2", "Samsung Electronics Co.,Ltd"}, {"08:08:C2", "Samsung Electronics Co.,Ltd"},
+	{"08:37:3D", "Samsung Electronics Co.,Ltd"}, {"08:FC:88", "Samsung Electronics Co.,Ltd"}, {"0C:14:20", "Samsung Electronics Co.,Ltd"},
+	{"0C:89:10", "Samsung Electronics Co.,Ltd"}, {"10:1D:C0", "Samsung Electronics Co.,Ltd"}, {"14:7D:C5", "Samsung Electronics Co.,Ltd"},
+	{"18:3A:2D", "Samsung Electronics Co.,Ltd"}, {"18:AF:8F", "Samsung Electronics Co.,Ltd"}, {"1C:28:AF", "Samsung Electronics Co.,Ltd"},
+	{"1C:5A:3E", "Samsung Electronics Co.,Ltd"}, {"20:13:E0", "Samsung Electronics Co.,Ltd"}, {"20:64:32", "Samsung Electronics Co.,Ltd"},
+	{"20:A5:9E", "Samsung Electronics Co.,Ltd"}, {"24:4B:81", "Samsung Electronics Co.,Ltd"}, {"28:39:5E", "Samsung Electronics Co.,Ltd"},
+	{"28:ED:6A", "Samsung Electronics Co.,Ltd"}, {"2C:44:01", "Samsung Electronics Co.,Ltd"}, {"30:07:4D", "Samsung Electronics Co.,Ltd"},
+	{"30:19:66", "Samsung Electronics Co.,Ltd"}, {"30:CD:A7", "Samsung Electronics Co.,Ltd"}, {"34:23:87", "Samsung Electronics Co.,Ltd"},
+	{"34:BE:00", "Samsung Electronics Co.,Ltd"}, {"38:AA:3C", "Samsung Electronics Co.,Ltd"}, {"3C:8B:FE", "Samsung Electronics Co.,Ltd"},
+	{"40:0E:85", "Samsung Electronics Co.,Ltd"}, {"40:43:7A", "Samsung Electronics Co.,Ltd"}, {"44:4E:6D", "Samsung Electronics Co.,Ltd"},
+	{"44:78:3E", "Samsung Electronics Co.,Ltd"}, {"48:5A:3F", "Samsung Electronics Co.,Ltd"}, {"4C:3C:16", "Samsung Electronics Co.,Ltd"},
+	{"4C:66:41", "Samsung Electronics Co.,Ltd"}, {"50:32:75", "Samsung Electronics Co.,Ltd"}, {"50:CC:F8", "Samsung Electronics Co.,Ltd"},
+	{"54:88:0E", "Samsung Electronics Co.,Ltd"}, {"58:8B:F4", "Samsung Electronics Co.,Ltd"}, {"5C:0A:5B", "Samsung Electronics Co.,Ltd"},
+	{"5C:51:88", "Samsung Electronics Co.,Ltd"}, {"60:6B:BD", "Samsung Electronics Co.,Ltd"}, {"60:A1:0A", "Samsung Electronics Co.,Ltd"},
+	{"64:3E:8C", "Samsung Electronics Co.,Ltd"}, {"68:EB:AE", "Samsung Electronics Co.,Ltd"}, {"6C:2F:2C", "Samsung Electronics Co.,Ltd"},
+	{"6C:94:66", "Samsung Electronics Co.,Ltd"}, {"70:F9:27", "Samsung Electronics Co.,Ltd"}, {"74:45:8A", "Samsung Electronics Co.,Ltd"},
+	{"78:1F:DB", "Samsung Electronics Co.,Ltd"}, {"78:52:1A", "Samsung Electronics Co.,Ltd"}, {"78:9E:D0", "Samsung Electronics Co.,Ltd"},
+	{"7C:61:66", "Samsung Electronics Co.,Ltd"}, {"7C:A1:AE", "Samsung Electronics Co.,Ltd"}, {"80:18:A7", "Samsung Electronics Co.,Ltd"},
+	{"84:25:3F", "Samsung Electronics Co.,Ltd"}, {"88:32:9B", "Samsung Electronics Co.,Ltd"}, {"8C:77:12", "Samsung Electronics Co.,Ltd"},
+	{"90:18:7C", "Samsung Electronics Co.,Ltd"}, {"94:35:0A", "Samsung Electronics Co.,Ltd"}, {"9C:02:98", "Samsung Electronics Co.,Ltd"},
+	{"9C:3A:AF", "Samsung Electronics Co.,Ltd"}, {"A0:0B:BA", "Samsung Electronics Co.,Ltd"}, {"A0:75:91", "Samsung Electronics Co.,Ltd"},
+	{"A4:EB:D3", "Samsung Electronics Co.,Ltd"}, {"A8:F2:74", "Samsung Electronics Co.,Ltd"}, {"AC:5F:3E", "Samsung Electronics Co.,Ltd"},
+	{"B0:EC:71", "Samsung Electronics Co.,Ltd"}, {"B4:62:93", "Samsung Electronics Co.,Ltd"}, {"B8:5E:7B", "Samsung Electronics Co.,Ltd"},
+	{"BC:14:85", "Samsung Electronics Co.,Ltd"}, {"BC:F5:AC", "Samsung Electronics Co.,Ltd"}, {"C0:BD:D1", "Samsung Electronics Co.,Ltd"},
+	{"C4:57:6E", "Samsung Electronics Co.,Ltd"}, {"C8:A8:23", "Samsung Electronics Co.,Ltd"}, {"CC:07:AB", "Samsung Electronics Co.,Ltd"},
+	{"D0:17:6A", "Samsung Electronics Co.,Ltd"}, {"D0:59:E4", "Samsung Electronics Co.,Ltd"}, {"D4:E8:B2", "Samsung Electronics Co.,Ltd"},
+	{"D8:57:EF", "Samsung Electronics Co.,Ltd"}, {"DC:71:44", "Samsung Electronics Co.,Ltd"}, {"E0:91:F5", "Samsung Electronics Co.,Ltd"},
+	{"E4:40:E2", "Samsung Electronics Co.,Ltd"}, {"E8:50:8B", "Samsung Electronics Co.,Ltd"}, {"EC:1F:72", "Samsung Electronics Co.,Ltd"},
+	{"F0:25:B7", "Samsung Electronics Co.,Ltd"}, {"F4:09:D8", "Samsung Electronics Co.,Ltd"}, {"F8:04:2E", "Samsung Electronics Co.,Ltd"},
+	{"FC:00:12", "Samsung Electronics Co.,Ltd"},
+
+	// Xiaomi
+	{"34:CE:00", "Xiaomi Communications Co Ltd"}, {"50:8F:4C", "Xiaomi Communications Co Ltd"}, {"64:09:80", "Xiaomi Communications Co Ltd"},
+	{"68:DF:DD", "Xiaomi Communications Co Ltd"}, {"6C:96:CF", "Xiaomi Communications Co Ltd"}, {"74:51:BA", "Xiaomi Communications Co Ltd"},
+	{"78:11:DC", "Xiaomi Communications Co Ltd"}, {"7C:49:EB", "Xiaomi Communications Co Ltd"}, {"8C:BE:BE", "Xiaomi Communications Co Ltd"},
+	{"98:FA:9B", "Xiaomi Communications Co Ltd"}, {"A0:86:C6", "Xiaomi Communications Co Ltd"}, {"AC:C1:EE", "Xiaomi Communications Co Ltd"},
+	{"B0:E2:35", "Xiaomi Communications Co Ltd"}, {"C4:0B:CB", "Xiaomi Communications Co Ltd"}, {"D4:61:9D", "Xiaomi Communications Co Ltd"},
+	{"E4:46:DA", "Xiaomi Communications Co Ltd"}, {"F0:B4:29", "Xiaomi Communications Co Ltd"}, {"F8:8F:CA", "Xiaomi Communications Co Ltd"},
+	{"FC:64:BA", "Xiaomi Communications Co Ltd"}, {"2C:EA:7F", "Xiaomi Communications Co Ltd"}, {"50:EC:50", "Xiaomi Communications Co Ltd"},
+	{"58:44:98", "Xiaomi Communications Co Ltd"}, {"68:3B:78", "Xiaomi Communications Co Ltd"}, {"78:02:F8", "Xiaomi Communications Co Ltd"},
+	{"88:C3:97", "Xiaomi Communications Co Ltd"}, {"9C:99:A0", "Xiaomi Communications Co Ltd"}, {"A4:DA:32", "Xiaomi Communications Co Ltd"},
+	{"B0:E5:ED", "Xiaomi Communications Co Ltd"}, {"C8:47:8C", "Xiaomi Communications Co Ltd"}, {"D0:7E:35", "Xiaomi Communications Co Ltd"},
+	{"E8:78:29", "Xiaomi Communications Co Ltd"}, {"F4:8E:38", "Xiaomi Communications Co Ltd"}, {"F4:F5:DB", "Xiaomi Communications Co Ltd"},
+
+	// Huawei
+	{"00:E0:FC", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"18:66:DA", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"1C:1D:67", "HUAWEI TECHNOLOGIES CO.,LTD"},
+	{"20:F3:A3", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"24:69:68", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"28:6E:D4", "HUAWEI TECHNOLOGIES CO.,LTD"},
+	{"2C:AB:00", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"34:6B:D3", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"38:BC:01", "HUAWEI TECHNOLOGIES CO.,LTD"},
+	{"3C:F8:08", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"40:4E:36", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"44:6D:6C", "HUAWEI TECHNOLOGIES CO.,LTD"},
+	{"48:59:29", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"4C:54:99", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"50:3D:E5", "HUAWEI TECHNOLOGIES CO.,LTD"},
+	{"54:51:1B", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"58:2A:F7", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"5C:63:BF", "HUAWEI TECHNOLOGIES CO.,LTD"},
+	{"60:DE:44", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"64:3B:78", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"68:3E:34", "HUAWEI TECHNOLOGIES CO.,LTD"},
+	{"6C:4B:90", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"70:72:3C", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"74:A7:22", "HUAWEI TECHNOLOGIES CO.,LTD"},
+	{"78:D7:52", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"7C:A2:3E", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"80:71:7A", "HUAWEI TECHNOLOGIES CO.,LTD"},
+	{"84:A8:E4", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"88:25:93", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"8C:0E:E3", "HUAWEI TECHNOLOGIES CO.,LTD"},
+	{"90:67:1C", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"94:04:9C", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"98:52:3D", "HUAWEI TECHNOLOGIES CO.,LTD"},
+	{"9C:28:EF", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"A0:48:1C", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"A4:50:46", "HUAWEI TECHNOLOGIES CO.,LTD"},
+	{"A8:4E:3F", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"AC:E2:15", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"B0:91:34", "HUAWEI TECHNOLOGIES CO.,LTD"},
+	{"B4:CD:27", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"B8:08:CF", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"BC:76:5E", "HUAWEI TECHNOLOGIES CO.,LTD"},
+	{"C0:EE:40", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"C4:F0:81", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"C8:94:02", "HUAWEI TECHNOLOGIES CO.,LTD"},
+	{"CC:96:A0", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"D0:7A:B5", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"D4:20:B0", "HUAWEI TECHNOLOGIES CO.,LTD"},
+	{"D8:49:2F", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"DC:D9:16", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"E0:19:1D", "HUAWEI TECHNOLOGIES CO.,LTD"},
+	{"E4:D3:32", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"E8:CD:2D", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"EC:23:3D", "HUAWEI TECHNOLOGIES CO.,LTD"},
+	{"F0:79:59", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"F4:C7:14", "HUAWEI TECHNOLOGIES CO.,LTD"}, {"F8:E7:1E", "HUAWEI TECHNOLOGIES CO.,LTD"},
+	{"FC:48:EF", "HUAWEI TECHNOLOGIES CO.,LTD"},
+
+	// Microsoft
+	{"00:0D:3A", "Microsoft Corporation"}, {"00:12:5A", "Microsoft Corporation"}, {"00:15:5D", "Microsoft Corporation"},
+	{"00:17:FA", "Microsoft Corporation"}, {"00:1D:D8", "Microsoft Corporation"}, {"00:50:F2", "Microsoft Corporation"},
+	{"00:9D:8E", "Microsoft Corporation"}, {"04:C5:A4", "Microsoft Corporation"}, {"18:60:24", "Microsoft Corporation"},
+	{"1C:B7:2C", "Microsoft Corporation"}, {"28:18:78", "Microsoft Corporation"}, {"30:59:B7", "Microsoft Corporation"},
+	{"34:17:EB", "Microsoft Corporation"}, {"38:2C:4A", "Microsoft Corporation"}, {"3C:5A:B4", "Microsoft Corporation"},
+	{"40:E2:30", "Microsoft Corporation"}, {"44:85:00", "Microsoft Corporation"}, {"54:27:1E", "Microsoft Corporation"},
+	{"60:45:BD", "Microsoft Corporation"}, {"64:00:F1", "Microsoft Corporation"}, {"74:2F:68", "Microsoft Corporation"},
+	{"7C:1E:52", "Microsoft Corporation"}, {"80:E6:50", "Microsoft Corporation"}, {"98:5F:D3", "Microsoft Corporation"},
+	{"A0:CE:C8", "Microsoft Corporation"}, {"B8:86:87", "Microsoft Corporation"}, {"D0:57:7B", "Microsoft Corporation"},
+	{"F4:A4:75", "Microsoft Corporation"}, {"F8:63:3F", "Microsoft Corporation"},
+
+	// Intel
+	{"00:02:B3", "Intel Corporate"}, {"00:03:47", "Intel Corporate"}, {"00:04:23", "Intel Corporate"},
+	{"00:07:E9", "Intel Corporate"}, {"00:0E:35", "Intel Corporate"}, {"00:11:25", "Intel Corporate"},
+	{"00:12:F0", "Intel Corporate"}, {"00:13:02", "Intel Corporate"}, {"00:13:CE", "Intel Corporate"},
+	{"00:15:00", "Intel Corporate"}, {"00:16:E3", "Intel Corporate"}, {"00:19:D1", "Intel Corporate"},
+	{"00:1B:21", "Intel Corporate"}, {"00:1E:64", "Intel Corporate"}, {"00:1F:3B", "Intel Corporate"},
+	{"00:21:6A", "Intel Corporate"}, {"00:22:FB", "Intel Corporate"}, {"00:24:D7", "Intel Corporate"},
+	{"00:27:10", "Intel Corporate"}, {"04:79:B7", "Intel Corporate"}, {"08:11:96", "Intel Corporate"},
+	{"0C:8B:FD", "Intel Corporate"}, {"10:0B:A9", "Intel Corporate"}, {"18:56:80", "Intel Corporate"},
+	{"1C:3E:84", "Intel Corporate"}, {"20:16:B9", "Intel Corporate"}, {"24:77:03", "Intel Corporate"},
+	{"28:D2:44", "Intel Corporate"}, {"2C:6E:85", "Intel Corporate"}, {"30:E1:71", "Intel Corporate"},
+	{"34:13:E8", "Intel Corporate"}, {"38:2C:4A", "Intel Corporate"}, {"3C:A9:F4", "Intel Corporate"},
+	{"40:B0:34", "Intel Corporate"}, {"44:85:00", "Intel Corporate"}, {"48:2A:E3", "Intel Corporate"},
+	{"4C:79:6E", "Intel Corporate"}, {"50:76:AF", "Intel Corporate"}, {"54:27:1E", "Intel Corporate"},
+	{"58:91:CF", "Intel Corporate"}, {"5C:E0:C5", "Intel Corporate"}, {"60:67:20", "Intel Corporate"},
+	{"64:27:37", "Intel Corporate"}, {"68:17:29", "Intel Corporate"}, {"6C:88:14", "Intel Corporate"},
+	{"70:5A:0F", "Intel Corporate"}, {"74:E5:43", "Intel Corporate"}, {"78:92:9C", "Intel Corporate"},
+	{"7C:7A:91", "Intel Corporate"}, {"80:19:34", "Intel Corporate"}, {"84:3A:4B", "Intel Corporate"},
+	{"88:53:2E", "Intel Corporate"}, {"8C:A9:82", "Intel Corporate"}, {"90:E2:BA", "Intel Corporate"},
+	{"94:65:9C", "Intel Corporate"}, {"98:4F:EE", "Intel Corporate"}, {"9C:B6:D0", "Intel Corporate"},
+	{"A0:A8:CD", "Intel Corporate"}, {"A4:4C:C8", "Intel Corporate"}, {"A8:6D:AA", "Intel Corporate"},
+	{"AC:7B:A1", "Intel Corporate"}, {"B0:C0:90", "Intel Corporate"}, {"B4:B6:76", "Intel Corporate"},
+	{"B8:AE:ED", "Intel Corporate"}, {"BC:77:37", "Intel Corporate"}, {"C0:3F:D5", "Intel Corporate"},
+	{"C4:8E:8F", "Intel Corporate"}, {"C8:D9:D2", "Intel Corporate"}, {"CC:3D:82", "Intel Corporate"},
+	{"D0:50:99", "Intel Corporate"}, {"D4:BE:D9", "Intel Corporate"}, {"D8:CB:8A", "Intel Corporate"},
+	{"DC:53:60", "Intel Corporate"}, {"E0:94:67", "Intel Corporate"}, {"E4:A4:71", "Intel Corporate"},
+	{"E8:39:35", "Intel Corporate"}, {"EC:A8:6B", "Intel Corporate"}, {"F0:76:1C", "Intel Corporate"},
+	{"F4:06:69", "Intel Corporate"}, {"F8:63:3F", "Intel Corporate"}, {"FC:AA:14", "Intel Corporate"},
+}
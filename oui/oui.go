@@ -0,0 +1,330 @@
+package oui
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IEEE注册类型
+const (
+	RegistryMAL = "MA-L" // 24位前缀
+	RegistryMAM = "MA-M" // 28位前缀
+	RegistryMAS = "MA-S" // 36位前缀
+	RegistryIAB = "IAB"  // 36位前缀（旧版个体地址块）
+	RegistryCID = "CID"  // 24位公司ID，位于本地管理地址空间
+)
+
+// 支持的前缀长度，查找时按最长前缀优先
+var prefixBits = []int{36, 28, 24}
+
+// registryNames 注册类型索引，条目中只保存下标以节省内存
+var registryNames = []string{RegistryMAL, RegistryMAM, RegistryMAS, RegistryIAB, RegistryCID, "builtin"}
+
+// Info MAC地址识别结果
+type Info struct {
+	MAC                 string `json:"mac"`
+	Vendor              string `json:"vendor"`               // 厂商名称，未知时为空
+	Registry            string `json:"registry,omitempty"`   // 命中的注册类型
+	Prefix              string `json:"prefix,omitempty"`     // 命中的前缀，如 70:B3:D5:1
+	LocallyAdministered bool   `json:"locally_administered"` // 本地管理地址（U/L位为1）
+	Multicast           bool   `json:"multicast"`            // 组播地址（I/G位为1）
+	Randomized          bool   `json:"randomized"`           // 疑似随机化MAC（本地管理、单播且未注册）
+}
+
+// entry 紧凑索引条目
+type entry struct {
+	prefix   uint64
+	vendor   uint32 // vendors下标
+	registry uint8  // registryNames下标
+}
+
+// Registry OUI注册表，按前缀长度分别保存有序数组，通过二分查找匹配
+type Registry struct {
+	blocks  map[int][]entry
+	vendors []string
+	sources []string
+	loaded  time.Time
+}
+
+// Stats 注册表统计信息
+type Stats struct {
+	Entries  map[string]int `json:"entries"` // 各注册类型的条目数
+	Total    int            `json:"total"`
+	Vendors  int            `json:"vendors"`
+	Sources  []string       `json:"sources"`
+	LoadedAt time.Time      `json:"loaded_at"`
+}
+
+// builder 注册表构建器，同一前缀后加入的条目覆盖先加入的
+type builder struct {
+	entries   map[int]map[uint64]entry
+	vendorIdx map[string]uint32
+	vendors   []string
+	sources   []string
+}
+
+func newBuilder() *builder {
+	b := &builder{
+		entries:   make(map[int]map[uint64]entry),
+		vendorIdx: make(map[string]uint32),
+	}
+	for _, bits := range prefixBits {
+		b.entries[bits] = make(map[uint64]entry)
+	}
+	return b
+}
+
+// add 添加一个前缀，assignment为十六进制字符串（6、7或9位）
+func (b *builder) add(registry, assignment, vendor string) error {
+	assignment = strings.ToUpper(strings.NewReplacer(":", "", "-", "", ".", "").Replace(strings.TrimSpace(assignment)))
+	vendor = strings.TrimSpace(vendor)
+	if vendor == "" {
+		return fmt.Errorf("前缀 %s 缺少厂商名称", assignment)
+	}
+
+	bits := len(assignment) * 4
+	if _, ok := b.entries[bits]; !ok {
+		return fmt.Errorf("无效的前缀长度: %s", assignment)
+	}
+	prefix, err := strconv.ParseUint(assignment, 16, 64)
+	if err != nil {
+		return fmt.Errorf("无效的前缀: %s", assignment)
+	}
+
+	regIdx := -1
+	for i, name := range registryNames {
+		if name == registry {
+			regIdx = i
+			break
+		}
+	}
+	if regIdx < 0 {
+		return fmt.Errorf("未知的注册类型: %s", registry)
+	}
+
+	idx, ok := b.vendorIdx[vendor]
+	if !ok {
+		idx = uint32(len(b.vendors))
+		b.vendors = append(b.vendors, vendor)
+		b.vendorIdx[vendor] = idx
+	}
+
+	b.entries[bits][prefix] = entry{prefix: prefix, vendor: idx, registry: uint8(regIdx)}
+	return nil
+}
+
+// loadCSV 解析IEEE CSV格式（Registry,Assignment,Organization Name,Organization Address）
+func (b *builder) loadCSV(reader io.Reader, source string) (int, error) {
+	r := csv.NewReader(reader)
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	count := 0
+	line := 0
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return count, fmt.Errorf("%s 第%d行解析失败: %v", source, line, err)
+		}
+		if len(record) < 3 {
+			continue
+		}
+		// 跳过表头
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "Registry") {
+			continue
+		}
+		registry := strings.ToUpper(strings.TrimSpace(record[0]))
+		if err := b.add(registry, record[1], record[2]); err != nil {
+			return count, fmt.Errorf("%s 第%d行: %v", source, line, err)
+		}
+		count++
+	}
+
+	b.sources = append(b.sources, source)
+	return count, nil
+}
+
+// build 生成有序紧凑索引
+func (b *builder) build() *Registry {
+	reg := &Registry{
+		blocks:  make(map[int][]entry),
+		vendors: b.vendors,
+		sources: b.sources,
+		loaded:  time.Now(),
+	}
+	for bits, entries := range b.entries {
+		list := make([]entry, 0, len(entries))
+		for _, e := range entries {
+			list = append(list, e)
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].prefix < list[j].prefix })
+		reg.blocks[bits] = list
+	}
+	return reg
+}
+
+// NewRegistry 从内置数据和IEEE CSV文件构建注册表，文件中的条目覆盖内置数据
+func NewRegistry(files ...string) (*Registry, error) {
+	b := newBuilder()
+	for _, item := range builtinEntries {
+		if err := b.add("builtin", item.prefix, item.vendor); err != nil {
+			return nil, fmt.Errorf("内置OUI数据错误: %v", err)
+		}
+	}
+	b.sources = append(b.sources, "builtin")
+
+	for _, path := range files {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("打开OUI数据文件失败: %v", err)
+		}
+		_, err = b.loadCSV(file, path)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return b.build(), nil
+}
+
+// Lookup 查询MAC地址对应的厂商，按最长前缀匹配
+func (reg *Registry) Lookup(mac string) Info {
+	info := Info{MAC: mac}
+	value, ok := parseMAC(mac)
+	if !ok {
+		return info
+	}
+
+	firstOctet := byte(value >> 40)
+	info.Multicast = firstOctet&0x01 != 0
+	info.LocallyAdministered = firstOctet&0x02 != 0
+
+	for _, bits := range prefixBits {
+		list := reg.blocks[bits]
+		key := value >> uint(48-bits)
+		i := sort.Search(len(list), func(i int) bool { return list[i].prefix >= key })
+		if i < len(list) && list[i].prefix == key {
+			info.Vendor = reg.vendors[list[i].vendor]
+			info.Registry = registryNames[list[i].registry]
+			info.Prefix = formatPrefix(key, bits)
+			break
+		}
+	}
+
+	// 本地管理的单播地址若未在CID中注册，通常是操作系统生成的随机MAC
+	info.Randomized = info.LocallyAdministered && !info.Multicast && info.Vendor == ""
+	return info
+}
+
+// Stats 返回注册表统计信息
+func (reg *Registry) Stats() Stats {
+	stats := Stats{
+		Entries:  make(map[string]int),
+		Vendors:  len(reg.vendors),
+		Sources:  reg.sources,
+		LoadedAt: reg.loaded,
+	}
+	for _, list := range reg.blocks {
+		for _, e := range list {
+			stats.Entries[registryNames[e.registry]]++
+			stats.Total++
+		}
+	}
+	return stats
+}
+
+// parseMAC 将MAC地址转换为48位整数，支持冒号、短横线、点分和纯十六进制格式
+func parseMAC(mac string) (uint64, bool) {
+	mac = strings.TrimSpace(mac)
+	if hw, err := net.ParseMAC(mac); err == nil && len(hw) == 6 {
+		var value uint64
+		for _, b := range hw {
+			value = value<<8 | uint64(b)
+		}
+		return value, true
+	}
+	if len(mac) == 12 {
+		if value, err := strconv.ParseUint(mac, 16, 64); err == nil {
+			return value, true
+		}
+	}
+	return 0, false
+}
+
+// formatPrefix 将前缀格式化为冒号分隔形式
+func formatPrefix(prefix uint64, bits int) string {
+	hex := fmt.Sprintf("%0*X", bits/4, prefix)
+	parts := make([]string, 0, (len(hex)+1)/2)
+	for i := 0; i < len(hex); i += 2 {
+		end := i + 2
+		if end > len(hex) {
+			end = len(hex)
+		}
+		parts = append(parts, hex[i:end])
+	}
+	return strings.Join(parts, ":")
+}
+
+// 默认注册表
+var (
+	defaultRegistry *Registry
+	defaultMutex    sync.RWMutex
+)
+
+func init() {
+	reg, err := NewRegistry()
+	if err != nil {
+		panic(err)
+	}
+	defaultRegistry = reg
+}
+
+// Default 获取默认注册表
+func Default() *Registry {
+	defaultMutex.RLock()
+	defer defaultMutex.RUnlock()
+	return defaultRegistry
+}
+
+// Load 从IEEE CSV文件重新加载默认注册表，失败时保留原注册表
+func Load(files []string) error {
+	reg, err := NewRegistry(files...)
+	if err != nil {
+		return err
+	}
+
+	defaultMutex.Lock()
+	defaultRegistry = reg
+	defaultMutex.Unlock()
+
+	stats := reg.Stats()
+	log.Printf("OUI数据库已加载: %d 个前缀, %d 个厂商, 来源: %v", stats.Total, stats.Vendors, stats.Sources)
+	return nil
+}
+
+// Lookup 使用默认注册表查询MAC地址
+func Lookup(mac string) Info {
+	return Default().Lookup(mac)
+}
+
+// Vendor 返回MAC地址的厂商名称，未知时返回 Unknown
+func Vendor(mac string) string {
+	if vendor := Lookup(mac).Vendor; vendor != "" {
+		return vendor
+	}
+	return "Unknown"
+}
@@ -0,0 +1,483 @@
+package presence
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"dhcp-server/config"
+	"dhcp-server/events"
+)
+
+// 发现来源
+const (
+	SourceScan = "scan"
+	SourceDHCP = "dhcp"
+)
+
+const (
+	defaultOfflineTimeout = 10 * time.Minute
+	defaultRetentionDays  = 30
+	maxSessionsPerDevice  = 1000
+	sweepInterval         = 30 * time.Second
+)
+
+// Session 一次连续在线的时间段
+type Session struct {
+	Start  time.Time  `json:"start"`
+	End    *time.Time `json:"end,omitempty"` // 为空表示仍在线
+	Source string     `json:"source"`        // 会话开始时的发现来源：scan、dhcp
+}
+
+// Record 单个设备的在线记录
+type Record struct {
+	MAC        string    `json:"mac"`
+	IP         string    `json:"ip"`
+	Hostname   string    `json:"hostname"`
+	FirstSeen  time.Time `json:"first_seen"`
+	LastSeen   time.Time `json:"last_seen"`
+	LastSource string    `json:"last_source"`
+	Online     bool      `json:"online"`
+	Sessions   []Session `json:"sessions,omitempty"`
+}
+
+// DailyUptime 单日在线时长
+type DailyUptime struct {
+	Date    string `json:"date"` // 2006-01-02，本地时区
+	Seconds int64  `json:"seconds"`
+}
+
+// Timeline 设备在线时间线
+type Timeline struct {
+	Record
+	Daily       []DailyUptime `json:"daily"`
+	TotalUptime int64         `json:"total_uptime"` // 统计范围内的在线总秒数
+}
+
+// Tracker 根据扫描结果和DHCP活动维护设备在线记录
+type Tracker struct {
+	mutex          sync.Mutex
+	records        map[string]*Record
+	file           string
+	offlineTimeout time.Duration
+	retention      time.Duration
+	isCritical     func(mac string) bool
+	startedAt      time.Time
+	dirty          bool
+}
+
+// NewTracker 创建在线记录跟踪器，并启动离线检测循环
+func NewTracker() *Tracker {
+	tracker := &Tracker{
+		records:        make(map[string]*Record),
+		offlineTimeout: defaultOfflineTimeout,
+		retention:      defaultRetentionDays * 24 * time.Hour,
+		startedAt:      time.Now(),
+	}
+	go tracker.sweepLoop()
+	return tracker
+}
+
+// normalizeMAC 统一MAC地址格式
+func normalizeMAC(mac string) string {
+	return strings.ToLower(strings.TrimSpace(mac))
+}
+
+// Configure 应用配置，持久化文件变化时重新加载历史记录
+func (t *Tracker) Configure(cfg config.PresenceConfig, isCritical func(mac string) bool) error {
+	offlineTimeout := time.Duration(cfg.OfflineTimeout) * time.Second
+	if offlineTimeout <= 0 {
+		offlineTimeout = defaultOfflineTimeout
+	}
+	retentionDays := cfg.RetentionDays
+	if retentionDays <= 0 {
+		retentionDays = defaultRetentionDays
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.offlineTimeout = offlineTimeout
+	t.retention = time.Duration(retentionDays) * 24 * time.Hour
+	t.isCritical = isCritical
+
+	if cfg.File == t.file {
+		return nil
+	}
+	t.file = cfg.File
+	if t.file == "" {
+		return nil
+	}
+	return t.load()
+}
+
+// load 从持久化文件加载记录（调用方需持有mutex）
+func (t *Tracker) load() error {
+	data, err := ioutil.ReadFile(t.file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取在线记录文件失败: %v", err)
+	}
+
+	var records []*Record
+	if err := json.Unmarshal(data, &records); err != nil {
+		return fmt.Errorf("解析在线记录文件失败: %v", err)
+	}
+
+	for _, record := range records {
+		if record.MAC == "" {
+			continue
+		}
+		record.MAC = normalizeMAC(record.MAC)
+		t.records[record.MAC] = record
+	}
+	// 加载前离线的设备给予一个离线超时周期的宽限，避免重启后批量误报离线
+	t.startedAt = time.Now()
+	log.Printf("已加载 %d 个设备的在线记录: %s", len(records), t.file)
+	return nil
+}
+
+// Save 将记录写入持久化文件
+func (t *Tracker) Save() error {
+	t.mutex.Lock()
+	if t.file == "" || !t.dirty {
+		t.mutex.Unlock()
+		return nil
+	}
+	records := make([]*Record, 0, len(t.records))
+	for _, record := range t.records {
+		records = append(records, record)
+	}
+	data, err := json.Marshal(records)
+	file := t.file
+	t.dirty = false
+	t.mutex.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("序列化在线记录失败: %v", err)
+	}
+
+	// 先写临时文件再重命名，避免写入中断导致文件损坏
+	tmp := file + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("写入在线记录文件失败: %v", err)
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		return fmt.Errorf("写入在线记录文件失败: %v", err)
+	}
+	return nil
+}
+
+// Observe 记录设备出现，离线设备转为在线时发布device.online事件
+func (t *Tracker) Observe(mac, ip, hostname, source string) {
+	mac = normalizeMAC(mac)
+	if mac == "" {
+		return
+	}
+	now := time.Now()
+
+	t.mutex.Lock()
+	record, exists := t.records[mac]
+	if !exists {
+		record = &Record{MAC: mac, FirstSeen: now}
+		t.records[mac] = record
+	}
+
+	cameOnline := false
+	if !record.Online {
+		record.Online = true
+		record.Sessions = append(record.Sessions, Session{Start: now, Source: source})
+		cameOnline = true
+	} else if now.Sub(record.LastSeen) > t.offlineTimeout {
+		// 服务停止期间设备已离线，在最后见到的时间结束旧会话并开始新会话
+		t.closeSession(record, record.LastSeen)
+		record.Online = true
+		record.Sessions = append(record.Sessions, Session{Start: now, Source: source})
+	}
+
+	if ip != "" {
+		record.IP = ip
+	}
+	if hostname != "" {
+		record.Hostname = hostname
+	}
+	record.LastSeen = now
+	record.LastSource = source
+	t.trimSessions(record, now)
+	t.dirty = true
+	snapshot := *record
+	t.mutex.Unlock()
+
+	if cameOnline {
+		t.publish("device.online", snapshot, time.Duration(0), source)
+	}
+}
+
+// MarkOffline 设备主动离开（如DHCP RELEASE）时立即结束会话
+func (t *Tracker) MarkOffline(mac, source string) {
+	mac = normalizeMAC(mac)
+	now := time.Now()
+
+	t.mutex.Lock()
+	record, exists := t.records[mac]
+	if !exists || !record.Online {
+		t.mutex.Unlock()
+		return
+	}
+	record.LastSeen = now
+	duration := t.closeSession(record, now)
+	t.dirty = true
+	snapshot := *record
+	t.mutex.Unlock()
+
+	t.publish("device.offline", snapshot, duration, source)
+}
+
+// closeSession 结束当前会话并标记离线，返回会话时长（调用方需持有mutex）
+func (t *Tracker) closeSession(record *Record, end time.Time) time.Duration {
+	record.Online = false
+	if len(record.Sessions) == 0 {
+		return 0
+	}
+	last := &record.Sessions[len(record.Sessions)-1]
+	if last.End != nil {
+		return 0
+	}
+	if end.Before(last.Start) {
+		end = last.Start
+	}
+	last.End = &end
+	return end.Sub(last.Start)
+}
+
+// trimSessions 清理超出保留期限和数量上限的会话（调用方需持有mutex）
+func (t *Tracker) trimSessions(record *Record, now time.Time) {
+	cutoff := now.Add(-t.retention)
+	start := 0
+	for start < len(record.Sessions) {
+		session := record.Sessions[start]
+		if session.End == nil || !session.End.Before(cutoff) {
+			break
+		}
+		start++
+	}
+	if len(record.Sessions)-start > maxSessionsPerDevice {
+		start = len(record.Sessions) - maxSessionsPerDevice
+	}
+	if start > 0 {
+		record.Sessions = append([]Session(nil), record.Sessions[start:]...)
+	}
+}
+
+// sweep 将超过离线超时未见到的设备标记为离线，并清理过期记录
+func (t *Tracker) sweep(now time.Time) {
+	type transition struct {
+		record   Record
+		duration time.Duration
+	}
+	var offline []transition
+
+	t.mutex.Lock()
+	for mac, record := range t.records {
+		if record.Online {
+			lastSeen := record.LastSeen
+			if lastSeen.Before(t.startedAt) {
+				lastSeen = t.startedAt
+			}
+			if now.Sub(lastSeen) > t.offlineTimeout {
+				duration := t.closeSession(record, record.LastSeen)
+				offline = append(offline, transition{record: *record, duration: duration})
+				t.dirty = true
+			}
+		}
+
+		t.trimSessions(record, now)
+		if !record.Online && now.Sub(record.LastSeen) > t.retention {
+			delete(t.records, mac)
+			t.dirty = true
+		}
+	}
+	t.mutex.Unlock()
+
+	for _, item := range offline {
+		t.publish("device.offline", item.record, item.duration, "timeout")
+	}
+}
+
+// sweepLoop 定时执行离线检测并保存记录
+func (t *Tracker) sweepLoop() {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		t.sweep(now)
+		if err := t.Save(); err != nil {
+			log.Printf("保存在线记录失败: %v", err)
+		}
+	}
+}
+
+// publish 发布在线状态变化事件，关键设备离线使用critical级别
+func (t *Tracker) publish(eventType string, record Record, duration time.Duration, reason string) {
+	t.mutex.Lock()
+	isCritical := t.isCritical
+	t.mutex.Unlock()
+
+	critical := isCritical != nil && isCritical(record.MAC)
+
+	event := events.Event{
+		Type:     eventType,
+		Source:   "presence",
+		Severity: events.SeverityInfo,
+		Data: map[string]interface{}{
+			"mac":       record.MAC,
+			"ip":        record.IP,
+			"hostname":  record.Hostname,
+			"critical":  critical,
+			"reason":    reason,
+			"last_seen": record.LastSeen,
+		},
+	}
+
+	name := record.MAC
+	if record.Hostname != "" {
+		name = fmt.Sprintf("%s (%s)", record.Hostname, record.MAC)
+	}
+	if eventType == "device.online" {
+		event.Message = fmt.Sprintf("设备 %s 上线，IP: %s", name, record.IP)
+	} else {
+		event.Message = fmt.Sprintf("设备 %s 离线，本次在线 %v", name, duration.Round(time.Second))
+		event.Data["session_seconds"] = int64(duration / time.Second)
+		if critical {
+			event.Severity = events.SeverityCritical
+			event.Message = "关键" + event.Message
+		}
+	}
+
+	events.Publish(event)
+}
+
+// List 获取所有设备的在线记录（不含会话明细），在线设备在前
+func (t *Tracker) List() []Record {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	records := make([]Record, 0, len(t.records))
+	for _, record := range t.records {
+		snapshot := *record
+		snapshot.Sessions = nil
+		records = append(records, snapshot)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Online != records[j].Online {
+			return records[i].Online
+		}
+		return records[i].LastSeen.After(records[j].LastSeen)
+	})
+	return records
+}
+
+// Get 获取设备最近days天的在线时间线
+func (t *Tracker) Get(mac string, days int) (Timeline, bool) {
+	now := time.Now()
+
+	t.mutex.Lock()
+	record, exists := t.records[normalizeMAC(mac)]
+	if !exists {
+		t.mutex.Unlock()
+		return Timeline{}, false
+	}
+	snapshot := *record
+	snapshot.Sessions = append([]Session(nil), record.Sessions...)
+	t.mutex.Unlock()
+
+	if days <= 0 {
+		days = 7
+	}
+
+	timeline := Timeline{Record: snapshot}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	windowStart := today.AddDate(0, 0, -(days - 1))
+
+	for i := 0; i < days; i++ {
+		dayStart := windowStart.AddDate(0, 0, i)
+		dayEnd := dayStart.AddDate(0, 0, 1)
+		var seconds int64
+		for _, session := range snapshot.Sessions {
+			seconds += int64(overlap(session, dayStart, dayEnd, now) / time.Second)
+		}
+		timeline.Daily = append(timeline.Daily, DailyUptime{Date: dayStart.Format("2006-01-02"), Seconds: seconds})
+		timeline.TotalUptime += seconds
+	}
+
+	// 只返回统计范围内的会话
+	var sessions []Session
+	for _, session := range snapshot.Sessions {
+		if session.End == nil || session.End.After(windowStart) {
+			sessions = append(sessions, session)
+		}
+	}
+	timeline.Sessions = sessions
+
+	return timeline, true
+}
+
+// overlap 计算会话与[from, to)时间段的重叠时长
+func overlap(session Session, from, to, now time.Time) time.Duration {
+	start := session.Start
+	end := now
+	if session.End != nil {
+		end = *session.End
+	}
+	if start.Before(from) {
+		start = from
+	}
+	if end.After(to) {
+		end = to
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// 默认跟踪器
+var defaultTracker = NewTracker()
+
+// Configure 配置默认跟踪器
+func Configure(cfg config.PresenceConfig, isCritical func(mac string) bool) error {
+	return defaultTracker.Configure(cfg, isCritical)
+}
+
+// Observe 向默认跟踪器记录设备出现
+func Observe(mac, ip, hostname, source string) {
+	defaultTracker.Observe(mac, ip, hostname, source)
+}
+
+// MarkOffline 在默认跟踪器中将设备标记为离线
+func MarkOffline(mac, source string) {
+	defaultTracker.MarkOffline(mac, source)
+}
+
+// List 获取默认跟踪器的所有记录
+func List() []Record {
+	return defaultTracker.List()
+}
+
+// Get 获取默认跟踪器中设备的在线时间线
+func Get(mac string, days int) (Timeline, bool) {
+	return defaultTracker.Get(mac, days)
+}
+
+// Save 保存默认跟踪器的记录
+func Save() error {
+	return defaultTracker.Save()
+}
@@ -0,0 +1,109 @@
+package presence
+
+import (
+	"testing"
+	"time"
+
+	"dhcp-server/config"
+	"dhcp-server/events"
+)
+
+// offlineEvents 统计设备因超时离线的事件数
+func offlineEvents(mac string) int {
+	count := 0
+	for _, event := range events.Recent(500, "device.offline") {
+		if event.Data["mac"] == mac && event.Data["reason"] == "timeout" {
+			count++
+		}
+	}
+	return count
+}
+
+func TestSweep(t *testing.T) {
+	tracker := NewTracker()
+	if err := tracker.Configure(config.PresenceConfig{OfflineTimeout: 60, RetentionDays: 1}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	const mac = "aa:bb:cc:34:10:01"
+	tracker.Observe(mac, "192.168.1.20", "phone", SourceScan)
+	lastSeen := tracker.records[mac].LastSeen
+
+	// 未超过离线超时时保持在线
+	tracker.sweep(lastSeen.Add(30 * time.Second))
+	if !tracker.records[mac].Online || offlineEvents(mac) != 0 {
+		t.Fatal("未超过离线超时不应标记离线")
+	}
+
+	// 超时后离线，会话在最后见到的时间结束
+	tracker.sweep(lastSeen.Add(2 * time.Minute))
+	record := tracker.records[mac]
+	if record.Online || len(record.Sessions) != 1 || record.Sessions[0].End == nil || !record.Sessions[0].End.Equal(lastSeen) {
+		t.Fatalf("超时后应离线并结束会话: %+v", record)
+	}
+	if offlineEvents(mac) != 1 {
+		t.Errorf("应发布1次超时离线事件, 实际为 %d", offlineEvents(mac))
+	}
+
+	// 已离线的设备不重复告警
+	tracker.sweep(lastSeen.Add(5 * time.Minute))
+	if offlineEvents(mac) != 1 {
+		t.Errorf("已离线的设备不应重复发布事件, 实际为 %d", offlineEvents(mac))
+	}
+
+	// 离线超过保留期限后删除记录
+	tracker.sweep(lastSeen.Add(25 * time.Hour))
+	if _, ok := tracker.records[mac]; ok {
+		t.Error("超过保留期限的离线设备应被删除")
+	}
+}
+
+func TestSweepStartupGrace(t *testing.T) {
+	tracker := NewTracker()
+	const mac = "aa:bb:cc:34:10:02"
+	startedAt := time.Now()
+	tracker.startedAt = startedAt
+
+	// 加载的记录在启动前已在线，启动后给予一个离线超时周期的宽限
+	tracker.records[mac] = &Record{
+		MAC:      mac,
+		LastSeen: startedAt.Add(-time.Hour),
+		Online:   true,
+		Sessions: []Session{{Start: startedAt.Add(-2 * time.Hour), Source: SourceDHCP}},
+	}
+	tracker.sweep(startedAt.Add(defaultOfflineTimeout / 2))
+	if !tracker.records[mac].Online {
+		t.Fatal("启动后的宽限期内不应标记离线")
+	}
+	tracker.sweep(startedAt.Add(defaultOfflineTimeout + time.Second))
+	record := tracker.records[mac]
+	if record.Online || record.Sessions[0].End == nil || !record.Sessions[0].End.Equal(record.LastSeen) {
+		t.Errorf("宽限期后应离线，会话在最后见到的时间结束: %+v", record)
+	}
+}
+
+func TestObserveAfterDowntime(t *testing.T) {
+	tracker := NewTracker()
+	if err := tracker.Configure(config.PresenceConfig{OfflineTimeout: 60}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// 服务停止期间设备离开，再次见到时结束旧会话并开始新会话
+	const mac = "aa:bb:cc:34:10:03"
+	lastSeen := time.Now().Add(-time.Hour)
+	tracker.records[mac] = &Record{
+		MAC:      mac,
+		LastSeen: lastSeen,
+		Online:   true,
+		Sessions: []Session{{Start: lastSeen.Add(-time.Hour), Source: SourceDHCP}},
+	}
+	tracker.Observe(mac, "", "", SourceScan)
+
+	record := tracker.records[mac]
+	if !record.Online || len(record.Sessions) != 2 {
+		t.Fatalf("应开始新会话: %+v", record)
+	}
+	if record.Sessions[0].End == nil || !record.Sessions[0].End.Equal(lastSeen) || record.Sessions[1].End != nil {
+		t.Errorf("旧会话应在最后见到的时间结束: %+v", record.Sessions)
+	}
+}